@@ -6,6 +6,7 @@ import (
 
 	tea "charm.land/bubbletea/v2"
 	"github.com/avitaltamir/vibecommander/internal/app"
+	"github.com/avitaltamir/vibecommander/internal/history"
 )
 
 var version = "dev"
@@ -19,7 +20,32 @@ func main() {
 		return
 	}
 
-	p := tea.NewProgram(app.New())
+	// vc dir starts in a folder; vc path[:line] opens a file, e.g. straight
+	// from compiler output
+	var open *history.Location
+	if len(os.Args) > 1 {
+		if info, err := os.Stat(os.Args[1]); err == nil && info.IsDir() {
+			if err := os.Chdir(os.Args[1]); err != nil {
+				fmt.Fprintf(os.Stderr, "vc: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			loc, err := app.ParseFileArg(os.Args[1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "vc: %v\n", err)
+				os.Exit(1)
+			}
+			open = &loc
+		}
+	}
+
+	m := app.New()
+	if open != nil {
+		m.OpenAt(*open)
+	}
+
+	// The model holds locks, so it's handed over rather than copied
+	p := tea.NewProgram(&m)
 
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -13,15 +13,24 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/avitaltamir/vibecommander/internal/components/content"
+	"github.com/avitaltamir/vibecommander/internal/components/content/diff"
+	"github.com/avitaltamir/vibecommander/internal/components/content/player"
+	"github.com/avitaltamir/vibecommander/internal/components/content/review"
 	"github.com/avitaltamir/vibecommander/internal/components/content/viewer"
 	"github.com/avitaltamir/vibecommander/internal/components/filetree"
 	"github.com/avitaltamir/vibecommander/internal/components/gitpanel"
 	"github.com/avitaltamir/vibecommander/internal/components/minibuffer"
+	"github.com/avitaltamir/vibecommander/internal/components/quickpick"
 	"github.com/avitaltamir/vibecommander/internal/components/terminal"
 	"github.com/avitaltamir/vibecommander/internal/git"
+	"github.com/avitaltamir/vibecommander/internal/history"
+	"github.com/avitaltamir/vibecommander/internal/ignore"
 	"github.com/avitaltamir/vibecommander/internal/layout"
+	"github.com/avitaltamir/vibecommander/internal/links"
 	"github.com/avitaltamir/vibecommander/internal/state"
+	"github.com/avitaltamir/vibecommander/internal/syntax"
 	"github.com/avitaltamir/vibecommander/internal/theme"
+	"github.com/avitaltamir/vibecommander/internal/watcher"
 	"github.com/fsnotify/fsnotify"
 )
 
@@ -71,6 +80,30 @@ type Model struct {
 	theme            *theme.Theme
 	keys             KeyMap
 
+	// Dual pane
+	layoutMode      layout.Mode
+	otherTree       filetree.Model // Second pane, created on first use
+	otherTreeReady  bool
+	pendingTransfer *transfer // Copy/move awaiting confirmation
+
+	// File or directory marked in a tree to be compared with another
+	compareFrom    string
+	compareFromDir bool
+
+	// Bookmarks, recent files and the jump list
+	bookmarks history.Bookmarks
+	recent    history.Recent
+	jumps     history.JumpList
+	picker    quickpick.Model
+	projects  map[string]state.Project // Saved state of every project, rewritten on save
+
+	transcripts []string // Temporary transcript files opened in the viewer, removed on quit
+
+	// Status message
+	statusText    string
+	statusIsError bool
+	statusSeq     int // Bumped per message so stale clear ticks are ignored
+
 	// Git
 	gitProvider    *git.ShellProvider
 	gitStatus      *git.Status
@@ -79,7 +112,15 @@ type Model struct {
 	gitRefreshTime time.Time // Last git refresh time for debouncing
 
 	// File watcher
-	watcher              *fsnotify.Watcher
+	watcher              *watcher.Watcher
+	treeWatchDirs        map[string]bool        // Directories watched because they're expanded
+	treeWatchVersion     uint64                 // Sum of the trees' ExpandedVersion at the last sync
+	trackedWatchDirs     map[string]bool        // Directories watched because they hold tracked files
+	ignore               *ignore.Matcher        // .gitignore rules shared with the file tree
+	gitDir               string                 // Absolute .git directory ("" outside a repo)
+	gitCommonDir         string                 // Directory holding refs (differs for worktrees)
+	gitWatched           bool                   // Git metadata is watched; polling is a fallback only
+	gitMetaDebouncing    bool                   // Whether a git metadata refresh is scheduled
 	lastFileChangeTime   time.Time              // Last file change time for debouncing
 	pendingFileChanges   map[string]fsnotify.Op // Pending file changes to process
 	fileChangeDebouncing bool                   // Whether we're waiting to process file changes
@@ -94,9 +135,13 @@ type Model struct {
 	restoreAI       bool // Flag to restore AI on first window size msg
 	initialThemeIdx int  // Theme index to restore
 
+	// File from the command line, opened on first window size msg
+	openAt *history.Location
+
 	// AI assistant selection
 	aiCommand       string   // Persisted AI command (e.g., "claude", "gemini")
 	aiArgs          []string // Persisted AI args
+	recordAI        bool     // Record AI sessions as asciicast files
 	showAIDialog    bool     // Whether AI selection dialog is visible
 	aiDialogIndex   int      // Current selection in dialog (0=Claude, 1=Gemini, 2=Codex, 3=Other)
 	aiDialogCustom  string   // Custom command input when "Other" selected
@@ -119,15 +164,22 @@ func New() Model {
 	// Create content pane with git provider
 	contentPane := content.New()
 	contentPane.SetGitProvider(gitProvider)
+	contentPane.SetWorkDir(workDir)
 
 	// Create file watcher
-	watcher, _ := fsnotify.NewWatcher()
+	fileWatcher := watcher.New()
+
+	// Watch git metadata so commits, checkouts and staging from other
+	// processes show up immediately; polling is only used when this fails
+	gitDir, gitCommonDir, _ := gitProvider.GitDirs()
+	gitWatched := watchGitMetadata(fileWatcher, gitDir, gitCommonDir)
 
 	// Load persisted state (global)
 	savedState := state.Load()
 
 	// Apply saved theme
 	theme.SetThemeIndex(savedState.ThemeIndex)
+	syntax.SetOverride(savedState.SyntaxStyle)
 
 	// Apply saved left panel percent (validate it's within bounds)
 	leftPanelPercent := savedState.LeftPanelPercent
@@ -138,6 +190,15 @@ func New() Model {
 	// Apply saved compact indent to file tree
 	ft.SetCompactIndent(savedState.CompactIndent)
 
+	// Share .gitignore rules between the file tree and the watcher
+	ignoreMatcher := ignore.New(workDir)
+	ft.SetIgnoreMatcher(ignoreMatcher)
+	ft.SetIgnoredMode(filetree.IgnoredMode(savedState.IgnoredMode))
+
+	// Restore this project's bookmarks and recent files
+	project := savedState.Projects[workDir]
+	contentPane.SetReviewed(project.Reviewed)
+
 	// Initialize commit message input
 	commitInput := textinput.New()
 	commitInput.Placeholder = ""
@@ -158,13 +219,24 @@ func New() Model {
 		gitProvider:        gitProvider,
 		workDir:            workDir,
 		isGitRepo:          gitProvider.IsRepo(),
-		watcher:            watcher,
+		watcher:            fileWatcher,
+		treeWatchDirs:      make(map[string]bool),
+		trackedWatchDirs:   make(map[string]bool),
+		ignore:             ignoreMatcher,
+		gitDir:             gitDir,
+		gitCommonDir:       gitCommonDir,
+		gitWatched:         gitWatched,
 		pendingFileChanges: make(map[string]fsnotify.Op),
 		restoreAI:          savedState.AIWindowOpen,
 		initialThemeIdx:    savedState.ThemeIndex,
 		aiCommand:          savedState.AICommand,
 		aiArgs:             savedState.AIArgs,
+		recordAI:           savedState.RecordAI,
 		commitInput:        commitInput,
+		bookmarks:          history.NewBookmarks(project.Bookmarks),
+		recent:             history.NewRecent(project.Recent),
+		picker:             quickpick.New(),
+		projects:           savedState.Projects,
 	}
 }
 
@@ -175,62 +247,174 @@ func (m Model) Init() tea.Cmd {
 		m.content.Init(),
 		m.miniBuffer.Init(),
 		m.refreshGitStatus(),
-		gitTick(), // Start periodic git status refresh
 	}
 
-	// Start file watcher
+	// Poll git status only if its metadata can't be watched
+	if !m.gitWatched {
+		cmds = append(cmds, gitTick())
+	}
+
+	// Start file watcher. Directories are watched lazily: expanded ones are
+	// synced from the file tree, tracked ones come from git.
 	if m.watcher != nil && m.workDir != "" {
-		// Recursively watch directories (with exclusions)
-		m.addWatchRecursive(m.workDir)
-		cmds = append(cmds, m.watchFilesCmd())
+		cmds = append(cmds, m.loadTrackedDirs(), m.watchFilesCmd())
 	}
 
 	return tea.Batch(cmds...)
 }
 
-// addWatchRecursive adds watches for a directory and its subdirectories
-func (m Model) addWatchRecursive(root string) {
-	if m.watcher == nil {
+// syncTreeWatches watches the directories expanded in the file trees and
+// drops watches for collapsed ones.
+func (m *Model) syncTreeWatches() {
+	version := m.fileTree.ExpandedVersion() + m.otherTree.ExpandedVersion()
+	if m.watcher == nil || version == m.treeWatchVersion {
 		return
 	}
+	m.treeWatchVersion = version
 
-	// Directories to skip
-	skipDirs := map[string]bool{
-		".git":         true,
-		"node_modules": true,
-		"vendor":       true,
-		".venv":        true,
-		"__pycache__":  true,
-		".cache":       true,
-		"dist":         true,
-		"build":        true,
+	dirs := m.fileTree.ExpandedDirs()
+	if m.otherTreeReady {
+		dirs = append(dirs, m.otherTree.ExpandedDirs()...)
 	}
+	m.treeWatchDirs = m.syncWatches(m.treeWatchDirs, dirs, watcher.ReasonTree)
+}
 
-	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // Skip errors
+// syncWatches makes dirs the set watched for reason, given the previously
+// watched set, and returns the new set. Directories the repository ignores
+// are left out. The watcher is asked what it holds, since it drops watches
+// on its own when a directory is removed.
+func (m *Model) syncWatches(previous map[string]bool, dirs []string, reason watcher.Reason) map[string]bool {
+	current := make(map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		if m.ignore.Match(dir, true) {
+			continue
+		}
+		current[dir] = true
+		if !m.watcher.Has(dir, reason) {
+			m.watcher.Add(dir, reason)
+		}
+	}
+	for dir := range previous {
+		if !current[dir] && m.watcher.Has(dir, reason) {
+			m.watcher.Remove(dir, reason)
 		}
+	}
+	return current
+}
 
-		if info.IsDir() {
-			// Check if this directory should be skipped
-			name := info.Name()
-			if skipDirs[name] {
-				return filepath.SkipDir
+// rewatchCreated restores the watches for a directory created at path (and
+// for any directories under it) that were wanted before the watcher released
+// them with a deleted directory at the same path.
+func (m *Model) rewatchCreated(path string) {
+	prefix := path + string(filepath.Separator)
+	for _, set := range []struct {
+		dirs   map[string]bool
+		reason watcher.Reason
+	}{{m.treeWatchDirs, watcher.ReasonTree}, {m.trackedWatchDirs, watcher.ReasonTracked}} {
+		for dir := range set.dirs {
+			if (dir == path || strings.HasPrefix(dir, prefix)) && !m.watcher.Has(dir, set.reason) && !m.ignore.Match(dir, true) {
+				m.watcher.Add(dir, set.reason)
 			}
+		}
+	}
+}
 
-			// Skip hidden directories (except root)
-			if path != root && strings.HasPrefix(name, ".") {
-				return filepath.SkipDir
-			}
+// trackedDirsMsg carries the directories holding git-tracked files.
+type trackedDirsMsg struct {
+	Dirs []string // Absolute paths
+}
+
+// loadTrackedDirs lists the directories that hold tracked files so edits
+// anywhere in the repository refresh git status, even in collapsed folders.
+func (m *Model) loadTrackedDirs() tea.Cmd {
+	isGitRepo, provider, workDir := m.isGitRepo, m.gitProvider, m.workDir
+	return func() tea.Msg {
+		if !isGitRepo {
+			return trackedDirsMsg{}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		rel, err := provider.TrackedDirs(ctx)
+		if err != nil {
+			return trackedDirsMsg{}
+		}
+		dirs := make([]string, 0, len(rel))
+		for _, dir := range rel {
+			dirs = append(dirs, filepath.Join(workDir, dir))
+		}
+		return trackedDirsMsg{Dirs: dirs}
+	}
+}
+
+// watchGitMetadata watches the parts of the git directory that change when
+// HEAD, the index or any ref moves. Directories are watched rather than the
+// files themselves because git replaces files by renaming lock files over
+// them. It reports whether the watches were set up.
+func watchGitMetadata(w *watcher.Watcher, gitDir, commonDir string) bool {
+	if w == nil || gitDir == "" {
+		return false
+	}
+
+	// HEAD and index (and packed-refs when not using worktrees)
+	if err := w.Add(gitDir, watcher.ReasonGit); err != nil {
+		return false
+	}
+	if commonDir != "" && commonDir != gitDir {
+		if err := w.Add(commonDir, watcher.ReasonGit); err != nil {
+			return false
+		}
+	} else {
+		commonDir = gitDir
+	}
+
+	// Every directory under refs/ (loose refs)
+	watchGitRefs(w, filepath.Join(commonDir, "refs"))
+	return true
+}
 
-			// Add watch for this directory
-			m.watcher.Add(path)
+// watchGitRefs watches dir and every directory below it for ref changes.
+func watchGitRefs(w *watcher.Watcher, dir string) {
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.IsDir() {
+			w.Add(path, watcher.ReasonGit)
 		}
 		return nil
 	})
+}
 
-	// Note: We don't watch .git directory to avoid lock file conflicts.
-	// Git status is refreshed periodically via ticker instead.
+// isGitMetadataPath reports whether path lies inside the watched git
+// directories.
+func (m *Model) isGitMetadataPath(path string) bool {
+	for _, dir := range []string{m.gitDir, m.gitCommonDir} {
+		if dir != "" && (path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))) {
+			return true
+		}
+	}
+	return false
+}
+
+// gitMetadataChanged reports whether a change to path inside the git
+// directory affects git status. Lock files are churn from git's own
+// writes and are ignored; the rename that replaces the real file is what
+// signals the change.
+func (m *Model) gitMetadataChanged(path string) bool {
+	name := filepath.Base(path)
+	if strings.HasSuffix(name, ".lock") {
+		return false
+	}
+
+	switch name {
+	case "HEAD", "index", "packed-refs":
+		return true
+	}
+
+	return m.isGitRefsPath(path)
+}
+
+// isGitRefsPath reports whether path is under the refs directory.
+func (m *Model) isGitRefsPath(path string) bool {
+	refsDir := filepath.Join(m.gitCommonDir, "refs")
+	return m.gitCommonDir != "" && strings.HasPrefix(path, refsDir+string(filepath.Separator))
 }
 
 // watchFilesCmd returns a command that listens for file system changes
@@ -242,18 +426,11 @@ func (m Model) watchFilesCmd() tea.Cmd {
 
 		// Block until we get an actual file change event
 		// Don't use timeouts - they cause unnecessary redraws
-		for {
-			select {
-			case event, ok := <-m.watcher.Events:
-				if !ok {
-					return nil // Channel closed
-				}
-				return FileChangeMsg{Path: event.Name, Op: event.Op}
-			case <-m.watcher.Errors:
-				// Ignore errors but continue watching
-				continue
-			}
+		event, ok := <-m.watcher.Events()
+		if !ok {
+			return nil // Channel closed
 		}
+		return FileChangeMsg{Path: event.Name, Op: event.Op}
 	}
 }
 
@@ -290,7 +467,12 @@ func (m *Model) refreshGitStatusDebounced() tea.Cmd {
 }
 
 type gitTickMsg struct{}
-type gitRefreshMsg struct{} // Immediate git refresh request
+type gitRefreshMsg struct{}      // Immediate git refresh request
+type gitMetaDebounceMsg struct{} // Sent after git metadata settles
+
+// gitMetaDebounceInterval lets a burst of ref/index updates (e.g. a commit
+// touching index, HEAD and a branch ref) settle into a single refresh.
+const gitMetaDebounceInterval = 150 * time.Millisecond
 
 // gitTick returns a command that sends a gitTickMsg after the tick interval
 func gitTick() tea.Cmd {
@@ -315,28 +497,52 @@ func (m *Model) scheduleFileChangeDebounce() tea.Cmd {
 
 // Update handles messages and updates the model.
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+
+	// Keep watches in step with whatever the file tree now shows
+	if updated, ok := model.(*Model); ok {
+		updated.syncTreeWatches()
+		return *updated, cmd
+	}
+	return model, cmd
+}
+
+// update dispatches a message to the handlers and child components. It
+// updates m in place, returning m itself unless a dialog handler returns
+// another model.
+func (m *Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		m.layout = layout.Calculate(msg.Width, msg.Height, m.miniVisible, m.leftPanelPercent, m.gitPanelVisible)
+		m.layout = layout.Calculate(msg.Width, msg.Height, m.miniVisible, m.leftPanelPercent, m.gitPanelVisible, m.layoutMode)
 		wasReady := m.ready
 		m.ready = true
 		// Update child component sizes
-		m = m.updateSizes()
+		*m = m.updateSizes()
+
+		// Open the file given on the command line instead of the AI
+		if !wasReady && m.openAt != nil {
+			loc := *m.openAt
+			m.openAt = nil
+			m.restoreAI = false
+			cmd := m.gotoLocation(loc)
+			return m, cmd
+		}
 
 		// Restore AI window on first ready (if it was open before)
 		if !wasReady && m.restoreAI {
 			m.restoreAI = false // Only restore once
 			m.aiLaunched = true
 			var focusCmd tea.Cmd
-			m, focusCmd = m.setFocus(PanelContent)
+			*m, focusCmd = m.setFocus(PanelContent)
 			return m, tea.Batch(focusCmd, func() tea.Msg {
 				return content.LaunchAIMsg{
 					Command: "claude",
 					Args:    []string{},
+					Record:  m.recordPath("claude"),
 				}
 			})
 		}
@@ -353,6 +559,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.Status != nil {
 			m.fileTree = m.fileTree.SetGitStatus(msg.Status)
 			m.gitPanel = m.gitPanel.SetGitStatus(msg.Status)
+			if m.otherTreeReady {
+				m.otherTree = m.otherTree.SetGitStatus(msg.Status)
+			}
 		}
 		return m, nil
 
@@ -370,6 +579,54 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Immediate git refresh (after staging/unstaging)
 		return m, m.refreshGitStatus()
 
+	case gitMetaDebounceMsg:
+		// Git metadata settled - refresh status without touching the file tree.
+		// A checkout or commit may also have changed which directories are tracked.
+		m.gitMetaDebouncing = false
+		m.gitRefreshTime = time.Now()
+		return m, tea.Batch(m.refreshGitStatus(), m.loadTrackedDirs())
+
+	case trackedDirsMsg:
+		m.trackedWatchDirs = m.syncWatches(m.trackedWatchDirs, msg.Dirs, watcher.ReasonTracked)
+		return m, nil
+
+	case ErrorMsg:
+		return m, m.setStatus(msg.Err.Error(), true)
+
+	case StatusMsg:
+		return m, m.setStatus(msg.Text, false)
+
+	case clearStatusMsg:
+		if msg.seq == m.statusSeq {
+			m.statusText = ""
+		}
+		return m, nil
+
+	case transferDoneMsg:
+		cmd := m.handleTransferDone(msg)
+		return m, cmd
+
+	case quickpick.SelectMsg:
+		cmd := m.handlePickerSelect(msg)
+		return m, cmd
+
+	case quickpick.RemoveMsg:
+		cmd := m.handlePickerRemove(msg)
+		return m, cmd
+
+	case links.OpenMsg:
+		cmd := m.openLink(msg)
+		return m, cmd
+
+	case viewer.JumpMsg:
+		// A search moved the viewer - remember where it was
+		m.jumps.Push(history.Location{Path: msg.Path, Line: msg.Line})
+		return m, nil
+
+	case compareDoneMsg:
+		cmd := m.handleCompareDone(msg)
+		return m, cmd
+
 	case gitCommitFinishedMsg:
 		// Git commit finished (after GPG passphrase entry, etc.)
 		if msg.err != nil {
@@ -389,14 +646,29 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Batch(cmds...)
 		}
 
-		// If a new directory was created, add a watch for it
-		if msg.Op&fsnotify.Create != 0 {
-			if info, err := os.Stat(msg.Path); err == nil && info.IsDir() {
-				name := filepath.Base(msg.Path)
-				if !strings.HasPrefix(name, ".") && name != "node_modules" && name != "vendor" {
-					m.watcher.Add(msg.Path)
+		// Changes inside .git only ever need a git status refresh
+		if m.isGitMetadataPath(msg.Path) {
+			// Watch new ref namespaces (e.g. refs/remotes/origin after a first fetch)
+			if msg.Op&fsnotify.Create != 0 && m.isGitRefsPath(msg.Path) {
+				if info, err := os.Stat(msg.Path); err == nil && info.IsDir() {
+					watchGitRefs(m.watcher, msg.Path)
 				}
 			}
+			if m.gitMetadataChanged(msg.Path) && !m.gitMetaDebouncing {
+				m.gitMetaDebouncing = true
+				cmds = append(cmds, tea.Tick(gitMetaDebounceInterval, func(time.Time) tea.Msg {
+					return gitMetaDebounceMsg{}
+				}))
+			}
+			return m, tea.Batch(cmds...)
+		}
+
+		// New directories aren't watched until they're expanded or hold
+		// tracked files, unless they replace one that was
+		if msg.Op&fsnotify.Create != 0 && m.watcher != nil {
+			if info, err := os.Stat(msg.Path); err == nil && info.IsDir() {
+				m.rewatchCreated(msg.Path)
+			}
 		}
 
 		// Add to pending file changes (debounce multiple rapid changes)
@@ -414,9 +686,20 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Collect unique directories to refresh
 		dirsToRefresh := make(map[string]bool)
+		ignoreRulesChanged := false
 		for path := range m.pendingFileChanges {
 			dirPath := filepath.Dir(path)
 			dirsToRefresh[dirPath] = true
+			if filepath.Base(path) == ".gitignore" {
+				ignoreRulesChanged = true
+			}
+		}
+
+		// Re-evaluate ignored entries (the tree sync then adjusts watches)
+		if ignoreRulesChanged {
+			m.ignore.Reload()
+			m.fileTree.RefreshIgnored()
+			m.otherTree.RefreshIgnored()
 		}
 
 		// Clear pending changes
@@ -427,6 +710,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if cmd := m.fileTree.RefreshDir(dirPath); cmd != nil {
 				cmds = append(cmds, cmd)
 			}
+			if cmd := m.otherTree.RefreshDir(dirPath); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
 		}
 
 		// Refresh git status (debounced to avoid excessive calls)
@@ -440,9 +726,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.showQuit {
 			switch msg.String() {
 			case "y", "Y", "enter", "ctrl+q":
-				// Save state before quitting
-				m.saveState()
-				return m, tea.Quit
+				// Unsaved edits take an explicit yes, not a double tap
+				if msg.String() == "ctrl+q" && m.content.IsModified() {
+					return m, nil
+				}
+				cmd := m.quit()
+				return m, cmd
 			case "n", "N", "esc":
 				m.showQuit = false
 				return m, nil
@@ -460,14 +749,31 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleAIDialog(msg)
 		}
 
+		// Handle copy/move confirmation
+		if m.pendingTransfer != nil {
+			cmd := m.handleTransferDialog(msg)
+			return m, cmd
+		}
+
+		// Handle quick-pick overlay
+		if m.picker.IsOpen() {
+			var cmd tea.Cmd
+			m.picker, cmd = m.picker.Update(msg)
+			return m, cmd
+		}
+
+		// Keys for the dual-pane trees
+		paneKeys := m.dualPane() && (m.focus == PanelFileTree || m.focus == PanelOtherTree)
+
 		// Handle global keys
 		switch {
 		case key.Matches(msg, m.keys.Quit):
-			// Check for double-tap ctrl+q (within 400ms) for immediate quit
+			// Check for double-tap ctrl+q (within 400ms) for immediate quit,
+			// unless there are unsaved edits to confirm losing
 			now := time.Now()
-			if now.Sub(m.lastQuitPress) < 400*time.Millisecond {
-				m.saveState()
-				return m, tea.Quit
+			if now.Sub(m.lastQuitPress) < 400*time.Millisecond && !m.content.IsModified() {
+				cmd := m.quit()
+				return m, cmd
 			}
 			m.lastQuitPress = now
 			m.showQuit = true
@@ -481,65 +787,69 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Exit fullscreen if in fullscreen mode
 			if m.fullscreen != PanelNone {
 				m.fullscreen = PanelNone
-				m = m.updateSizes()
+				*m = m.updateSizes()
 			}
 			// Close mini buffer if open
 			if m.miniVisible {
 				m.miniVisible = false
-				m.layout = layout.Calculate(m.width, m.height, m.miniVisible, m.leftPanelPercent, m.gitPanelVisible)
-				m = m.updateSizes()
+				m.layout = layout.Calculate(m.width, m.height, m.miniVisible, m.leftPanelPercent, m.gitPanelVisible, m.layoutMode)
+				*m = m.updateSizes()
 			}
 			var focusCmd tea.Cmd
-			m, focusCmd = m.setFocus(PanelFileTree)
+			*m, focusCmd = m.setFocus(PanelFileTree)
 			return m, focusCmd
 
 		case key.Matches(msg, m.keys.FocusContent):
 			// If content is already fullscreen, just exit fullscreen
 			if m.fullscreen == PanelContent {
 				m.fullscreen = PanelNone
-				m = m.updateSizes()
+				*m = m.updateSizes()
 				return m, nil
 			}
+			// The content pane is hidden in dual-pane mode - bring it back
+			if m.dualPane() {
+				m.setLayoutMode(layout.ModeNormal)
+			}
 			// Close mini buffer if open
 			if m.miniVisible {
 				m.miniVisible = false
-				m.layout = layout.Calculate(m.width, m.height, m.miniVisible, m.leftPanelPercent, m.gitPanelVisible)
-				m = m.updateSizes()
+				m.layout = layout.Calculate(m.width, m.height, m.miniVisible, m.leftPanelPercent, m.gitPanelVisible, m.layoutMode)
+				*m = m.updateSizes()
 			}
 			// Enter fullscreen if already focused
 			if m.focus == PanelContent {
 				m.fullscreen = PanelContent
-				m = m.updateSizes()
+				*m = m.updateSizes()
 			}
 			var focusCmd tea.Cmd
-			m, focusCmd = m.setFocus(PanelContent)
+			*m, focusCmd = m.setFocus(PanelContent)
 			return m, focusCmd
 
 		case key.Matches(msg, m.keys.ToggleMini):
 			// Exit fullscreen if in fullscreen mode
 			if m.fullscreen != PanelNone {
 				m.fullscreen = PanelNone
-				m = m.updateSizes()
+				*m = m.updateSizes()
 			}
 			// If terminal is visible but not focused, just focus it
 			if m.miniVisible && m.focus != PanelMiniBuffer {
 				var focusCmd tea.Cmd
-				m, focusCmd = m.setFocus(PanelMiniBuffer)
+				*m, focusCmd = m.setFocus(PanelMiniBuffer)
 				return m, focusCmd
 			}
 			// Otherwise toggle terminal on/off
 			m.miniVisible = !m.miniVisible
-			m.layout = layout.Calculate(m.width, m.height, m.miniVisible, m.leftPanelPercent, m.gitPanelVisible)
-			m = m.updateSizes()
+			m.layout = layout.Calculate(m.width, m.height, m.miniVisible, m.leftPanelPercent, m.gitPanelVisible, m.layoutMode)
+			*m = m.updateSizes()
 			var focusCmd tea.Cmd
 			if m.miniVisible {
-				m, focusCmd = m.setFocus(PanelMiniBuffer)
+				*m, focusCmd = m.setFocus(PanelMiniBuffer)
 				// Start shell if not running
 				if !m.miniBuffer.Running() {
 					return m, tea.Batch(focusCmd, m.miniBuffer.StartShell())
 				}
 			} else {
-				m, focusCmd = m.setFocus(m.prevFocus)
+				*m, focusCmd = m.setFocus(m.prevFocus)
 			}
 			return m, focusCmd
 
@@ -547,21 +857,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// If git panel is visible but not focused, just focus it
 			if m.gitPanelVisible && m.focus != PanelGitPanel {
 				var focusCmd tea.Cmd
-				m, focusCmd = m.setFocus(PanelGitPanel)
+				*m, focusCmd = m.setFocus(PanelGitPanel)
 				return m, focusCmd
 			}
 			// Otherwise toggle git panel visibility
 			m.gitPanelVisible = !m.gitPanelVisible
-			m.layout = layout.Calculate(m.width, m.height, m.miniVisible, m.leftPanelPercent, m.gitPanelVisible)
-			m = m.updateSizes()
+			m.layout = layout.Calculate(m.width, m.height, m.miniVisible, m.leftPanelPercent, m.gitPanelVisible, m.layoutMode)
+			*m = m.updateSizes()
 			var focusCmd tea.Cmd
 			if m.gitPanelVisible {
 				// Focus git panel when opened
-				m, focusCmd = m.setFocus(PanelGitPanel)
+				*m, focusCmd = m.setFocus(PanelGitPanel)
 			} else {
 				// Return focus to file tree when closed
 				if m.focus == PanelGitPanel {
-					m, focusCmd = m.setFocus(PanelFileTree)
+					*m, focusCmd = m.setFocus(PanelFileTree)
 				}
 			}
 			return m, focusCmd
@@ -585,19 +895,109 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			// Launch AI assistant in content pane
 			m.aiLaunched = true
-			var focusCmd tea.Cmd
-			m, focusCmd = m.setFocus(PanelContent)
-			return m, tea.Batch(focusCmd, func() tea.Msg {
+			var modeCmd, focusCmd tea.Cmd
+			modeCmd = m.setLayoutMode(layout.ModeNormal)
+			*m, focusCmd = m.setFocus(PanelContent)
+			return m, tea.Batch(modeCmd, focusCmd, func() tea.Msg {
 				return content.LaunchAIMsg{
 					Command: m.aiCommand,
 					Args:    m.aiArgs,
+					Record:  m.recordPath(m.aiCommand),
 				}
 			})
 
 		case key.Matches(msg, m.keys.CycleTheme):
 			// Cycle to next theme
 			theme.NextTheme()
-			return m, nil
+			var cmd tea.Cmd
+			m.content, cmd = m.content.Update(content.ThemeChangedMsg{})
+			return m, cmd
+
+		case key.Matches(msg, m.keys.SyntaxStyle):
+			cmd := m.openPicker(pickSyntax)
+			return m, cmd
+
+		case key.Matches(msg, m.keys.Review):
+			if !m.isGitRepo {
+				return m, m.setStatus("Not a git repository", true)
+			}
+			var cmd, focusCmd tea.Cmd
+			m.content, cmd = m.content.Update(content.OpenReviewMsg{})
+			*m, focusCmd = m.setFocus(PanelContent)
+			return m, tea.Batch(cmd, focusCmd)
+
+		case key.Matches(msg, m.keys.ToggleDualPane):
+			if m.dualPane() {
+				cmd := m.setLayoutMode(layout.ModeNormal)
+				return m, cmd
+			}
+			cmd := m.setLayoutMode(layout.ModeDualPane)
+			return m, cmd
+
+		case paneKeys && key.Matches(msg, m.keys.SwitchPane):
+			target := PanelOtherTree
+			if m.focus == PanelOtherTree {
+				target = PanelFileTree
+			}
+			var focusCmd tea.Cmd
+			*m, focusCmd = m.setFocus(target)
+			return m, focusCmd
+
+		case paneKeys && key.Matches(msg, m.keys.CopyToPane):
+			cmd := m.startTransfer(false)
+			return m, cmd
+
+		case paneKeys && key.Matches(msg, m.keys.MoveToPane):
+			cmd := m.startTransfer(true)
+			return m, cmd
+
+		case paneKeys && key.Matches(msg, m.keys.CompareDirs):
+			cmd := m.compareDirs()
+			return m, cmd
+
+		case paneKeys && key.Matches(msg, m.keys.RerootPane):
+			cmd := m.rerootPane()
+			return m, cmd
+
+		case !m.capturesKeys() && key.Matches(msg, m.keys.JumpBack):
+			cmd := m.jumpBack()
+			return m, cmd
+
+		case !m.capturesKeys() && key.Matches(msg, m.keys.JumpForward):
+			cmd := m.jumpForward()
+			return m, cmd
+
+		case key.Matches(msg, m.keys.OpenInEditor):
+			cmd := m.openInEditor()
+			return m, cmd
+
+		case key.Matches(msg, m.keys.ToggleBookmark):
+			cmd := m.toggleBookmark()
+			return m, cmd
+
+		case key.Matches(msg, m.keys.ShowBookmarks):
+			cmd := m.openPicker(pickBookmarks)
+			return m, cmd
+
+		case key.Matches(msg, m.keys.ShowRecent):
+			cmd := m.openPicker(pickRecent)
+			return m, cmd
+
+		case key.Matches(msg, m.keys.ShowJumps):
+			cmd := m.openPicker(pickJumps)
+			return m, cmd
+
+		case key.Matches(msg, m.keys.ShowOutline):
+			cmd := m.showOutline()
+			return m, cmd
+
+		case key.Matches(msg, m.keys.RecordAI):
+			cmd := m.toggleRecording()
+			return m, cmd
+
+		case key.Matches(msg, m.keys.ShowRecordings):
+			cmd := m.showRecordings()
+			return m, cmd
 
 		case key.Matches(msg, m.keys.ShrinkTree):
 			// Shrink file tree by 5%
@@ -605,8 +1005,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.leftPanelPercent < layout.MinLeftPanelPercent {
 				m.leftPanelPercent = layout.MinLeftPanelPercent
 			}
-			m.layout = layout.Calculate(m.width, m.height, m.miniVisible, m.leftPanelPercent, m.gitPanelVisible)
-			m = m.updateSizes()
+			m.layout = layout.Calculate(m.width, m.height, m.miniVisible, m.leftPanelPercent, m.gitPanelVisible, m.layoutMode)
+			*m = m.updateSizes()
 			return m, nil
 
 		case key.Matches(msg, m.keys.WidenTree):
@@ -615,8 +1015,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.leftPanelPercent > layout.MaxLeftPanelPercent {
 				m.leftPanelPercent = layout.MaxLeftPanelPercent
 			}
-			m.layout = layout.Calculate(m.width, m.height, m.miniVisible, m.leftPanelPercent, m.gitPanelVisible)
-			m = m.updateSizes()
+			m.layout = layout.Calculate(m.width, m.height, m.miniVisible, m.leftPanelPercent, m.gitPanelVisible, m.layoutMode)
+			*m = m.updateSizes()
 			return m, nil
 		}
 
@@ -628,26 +1028,35 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case FocusMsg:
 		var focusCmd tea.Cmd
-		m, focusCmd = m.setFocus(msg.Target)
+		*m, focusCmd = m.setFocus(msg.Target)
 		return m, focusCmd
 
 	case ToggleMiniBufferMsg:
 		m.miniVisible = !m.miniVisible
-		m.layout = layout.Calculate(m.width, m.height, m.miniVisible, m.leftPanelPercent, m.gitPanelVisible)
-		m = m.updateSizes()
+		m.layout = layout.Calculate(m.width, m.height, m.miniVisible, m.leftPanelPercent, m.gitPanelVisible, m.layoutMode)
+		*m = m.updateSizes()
 		return m, nil
 
 	case filetree.SelectMsg:
 		// File selected in file tree - open it in content pane and focus viewer
 		if !msg.IsDir {
+			m.setLayoutMode(layout.ModeNormal)
 			var focusCmd tea.Cmd
-			m, focusCmd = m.setFocus(PanelContent)
+			*m, focusCmd = m.setFocus(PanelContent)
 			return m, tea.Batch(focusCmd, func() tea.Msg {
 				return content.OpenFileMsg{Path: msg.Path}
 			})
 		}
 		return m, nil
 
+	case filetree.CompareMarkMsg:
+		cmd := m.markCompare(msg)
+		return m, cmd
+
+	case filetree.CompareWithMsg:
+		cmd := m.compareWith(msg)
+		return m, cmd
+
 	case filetree.StageToggleMsg:
 		// Toggle staging for a file from file tree
 		return m, func() tea.Msg {
@@ -695,33 +1104,85 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Open file from git panel - same as file tree behavior
 		fullPath := filepath.Join(m.workDir, msg.Path)
 		var focusCmd tea.Cmd
-		m, focusCmd = m.setFocus(PanelContent)
+		*m, focusCmd = m.setFocus(PanelContent)
 		return m, tea.Batch(focusCmd, func() tea.Msg {
 			return content.OpenFileMsg{Path: fullPath}
 		})
 
 	case filetree.LoadedMsg:
-		// Route to file tree
+		// Route to both file trees; each ignores loads it didn't request
 		var cmd tea.Cmd
 		m.fileTree, cmd = m.fileTree.Update(msg)
 		cmds = append(cmds, cmd)
+		if m.otherTreeReady {
+			m.otherTree, cmd = m.otherTree.Update(msg)
+			cmds = append(cmds, cmd)
+		}
 		return m, tea.Batch(cmds...)
 
 	case content.OpenFileMsg:
-		// Route to content pane
+		if cmd := m.unsavedEditsCmd(msg.Path); cmd != nil {
+			return m, cmd
+		}
+		// Remember where we were, then route to content pane
+		m.jumps.Push(m.currentLocation())
 		var cmd tea.Cmd
 		m.content, cmd = m.content.Update(msg)
 		cmds = append(cmds, cmd)
 		return m, tea.Batch(cmds...)
 
 	case viewer.FileLoadedMsg:
+		if msg.Err == nil {
+			m.recent.Touch(msg.Path)
+		}
 		// Route to content pane
 		var cmd tea.Cmd
 		m.content, cmd = m.content.Update(msg)
 		cmds = append(cmds, cmd)
 		return m, tea.Batch(cmds...)
 
+	case viewer.IndexMsg, viewer.StreamSearchMsg, viewer.HexSearchMsg:
+		// Background work on a large or binary file - route to content pane
+		// whatever has focus
+		var cmd tea.Cmd
+		m.content, cmd = m.content.Update(msg)
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
+
+	case editorFinishedMsg:
+		cmd := m.handleEditorFinished(msg)
+		return m, cmd
+
+	case viewer.FileSavedMsg:
+		// Route to content pane, then pick up the change in git and the tree
+		var cmd tea.Cmd
+		m.content, cmd = m.content.Update(msg)
+		cmds = append(cmds, cmd)
+		if msg.Err == nil {
+			cmds = append(cmds, m.refreshGitStatus(), m.fileTree.RefreshDir(filepath.Dir(msg.Path)))
+		}
+		return m, tea.Batch(cmds...)
+
+	case review.LoadedMsg, diff.OptionsChangedMsg, diff.DiffLoadedMsg:
+		// Route to content pane whatever has focus
+		var cmd tea.Cmd
+		m.content, cmd = m.content.Update(msg)
+		return m, cmd
+
+	case review.StagedMsg:
+		// Route to content pane, then pick up the change in git
+		var cmd tea.Cmd
+		m.content, cmd = m.content.Update(msg)
+		cmds = append(cmds, cmd, m.refreshGitStatus())
+		if msg.Err != nil {
+			cmds = append(cmds, m.setStatus(msg.Err.Error(), true))
+		}
+		return m, tea.Batch(cmds...)
+
 	case content.FileWithDiffMsg:
+		if msg.Err == nil {
+			m.recent.Touch(msg.Path)
+		}
 		// Route to content pane (file loaded with diff check)
 		var cmd tea.Cmd
 		m.content, cmd = m.content.Update(msg)
@@ -751,6 +1212,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 		return m, tea.Batch(cmds...)
 
+	case terminal.ExportedMsg:
+		if msg.Err != nil {
+			return m, m.setStatus("Saving transcript failed: "+msg.Err.Error(), true)
+		}
+		if msg.View {
+			m.transcripts = append(m.transcripts, msg.Path)
+			return m, func() tea.Msg {
+				return content.OpenFileMsg{Path: msg.Path}
+			}
+		}
+		return m, m.setStatus("Saved transcript to "+msg.Path, false)
+
+	case player.LoadedMsg, player.TickMsg:
+		// Route to content pane, which plays even when not focused
+		var cmd tea.Cmd
+		m.content, cmd = m.content.Update(msg)
+		return m, cmd
+
 	case terminal.OutputMsg, terminal.ExitMsg:
 		// Route to content pane (for AI terminal)
 		// Note: content.Update already calls ContinueReading() when needed
@@ -765,7 +1244,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if mouse.Button == tea.MouseLeft {
 			// Check if clicking on the border between file tree and content for resize
 			borderX := m.layout.LeftWidth
-			if mouse.X >= borderX-1 && mouse.X <= borderX+1 && m.fullscreen == PanelNone {
+			if mouse.X >= borderX-1 && mouse.X <= borderX+1 && m.fullscreen == PanelNone && !m.dualPane() {
 				m.resizingPanel = true
 				return m, nil
 			}
@@ -784,7 +1263,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					// Also focus the content panel if not already focused
 					if m.focus != PanelContent {
 						var focusCmd tea.Cmd
-						m, focusCmd = m.setFocus(PanelContent)
+						*m, focusCmd = m.setFocus(PanelContent)
 						if focusCmd != nil {
 							cmds = append(cmds, focusCmd)
 						}
@@ -795,7 +1274,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			if targetPanel != PanelNone && targetPanel != m.focus {
 				var focusCmd tea.Cmd
-				m, focusCmd = m.setFocus(targetPanel)
+				*m, focusCmd = m.setFocus(targetPanel)
 				if focusCmd != nil {
 					cmds = append(cmds, focusCmd)
 				}
@@ -822,8 +1301,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					newPercent = layout.MaxLeftPanelPercent
 				}
 				m.leftPanelPercent = newPercent
-				m.layout = layout.Calculate(m.width, m.height, m.miniVisible, m.leftPanelPercent, m.gitPanelVisible)
-				m = m.updateSizes()
+				m.layout = layout.Calculate(m.width, m.height, m.miniVisible, m.leftPanelPercent, m.gitPanelVisible, m.layoutMode)
+				*m = m.updateSizes()
 			}
 			return m, nil
 		}
@@ -911,6 +1390,10 @@ func (m Model) updateSizes() Model {
 		m.gitPanel = m.gitPanel.SetSize(leftWidth, gitPanelHeight)
 	}
 	m.content = m.content.SetSize(rightWidth, mainHeight)
+	m.picker = m.picker.SetSize(min(70, m.width-4), 0)
+	if m.otherTreeReady {
+		m.otherTree = m.otherTree.SetSize(rightWidth, mainHeight)
+	}
 
 	// Size mini buffer if visible
 	if m.miniVisible {
@@ -941,6 +1424,8 @@ func (m *Model) routeToFocused(msg tea.Msg) tea.Cmd {
 		m.content, cmd = m.content.Update(msg)
 	case PanelMiniBuffer:
 		m.miniBuffer, cmd = m.miniBuffer.Update(msg)
+	case PanelOtherTree:
+		m.otherTree, cmd = m.otherTree.Update(msg)
 	}
 
 	return cmd
@@ -982,7 +1467,12 @@ func (m Model) View() tea.View {
 	} else {
 		// Render panels
 		leftPanel := m.renderLeftPanel()
-		rightPanel := m.renderRightPanel()
+		var rightPanel string
+		if m.dualPane() {
+			rightPanel = m.renderOtherTreePanel()
+		} else {
+			rightPanel = m.renderRightPanel()
+		}
 
 		// Join horizontally
 		mainArea := lipgloss.JoinHorizontal(lipgloss.Top, leftPanel, rightPanel)
@@ -1023,6 +1513,22 @@ func (m Model) View() tea.View {
 		return v
 	}
 
+	// Show quick-pick overlay
+	if m.picker.IsOpen() {
+		v := tea.NewView(m.renderPicker(view))
+		v.AltScreen = true
+		v.MouseMode = tea.MouseModeCellMotion
+		return v
+	}
+
+	// Show copy/move confirmation
+	if m.pendingTransfer != nil {
+		v := tea.NewView(m.renderTransferDialog(view))
+		v.AltScreen = true
+		v.MouseMode = tea.MouseModeCellMotion
+		return v
+	}
+
 	// Show commit dialog
 	if m.showCommitDialog {
 		v := tea.NewView(m.renderCommitDialog(view))
@@ -1031,9 +1537,10 @@ func (m Model) View() tea.View {
 		return v
 	}
 
+	// All motion, not just drags, so links in the terminals underline on hover
 	v := tea.NewView(view)
 	v.AltScreen = true
-	v.MouseMode = tea.MouseModeCellMotion
+	v.MouseMode = tea.MouseModeAllMotion
 	return v
 }
 
@@ -1044,14 +1551,21 @@ func (m Model) renderLeftPanel() string {
 	var fileTreeHints string
 	if fileTreeFocused {
 		bottomHints := "↑↓:nav  enter:open"
-		if m.isGitRepo {
+		if m.dualPane() {
+			bottomHints = "tab:switch  F5:copy  F6:move"
+		} else if m.isGitRepo {
 			bottomHints += "  space:stage"
 		}
 		fileTreeHints = bottomHints
 	}
 
+	fileTreeTitle := "FILES"
+	if m.dualPane() {
+		fileTreeTitle = m.paneTitle(m.fileTree)
+	}
+
 	fileTreeOpts := theme.PanelTitleOptions{
-		Title:         "FILES",
+		Title:         fileTreeTitle,
 		ScrollPercent: -1, // Don't show scroll indicator
 		BottomHints:   fileTreeHints,
 	}
@@ -1116,13 +1630,20 @@ func (m Model) renderRightPanel() string {
 	if focused {
 		switch mode {
 		case content.ModeViewer:
-			if m.content.HasActiveSearch() {
+			if m.content.IsEditing() {
+				bottomHints = "ctrl+s:save  ctrl+z/y:undo/redo  esc:done"
+			} else if m.content.HasActiveSearch() {
 				bottomHints = "n/p:search  esc:clear"
 			} else {
-				bottomHints = "↑↓:scroll  /:search"
+				bottomHints = "↑↓:scroll  /:search  e:edit"
+				if m.content.IsPreview() {
+					bottomHints += "  m:source"
+				} else if m.content.CanPreview() {
+					bottomHints += "  m:preview"
+				}
 			}
 		case content.ModeDiff:
-			bottomHints = "↑↓:scroll"
+			bottomHints = "↑↓:scroll  e:edit"
 		}
 	}
 
@@ -1274,8 +1795,8 @@ func (m Model) renderStatusBar() string {
 		Render(Version)
 
 	// Layout the status bar
-	left := branch + panelInfo + help
-	right := themeName + " │ " + version
+	left := branch + panelInfo + help + m.renderStatusText()
+	right := m.renderWatchStatus() + " │ " + themeName + " │ " + version
 
 	gap := m.layout.TotalWidth - lipgloss.Width(left) - lipgloss.Width(right) - 2
 	if gap < 0 {
@@ -1285,6 +1806,60 @@ func (m Model) renderStatusBar() string {
 	return style.Render(left + lipgloss.NewStyle().Width(gap).Render("") + right)
 }
 
+// statusDuration is how long a status bar message stays visible.
+const statusDuration = 4 * time.Second
+
+// clearStatusMsg clears the status message it was scheduled for.
+type clearStatusMsg struct {
+	seq int
+}
+
+// setStatus shows a message in the status bar and returns the command that
+// clears it again.
+func (m *Model) setStatus(text string, isError bool) tea.Cmd {
+	m.statusSeq++
+	m.statusText = text
+	m.statusIsError = isError
+	seq := m.statusSeq
+	return tea.Tick(statusDuration, func(time.Time) tea.Msg {
+		return clearStatusMsg{seq: seq}
+	})
+}
+
+// renderStatusText renders the current status message, if any.
+func (m *Model) renderStatusText() string {
+	if m.statusText == "" {
+		return ""
+	}
+	color := theme.CyberCyan
+	if m.statusIsError {
+		color = theme.NeonRed
+	}
+	return lipgloss.NewStyle().
+		Foreground(color).
+		Render(" │ " + m.statusText)
+}
+
+// renderWatchStatus summarizes how file changes are being detected.
+func (m *Model) renderWatchStatus() string {
+	stats := m.watcher.Stats()
+	switch stats.Mode {
+	case watcher.ModeNotify:
+		return lipgloss.NewStyle().
+			Foreground(theme.DimPurple).
+			Render("◉ " + itoa(stats.Watched) + " watched")
+	case watcher.ModePolling:
+		// Watch limit reached - some directories are scanned instead
+		return lipgloss.NewStyle().
+			Foreground(theme.ElectricYellow).
+			Render("◌ " + itoa(stats.Polled) + " polled")
+	default:
+		return lipgloss.NewStyle().
+			Foreground(theme.NeonRed).
+			Render("○ not watching")
+	}
+}
+
 // itoa converts int to string without importing strconv
 func itoa(n int) string {
 	if n == 0 {
@@ -1333,6 +1908,8 @@ func (m Model) setFocus(target PanelID) (Model, tea.Cmd) {
 		m.content = m.content.Blur()
 	case PanelMiniBuffer:
 		m.miniBuffer = m.miniBuffer.Blur()
+	case PanelOtherTree:
+		m.otherTree = m.otherTree.Blur()
 	}
 
 	m.prevFocus = m.focus
@@ -1349,6 +1926,8 @@ func (m Model) setFocus(target PanelID) (Model, tea.Cmd) {
 		m.content, cmd = m.content.Focus()
 	case PanelMiniBuffer:
 		m.miniBuffer = m.miniBuffer.Focus()
+	case PanelOtherTree:
+		m.otherTree = m.otherTree.Focus()
 	}
 
 	return m, cmd
@@ -1381,16 +1960,38 @@ func (m Model) renderHelpOverlay(_ string) string {
 		"║   Home/g End/G Top/Bottom  │   /       Search (regex)   ║",
 		"║                            │   n/p     Next/Prev match  ║",
 		"║ PANELS                     │   Esc     Cancel search    ║",
-		"║   Alt+1   Focus file tree  │                            ║",
-		"║   Alt+2   Focus content    │ ACTIONS                    ║",
-		"║   Alt+3   Toggle terminal  │   Alt+A   Launch AI        ║",
-		"║   Alt+G   Toggle git panel │   Alt+S   Select AI        ║",
-		"║   Alt+[/] Resize panels    │   Alt+T   Cycle theme      ║",
-		"║                            │   Ctrl+H  Toggle help      ║",
-		"║ FILE TREE                  │   Ctrl+Q  Quit             ║",
-		"║   /       Search files     │                            ║",
-		"║   Esc     Clear filter     │                            ║",
-		"║   Alt+I   Compact indent   │   Press any key to close   ║",
+		"║   Alt+1   Focus file tree  │   e       Edit file        ║",
+		"║   Alt+2   Focus content    │   m       Rendered view    ║",
+		"║   Alt+3   Toggle terminal  │   z/Z     Fold/Fold all    ║",
+		"║   Alt+G   Toggle git panel │   :       Go to line[:col] ║",
+		"║   Alt+[/] Resize panels    │   w       Wrap long lines  ║",
+		"║                            │   ←/→     Scroll sideways  ║",
+		"║ FILE TREE                  │ ACTIONS                    ║",
+		"║   /       Search files     │   Alt+A   Launch AI        ║",
+		"║   Esc     Clear filter     │   Alt+S   Select AI        ║",
+		"║   Alt+I   Compact indent   │   Alt+E   Open in $EDITOR  ║",
+		"║   Alt+.   Ignored files    │   Alt+T   Cycle theme      ║",
+		"║   m / =   Mark/compare to  │   Alt+Y   Syntax colors    ║",
+		"║ JUMP                       │   Ctrl+H  Toggle help      ║",
+		"║   Ctrl+O/I Back/Forward    │   Ctrl+Q  Quit             ║",
+		"║   Alt+M   Toggle bookmark  │ DUAL PANE                  ║",
+		"║   Alt+B   Bookmarks        │   Alt+D   Toggle dual pane ║",
+		"║   Alt+O   Recent files     │   Tab     Switch pane      ║",
+		"║   Alt+J   Jump list        │   F5/F6   Copy/Move        ║",
+		"║   Alt+L   Outline          │   Alt+C   Compare dirs     ║",
+		"║                            │   Alt+R   Pane root/up     ║",
+		"║ DIFF                       │ REVIEW (Alt+V)             ║",
+		"║   n/p     Next/Prev hunk   │   Space   Mark reviewed    ║",
+		"║   [/]     Prev/Next file   │   a       Approve & stage  ║",
+		"║   s       Split/unified    │   v/c     Scope/Since SHA  ║",
+		"║   Enter   View at line     │ TERMINAL                   ║",
+		"║   w/B     Whitespace/blank │   Alt+/   Search history   ║",
+		"║   +/-/F   Context/function │   Alt+X   Save transcript  ║",
+		"║   r       Diff against ref │   Alt+K   Open file:line   ║",
+		"║ REPLAY                     │   Space   Play/pause       ║",
+		"║   Alt+W   Record AI        │   ←→/↑↓   Seek 5s/30s      ║",
+		"║   Alt+P   Recordings       │   +/-     Speed            ║",
+		"║                            │   Press any key to close   ║",
 		"╚════════════════════════════╧════════════════════════════╝",
 	}
 
@@ -1425,6 +2026,10 @@ func (m Model) renderQuitDialog(_ string) string {
 		"║                                    ║",
 		"╚════════════════════════════════════╝",
 	}
+	if m.content.IsModified() {
+		quitLines[3] = "║    Unsaved edits will be lost!     ║"
+		quitLines[6] = "║           [Y]es    [N]o            ║"
+	}
 
 	quitContent := lipgloss.JoinVertical(lipgloss.Left, quitLines...)
 
@@ -1606,6 +2211,7 @@ func (m Model) handleAIDialog(msg tea.KeyPressMsg) (Model, tea.Cmd) {
 					return content.LaunchAIMsg{
 						Command: m.aiCommand,
 						Args:    m.aiArgs,
+						Record:  m.recordPath(m.aiCommand),
 					}
 				})
 			}
@@ -1684,12 +2290,27 @@ func (m Model) handleAIDialog(msg tea.KeyPressMsg) (Model, tea.Cmd) {
 			return content.LaunchAIMsg{
 				Command: m.aiCommand,
 				Args:    m.aiArgs,
+				Record:  m.recordPath(m.aiCommand),
 			}
 		})
 	}
 	return m, nil
 }
 
+// quit saves the state and stops the terminals before quitting, so a
+// recording in progress is written out in full, and removes the temporary
+// transcript files.
+func (m *Model) quit() tea.Cmd {
+	m.saveState()
+	m.content.StopTerminal()
+	m.miniBuffer.Stop()
+	for _, path := range m.transcripts {
+		os.Remove(path)
+	}
+	m.transcripts = nil
+	return tea.Quit
+}
+
 // saveState persists the current application state globally.
 func (m Model) saveState() {
 	s := state.State{
@@ -1697,8 +2318,12 @@ func (m Model) saveState() {
 		ThemeIndex:       theme.CurrentThemeIndex(),
 		LeftPanelPercent: m.leftPanelPercent,
 		CompactIndent:    m.fileTree.CompactIndent(),
+		IgnoredMode:      int(m.fileTree.IgnoredMode()),
+		SyntaxStyle:      syntax.Override(),
 		AICommand:        m.aiCommand,
 		AIArgs:           m.aiArgs,
+		RecordAI:         m.recordAI,
+		Projects:         m.projectState(),
 	}
 	// Ignore errors - state persistence is best-effort
 	_ = state.Save(s)
@@ -1735,9 +2360,12 @@ func (m Model) panelAtPosition(x, y int) PanelID {
 		}
 	}
 
-	// Check content panel
+	// Check content panel (the second tree in dual-pane mode)
 	if y < m.layout.MainHeight {
 		if x >= leftW {
+			if m.dualPane() {
+				return PanelOtherTree
+			}
 			return PanelContent
 		}
 	}
@@ -1807,8 +2435,18 @@ func (m *Model) routeMouseClickToPanel(msg tea.MouseClickMsg) tea.Cmd {
 		m.content, cmd = m.content.Update(tea.MouseClickMsg(adjustedMouse))
 	case PanelMiniBuffer:
 		if m.miniVisible {
-			m.miniBuffer, cmd = m.miniBuffer.Update(msg)
+			// Adjust Y coordinate relative to mini buffer
+			_, miniY, _, _ := m.layout.MiniBufferBounds()
+			adjustedMouse := mouse
+			adjustedMouse.Y = mouse.Y - miniY
+			m.miniBuffer, cmd = m.miniBuffer.Update(tea.MouseClickMsg(adjustedMouse))
 		}
+	case PanelOtherTree:
+		// Adjust X coordinate relative to the right pane
+		paneX, _, _, _ := m.layout.RightPanelBounds()
+		adjustedMouse := mouse
+		adjustedMouse.X = mouse.X - paneX
+		m.otherTree, cmd = m.otherTree.Update(tea.MouseClickMsg(adjustedMouse))
 	}
 
 	return cmd
@@ -1859,8 +2497,18 @@ func (m *Model) routeMouseToPanel(msg tea.Msg) tea.Cmd {
 		}
 	case PanelMiniBuffer:
 		if m.miniVisible {
+			// Adjust Y coordinate relative to mini buffer for link hovering
+			if mm, ok := msg.(tea.MouseMotionMsg); ok {
+				_, miniY, _, _ := m.layout.MiniBufferBounds()
+				adjustedMouse := mm.Mouse()
+				adjustedMouse.Y -= miniY
+				msg = tea.MouseMotionMsg(adjustedMouse)
+			}
 			m.miniBuffer, cmd = m.miniBuffer.Update(msg)
 		}
+	case PanelOtherTree:
+		// The tree only scrolls on the wheel, which carries no position it uses
+		m.otherTree, cmd = m.otherTree.Update(msg)
 	}
 
 	return cmd
@@ -1,10 +1,28 @@
 package app
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
+	"github.com/avitaltamir/vibecommander/internal/compare"
+	"github.com/avitaltamir/vibecommander/internal/components/content"
+	"github.com/avitaltamir/vibecommander/internal/components/content/viewer"
+	"github.com/avitaltamir/vibecommander/internal/components/filetree"
+	"github.com/avitaltamir/vibecommander/internal/components/quickpick"
+	"github.com/avitaltamir/vibecommander/internal/components/terminal"
+	"github.com/avitaltamir/vibecommander/internal/history"
+	"github.com/avitaltamir/vibecommander/internal/ignore"
+	"github.com/avitaltamir/vibecommander/internal/layout"
+	"github.com/avitaltamir/vibecommander/internal/links"
+	"github.com/avitaltamir/vibecommander/internal/state"
+	"github.com/avitaltamir/vibecommander/internal/syntax"
+	"github.com/avitaltamir/vibecommander/internal/watcher"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNew(t *testing.T) {
@@ -25,6 +43,7 @@ func TestPanelIDString(t *testing.T) {
 		{PanelFileTree, "FileTree"},
 		{PanelContent, "Content"},
 		{PanelMiniBuffer, "MiniBuffer"},
+		{PanelOtherTree, "OtherTree"},
 		{PanelID(99), "Unknown"},
 	}
 
@@ -169,7 +188,7 @@ func TestModelView(t *testing.T) {
 
 		// Verify view is properly configured
 		assert.True(t, view.AltScreen)
-		assert.Equal(t, tea.MouseModeCellMotion, view.MouseMode)
+		assert.Equal(t, tea.MouseModeAllMotion, view.MouseMode, "links underline on hover")
 	})
 
 	t.Run("renders mini buffer when visible", func(t *testing.T) {
@@ -575,3 +594,701 @@ func TestSelectAIKeyBinding(t *testing.T) {
 	assert.NotEmpty(t, km.SelectAI.Keys())
 	assert.Contains(t, km.SelectAI.Keys(), "alt+s")
 }
+
+func TestGitMetadataChanged(t *testing.T) {
+	m := Model{gitDir: "/repo/.git", gitCommonDir: "/repo/.git"}
+
+	tests := []struct {
+		path     string
+		relevant bool
+	}{
+		{"/repo/.git/HEAD", true},
+		{"/repo/.git/index", true},
+		{"/repo/.git/packed-refs", true},
+		{"/repo/.git/refs/heads/main", true},
+		{"/repo/.git/refs/remotes/origin/main", true},
+		{"/repo/.git/index.lock", false},
+		{"/repo/.git/refs/heads/main.lock", false},
+		{"/repo/.git/HEAD.lock", false},
+		{"/repo/.git/COMMIT_EDITMSG", false},
+		{"/repo/.git/config", false},
+		{"/repo/.git/logs/refs/heads/main", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.True(t, m.isGitMetadataPath(tt.path))
+			assert.Equal(t, tt.relevant, m.gitMetadataChanged(tt.path))
+		})
+	}
+
+	t.Run("working tree paths are not metadata", func(t *testing.T) {
+		assert.False(t, m.isGitMetadataPath("/repo/main.go"))
+		assert.False(t, m.isGitMetadataPath("/repo/.github/workflows/ci.yml"))
+	})
+}
+
+func TestGitMetadataChangeSchedulesRefresh(t *testing.T) {
+	m := New()
+	m.gitDir = "/repo/.git"
+	m.gitCommonDir = "/repo/.git"
+
+	t.Run("lock files are ignored", func(t *testing.T) {
+		newModel, _ := m.Update(FileChangeMsg{Path: "/repo/.git/index.lock"})
+		updated := newModel.(Model)
+		assert.False(t, updated.gitMetaDebouncing)
+		assert.Empty(t, updated.pendingFileChanges)
+	})
+
+	t.Run("index change schedules a refresh without touching the tree", func(t *testing.T) {
+		newModel, cmd := m.Update(FileChangeMsg{Path: "/repo/.git/index"})
+		updated := newModel.(Model)
+		assert.True(t, updated.gitMetaDebouncing)
+		assert.NotNil(t, cmd)
+		assert.Empty(t, updated.pendingFileChanges)
+	})
+}
+
+func TestSyncWatches(t *testing.T) {
+	dirA := t.TempDir()
+	dirB := t.TempDir()
+
+	m := New()
+	defer m.watcher.Close()
+
+	watched := m.syncWatches(nil, []string{dirA, dirB}, watcher.ReasonTree)
+	assert.True(t, m.watcher.Has(dirA, watcher.ReasonTree))
+	assert.True(t, m.watcher.Has(dirB, watcher.ReasonTree))
+
+	watched = m.syncWatches(watched, []string{dirB}, watcher.ReasonTree)
+	assert.False(t, m.watcher.Has(dirA, watcher.ReasonTree))
+	assert.True(t, m.watcher.Has(dirB, watcher.ReasonTree))
+	assert.Len(t, watched, 1)
+
+	// A watch the watcher dropped by itself is added back
+	m.watcher.Remove(dirB, watcher.ReasonTree)
+	m.syncWatches(watched, []string{dirB}, watcher.ReasonTree)
+	assert.True(t, m.watcher.Has(dirB, watcher.ReasonTree))
+}
+
+func TestSyncWatchesIgnored(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("node_modules/\n"), 0644))
+	src := filepath.Join(root, "src")
+	deps := filepath.Join(root, "node_modules")
+	gitDir := filepath.Join(root, ".git")
+	for _, dir := range []string{src, deps, gitDir} {
+		require.NoError(t, os.Mkdir(dir, 0755))
+	}
+
+	m := New()
+	defer m.watcher.Close()
+	m.ignore = ignore.New(root)
+
+	watched := m.syncWatches(nil, []string{src, deps, gitDir}, watcher.ReasonTree)
+	assert.True(t, m.watcher.Has(src, watcher.ReasonTree))
+	assert.False(t, m.watcher.Has(deps, watcher.ReasonTree))
+	assert.False(t, m.watcher.Has(gitDir, watcher.ReasonTree))
+	assert.Equal(t, map[string]bool{src: true}, watched)
+
+	// Ignored after the rules change: the watch is dropped
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("node_modules/\nsrc/\n"), 0644))
+	m.ignore.Reload()
+	watched = m.syncWatches(watched, []string{src, deps}, watcher.ReasonTree)
+	assert.False(t, m.watcher.Has(src, watcher.ReasonTree))
+	assert.Empty(t, watched)
+}
+
+func TestRewatchCreated(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "a", "b")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+
+	m := New()
+	defer m.watcher.Close()
+	m.trackedWatchDirs = m.syncWatches(nil, []string{sub}, watcher.ReasonTracked)
+
+	// Deleted and recreated: the watcher let go, the model still wants it
+	require.NoError(t, os.RemoveAll(filepath.Join(root, "a")))
+	require.Eventually(t, func() bool {
+		return !m.watcher.Has(sub, watcher.ReasonTracked)
+	}, 2*time.Second, 10*time.Millisecond)
+	require.NoError(t, os.MkdirAll(sub, 0755))
+
+	m.rewatchCreated(filepath.Join(root, "a"))
+	assert.True(t, m.watcher.Has(sub, watcher.ReasonTracked))
+}
+
+func TestDualPaneToggle(t *testing.T) {
+	m := New()
+	defer m.watcher.Close()
+	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = newModel.(Model)
+
+	toggle := tea.KeyPressMsg{Code: 'd', Mod: tea.ModAlt}
+
+	t.Run("opens the second pane", func(t *testing.T) {
+		newModel, cmd := m.Update(toggle)
+		m = newModel.(Model)
+		assert.Equal(t, layout.ModeDualPane, m.layoutMode)
+		assert.True(t, m.otherTreeReady)
+		assert.NotNil(t, cmd) // Loads the second tree
+		assert.Equal(t, PanelOtherTree, m.panelAtPosition(m.layout.LeftWidth+5, 5))
+	})
+
+	t.Run("tab switches panes", func(t *testing.T) {
+		newModel, _ := m.Update(tea.KeyPressMsg{Code: tea.KeyTab})
+		m = newModel.(Model)
+		assert.Equal(t, PanelOtherTree, m.Focus())
+
+		newModel, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyTab})
+		m = newModel.(Model)
+		assert.Equal(t, PanelFileTree, m.Focus())
+	})
+
+	t.Run("closing returns focus to the file tree", func(t *testing.T) {
+		newModel, _ := m.Update(tea.KeyPressMsg{Code: tea.KeyTab})
+		m = newModel.(Model)
+
+		newModel, _ = m.Update(toggle)
+		m = newModel.(Model)
+		assert.Equal(t, layout.ModeNormal, m.layoutMode)
+		assert.Equal(t, PanelFileTree, m.Focus())
+		assert.Equal(t, PanelContent, m.panelAtPosition(m.layout.LeftWidth+5, 5))
+	})
+}
+
+func TestHandleCompareDone(t *testing.T) {
+	m := New()
+	defer m.watcher.Close()
+
+	left := "/a"
+	right := "/b"
+	m.handleCompareDone(compareDoneMsg{
+		left:  left,
+		right: right,
+		entries: []compare.Entry{
+			{Path: "sub/deep.go", Status: compare.StatusDiffers},
+			{Path: "only-left.go", Status: compare.StatusLeftOnly},
+			{Path: "sub/only-right.go", Status: compare.StatusRightOnly},
+		},
+	})
+
+	assert.True(t, m.fileTree.HasCompareMarks())
+	assert.True(t, m.otherTree.HasCompareMarks())
+	assert.Equal(t, "3 differences (Alt+C to clear)", m.statusText)
+
+	t.Run("marks parents of differing entries", func(t *testing.T) {
+		marks := make(map[string]filetree.CompareMark)
+		markPath(marks, left, filepath.Join("sub", "inner", "x.go"), filetree.MarkOnlyHere)
+		assert.Equal(t, map[string]filetree.CompareMark{
+			filepath.Join(left, "sub", "inner", "x.go"): filetree.MarkOnlyHere,
+			filepath.Join(left, "sub", "inner"):         filetree.MarkDiffers,
+			filepath.Join(left, "sub"):                  filetree.MarkDiffers,
+		}, marks)
+	})
+
+	t.Run("identical directories only report status", func(t *testing.T) {
+		m := New()
+		defer m.watcher.Close()
+		m.handleCompareDone(compareDoneMsg{left: left, right: right})
+		assert.False(t, m.fileTree.HasCompareMarks())
+		assert.Equal(t, "Directories are identical", m.statusText)
+	})
+}
+
+func TestTransferDialog(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(src, []byte("hello"), 0644))
+	dst := filepath.Join(dir, "out", "a.txt")
+	require.NoError(t, os.MkdirAll(filepath.Dir(dst), 0755))
+
+	m := New()
+	defer m.watcher.Close()
+
+	t.Run("escape cancels", func(t *testing.T) {
+		m.pendingTransfer = &transfer{src: src, dst: dst}
+		cmd := m.handleTransferDialog(tea.KeyPressMsg{Code: tea.KeyEscape})
+		assert.Nil(t, m.pendingTransfer)
+		assert.Nil(t, cmd)
+	})
+
+	t.Run("enter copies", func(t *testing.T) {
+		m.pendingTransfer = &transfer{src: src, dst: dst}
+		cmd := m.handleTransferDialog(tea.KeyPressMsg{Code: tea.KeyEnter})
+		assert.Nil(t, m.pendingTransfer)
+		require.NotNil(t, cmd)
+
+		done, ok := cmd().(transferDoneMsg)
+		require.True(t, ok)
+		require.NoError(t, done.err)
+		assert.FileExists(t, dst)
+		assert.FileExists(t, src)
+
+		m.handleTransferDone(done)
+		assert.False(t, m.statusIsError)
+		assert.Contains(t, m.statusText, "Copy done")
+	})
+
+	t.Run("failures are shown as errors", func(t *testing.T) {
+		m.pendingTransfer = &transfer{src: src, dst: dst}
+		cmd := m.handleTransferDialog(tea.KeyPressMsg{Code: 'y', Text: "y"})
+		done := cmd().(transferDoneMsg)
+		require.Error(t, done.err)
+
+		m.handleTransferDone(done)
+		assert.True(t, m.statusIsError)
+		assert.Contains(t, m.statusText, "Copy failed")
+	})
+}
+
+func TestStatusMessageClears(t *testing.T) {
+	m := New()
+	defer m.watcher.Close()
+
+	newModel, _ := m.Update(StatusMsg{Text: "first"})
+	m = newModel.(Model)
+	first := m.statusSeq
+	newModel, _ = m.Update(StatusMsg{Text: "second"})
+	m = newModel.(Model)
+
+	// A stale clear tick leaves the newer message alone
+	newModel, _ = m.Update(clearStatusMsg{seq: first})
+	m = newModel.(Model)
+	assert.Equal(t, "second", m.statusText)
+
+	newModel, _ = m.Update(clearStatusMsg{seq: m.statusSeq})
+	m = newModel.(Model)
+	assert.Empty(t, m.statusText)
+}
+
+func TestRecentFilesAndJumps(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.go")
+	b := filepath.Join(dir, "b.go")
+	require.NoError(t, os.WriteFile(a, []byte("package a\n"), 0644))
+	require.NoError(t, os.WriteFile(b, []byte("package b\n"), 0644))
+
+	m := New()
+	defer m.watcher.Close()
+	m.workDir = dir
+	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = newModel.(Model)
+
+	open := func(m Model, path string) Model {
+		newModel, _ := m.Update(content.OpenFileMsg{Path: path})
+		newModel, _ = newModel.Update(viewer.FileLoadedMsg{Path: path, Content: "package x\n"})
+		return newModel.(Model)
+	}
+
+	m = open(m, a)
+	m = open(m, b)
+
+	t.Run("loaded files are recent", func(t *testing.T) {
+		assert.Equal(t, []string{b, a}, m.recent.Paths())
+	})
+
+	t.Run("opening a file records a jump", func(t *testing.T) {
+		assert.Equal(t, []history.Location{{Path: a}}, m.jumps.Entries())
+	})
+
+	t.Run("jump back returns to the previous file", func(t *testing.T) {
+		cmd := m.jumpBack()
+		assert.Equal(t, PanelContent, m.Focus())
+		assert.NotNil(t, cmd)
+		assert.Equal(t, a, m.content.CurrentPath())
+
+		_, ok := m.jumps.Forward()
+		assert.True(t, ok)
+	})
+
+	t.Run("recent picker lists newest first", func(t *testing.T) {
+		m.openPicker(pickRecent)
+		assert.True(t, m.picker.IsOpen())
+		item, ok := m.picker.Selected()
+		require.True(t, ok)
+		assert.Equal(t, b, item.Value)
+	})
+}
+
+func TestOutlinePicker(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	src := "package main\n\ntype server struct{}\n\nfunc (s *server) run() {\n" +
+		strings.Repeat("\ts.step()\n", 100) + "}\n\nfunc main() {}\n"
+	require.NoError(t, os.WriteFile(file, []byte(src), 0644))
+
+	m := New()
+	defer m.watcher.Close()
+	m.workDir = dir
+	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = newModel.(Model)
+
+	m.showOutline()
+	assert.False(t, m.picker.IsOpen())
+	assert.Equal(t, "Open a file to see its outline", m.statusText)
+
+	newModel, _ = m.Update(content.OpenFileMsg{Path: file})
+	newModel, _ = newModel.Update(viewer.FileLoadedMsg{Path: file, Content: src, Line: 50})
+	m = newModel.(Model)
+
+	m.showOutline()
+	require.True(t, m.picker.IsOpen())
+	item, ok := m.picker.Selected()
+	require.True(t, ok)
+	assert.Equal(t, "server.run", item.Title, "starts on the function around the current line")
+	assert.Equal(t, "method · line 5", item.Detail)
+
+	m.picker = m.picker.SetCursor(0)
+	item, _ = m.picker.Selected()
+	m.handlePickerSelect(quickpick.SelectMsg{ID: pickOutline, Item: item})
+	assert.Equal(t, PanelContent, m.Focus())
+	assert.Equal(t, 2, m.content.CurrentLine())
+	assert.Equal(t, []history.Location{{Path: file, Line: 50}}, m.jumps.Entries())
+}
+
+func TestSyntaxStylePicker(t *testing.T) {
+	t.Cleanup(func() { syntax.SetOverride("") })
+	syntax.SetOverride("")
+
+	m := New()
+	defer m.watcher.Close()
+	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = newModel.(Model)
+
+	m.openPicker(pickSyntax)
+	require.True(t, m.picker.IsOpen())
+	item, ok := m.picker.Selected()
+	require.True(t, ok)
+	assert.Equal(t, "Match theme", item.Title, "starts on the theme's colors")
+
+	m.handlePickerSelect(quickpick.SelectMsg{ID: pickSyntax, Item: quickpick.Item{Title: "dracula", Value: "dracula"}})
+	assert.Equal(t, "dracula", syntax.Override())
+	assert.Equal(t, "Highlighting code with dracula", m.statusText)
+
+	m.openPicker(pickSyntax)
+	item, _ = m.picker.Selected()
+	assert.Equal(t, "dracula", item.Title, "starts on the style in use")
+
+	m.handlePickerSelect(quickpick.SelectMsg{ID: pickSyntax, Item: quickpick.Item{Title: "Match theme"}})
+	assert.Equal(t, "", syntax.Override())
+}
+
+func TestParseFileArg(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(file, []byte("package main\n"), 0644))
+
+	loc, err := ParseFileArg(file)
+	require.NoError(t, err)
+	assert.Equal(t, history.Location{Path: file}, loc)
+
+	loc, err = ParseFileArg(file + ":42")
+	require.NoError(t, err)
+	assert.Equal(t, history.Location{Path: file, Line: 41}, loc)
+
+	loc, err = ParseFileArg(file + ":42:7")
+	require.NoError(t, err)
+	assert.Equal(t, history.Location{Path: file, Line: 41}, loc)
+
+	_, err = ParseFileArg(filepath.Join(dir, "missing.go:3"))
+	assert.Error(t, err)
+
+	_, err = ParseFileArg(dir)
+	assert.ErrorContains(t, err, "is a directory")
+}
+
+func TestOpenAtStartup(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(file, []byte("package main\n"), 0644))
+
+	m := New()
+	defer m.watcher.Close()
+	m.restoreAI = true
+	m.OpenAt(history.Location{Path: file, Line: 3})
+
+	newModel, cmd := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = newModel.(Model)
+	assert.Equal(t, PanelContent, m.Focus())
+	assert.Equal(t, file, m.content.CurrentPath())
+	assert.False(t, m.restoreAI, "the file is shown instead of the AI")
+	assert.NotNil(t, cmd)
+
+	newModel, _ = m.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	assert.Nil(t, newModel.(Model).openAt, "only opened once")
+}
+
+func TestToggleBookmark(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(file, []byte(""), 0644))
+
+	m := New()
+	defer m.watcher.Close()
+	m.workDir = dir
+	newModel, _ := m.Update(content.OpenFileMsg{Path: file})
+	m = newModel.(Model)
+	m, _ = m.setFocus(PanelContent)
+
+	m.toggleBookmark()
+	assert.Equal(t, []string{file}, m.bookmarks.Paths())
+	assert.Equal(t, "Bookmarked main.go", m.statusText)
+
+	t.Run("removing from the picker", func(t *testing.T) {
+		m := m
+		m.openPicker(pickBookmarks)
+		item, ok := m.picker.Selected()
+		require.True(t, ok)
+
+		m.handlePickerRemove(quickpick.RemoveMsg{ID: pickBookmarks, Item: item})
+		assert.Empty(t, m.bookmarks.Paths())
+		_, ok = m.picker.Selected()
+		assert.False(t, ok)
+	})
+
+	t.Run("toggling again removes it", func(t *testing.T) {
+		m.toggleBookmark()
+		assert.Empty(t, m.bookmarks.Paths())
+	})
+}
+
+func TestProjectState(t *testing.T) {
+	m := New()
+	defer m.watcher.Close()
+	m.workDir = "/work/app"
+	m.projects = map[string]state.Project{
+		"/work/other": {Recent: []string{"/work/other/x.go"}},
+		"/work/app":   {Recent: []string{"/work/app/stale.go"}},
+	}
+	m.bookmarks = history.NewBookmarks([]string{"/work/app/main.go"})
+	m.recent = history.Recent{}
+	m.content.SetReviewed(map[string]string{"main.go": "1234"})
+
+	projects := m.projectState()
+	assert.Equal(t, []string{"/work/other/x.go"}, projects["/work/other"].Recent)
+	assert.Equal(t, []string{"/work/app/main.go"}, projects["/work/app"].Bookmarks)
+	assert.Empty(t, projects["/work/app"].Recent)
+	assert.Equal(t, map[string]string{"main.go": "1234"}, projects["/work/app"].Reviewed)
+
+	t.Run("empty projects are dropped", func(t *testing.T) {
+		m.bookmarks = history.Bookmarks{}
+		m.content.SetReviewed(nil)
+		projects := m.projectState()
+		assert.NotContains(t, projects, "/work/app")
+		assert.Contains(t, projects, "/work/other")
+	})
+}
+
+func TestUnsavedEditsGuard(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.go")
+	b := filepath.Join(dir, "b.go")
+	require.NoError(t, os.WriteFile(a, []byte("package a\n"), 0644))
+	require.NoError(t, os.WriteFile(b, []byte("package b\n"), 0644))
+
+	m := New()
+	defer m.watcher.Close()
+	m.workDir = dir
+	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = newModel.(Model)
+
+	newModel, _ = m.Update(content.OpenFileMsg{Path: a})
+	newModel, _ = newModel.Update(viewer.EditFileAt(a, 0)())
+	m = newModel.(Model)
+	m, _ = m.setFocus(PanelContent)
+	require.True(t, m.content.IsEditing())
+	assert.True(t, m.capturesKeys())
+
+	newModel, _ = m.Update(tea.KeyPressMsg{Code: 'x', Text: "x"})
+	m = newModel.(Model)
+	require.True(t, m.content.IsModified())
+
+	t.Run("opening another file is refused", func(t *testing.T) {
+		newModel, _ := m.Update(content.OpenFileMsg{Path: b})
+		m := newModel.(Model)
+		assert.Equal(t, a, m.content.CurrentPath())
+		assert.True(t, m.statusIsError)
+		assert.Contains(t, m.statusText, "Unsaved edits in a.go")
+	})
+
+	t.Run("jumps are refused", func(t *testing.T) {
+		m.gotoLocation(history.Location{Path: b})
+		assert.Equal(t, a, m.content.CurrentPath())
+	})
+
+	t.Run("title marks unsaved edits", func(t *testing.T) {
+		title, _ := m.content.TitleInfo()
+		assert.Equal(t, "a.go [+]", title)
+	})
+
+	t.Run("double-tap quit asks first", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir()) // State goes under it
+		quit := tea.KeyPressMsg{Code: 'q', Mod: tea.ModCtrl}
+		newModel, _ := m.Update(quit)
+		m := newModel.(Model)
+		require.True(t, m.showQuit)
+		newModel, cmd := m.Update(quit)
+		m = newModel.(Model)
+		assert.Nil(t, cmd)
+		assert.True(t, m.showQuit)
+		assert.Contains(t, m.renderQuitDialog(""), "Unsaved edits will be lost!")
+
+		// Dismissed and tapped twice again
+		m.showQuit = false
+		newModel, _ = m.Update(quit)
+		m = newModel.(Model)
+		assert.True(t, m.showQuit)
+
+		_, cmd = m.Update(tea.KeyPressMsg{Code: 'y', Text: "y"})
+		assert.NotNil(t, cmd)
+	})
+}
+
+func TestOpenInEditor(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.go")
+	require.NoError(t, os.WriteFile(a, []byte("package a\n\nfunc needle() {}\n"), 0644))
+
+	m := New()
+	defer m.watcher.Close()
+	m.workDir = dir
+	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = newModel.(Model)
+
+	t.Run("nothing to open", func(t *testing.T) {
+		m, _ := m.setFocus(PanelContent)
+		m.openInEditor()
+		assert.True(t, m.statusIsError)
+	})
+
+	newModel, _ = m.Update(content.OpenFileMsg{Path: a})
+	m = newModel.(Model)
+	newModel, _ = m.Update(viewer.FileLoadedMsg{Path: a, Content: "package a\n\nfunc needle() {}\n"})
+	m = newModel.(Model)
+	m, _ = m.setFocus(PanelContent)
+
+	t.Run("targets the search match", func(t *testing.T) {
+		newModel, _ := m.Update(tea.KeyPressMsg{Code: '/', Text: "/"})
+		m := newModel.(Model)
+		for _, r := range "needle" {
+			newModel, _ := m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+			m = newModel.(Model)
+		}
+		newModel, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+		m = newModel.(Model)
+
+		path, line, col := m.editorTarget()
+		assert.Equal(t, a, path)
+		assert.Equal(t, 2, line)
+		assert.Equal(t, 5, col)
+	})
+
+	t.Run("targets the line of the diff", func(t *testing.T) {
+		d := "@@ -1,3 +1,3 @@\n package a\n \n-func haystack() {}\n+func needle() {}"
+		newModel, _ := m.Update(content.FileWithDiffMsg{Path: a, Diff: d, HasDiff: true})
+		m := newModel.(Model)
+		require.Equal(t, content.ModeDiff, m.content.Mode())
+		m, _ = m.setFocus(PanelContent)
+		newModel, _ = m.Update(tea.KeyPressMsg{Code: 'n', Text: "n"}) // To the change
+		m = newModel.(Model)
+
+		path, line, col := m.editorTarget()
+		assert.Equal(t, a, path)
+		assert.Equal(t, 2, line)
+		assert.Equal(t, 0, col)
+	})
+
+	t.Run("reloads the shown file when the editor exits", func(t *testing.T) {
+		cmd := m.handleEditorFinished(editorFinishedMsg{path: a, line: 2})
+		assert.NotNil(t, cmd)
+		assert.Equal(t, a, m.content.CurrentPath())
+		assert.False(t, m.statusIsError)
+
+		m.handleEditorFinished(editorFinishedMsg{path: a, err: assert.AnError})
+		assert.True(t, m.statusIsError)
+	})
+}
+
+func TestQuitRemovesTranscripts(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // State goes under it
+
+	path := filepath.Join(t.TempDir(), "transcript-1.txt")
+	require.NoError(t, os.WriteFile(path, []byte("line\n"), 0644))
+
+	m := New()
+	defer m.watcher.Close()
+	newModel, cmd := m.Update(terminal.ExportedMsg{Path: path, View: true})
+	m = newModel.(Model)
+	require.NotNil(t, cmd)
+	assert.Equal(t, content.OpenFileMsg{Path: path}, cmd())
+	assert.FileExists(t, path)
+
+	m.showQuit = true
+	_, cmd = m.Update(tea.KeyPressMsg{Code: 'y', Text: "y"})
+	require.NotNil(t, cmd)
+	assert.NoFileExists(t, path)
+}
+
+func TestRecordingAISessions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // Recordings and state go under it
+
+	m := New()
+	defer m.watcher.Close()
+	m.workDir = t.TempDir()
+	m.recordAI = false
+	assert.Empty(t, m.recordPath("claude"))
+
+	m.toggleRecording()
+	require.True(t, m.recordAI)
+	assert.True(t, state.Load().RecordAI, "remembered")
+	path := m.recordPath("claude")
+	dir, err := state.RecordingsDir(m.workDir)
+	require.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(path))
+	assert.True(t, strings.HasSuffix(path, "-claude.cast"))
+
+	m.showRecordings()
+	assert.False(t, m.picker.IsOpen())
+	assert.Contains(t, m.statusText, "No recordings yet")
+
+	// Listed newest first
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	for _, name := range []string{"2026-01-02T10-00-00-claude.cast", "2026-03-04T10-00-00-codex.cast", "notes.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(`{"version": 2}`), 0644))
+	}
+	m.showRecordings()
+	require.True(t, m.picker.IsOpen())
+	item, ok := m.picker.Selected()
+	require.True(t, ok)
+	assert.Equal(t, "2026-03-04T10-00-00-codex", item.Title)
+	assert.Len(t, m.pickerItems(pickRecordings), 2)
+
+	m.toggleRecording()
+	assert.False(t, m.recordAI)
+	assert.False(t, state.Load().RecordAI)
+}
+
+func TestOpenLink(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(file, []byte("package main\n"), 0644))
+
+	m := New()
+	defer m.watcher.Close()
+	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = newModel.(Model)
+
+	newModel, cmd := m.Update(links.OpenMsg{Path: file, Line: 11})
+	m = newModel.(Model)
+	assert.NotNil(t, cmd)
+	assert.Equal(t, PanelContent, m.Focus())
+	assert.Equal(t, file, m.content.CurrentPath())
+
+	// Anything else is copied, or says why it couldn't be
+	newModel, _ = m.Update(links.OpenMsg{URL: "https://example.com"})
+	m = newModel.(Model)
+	assert.Contains(t, m.statusText, "https://example.com")
+	assert.Equal(t, file, m.content.CurrentPath())
+}
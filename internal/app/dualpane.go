@@ -0,0 +1,351 @@
+package app
+
+import (
+	"path/filepath"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/avitaltamir/vibecommander/internal/compare"
+	"github.com/avitaltamir/vibecommander/internal/components/content"
+	"github.com/avitaltamir/vibecommander/internal/components/filetree"
+	"github.com/avitaltamir/vibecommander/internal/fileops"
+	"github.com/avitaltamir/vibecommander/internal/layout"
+	"github.com/avitaltamir/vibecommander/internal/theme"
+)
+
+// transfer is a pending copy or move between the two panes.
+type transfer struct {
+	move bool
+	src  string
+	dst  string
+}
+
+// verb returns "Copy" or "Move".
+func (t transfer) verb() string {
+	if t.move {
+		return "Move"
+	}
+	return "Copy"
+}
+
+// Messages
+type (
+	// transferDoneMsg is sent when a copy or move finishes.
+	transferDoneMsg struct {
+		transfer transfer
+		err      error
+	}
+
+	// compareDoneMsg carries the result of comparing the panes' directories.
+	compareDoneMsg struct {
+		left, right string
+		entries     []compare.Entry
+		err         error
+	}
+)
+
+// setLayoutMode switches between the normal and the dual-pane layout.
+func (m *Model) setLayoutMode(mode layout.Mode) tea.Cmd {
+	if m.layoutMode == mode {
+		return nil
+	}
+
+	var cmds []tea.Cmd
+	m.layoutMode = mode
+	if mode == layout.ModeDualPane {
+		// Exit fullscreen - the content pane is replaced by the second tree
+		m.fullscreen = PanelNone
+		if !m.otherTreeReady {
+			m.otherTree = m.newOtherTree()
+			m.otherTreeReady = true
+			cmds = append(cmds, m.otherTree.Init())
+		}
+	} else {
+		// Marks only make sense while both panes are visible
+		m.fileTree.SetCompareMarks(nil)
+		m.otherTree.SetCompareMarks(nil)
+	}
+
+	m.layout = layout.Calculate(m.width, m.height, m.miniVisible, m.leftPanelPercent, m.gitPanelVisible, m.layoutMode)
+	*m = m.updateSizes()
+
+	// Move focus off whichever panel just disappeared
+	var focusCmd tea.Cmd
+	switch {
+	case mode == layout.ModeDualPane && m.focus == PanelContent:
+		*m, focusCmd = m.setFocus(PanelFileTree)
+	case mode == layout.ModeNormal && m.focus == PanelOtherTree:
+		*m, focusCmd = m.setFocus(PanelFileTree)
+	}
+	cmds = append(cmds, focusCmd)
+
+	return tea.Batch(cmds...)
+}
+
+// newOtherTree creates the second pane with the same settings as the first.
+func (m *Model) newOtherTree() filetree.Model {
+	tree, err := filetree.NewWithPath(m.workDir)
+	if err != nil {
+		tree = filetree.New()
+	}
+	tree.SetIgnoreMatcher(m.ignore)
+	tree.SetCompactIndent(m.fileTree.CompactIndent())
+	tree.SetIgnoredMode(m.fileTree.IgnoredMode())
+	tree.SetComparing(m.compareFrom)
+	if m.gitStatus != nil {
+		tree = tree.SetGitStatus(m.gitStatus)
+	}
+	return tree
+}
+
+// dualPane reports whether the dual-pane layout is active.
+func (m *Model) dualPane() bool {
+	return m.layoutMode == layout.ModeDualPane
+}
+
+// activeAndOtherTree returns the focused pane and the opposite one.
+func (m *Model) activeAndOtherTree() (active, other filetree.Model) {
+	if m.focus == PanelOtherTree {
+		return m.otherTree, m.fileTree
+	}
+	return m.fileTree, m.otherTree
+}
+
+// rerootPane roots the focused pane at the selected directory, or moves it
+// up a level when the root itself is selected.
+func (m *Model) rerootPane() tea.Cmd {
+	tree := &m.fileTree
+	if m.focus == PanelOtherTree {
+		tree = &m.otherTree
+	}
+
+	target := tree.CurrentDir()
+	if node := tree.SelectedNode(); node == nil || node.Parent == nil {
+		target = filepath.Dir(tree.Root())
+	}
+	if target == tree.Root() {
+		return nil
+	}
+
+	if err := tree.SetRoot(target); err != nil {
+		return m.setStatus(err.Error(), true)
+	}
+	return tree.Init()
+}
+
+// startTransfer asks to confirm copying or moving the active pane's
+// selection into the other pane's current directory.
+func (m *Model) startTransfer(move bool) tea.Cmd {
+	active, other := m.activeAndOtherTree()
+
+	node := active.SelectedNode()
+	if node == nil || node.Parent == nil {
+		return m.setStatus("Select a file or directory to "+strings.ToLower(transfer{move: move}.verb()), true)
+	}
+
+	m.pendingTransfer = &transfer{
+		move: move,
+		src:  node.Path,
+		dst:  filepath.Join(other.CurrentDir(), node.Name),
+	}
+	return nil
+}
+
+// handleTransferDialog handles keyboard input for the copy/move confirmation.
+func (m *Model) handleTransferDialog(msg tea.KeyPressMsg) tea.Cmd {
+	switch msg.String() {
+	case "y", "Y", "enter":
+		t := *m.pendingTransfer
+		m.pendingTransfer = nil
+		return func() tea.Msg {
+			var err error
+			if t.move {
+				err = fileops.Move(t.src, t.dst)
+			} else {
+				err = fileops.Copy(t.src, t.dst)
+			}
+			return transferDoneMsg{transfer: t, err: err}
+		}
+	case "n", "N", "esc":
+		m.pendingTransfer = nil
+	}
+	return nil
+}
+
+// handleTransferDone refreshes both panes after a copy or move.
+func (m *Model) handleTransferDone(msg transferDoneMsg) tea.Cmd {
+	t := msg.transfer
+	if msg.err != nil {
+		return m.setStatus(t.verb()+" failed: "+msg.err.Error(), true)
+	}
+
+	dirs := []string{filepath.Dir(t.dst)}
+	if t.move {
+		dirs = append(dirs, filepath.Dir(t.src))
+	}
+
+	cmds := []tea.Cmd{m.setStatus(t.verb()+" done: "+filepath.Base(t.src)+" → "+m.displayPath(filepath.Dir(t.dst)), false)}
+	for _, dir := range dirs {
+		cmds = append(cmds, m.fileTree.RefreshDir(dir), m.otherTree.RefreshDir(dir))
+	}
+	cmds = append(cmds, m.refreshGitStatus())
+	return tea.Batch(cmds...)
+}
+
+// compareDirs compares the panes' current directories, or clears the marks
+// of a previous comparison.
+func (m *Model) compareDirs() tea.Cmd {
+	if m.fileTree.HasCompareMarks() || m.otherTree.HasCompareMarks() {
+		m.fileTree.SetCompareMarks(nil)
+		m.otherTree.SetCompareMarks(nil)
+		return nil
+	}
+
+	left := m.fileTree.CurrentDir()
+	right := m.otherTree.CurrentDir()
+	matcher := m.ignore
+	return func() tea.Msg {
+		entries, err := compare.Dirs(left, right, matcher.Match)
+		return compareDoneMsg{left: left, right: right, entries: entries, err: err}
+	}
+}
+
+// handleCompareDone marks the differences in both panes.
+func (m *Model) handleCompareDone(msg compareDoneMsg) tea.Cmd {
+	if msg.err != nil {
+		return m.setStatus("Compare failed: "+msg.err.Error(), true)
+	}
+	if len(msg.entries) == 0 {
+		return m.setStatus("Directories are identical", false)
+	}
+
+	leftMarks := make(map[string]filetree.CompareMark)
+	rightMarks := make(map[string]filetree.CompareMark)
+	for _, e := range msg.entries {
+		rel := filepath.FromSlash(e.Path)
+		switch e.Status {
+		case compare.StatusDiffers:
+			markPath(leftMarks, msg.left, rel, filetree.MarkDiffers)
+			markPath(rightMarks, msg.right, rel, filetree.MarkDiffers)
+		case compare.StatusLeftOnly:
+			markPath(leftMarks, msg.left, rel, filetree.MarkOnlyHere)
+		case compare.StatusRightOnly:
+			markPath(rightMarks, msg.right, rel, filetree.MarkOnlyHere)
+		}
+	}
+	m.fileTree.SetCompareMarks(leftMarks)
+	m.otherTree.SetCompareMarks(rightMarks)
+
+	return m.setStatus(itoa(len(msg.entries))+" differences (Alt+C to clear)", false)
+}
+
+// markPath marks root/rel and flags its parent directories (below root) as
+// containing differences, so collapsed folders still show them.
+func markPath(marks map[string]filetree.CompareMark, root, rel string, mark filetree.CompareMark) {
+	marks[filepath.Join(root, rel)] = mark
+	for dir := filepath.Dir(rel); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		path := filepath.Join(root, dir)
+		if marks[path] == filetree.MarkNone {
+			marks[path] = filetree.MarkDiffers
+		}
+	}
+}
+
+// displayPath shortens a path relative to the working directory.
+func (m *Model) displayPath(path string) string {
+	if rel, err := filepath.Rel(m.workDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		if rel == "." {
+			return "./"
+		}
+		return rel + "/"
+	}
+	return path
+}
+
+// paneTitle returns the title for a file tree pane in dual-pane mode.
+func (m *Model) paneTitle(tree filetree.Model) string {
+	return "FILES " + filepath.Base(tree.Root()) + "/"
+}
+
+// renderOtherTreePanel renders the second pane in place of the content panel.
+func (m *Model) renderOtherTreePanel() string {
+	focused := m.focus == PanelOtherTree
+
+	var hints string
+	if focused {
+		hints = "tab:switch  F5:copy  F6:move"
+	}
+
+	opts := theme.PanelTitleOptions{
+		Title:         m.paneTitle(m.otherTree),
+		ScrollPercent: -1,
+		BottomHints:   hints,
+	}
+
+	return theme.RenderPanelWithTitle(
+		m.otherTree.View(),
+		opts,
+		m.layout.RightWidth,
+		m.layout.MainHeight,
+		focused,
+	)
+}
+
+// renderTransferDialog renders the copy/move confirmation dialog.
+func (m *Model) renderTransferDialog(_ string) string {
+	t := m.pendingTransfer
+
+	var content strings.Builder
+	content.WriteString(strings.ToUpper(t.verb()) + "\n\n")
+	content.WriteString("  " + m.displayPath(filepath.Dir(t.src)) + filepath.Base(t.src) + "\n")
+	content.WriteString("  → " + m.displayPath(filepath.Dir(t.dst)) + "\n\n")
+	content.WriteString("[Enter] " + t.verb() + "    [Esc] Cancel")
+
+	dialogStyle := lipgloss.NewStyle().
+		Foreground(theme.CyberCyan).
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.CyberCyan).
+		Padding(1, 2)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogStyle.Render(content.String()),
+	)
+}
+
+// markCompare remembers the file or directory marked in either tree to be
+// compared with another, and shows the mark in both.
+func (m *Model) markCompare(msg filetree.CompareMarkMsg) tea.Cmd {
+	m.compareFrom, m.compareFromDir = msg.Path, msg.IsDir
+	m.fileTree.SetComparing(msg.Path)
+	if m.otherTreeReady {
+		m.otherTree.SetComparing(msg.Path)
+	}
+	if msg.Path == "" {
+		return m.setStatus("Compare mark cleared", false)
+	}
+	return m.setStatus("Marked "+m.relPath(msg.Path)+" - = on another to compare with it", false)
+}
+
+// compareWith diffs the marked file or directory with another in the
+// content pane.
+func (m *Model) compareWith(msg filetree.CompareWithMsg) tea.Cmd {
+	switch {
+	case m.compareFrom == "":
+		return m.setStatus("Mark a file or directory with m first", true)
+	case m.compareFrom == msg.Path:
+		return m.setStatus("Pick another one to compare with", true)
+	case m.compareFromDir != msg.IsDir:
+		return m.setStatus("Compare a file with a file, or a directory with a directory", true)
+	}
+
+	compareMsg := content.CompareMsg{Left: m.compareFrom, Right: msg.Path, Skip: m.ignore.Match}
+	var cmd, focusCmd tea.Cmd
+	m.content, cmd = m.content.Update(compareMsg)
+	*m, focusCmd = m.setFocus(PanelContent)
+	return tea.Batch(cmd, focusCmd)
+}
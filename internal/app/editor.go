@@ -0,0 +1,79 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/avitaltamir/vibecommander/internal/components/content"
+	"github.com/avitaltamir/vibecommander/internal/editor"
+)
+
+// editorFinishedMsg is sent when the external editor exits.
+type editorFinishedMsg struct {
+	path string
+	line int // Line the file was opened at (0-indexed)
+	err  error
+}
+
+// editorTarget returns the file to open in the external editor and the
+// position (0-indexed) to open it at: the file under the tree cursor, or the
+// one in the content pane at its current line or search match.
+func (m *Model) editorTarget() (path string, line, col int) {
+	switch m.focus {
+	case PanelFileTree, PanelOtherTree:
+		tree, _ := m.activeAndOtherTree()
+		path = tree.SelectedPath()
+	case PanelContent:
+		if m.content.Mode() == content.ModeViewer || m.content.Mode() == content.ModeDiff {
+			path = m.content.CurrentPath()
+		}
+	}
+
+	if path != "" && path == m.content.CurrentPath() {
+		line, col = m.content.CursorPosition()
+	}
+	return path, line, col
+}
+
+// openInEditor suspends the TUI and opens the focused file in $EDITOR.
+func (m *Model) openInEditor() tea.Cmd {
+	path, line, col := m.editorTarget()
+	if path == "" {
+		return m.setStatus("Select a file to open in the editor", true)
+	}
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return m.setStatus("Not a file: "+m.relPath(path), true)
+	}
+	if path == m.content.CurrentPath() && m.content.IsModified() {
+		return m.setStatus("Save (Ctrl+S) or discard (Esc) your edits first", true)
+	}
+
+	cmd := editor.Command(path, line+1, col+1)
+	cmd.Dir = m.workDir
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return editorFinishedMsg{path: path, line: line, err: err}
+	})
+}
+
+// handleEditorFinished reloads the edited file if it's shown and refreshes
+// git status and the file trees.
+func (m *Model) handleEditorFinished(msg editorFinishedMsg) tea.Cmd {
+	dir := filepath.Dir(msg.path)
+	cmds := []tea.Cmd{
+		m.refreshGitStatus(),
+		m.fileTree.RefreshDir(dir),
+		m.otherTree.RefreshDir(dir),
+	}
+	if msg.err != nil {
+		cmds = append(cmds, m.setStatus("Editor failed: "+msg.err.Error(), true))
+	}
+
+	mode := m.content.Mode()
+	if msg.path == m.content.CurrentPath() && (mode == content.ModeViewer || mode == content.ModeDiff) {
+		var cmd tea.Cmd
+		m.content, cmd = m.content.Update(content.OpenFileMsg{Path: msg.path, Line: msg.line})
+		cmds = append(cmds, cmd)
+	}
+	return tea.Batch(cmds...)
+}
@@ -31,14 +31,38 @@ type KeyMap struct {
 	Delete key.Binding
 
 	// AI
-	LaunchAI key.Binding
-	SelectAI key.Binding
+	LaunchAI       key.Binding
+	SelectAI       key.Binding
+	RecordAI       key.Binding
+	ShowRecordings key.Binding
+
+	// External editor
+	OpenInEditor key.Binding
 
 	// Git
 	ToggleGitPanel key.Binding
+	Review         key.Binding
 
 	// Theme
-	CycleTheme key.Binding
+	CycleTheme  key.Binding
+	SyntaxStyle key.Binding
+
+	// Dual pane
+	ToggleDualPane key.Binding
+	SwitchPane     key.Binding
+	CopyToPane     key.Binding
+	MoveToPane     key.Binding
+	CompareDirs    key.Binding
+	RerootPane     key.Binding
+
+	// Bookmarks and history
+	JumpBack       key.Binding
+	JumpForward    key.Binding
+	ToggleBookmark key.Binding
+	ShowBookmarks  key.Binding
+	ShowRecent     key.Binding
+	ShowJumps      key.Binding
+	ShowOutline    key.Binding
 }
 
 // DefaultKeyMap returns the default key bindings.
@@ -133,18 +157,96 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("alt+s", "ß"), // ß = Option+s on Mac
 			key.WithHelp("M-s", "select AI"),
 		),
+		RecordAI: key.NewBinding(
+			key.WithKeys("alt+w", "∑"), // ∑ = Option+w on Mac
+			key.WithHelp("M-w", "record AI sessions"),
+		),
+		ShowRecordings: key.NewBinding(
+			key.WithKeys("alt+p", "π"), // π = Option+p on Mac
+			key.WithHelp("M-p", "recordings"),
+		),
 
 		// Git
 		ToggleGitPanel: key.NewBinding(
 			key.WithKeys("alt+g", "©"), // © = Option+g on Mac
 			key.WithHelp("M-g", "git panel"),
 		),
+		Review: key.NewBinding(
+			key.WithKeys("alt+v", "√"), // √ = Option+v on Mac
+			key.WithHelp("M-v", "review changes"),
+		),
 
 		// Theme
 		CycleTheme: key.NewBinding(
 			key.WithKeys("alt+t", "†"), // † = Option+t on Mac
 			key.WithHelp("M-t", "cycle theme"),
 		),
+		SyntaxStyle: key.NewBinding(
+			key.WithKeys("alt+y", "¥"), // ¥ = Option+y on Mac
+			key.WithHelp("M-y", "syntax colors"),
+		),
+
+		// Dual pane
+		ToggleDualPane: key.NewBinding(
+			key.WithKeys("alt+d", "∂"), // ∂ = Option+d on Mac
+			key.WithHelp("M-d", "dual pane"),
+		),
+		SwitchPane: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "switch pane"),
+		),
+		CopyToPane: key.NewBinding(
+			key.WithKeys("f5"),
+			key.WithHelp("F5", "copy to other pane"),
+		),
+		MoveToPane: key.NewBinding(
+			key.WithKeys("f6"),
+			key.WithHelp("F6", "move to other pane"),
+		),
+		CompareDirs: key.NewBinding(
+			key.WithKeys("alt+c", "ç"), // ç = Option+c on Mac
+			key.WithHelp("M-c", "compare dirs"),
+		),
+		RerootPane: key.NewBinding(
+			key.WithKeys("alt+r", "®"), // ® = Option+r on Mac
+			key.WithHelp("M-r", "pane root/up"),
+		),
+
+		// External editor
+		OpenInEditor: key.NewBinding(
+			key.WithKeys("alt+e", "´"), // ´ = Option+e on Mac
+			key.WithHelp("M-e", "open in $EDITOR"),
+		),
+
+		// Bookmarks and history
+		JumpBack: key.NewBinding(
+			key.WithKeys("ctrl+o"),
+			key.WithHelp("ctrl+o", "jump back"),
+		),
+		JumpForward: key.NewBinding(
+			key.WithKeys("ctrl+i", "tab"), // Most terminals send Ctrl+I as Tab
+			key.WithHelp("ctrl+i", "jump forward"),
+		),
+		ToggleBookmark: key.NewBinding(
+			key.WithKeys("alt+m", "µ"), // µ = Option+m on Mac
+			key.WithHelp("M-m", "toggle bookmark"),
+		),
+		ShowBookmarks: key.NewBinding(
+			key.WithKeys("alt+b", "∫"), // ∫ = Option+b on Mac
+			key.WithHelp("M-b", "bookmarks"),
+		),
+		ShowRecent: key.NewBinding(
+			key.WithKeys("alt+o", "ø"), // ø = Option+o on Mac
+			key.WithHelp("M-o", "recent files"),
+		),
+		ShowJumps: key.NewBinding(
+			key.WithKeys("alt+j", "∆"), // ∆ = Option+j on Mac
+			key.WithHelp("M-j", "jump list"),
+		),
+		ShowOutline: key.NewBinding(
+			key.WithKeys("alt+l", "¬"), // ¬ = Option+l on Mac
+			key.WithHelp("M-l", "outline"),
+		),
 	}
 }
 
@@ -161,7 +263,12 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 		{k.Enter, k.Back, k.Delete},
 		{k.FocusTree, k.FocusContent, k.ToggleMini},
 		{k.ShrinkTree, k.WidenTree},
-		{k.ToggleGitPanel, k.LaunchAI, k.SelectAI},
-		{k.CycleTheme, k.Help, k.Quit},
+		{k.ToggleGitPanel, k.Review, k.LaunchAI, k.SelectAI, k.OpenInEditor},
+		{k.RecordAI, k.ShowRecordings},
+		{k.ToggleDualPane, k.SwitchPane, k.CopyToPane, k.MoveToPane},
+		{k.CompareDirs, k.RerootPane},
+		{k.JumpBack, k.JumpForward, k.ToggleBookmark},
+		{k.ShowBookmarks, k.ShowRecent, k.ShowJumps, k.ShowOutline},
+		{k.CycleTheme, k.SyntaxStyle, k.Help, k.Quit},
 	}
 }
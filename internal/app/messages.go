@@ -9,6 +9,7 @@ const (
 	PanelGitPanel                  // 2
 	PanelContent                   // 3
 	PanelMiniBuffer                // 4
+	PanelOtherTree                 // 5 = second file tree in dual-pane mode
 )
 
 // String returns the panel name for debugging.
@@ -24,6 +25,8 @@ func (p PanelID) String() string {
 		return "Content"
 	case PanelMiniBuffer:
 		return "MiniBuffer"
+	case PanelOtherTree:
+		return "OtherTree"
 	default:
 		return "Unknown"
 	}
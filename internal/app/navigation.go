@@ -0,0 +1,418 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/atotto/clipboard"
+	"github.com/avitaltamir/vibecommander/internal/components/content"
+	"github.com/avitaltamir/vibecommander/internal/components/quickpick"
+	"github.com/avitaltamir/vibecommander/internal/history"
+	"github.com/avitaltamir/vibecommander/internal/layout"
+	"github.com/avitaltamir/vibecommander/internal/links"
+	"github.com/avitaltamir/vibecommander/internal/outline"
+	"github.com/avitaltamir/vibecommander/internal/state"
+	"github.com/avitaltamir/vibecommander/internal/syntax"
+	"github.com/avitaltamir/vibecommander/internal/theme"
+)
+
+// Quick-pick list IDs
+const (
+	pickBookmarks  = "bookmarks"
+	pickRecent     = "recent"
+	pickJumps      = "jumps"
+	pickOutline    = "outline"
+	pickSyntax     = "syntax"
+	pickRecordings = "recordings"
+)
+
+// fileArg matches a file followed by a line, and maybe a column, as
+// compilers print them: main.go:42 or main.go:42:7.
+var fileArg = regexp.MustCompile(`^(.+?):(\d+)(?::\d+)?$`)
+
+// ParseFileArg parses a file given on the command line, optionally followed
+// by :line (1-indexed). A column after the line is accepted and ignored.
+func ParseFileArg(arg string) (history.Location, error) {
+	path, line := arg, 0
+	if _, err := os.Stat(arg); err != nil {
+		if sub := fileArg.FindStringSubmatch(arg); sub != nil {
+			path = sub[1]
+			line, _ = strconv.Atoi(sub[2])
+			line = max(line-1, 0)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return history.Location{}, err
+	}
+	if info.IsDir() {
+		return history.Location{}, fmt.Errorf("%s is a directory", path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return history.Location{}, err
+	}
+	return history.Location{Path: abs, Line: line}, nil
+}
+
+// OpenAt opens a file at a line once the window size is known.
+func (m *Model) OpenAt(loc history.Location) {
+	m.openAt = &loc
+}
+
+// currentLocation returns the file and line shown in the content pane.
+func (m *Model) currentLocation() history.Location {
+	return history.Location{
+		Path: m.content.CurrentPath(),
+		Line: m.content.CurrentLine(),
+	}
+}
+
+// capturesKeys reports whether keys are going to a running terminal or the
+// editor, which must receive keys like Ctrl+O and Tab untouched.
+func (m *Model) capturesKeys() bool {
+	if m.focus == PanelMiniBuffer {
+		return true
+	}
+	return m.focus == PanelContent && (m.content.IsTerminalRunning() || m.content.IsEditing())
+}
+
+// unsavedEditsCmd refuses to leave a file with unsaved edits for another
+// one, returning the status message to show, or nil when it's safe.
+func (m *Model) unsavedEditsCmd(path string) tea.Cmd {
+	if !m.content.IsModified() || path == m.content.CurrentPath() {
+		return nil
+	}
+	return m.setStatus("Unsaved edits in "+m.relPath(m.content.CurrentPath())+" - save (Ctrl+S) or discard (Esc) first", true)
+}
+
+// gotoLocation shows a location from the jump list without recording a jump.
+func (m *Model) gotoLocation(loc history.Location) tea.Cmd {
+	if cmd := m.unsavedEditsCmd(loc.Path); cmd != nil {
+		return cmd
+	}
+
+	var modeCmd, focusCmd tea.Cmd
+	modeCmd = m.setLayoutMode(layout.ModeNormal)
+	*m, focusCmd = m.setFocus(PanelContent)
+
+	// Same file in the viewer - just scroll
+	if loc.Path == m.content.CurrentPath() && m.content.Mode() == content.ModeViewer {
+		m.content.GotoLine(loc.Line)
+		return tea.Batch(modeCmd, focusCmd)
+	}
+
+	var cmd tea.Cmd
+	m.content, cmd = m.content.Update(content.OpenFileMsg{Path: loc.Path, Line: loc.Line})
+	return tea.Batch(modeCmd, focusCmd, cmd)
+}
+
+// openLink opens a link clicked or picked in a terminal: a file at its line,
+// leaving the terminal running behind the viewer, or for any other URL, copies
+// it since there's nothing here to open it in.
+func (m *Model) openLink(msg links.OpenMsg) tea.Cmd {
+	if msg.URL != "" {
+		if err := clipboard.WriteAll(msg.URL); err != nil {
+			return m.setStatus("Couldn't copy "+msg.URL+": "+err.Error(), true)
+		}
+		return m.setStatus("Copied "+msg.URL, false)
+	}
+	m.jumps.Push(m.currentLocation())
+	return m.gotoLocation(history.Location{Path: msg.Path, Line: msg.Line})
+}
+
+// jumpBack goes to the previous location in the jump list.
+func (m *Model) jumpBack() tea.Cmd {
+	loc, ok := m.jumps.Back(m.currentLocation())
+	if !ok {
+		return m.setStatus("Already at the oldest jump", false)
+	}
+	return m.gotoLocation(loc)
+}
+
+// jumpForward goes to the next location in the jump list.
+func (m *Model) jumpForward() tea.Cmd {
+	loc, ok := m.jumps.Forward()
+	if !ok {
+		return m.setStatus("Already at the newest jump", false)
+	}
+	return m.gotoLocation(loc)
+}
+
+// bookmarkTarget returns the path a bookmark toggle applies to: the selected
+// tree entry, or the file shown in the content pane.
+func (m *Model) bookmarkTarget() string {
+	switch m.focus {
+	case PanelFileTree, PanelOtherTree:
+		tree, _ := m.activeAndOtherTree()
+		return tree.SelectedPath()
+	case PanelContent:
+		if m.content.Mode() == content.ModeViewer || m.content.Mode() == content.ModeDiff {
+			return m.content.CurrentPath()
+		}
+	}
+	return ""
+}
+
+// toggleBookmark bookmarks or un-bookmarks the focused file or directory.
+func (m *Model) toggleBookmark() tea.Cmd {
+	path := m.bookmarkTarget()
+	if path == "" {
+		return m.setStatus("Nothing to bookmark here", true)
+	}
+
+	if m.bookmarks.Toggle(path) {
+		return m.setStatus("Bookmarked "+m.relPath(path), false)
+	}
+	return m.setStatus("Removed bookmark "+m.relPath(path), false)
+}
+
+// openPicker shows the quick-pick overlay for one of the lists.
+func (m *Model) openPicker(id string) tea.Cmd {
+	var title string
+	removable := true
+	switch id {
+	case pickBookmarks:
+		title = "BOOKMARKS"
+	case pickRecent:
+		title = "RECENT FILES"
+	case pickJumps:
+		title = "JUMP LIST"
+		removable = false
+	case pickOutline:
+		title = "OUTLINE"
+		removable = false
+	case pickSyntax:
+		title = "SYNTAX COLORS"
+		removable = false
+	case pickRecordings:
+		title = "RECORDINGS"
+		removable = false
+	}
+
+	var cmd tea.Cmd
+	m.picker, cmd = m.picker.Open(id, title, m.pickerItems(id), removable)
+	if id == pickJumps {
+		// Start on the current position, which is listed first
+		m.picker = m.picker.SetCursor(len(m.jumps.Entries()) - m.jumps.Index() - 1)
+	}
+	if id == pickOutline {
+		// Start on the symbol around the current line
+		line, _ := m.content.CursorPosition()
+		m.picker = m.picker.SetCursor(enclosingSymbol(m.content.Outline(), line))
+	}
+	if id == pickSyntax {
+		// Start on the style in use
+		current := syntax.Override()
+		for i, name := range syntax.StyleNames() {
+			if name == current {
+				m.picker = m.picker.SetCursor(i + 1)
+			}
+		}
+	}
+	return cmd
+}
+
+// pickerItems builds the quick-pick entries for a list.
+func (m *Model) pickerItems(id string) []quickpick.Item {
+	var items []quickpick.Item
+	switch id {
+	case pickBookmarks:
+		for i, path := range m.bookmarks.Paths() {
+			items = append(items, m.pathItem(path, i))
+		}
+	case pickRecent:
+		for i, path := range m.recent.Paths() {
+			items = append(items, m.pathItem(path, i))
+		}
+	case pickJumps:
+		// Newest first, like the other lists
+		entries := m.jumps.Entries()
+		for i := len(entries) - 1; i >= 0; i-- {
+			item := m.pathItem(entries[i].Path, i)
+			item.Title += ":" + itoa(entries[i].Line+1)
+			items = append(items, item)
+		}
+	case pickOutline:
+		for _, sym := range m.content.Outline() {
+			items = append(items, quickpick.Item{
+				Title:  strings.Repeat("  ", sym.Depth) + sym.Name,
+				Detail: sym.Kind.String() + " · line " + itoa(sym.Line+1),
+				Index:  sym.Line,
+			})
+		}
+	case pickSyntax:
+		// The theme's own colors first, then every chroma style
+		items = append(items, quickpick.Item{
+			Title:  "Match theme",
+			Detail: theme.CurrentTheme().Name,
+		})
+		for i, name := range syntax.StyleNames() {
+			items = append(items, quickpick.Item{Title: name, Value: name, Index: i + 1})
+		}
+	case pickRecordings:
+		items = m.recordings()
+	}
+	return items
+}
+
+// showOutline lists the functions, types and headings of the file in the
+// viewer.
+func (m *Model) showOutline() tea.Cmd {
+	path := m.content.CurrentPath()
+	if m.content.Mode() != content.ModeViewer || path == "" {
+		return m.setStatus("Open a file to see its outline", true)
+	}
+	if len(m.content.Outline()) == 0 {
+		return m.setStatus("No functions, types or headings in "+m.relPath(path), false)
+	}
+	return m.openPicker(pickOutline)
+}
+
+// enclosingSymbol returns the index of the innermost symbol containing line,
+// or of the last one before it.
+func enclosingSymbol(symbols []outline.Symbol, line int) int {
+	found := 0
+	for i, sym := range symbols {
+		if sym.Line > line {
+			break
+		}
+		if line <= sym.End || symbols[found].End < line {
+			found = i
+		}
+	}
+	return found
+}
+
+// pathItem builds a quick-pick entry for a path.
+func (m *Model) pathItem(path string, index int) quickpick.Item {
+	title := filepath.Base(path)
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		title += "/"
+	}
+	detail := filepath.Dir(m.relPath(path))
+	if detail == "." {
+		detail = ""
+	}
+	return quickpick.Item{Title: title, Detail: detail, Value: path, Index: index}
+}
+
+// relPath shortens path relative to the working directory when inside it.
+func (m *Model) relPath(path string) string {
+	if rel, err := filepath.Rel(m.workDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// handlePickerSelect opens the entry picked from a quick-pick list.
+func (m *Model) handlePickerSelect(msg quickpick.SelectMsg) tea.Cmd {
+	if msg.ID == pickOutline {
+		// Remember where we were, then scroll to the symbol
+		m.jumps.Push(m.currentLocation())
+		return m.gotoLocation(history.Location{Path: m.content.CurrentPath(), Line: msg.Item.Index})
+	}
+
+	if msg.ID == pickSyntax {
+		return m.setSyntaxStyle(msg.Item.Value)
+	}
+
+	if msg.ID == pickRecordings {
+		return m.gotoLocation(history.Location{Path: msg.Item.Value})
+	}
+
+	if msg.ID == pickJumps {
+		loc, ok := m.jumps.Jump(msg.Item.Index, m.currentLocation())
+		if !ok {
+			return nil
+		}
+		return m.gotoLocation(loc)
+	}
+
+	path := msg.Item.Value
+	info, err := os.Stat(path)
+	if err != nil {
+		return m.setStatus("Can't open "+m.relPath(path)+": "+err.Error(), true)
+	}
+
+	// Directories are revealed in the file tree
+	if info.IsDir() {
+		var modeCmd, focusCmd tea.Cmd
+		modeCmd = m.setLayoutMode(layout.ModeNormal)
+		*m, focusCmd = m.setFocus(PanelFileTree)
+		revealCmd := m.fileTree.Reveal(path)
+		return tea.Batch(modeCmd, focusCmd, revealCmd)
+	}
+
+	return func() tea.Msg {
+		return content.OpenFileMsg{Path: path}
+	}
+}
+
+// setSyntaxStyle highlights code with the named chroma style, or with the
+// theme's colors when name is empty, and re-renders what's open.
+func (m *Model) setSyntaxStyle(name string) tea.Cmd {
+	syntax.SetOverride(name)
+	var cmd tea.Cmd
+	m.content, cmd = m.content.Update(content.ThemeChangedMsg{})
+	if name == "" {
+		name = "theme colors"
+	}
+	return tea.Batch(cmd, m.setStatus("Highlighting code with "+name, false))
+}
+
+// handlePickerRemove removes an entry from the bookmarks or recent files.
+func (m *Model) handlePickerRemove(msg quickpick.RemoveMsg) tea.Cmd {
+	switch msg.ID {
+	case pickBookmarks:
+		m.bookmarks.Remove(msg.Item.Value)
+	case pickRecent:
+		m.recent.Remove(msg.Item.Value)
+	default:
+		return nil
+	}
+	m.picker = m.picker.SetItems(m.pickerItems(msg.ID))
+	return nil
+}
+
+// renderPicker renders the quick-pick overlay.
+func (m *Model) renderPicker(_ string) string {
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		m.picker.View(),
+	)
+}
+
+// projectState returns the persisted per-project state, with this project's
+// bookmarks and recent files updated.
+func (m *Model) projectState() map[string]state.Project {
+	projects := make(map[string]state.Project, len(m.projects)+1)
+	for dir, p := range m.projects {
+		projects[dir] = p
+	}
+
+	current := state.Project{
+		Bookmarks: m.bookmarks.Paths(),
+		Recent:    m.recent.Paths(),
+		Reviewed:  m.content.Reviewed(),
+	}
+	if len(current.Bookmarks) == 0 && len(current.Recent) == 0 && len(current.Reviewed) == 0 {
+		delete(projects, m.workDir)
+	} else {
+		projects[m.workDir] = current
+	}
+
+	if len(projects) == 0 {
+		return nil
+	}
+	return projects
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/avitaltamir/vibecommander/internal/components/quickpick"
+	"github.com/avitaltamir/vibecommander/internal/state"
+)
+
+// recordPath returns the file to record an AI session running command to,
+// named after when it started, or "" when sessions aren't recorded.
+func (m *Model) recordPath(command string) string {
+	if !m.recordAI {
+		return ""
+	}
+	dir, err := state.RecordingsDir(m.workDir)
+	if err != nil {
+		return ""
+	}
+	name := time.Now().Format("2006-01-02T15-04-05") + "-" + filepath.Base(command) + ".cast"
+	return filepath.Join(dir, name)
+}
+
+// toggleRecording turns recording AI sessions on or off. Turning it on
+// records the next session; turning it off ends the one being recorded.
+func (m *Model) toggleRecording() tea.Cmd {
+	m.recordAI = !m.recordAI
+	m.saveState()
+	if m.recordAI {
+		return m.setStatus("Recording AI sessions from the next launch (Alt+P to play)", false)
+	}
+	if path := m.content.Recording(); path != "" {
+		m.content.StopRecording()
+		return m.setStatus("Stopped recording - saved "+filepath.Base(path), false)
+	}
+	return m.setStatus("Not recording AI sessions", false)
+}
+
+// showRecordings lists this project's recordings to play.
+func (m *Model) showRecordings() tea.Cmd {
+	if len(m.recordings()) == 0 {
+		return m.setStatus("No recordings yet - Alt+W records AI sessions", false)
+	}
+	cmd := m.openPicker(pickRecordings)
+	return cmd
+}
+
+// recordings returns the quick-pick entries for this project's recordings,
+// newest first.
+func (m *Model) recordings() []quickpick.Item {
+	dir, err := state.RecordingsDir(m.workDir)
+	if err != nil {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var items []quickpick.Item
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".cast" {
+			continue
+		}
+		item := quickpick.Item{
+			Title: strings.TrimSuffix(e.Name(), ".cast"),
+			Value: filepath.Join(dir, e.Name()),
+		}
+		if info, err := e.Info(); err == nil {
+			item.Detail = fmt.Sprintf("%d KB", (info.Size()+1023)/1024)
+		}
+		items = append(items, item)
+	}
+	// Named after when they started, so newest sorts last
+	sort.Slice(items, func(i, j int) bool { return items[i].Title > items[j].Title })
+	for i := range items {
+		items[i].Index = i
+	}
+	return items
+}
@@ -0,0 +1,236 @@
+// Package asciicast records terminal output in the asciicast v2 format and
+// reads it back. A recording is a JSON header line followed by one JSON
+// array per event: [seconds since the start, type, data].
+package asciicast
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Event types
+const (
+	Output = "o" // Data written to the terminal
+	Resize = "r" // The terminal was resized to "COLSxROWS"
+)
+
+// Header is the first line of a recording.
+type Header struct {
+	Version       int               `json:"version"`
+	Width         int               `json:"width"`
+	Height        int               `json:"height"`
+	Timestamp     int64             `json:"timestamp,omitempty"`
+	IdleTimeLimit float64           `json:"idle_time_limit,omitempty"`
+	Title         string            `json:"title,omitempty"`
+	Env           map[string]string `json:"env,omitempty"`
+}
+
+// Event is one thing that happened in the terminal.
+type Event struct {
+	Time float64 // Seconds since the start
+	Type string
+	Data string
+}
+
+// Size returns the columns and rows of a resize event.
+func (e Event) Size() (cols, rows int, ok bool) {
+	w, h, found := strings.Cut(e.Data, "x")
+	if e.Type != Resize || !found {
+		return 0, 0, false
+	}
+	cols, err1 := strconv.Atoi(w)
+	rows, err2 := strconv.Atoi(h)
+	return cols, rows, err1 == nil && err2 == nil && cols > 0 && rows > 0
+}
+
+// Recording is a whole recording read back.
+type Recording struct {
+	Header Header
+	Events []Event
+}
+
+// Duration returns the time of the last event.
+func (r Recording) Duration() float64 {
+	if len(r.Events) == 0 {
+		return 0
+	}
+	return r.Events[len(r.Events)-1].Time
+}
+
+// Writer records events to a file as they happen. It's safe to use from
+// several goroutines, and does nothing once closed.
+type Writer struct {
+	mu      sync.Mutex
+	f       *os.File
+	w       *bufio.Writer
+	start   time.Time
+	pending []byte // The start of a character split across writes
+	err     error
+}
+
+// Create starts a recording at path with the header given. The version and
+// timestamp are filled in.
+func Create(path string, h Header) (*Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	rec := &Writer{f: f, w: bufio.NewWriter(f), start: time.Now()}
+	h.Version = 2
+	h.Timestamp = rec.start.Unix()
+	line, err := json.Marshal(h)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	rec.w.Write(line)
+	rec.w.WriteByte('\n')
+	return rec, nil
+}
+
+// Output records data written to the terminal.
+func (rec *Writer) Output(data []byte) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.f == nil {
+		return
+	}
+
+	// Characters can be split across reads; keep the start of one for the
+	// next write so the event is valid UTF-8
+	data = append(rec.pending, data...)
+	rec.pending = nil
+	if cut := incompleteTail(data); cut < len(data) {
+		rec.pending = append([]byte(nil), data[cut:]...)
+		data = data[:cut]
+	}
+	if len(data) > 0 {
+		rec.event(Output, string(data))
+	}
+}
+
+// Resize records the terminal being resized.
+func (rec *Writer) Resize(cols, rows int) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.f == nil {
+		return
+	}
+	rec.event(Resize, fmt.Sprintf("%dx%d", cols, rows))
+}
+
+// event writes one event line, remembering the first error. Each line is
+// flushed so a crash loses nothing already shown.
+func (rec *Writer) event(typ, data string) {
+	elapsed := time.Since(rec.start).Seconds()
+	line, err := json.Marshal([]any{json.Number(strconv.FormatFloat(elapsed, 'f', 6, 64)), typ, data})
+	if err == nil {
+		rec.w.Write(line)
+		rec.w.WriteByte('\n')
+		err = rec.w.Flush()
+	}
+	if rec.err == nil {
+		rec.err = err
+	}
+}
+
+// Close ends the recording, returning the first error writing it.
+func (rec *Writer) Close() error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.f == nil {
+		return rec.err
+	}
+	if len(rec.pending) > 0 {
+		rec.event(Output, string(rec.pending))
+	}
+	err := rec.w.Flush()
+	if cerr := rec.f.Close(); err == nil {
+		err = cerr
+	}
+	rec.f = nil
+	if rec.err == nil {
+		rec.err = err
+	}
+	return rec.err
+}
+
+// incompleteTail returns where a character cut off at the end of data
+// starts, or len(data) when it ends on a whole character.
+func incompleteTail(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(data)
+}
+
+// Load reads the recording at path.
+func Load(path string) (Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Recording{}, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Read reads a recording. Events other than output and resizes are left
+// out, and gaps longer than the header's idle time limit are shortened to it.
+func Read(r io.Reader) (Recording, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var rec Recording
+	if !sc.Scan() {
+		if err := sc.Err(); err != nil {
+			return rec, err
+		}
+		return rec, errors.New("empty recording")
+	}
+	if err := json.Unmarshal(sc.Bytes(), &rec.Header); err != nil {
+		return rec, fmt.Errorf("reading header: %w", err)
+	}
+	if rec.Header.Version != 2 {
+		return rec, fmt.Errorf("unsupported asciicast version %d", rec.Header.Version)
+	}
+
+	var last, shift float64
+	for n := 2; sc.Scan(); n++ {
+		if len(strings.TrimSpace(sc.Text())) == 0 {
+			continue
+		}
+		var fields []json.RawMessage
+		if err := json.Unmarshal(sc.Bytes(), &fields); err != nil || len(fields) < 3 {
+			return rec, fmt.Errorf("line %d: not an event", n)
+		}
+		var e Event
+		if json.Unmarshal(fields[0], &e.Time) != nil ||
+			json.Unmarshal(fields[1], &e.Type) != nil ||
+			json.Unmarshal(fields[2], &e.Data) != nil {
+			return rec, fmt.Errorf("line %d: not an event", n)
+		}
+		if limit := rec.Header.IdleTimeLimit; limit > 0 && e.Time-last > limit {
+			shift += e.Time - last - limit
+		}
+		last = e.Time
+		e.Time -= shift
+		if e.Type == Output || e.Type == Resize {
+			rec.Events = append(rec.Events, e)
+		}
+	}
+	return rec, sc.Err()
+}
@@ -0,0 +1,84 @@
+package asciicast
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.cast")
+	w, err := Create(path, Header{Width: 80, Height: 24, Title: "claude"})
+	require.NoError(t, err)
+
+	w.Output([]byte("hello \xe2\x94")) // ─ split across two reads
+	w.Output([]byte("\x80 world\r\n"))
+	w.Resize(100, 30)
+	require.NoError(t, w.Close())
+	w.Output([]byte("after close")) // Ignored
+
+	rec, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, rec.Header.Version)
+	assert.Equal(t, 80, rec.Header.Width)
+	assert.Equal(t, 24, rec.Header.Height)
+	assert.Equal(t, "claude", rec.Header.Title)
+	assert.Positive(t, rec.Header.Timestamp)
+
+	require.Len(t, rec.Events, 3)
+	assert.Equal(t, Event{Time: rec.Events[0].Time, Type: Output, Data: "hello "}, rec.Events[0])
+	assert.Equal(t, "─ world\r\n", rec.Events[1].Data)
+	cols, rows, ok := rec.Events[2].Size()
+	assert.True(t, ok)
+	assert.Equal(t, 100, cols)
+	assert.Equal(t, 30, rows)
+	assert.LessOrEqual(t, rec.Events[0].Time, rec.Events[2].Time)
+}
+
+func TestWriteFlushesEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.cast")
+	w, err := Create(path, Header{Width: 80, Height: 24})
+	require.NoError(t, err)
+	defer w.Close()
+
+	// Readable before the recording is closed, as after a crash
+	w.Output([]byte("hello"))
+	rec, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, rec.Events, 1)
+	assert.Equal(t, "hello", rec.Events[0].Data)
+}
+
+func TestRead(t *testing.T) {
+	t.Run("idle time limit", func(t *testing.T) {
+		rec, err := Read(strings.NewReader(`{"version": 2, "width": 80, "height": 24, "idle_time_limit": 2}
+[0.5, "o", "a"]
+[1.0, "i", "typed"]
+[10.0, "o", "b"]
+[10.5, "o", "c"]
+`))
+		require.NoError(t, err)
+		require.Len(t, rec.Events, 3, "input events are left out")
+		assert.Equal(t, 0.5, rec.Events[0].Time)
+		assert.Equal(t, 3.0, rec.Events[1].Time)
+		assert.Equal(t, 3.5, rec.Events[2].Time)
+		assert.Equal(t, 3.5, rec.Duration())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := Read(strings.NewReader(""))
+		assert.Error(t, err)
+		_, err = Read(strings.NewReader(`{"version": 1}`))
+		assert.Error(t, err)
+		_, err = Read(strings.NewReader("{\"version\": 2}\nnot json\n"))
+		assert.Error(t, err)
+	})
+}
+
+func TestCreateFails(t *testing.T) {
+	_, err := Create(filepath.Join(t.TempDir(), "missing", "run.cast"), Header{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,191 @@
+package compare
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Status describes how an entry differs between the two directories.
+type Status int
+
+const (
+	StatusSame      Status = iota // Present on both sides with equal content
+	StatusDiffers                 // Present on both sides with different content
+	StatusLeftOnly                // Only in the left directory
+	StatusRightOnly               // Only in the right directory
+)
+
+// String returns a short label for the status.
+func (s Status) String() string {
+	switch s {
+	case StatusSame:
+		return "same"
+	case StatusDiffers:
+		return "differs"
+	case StatusLeftOnly:
+		return "left only"
+	case StatusRightOnly:
+		return "right only"
+	default:
+		return "unknown"
+	}
+}
+
+// Entry is one difference between the compared directories.
+type Entry struct {
+	Path   string // Slash-separated path relative to both roots
+	IsDir  bool
+	Status Status
+}
+
+// SkipFunc reports whether a path (absolute, on either side) should be left
+// out of the comparison.
+type SkipFunc func(path string, isDir bool) bool
+
+// Dirs compares two directory trees recursively and returns every entry that
+// is not the same on both sides, sorted by path. Directories that exist on
+// only one side are reported once, without their contents. .git
+// directories are always skipped; skip may be nil.
+func Dirs(left, right string, skip SkipFunc) ([]Entry, error) {
+	if skip == nil {
+		skip = func(string, bool) bool { return false }
+	}
+
+	var entries []Entry
+	if err := compareDir(left, right, "", skip, &entries); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+	return entries, nil
+}
+
+// compareDir compares one directory level and recurses into shared subdirectories.
+func compareDir(left, right, rel string, skip SkipFunc, out *[]Entry) error {
+	leftEntries, err := readDir(filepath.Join(left, rel), skip)
+	if err != nil {
+		return err
+	}
+	rightEntries, err := readDir(filepath.Join(right, rel), skip)
+	if err != nil {
+		return err
+	}
+
+	for name, leftInfo := range leftEntries {
+		path := joinRel(rel, name)
+		rightInfo, ok := rightEntries[name]
+		switch {
+		case !ok:
+			*out = append(*out, Entry{Path: path, IsDir: leftInfo.IsDir(), Status: StatusLeftOnly})
+		case leftInfo.IsDir() && rightInfo.IsDir():
+			if err := compareDir(left, right, path, skip, out); err != nil {
+				return err
+			}
+		case leftInfo.IsDir() != rightInfo.IsDir():
+			// A file on one side and a directory on the other
+			*out = append(*out, Entry{Path: path, IsDir: leftInfo.IsDir(), Status: StatusDiffers})
+		default:
+			same, err := Files(filepath.Join(left, filepath.FromSlash(path)), filepath.Join(right, filepath.FromSlash(path)))
+			if err != nil {
+				return err
+			}
+			if !same {
+				*out = append(*out, Entry{Path: path, Status: StatusDiffers})
+			}
+		}
+	}
+
+	for name, rightInfo := range rightEntries {
+		if _, ok := leftEntries[name]; !ok {
+			*out = append(*out, Entry{Path: joinRel(rel, name), IsDir: rightInfo.IsDir(), Status: StatusRightOnly})
+		}
+	}
+	return nil
+}
+
+// Files reports whether two files have identical content.
+func Files(left, right string) (bool, error) {
+	leftInfo, err := os.Stat(left)
+	if err != nil {
+		return false, err
+	}
+	rightInfo, err := os.Stat(right)
+	if err != nil {
+		return false, err
+	}
+	if leftInfo.Size() != rightInfo.Size() {
+		return false, nil
+	}
+
+	lf, err := os.Open(left)
+	if err != nil {
+		return false, err
+	}
+	defer lf.Close()
+	rf, err := os.Open(right)
+	if err != nil {
+		return false, err
+	}
+	defer rf.Close()
+
+	// Compare in chunks so large files aren't read into memory
+	lbuf := make([]byte, 32*1024)
+	rbuf := make([]byte, 32*1024)
+	for {
+		ln, lerr := io.ReadFull(lf, lbuf)
+		rn, rerr := io.ReadFull(rf, rbuf)
+		if ln != rn || !bytes.Equal(lbuf[:ln], rbuf[:rn]) {
+			return false, nil
+		}
+		if lerr == io.EOF || lerr == io.ErrUnexpectedEOF {
+			return rerr == io.EOF || rerr == io.ErrUnexpectedEOF, nil
+		}
+		if lerr != nil {
+			return false, lerr
+		}
+		if rerr != nil {
+			return false, rerr
+		}
+	}
+}
+
+// readDir lists a directory keyed by name, leaving out skipped entries.
+// A missing directory reads as empty.
+func readDir(dir string, skip SkipFunc) (map[string]os.FileInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	result := make(map[string]os.FileInfo, len(entries))
+	for _, e := range entries {
+		if e.Name() == ".git" && e.IsDir() {
+			continue
+		}
+		if skip(filepath.Join(dir, e.Name()), e.IsDir()) {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(dir, e.Name())) // Follow symlinks
+		if err != nil {
+			continue
+		}
+		result[e.Name()] = info
+	}
+	return result, nil
+}
+
+// joinRel joins a slash-separated relative path and a name.
+func joinRel(rel, name string) string {
+	if rel == "" {
+		return name
+	}
+	return rel + "/" + name
+}
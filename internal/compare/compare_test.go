@@ -0,0 +1,88 @@
+package compare
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestDirs(t *testing.T) {
+	left := t.TempDir()
+	right := t.TempDir()
+
+	writeFile(t, filepath.Join(left, "same.txt"), "hello")
+	writeFile(t, filepath.Join(right, "same.txt"), "hello")
+	writeFile(t, filepath.Join(left, "changed.txt"), "hello")
+	writeFile(t, filepath.Join(right, "changed.txt"), "hellO")
+	writeFile(t, filepath.Join(left, "only-left.txt"), "x")
+	writeFile(t, filepath.Join(right, "only-right.txt"), "x")
+	writeFile(t, filepath.Join(left, "sub", "deep.txt"), "a")
+	writeFile(t, filepath.Join(right, "sub", "deep.txt"), "ab")
+	writeFile(t, filepath.Join(left, "newdir", "a.txt"), "a")
+	writeFile(t, filepath.Join(left, ".git", "HEAD"), "ref: main")
+
+	entries, err := Dirs(left, right, nil)
+	require.NoError(t, err)
+
+	got := make(map[string]Status)
+	for _, e := range entries {
+		got[e.Path] = e.Status
+	}
+
+	assert.Equal(t, map[string]Status{
+		"changed.txt":    StatusDiffers,
+		"only-left.txt":  StatusLeftOnly,
+		"only-right.txt": StatusRightOnly,
+		"sub/deep.txt":   StatusDiffers,
+		"newdir":         StatusLeftOnly,
+	}, got)
+
+	t.Run("sorted by path", func(t *testing.T) {
+		for i := 1; i < len(entries); i++ {
+			assert.Less(t, entries[i-1].Path, entries[i].Path)
+		}
+	})
+
+	t.Run("skip function hides entries", func(t *testing.T) {
+		entries, err := Dirs(left, right, func(path string, isDir bool) bool {
+			return strings.HasSuffix(path, ".txt")
+		})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "newdir", entries[0].Path)
+		assert.True(t, entries[0].IsDir)
+	})
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a")
+	b := filepath.Join(dir, "b")
+	c := filepath.Join(dir, "c")
+
+	big := strings.Repeat("x", 100*1024)
+	writeFile(t, a, big)
+	writeFile(t, b, big)
+	writeFile(t, c, big[:len(big)-1]+"y")
+
+	same, err := Files(a, b)
+	require.NoError(t, err)
+	assert.True(t, same)
+
+	same, err = Files(a, c)
+	require.NoError(t, err)
+	assert.False(t, same)
+
+	_, err = Files(a, filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,128 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/avitaltamir/vibecommander/internal/compare"
+	"github.com/avitaltamir/vibecommander/internal/components/content/diff"
+	"github.com/avitaltamir/vibecommander/internal/git"
+)
+
+// comparison is a pair of files or directories compared in the diff view.
+type comparison struct {
+	left, right string
+	skip        compare.SkipFunc
+}
+
+// title returns the names of the two sides, with their parent directory when
+// the names alone are the same.
+func (c comparison) title() string {
+	left, right := filepath.Base(c.left), filepath.Base(c.right)
+	if left == right {
+		left = filepath.Join(filepath.Base(filepath.Dir(c.left)), left)
+		right = filepath.Join(filepath.Base(filepath.Dir(c.right)), right)
+	}
+	return left + " ↔ " + right
+}
+
+// dir returns the directory the two sides are compared from: the deepest
+// one holding both.
+func (c comparison) dir() string {
+	dir := filepath.Dir(c.left)
+	for dir != filepath.Dir(dir) && !within(c.right, dir) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// within reports whether path is inside dir.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// isDir reports whether path is a directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// loadComparison diffs the two sides with git diff --no-index, which needs
+// neither to be in a repository. Directories are compared recursively,
+// listing what's only on one side or differs, followed by the diffs.
+func loadComparison(c comparison, opts git.DiffOptions) tea.Cmd {
+	return func() tea.Msg {
+		text, err := c.diff(context.Background(), opts)
+		return diff.DiffLoadedMsg{Path: c.right, Diff: text, Err: err}
+	}
+}
+
+// diff returns the diff between the two sides.
+func (c comparison) diff(ctx context.Context, opts git.DiffOptions) (string, error) {
+	dir := c.dir()
+	left, err := filepath.Rel(dir, c.left)
+	if err != nil {
+		return "", err
+	}
+	right, err := filepath.Rel(dir, c.right)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(c.left)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return git.DiffNoIndex(ctx, dir, left, right, opts)
+	}
+
+	entries, err := compare.Dirs(c.left, c.right, c.skip)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "", nil
+	}
+
+	var differ, leftOnly, rightOnly int
+	var listing, diffs strings.Builder
+	for _, e := range entries {
+		name := e.Path
+		if e.IsDir {
+			name += "/"
+		}
+		fmt.Fprintf(&listing, "  %-10s  %s\n", e.Status, name)
+
+		from, to := path.Join(filepath.ToSlash(left), e.Path), path.Join(filepath.ToSlash(right), e.Path)
+		switch e.Status {
+		case compare.StatusDiffers:
+			differ++
+		case compare.StatusLeftOnly:
+			leftOnly++
+			to = "/dev/null"
+		case compare.StatusRightOnly:
+			rightOnly++
+			from = "/dev/null"
+		}
+		if e.IsDir || isDir(filepath.Join(dir, filepath.FromSlash(to))) {
+			continue // Listed, not diffed
+		}
+		d, err := git.DiffNoIndex(ctx, dir, filepath.FromSlash(from), filepath.FromSlash(to), opts)
+		if err != nil {
+			return "", err
+		}
+		diffs.WriteString(d)
+	}
+
+	summary := fmt.Sprintf("Comparing %s/ with %s/: %d differ, %d only left, %d only right\n",
+		left, right, differ, leftOnly, rightOnly)
+	return summary + listing.String() + diffs.String(), nil
+}
@@ -0,0 +1,83 @@
+package diff
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// SetRoot sets the directory the files a diff names are relative to. With a
+// root, Location returns the file of the current line rather than the path
+// the diff was loaded for, as a diff of many files needs.
+func (m *Model) SetRoot(root string) {
+	m.root = root
+}
+
+// fileOf returns the path of the file line i of the diff belongs to.
+func (m Model) fileOf(i int) string {
+	if m.root == "" || i < 0 || i >= len(m.parsed) || m.parsed[i].file == "" {
+		return m.path
+	}
+	return filepath.Join(m.root, filepath.FromSlash(m.parsed[i].file))
+}
+
+// Files returns the file each file of the diff is for, as named by the diff,
+// in order.
+func (m Model) Files() []string {
+	return fileNames(m.parsed, m.files)
+}
+
+// FileNames returns the files a diff of many files is for, as named by the
+// diff, in order.
+func FileNames(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	parsed := parseDiff("", lines)
+	return fileNames(parsed, fileStarts(lines, parsed))
+}
+
+// fileStarts returns the line each file's headers start at. Blank lines,
+// like the one the diff ends with, start none.
+func fileStarts(lines []string, parsed []diffLine) []int {
+	var starts []int
+	for i, l := range parsed {
+		if l.kind == lineHeader && lines[i] != "" && (i == 0 || parsed[i-1].kind != lineHeader || lines[i-1] == "") {
+			starts = append(starts, i)
+		}
+	}
+	return starts
+}
+
+// fileNames returns the file of each run of headers starting at starts.
+func fileNames(parsed []diffLine, starts []int) []string {
+	files := make([]string, len(starts))
+	for k, start := range starts {
+		// The last header names it best: +++ after diff --git
+		for i := start; i < len(parsed) && parsed[i].kind == lineHeader; i++ {
+			files[k] = parsed[i].file
+		}
+	}
+	return files
+}
+
+// FileIndex returns which of Files the current line is in, or -1 above the
+// first.
+func (m Model) FileIndex() int {
+	line := m.currentLine()
+	k := -1
+	for i, start := range m.files {
+		if start <= line {
+			k = i
+		}
+	}
+	return k
+}
+
+// GotoFile scrolls the headers of file k of Files to the top and puts the
+// cursor on them.
+func (m *Model) GotoFile(k int) {
+	if k >= 0 && k < len(m.files) {
+		m.moveTo(m.files[k], m.files[k])
+	}
+}
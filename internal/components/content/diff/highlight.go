@@ -0,0 +1,141 @@
+package diff
+
+import (
+	"image/color"
+	"strings"
+
+	"github.com/avitaltamir/vibecommander/internal/syntax"
+	"github.com/avitaltamir/vibecommander/internal/theme"
+)
+
+// codeLine is the code of a diff line after its +, - or space.
+type codeLine struct {
+	code    bool          // False for headers and other lines that aren't code
+	spans   []syntax.Span // The code, highlighted
+	changed []byteRange   // What changed from the paired removed or added line
+}
+
+// side collects the lines of one side of a file's hunks to highlight them
+// together, so strings and comments spanning lines color right.
+type side struct {
+	text  []string
+	lines []int // Index in the diff of each line, -1 for one shown from the other side
+}
+
+func (s *side) add(text string, line int) {
+	s.text = append(s.text, text)
+	s.lines = append(s.lines, line)
+}
+
+// highlightDiff highlights the code in a diff: removed lines as part of the
+// old file, added and context lines as part of the new one. Removed lines
+// followed by added ones are paired up, and what changed between them noted.
+func highlightDiff(lines []string, parsed []diffLine) []codeLine {
+	code := make([]codeLine, len(lines))
+	var old, new side
+	var file string
+	var removed, added []int
+
+	flush := func() {
+		for _, s := range []*side{&old, &new} {
+			if len(s.text) == 0 {
+				continue
+			}
+			text := strings.Join(s.text, "\n")
+			spans := syntax.Lines(syntax.LexerFor(file, text), text)
+			for k, i := range s.lines {
+				if i >= 0 {
+					code[i].spans = spans[k]
+				}
+			}
+			*s = side{}
+		}
+	}
+	pair := func() {
+		for k := 0; k < min(len(removed), len(added)); k++ {
+			o, n := removed[k], added[k]
+			code[o].changed, code[n].changed = wordDiff(lines[o][1:], lines[n][1:])
+		}
+		removed, added = nil, nil
+	}
+
+	for i, line := range lines {
+		l := parsed[i]
+		if l.isCode() && l.file != file {
+			flush()
+			file = l.file
+		}
+		text := line
+		if line != "" {
+			text = line[1:]
+		}
+		switch l.kind {
+		case lineContext:
+			pair()
+			old.add(text, -1)
+			new.add(text, i)
+		case lineRemoved:
+			if len(added) > 0 {
+				pair()
+			}
+			removed = append(removed, i)
+			old.add(text, i)
+		case lineAdded:
+			added = append(added, i)
+			new.add(text, i)
+		case lineNoNewline:
+			continue
+		default:
+			pair()
+		}
+		code[i].code = l.isCode()
+	}
+	pair()
+	flush()
+	return code
+}
+
+// renderCode renders a line of code after its +, - or space, on the added
+// or removed background, with what changed from its pair emphasized.
+func renderCode(prefix byte, c codeLine) string {
+	var bg, emph color.Color
+	prefixStyle := theme.DiffContextStyle
+	switch prefix {
+	case '+':
+		bg, emph = theme.BgDiffAdded, theme.BgDiffAddedWord
+		prefixStyle = theme.DiffAddedStyle.Background(bg)
+	case '-':
+		bg, emph = theme.BgDiffRemoved, theme.BgDiffRemovedWord
+		prefixStyle = theme.DiffRemovedStyle.Background(bg)
+	}
+
+	var b strings.Builder
+	b.WriteString(prefixStyle.Render(string(prefix)))
+
+	pos, r := 0, 0
+	for _, span := range c.spans {
+		text := span.Text
+		for text != "" {
+			for r < len(c.changed) && c.changed[r].end <= pos {
+				r++
+			}
+			n, style := len(text), span.Style
+			switch {
+			case r < len(c.changed) && c.changed[r].start <= pos:
+				n = min(n, c.changed[r].end-pos)
+				style = style.Background(emph)
+			case r < len(c.changed):
+				n = min(n, c.changed[r].start-pos)
+				fallthrough
+			default:
+				if bg != nil {
+					style = style.Background(bg)
+				}
+			}
+			b.WriteString(style.Render(text[:n]))
+			text = text[n:]
+			pos += n
+		}
+	}
+	return b.String()
+}
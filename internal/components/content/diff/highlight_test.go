@@ -0,0 +1,57 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+)
+
+const goDiff = `diff --git a/main.go b/main.go
+index 1234567..89abcde 100644
+--- a/main.go
++++ b/main.go
+@@ -1,4 +1,3 @@
+ package main
+ 
+-func run() { fmt.Println("hi") }
++func run() { fmt.Println("hello") }
+--- removed, not a header
+@@ -10 +10,2 @@ func run()
+-// old
++// new
++x := 1`
+
+func TestHighlightDiff(t *testing.T) {
+	lines := strings.Split(goDiff, "\n")
+	code := highlightDiff(lines, parseDiff("", lines))
+
+	for i, want := range []bool{false, false, false, false, false, true, true, true, true, true, false, true, true, true} {
+		assert.Equal(t, want, code[i].code, "line %d: %q", i, lines[i])
+	}
+
+	// Code keeps its text, split into colored tokens
+	var text []string
+	for _, s := range code[7].spans {
+		text = append(text, s.Text)
+	}
+	assert.Equal(t, `func run() { fmt.Println("hi") }`, strings.Join(text, ""))
+	assert.Greater(t, len(text), 5, "highlighted as Go")
+
+	// The paired lines have their change marked
+	assert.Equal(t, []string{"hi"}, changedText(lines[7][1:], code[7].changed))
+	assert.Equal(t, []string{"hello"}, changedText(lines[8][1:], code[8].changed))
+	assert.Nil(t, code[9].changed, "nothing to pair with")
+	assert.Equal(t, []string{"old"}, changedText(lines[11][1:], code[11].changed))
+}
+
+func TestRenderDiff(t *testing.T) {
+	m := New().SetSize(80, 20)
+	m.SetContent(goDiff, "main.go")
+
+	view := ansi.Strip(m.View())
+	assert.Contains(t, view, `-func run() { fmt.Println("hi") }`)
+	assert.Contains(t, view, `+func run() { fmt.Println("hello") }`)
+	assert.Contains(t, view, "@@ -10 +10,2 @@ func run()")
+}
@@ -1,12 +1,15 @@
 package diff
 
 import (
+	"errors"
 	"strings"
 
+	"charm.land/bubbles/v2/textinput"
 	"charm.land/bubbles/v2/viewport"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/avitaltamir/vibecommander/internal/components"
+	"github.com/avitaltamir/vibecommander/internal/git"
 	"github.com/avitaltamir/vibecommander/internal/theme"
 )
 
@@ -26,9 +29,25 @@ type Model struct {
 
 	viewport viewport.Model
 	path     string
+	root     string // What the files the diff names are relative to, if set
 	diff     string
 	ready    bool
 	err      error
+	layout   Layout
+
+	lines  []string   // Lines of the diff
+	parsed []diffLine // What each line is
+	code   []codeLine // Each line highlighted, in the theme's colors
+	hunks  []int      // Line each hunk starts at
+	files  []int      // Line each file's headers start at
+	split  []splitRow // Rows of the split view, nil when unified
+	cursor int        // Line the hunk keys and Enter go from, -1 for the top of the view
+
+	options    git.DiffOptions // How the diff is made
+	askingBase bool            // The prompt for the ref to diff against is open
+	baseInput  textinput.Model
+	baseErr    error  // Why the ref last entered was rejected
+	prevBase   string // Base to go back to if the one entered is rejected
 
 	theme *theme.Theme
 }
@@ -36,7 +55,9 @@ type Model struct {
 // New creates a new diff viewer model.
 func New() Model {
 	return Model{
-		theme: theme.DefaultTheme(),
+		theme:     theme.DefaultTheme(),
+		cursor:    -1,
+		baseInput: newBaseInput(),
 	}
 }
 
@@ -59,17 +80,25 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 		return m, tea.Batch(cmds...)
 
+	case tea.MouseClickMsg:
+		// A click picks the line the hunk keys and Enter go from
+		if line := m.lineOfRow(msg.Mouse().Y - 1 + m.viewport.YOffset()); line >= 0 && m.diff != "" {
+			m.cursor = line
+			m.render()
+		}
+		return m, nil
+
 	case DiffLoadedMsg:
+		if errors.Is(msg.Err, git.ErrUnknownCommit) && m.options.Base != "" {
+			return m, m.rejectBase(msg.Err)
+		}
 		if msg.Err != nil {
 			m.err = msg.Err
 			m.diff = ""
+			m.parse()
 			m.viewport.SetContent(m.renderError(msg.Err))
 		} else {
-			m.path = msg.Path
-			m.diff = msg.Diff
-			m.err = nil
-			m.viewport.SetContent(m.renderDiff())
-			m.viewport.GotoTop()
+			m.SetContent(msg.Diff, msg.Path)
 		}
 		return m, nil
 
@@ -77,6 +106,16 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		if !m.Focused() {
 			return m, nil
 		}
+		if m.askingBase {
+			return m.updateBase(msg)
+		}
+
+		if m.updateNav(msg) {
+			return m, nil
+		}
+		if ok, cmd := m.updateOptions(msg); ok {
+			return m, cmd
+		}
 
 		m.viewport, cmd = m.viewport.Update(msg)
 		cmds = append(cmds, cmd)
@@ -97,6 +136,14 @@ func (m Model) View() string {
 		return m.renderPlaceholder()
 	}
 
+	if m.askingBase {
+		w, _ := m.Size()
+		lines := strings.Split(m.viewport.View(), "\n")
+		if len(lines) > 1 {
+			lines = lines[:len(lines)-1]
+		}
+		return strings.Join(append(lines, m.renderBaseBar(w)), "\n")
+	}
 	return m.viewport.View()
 }
 
@@ -127,79 +174,24 @@ func (m Model) renderDiff() string {
 			Render("(no changes)")
 	}
 
-	lines := strings.Split(m.diff, "\n")
+	if m.split != nil {
+		return m.renderSplit()
+	}
+
 	var result strings.Builder
 
-	lineNumStyle := theme.DiffLineNumberStyle
 	sepStyle := lipgloss.NewStyle().Foreground(theme.DimPurple)
 
-	for i, line := range lines {
-		lineNum := lineNumStyle.Render(padLeft(i+1, 4))
+	for i, line := range m.lines {
+		// Numbered as in the old and the new file
+		l := m.parsed[i]
+		lineNum := lineNumber(l.old, i == m.cursor) + " " + lineNumber(l.new, i == m.cursor)
 		sep := sepStyle.Render(" │ ")
 
-		var styledLine string
-		if len(line) > 0 {
-			switch line[0] {
-			case '+':
-				if strings.HasPrefix(line, "+++") {
-					// File header
-					styledLine = theme.DiffHunkStyle.Render(line)
-				} else {
-					// Added line
-					styledLine = theme.DiffAddedStyle.
-						Background(theme.BgDiffAdded).
-						Render(line)
-				}
-			case '-':
-				if strings.HasPrefix(line, "---") {
-					// File header
-					styledLine = theme.DiffHunkStyle.Render(line)
-				} else {
-					// Removed line
-					styledLine = theme.DiffRemovedStyle.
-						Background(theme.BgDiffRemoved).
-						Render(line)
-				}
-			case '@':
-				// Hunk header
-				styledLine = theme.DiffHunkStyle.
-					Background(theme.BgDiffHunk).
-					Render(line)
-			case 'd':
-				if strings.HasPrefix(line, "diff ") {
-					// Diff header
-					styledLine = lipgloss.NewStyle().
-						Foreground(theme.CyberCyan).
-						Bold(true).
-						Render(line)
-				} else {
-					styledLine = theme.DiffContextStyle.Render(line)
-				}
-			case 'i', 'n', 's', 'o':
-				// index, new file mode, similarity, old mode, etc.
-				if strings.HasPrefix(line, "index ") ||
-					strings.HasPrefix(line, "new file") ||
-					strings.HasPrefix(line, "similarity") ||
-					strings.HasPrefix(line, "old mode") ||
-					strings.HasPrefix(line, "new mode") {
-					styledLine = lipgloss.NewStyle().
-						Foreground(theme.MutedLavender).
-						Render(line)
-				} else {
-					styledLine = theme.DiffContextStyle.Render(line)
-				}
-			default:
-				// Context line
-				styledLine = theme.DiffContextStyle.Render(line)
-			}
-		} else {
-			styledLine = ""
-		}
-
 		result.WriteString(lineNum)
 		result.WriteString(sep)
-		result.WriteString(styledLine)
-		if i < len(lines)-1 {
+		result.WriteString(styleLine(line, l, m.code[i]))
+		if i < len(m.lines)-1 {
 			result.WriteString("\n")
 		}
 	}
@@ -207,15 +199,109 @@ func (m Model) renderDiff() string {
 	return result.String()
 }
 
+// lineNumber renders a line number in the gutter, blank for 0. The line the
+// cursor is on stands out.
+func lineNumber(n int, current bool) string {
+	style := theme.DiffLineNumberStyle
+	if current {
+		style = style.Foreground(theme.HotPink).Bold(true)
+	}
+	if n == 0 {
+		return style.Render("")
+	}
+	return style.Render(padLeft(n, 4))
+}
+
+// styleLine renders a line of a diff: code highlighted on the added or
+// removed background, headers by what they are.
+func styleLine(line string, l diffLine, c codeLine) string {
+	if line == "" {
+		return ""
+	}
+	if c.code {
+		return renderCode(l.prefix(), c)
+	}
+
+	switch line[0] {
+	case '+', '-':
+		// File header
+		return theme.DiffHunkStyle.Render(line)
+	case '@':
+		// Hunk header
+		return theme.DiffHunkStyle.
+			Background(theme.BgDiffHunk).
+			Render(line)
+	case 'd':
+		if strings.HasPrefix(line, "diff ") {
+			// Diff header
+			return lipgloss.NewStyle().
+				Foreground(theme.CyberCyan).
+				Bold(true).
+				Render(line)
+		}
+	case 'i', 'n', 's', 'o', '\\':
+		// index, new file mode, similarity, old mode, no newline, etc.
+		if strings.HasPrefix(line, "index ") ||
+			strings.HasPrefix(line, "new file") ||
+			strings.HasPrefix(line, "similarity") ||
+			strings.HasPrefix(line, "old mode") ||
+			strings.HasPrefix(line, "new mode") ||
+			l.kind == lineNoNewline {
+			return lipgloss.NewStyle().
+				Foreground(theme.MutedLavender).
+				Render(line)
+		}
+	}
+	// Context line
+	return theme.DiffContextStyle.Render(line)
+}
+
 // SetContent sets the diff content directly.
 func (m *Model) SetContent(diff string, path string) {
 	m.diff = diff
 	m.path = path
 	m.err = nil
-	m.viewport.SetContent(m.renderDiff())
+	m.parse()
+	m.render()
 	m.viewport.GotoTop()
 }
 
+// parse splits the diff into lines and works out where its hunks and files
+// start.
+func (m *Model) parse() {
+	m.lines, m.parsed, m.code, m.hunks, m.files = nil, nil, nil, nil, nil
+	m.cursor = -1
+	if m.diff == "" {
+		return
+	}
+	m.lines = strings.Split(m.diff, "\n")
+	m.parsed = parseDiff(m.path, m.lines)
+	m.code = highlightDiff(m.lines, m.parsed)
+	for i, l := range m.parsed {
+		if l.kind == lineHunk {
+			m.hunks = append(m.hunks, i)
+		}
+	}
+	m.files = fileStarts(m.lines, m.parsed)
+}
+
+// render lays out and renders the diff.
+func (m *Model) render() {
+	m.split = nil
+	if m.IsSplit() {
+		m.split = splitRows(m.parsed)
+	}
+	m.viewport.SetContent(m.renderDiff())
+}
+
+// Refresh re-renders the diff, e.g. after the theme changed.
+func (m *Model) Refresh() {
+	if m.diff != "" {
+		m.code = highlightDiff(m.lines, m.parsed)
+		m.render()
+	}
+}
+
 // Path returns the current file path.
 func (m Model) Path() string {
 	return m.path
@@ -236,6 +322,8 @@ func (m *Model) Clear() {
 	m.path = ""
 	m.diff = ""
 	m.err = nil
+	m.parse()
+	m.split = nil
 	m.ready = false
 	m.viewport.SetContent("")
 }
@@ -270,7 +358,9 @@ func (m Model) SetSize(width, height int) Model {
 	}
 
 	if m.diff != "" {
-		m.viewport.SetContent(m.renderDiff())
+		top := m.lineOfRow(m.viewport.YOffset())
+		m.render()
+		m.viewport.SetYOffset(m.rowOfLine(top))
 	}
 
 	return m
@@ -0,0 +1,166 @@
+package diff
+
+import (
+	tea "charm.land/bubbletea/v2"
+)
+
+// lineOfRow returns the line of the diff shown on a row, or -1 past the end.
+func (m Model) lineOfRow(row int) int {
+	if m.split != nil {
+		if row < 0 || row >= len(m.split) {
+			return -1
+		}
+		return m.split[row].line()
+	}
+	if row < 0 || row >= len(m.lines) {
+		return -1
+	}
+	return row
+}
+
+// rowOfLine returns the row showing a line of the diff.
+func (m Model) rowOfLine(line int) int {
+	if m.split == nil {
+		return line
+	}
+	for r, row := range m.split {
+		if row.span == line || row.left == line || row.right == line {
+			return r
+		}
+	}
+	return 0
+}
+
+// currentLine returns the line of the diff the cursor is on, or the top one
+// in view when the cursor isn't in view.
+func (m Model) currentLine() int {
+	top := m.viewport.YOffset()
+	if m.cursor >= 0 {
+		if row := m.rowOfLine(m.cursor); row >= top && row < top+m.viewport.Height() {
+			return m.cursor
+		}
+	}
+	return max(m.lineOfRow(top), 0)
+}
+
+// HunkPosition returns which hunk the current line is in (1-indexed, 0
+// above the first) and how many there are.
+func (m Model) HunkPosition() (current, total int) {
+	line := m.currentLine()
+	for _, start := range m.hunks {
+		if start <= line {
+			current++
+		}
+	}
+	return current, len(m.hunks)
+}
+
+// firstChange returns the first removed or added line of the hunk starting
+// at line, or the line after the header when there's none.
+func (m Model) firstChange(start int) int {
+	for i := start + 1; i < len(m.parsed) && m.parsed[i].isCode(); i++ {
+		if m.parsed[i].kind != lineContext {
+			return i
+		}
+	}
+	return min(start+1, len(m.parsed)-1)
+}
+
+// NextHunk moves the cursor to the first change of the next hunk (dir 1) or
+// the previous one (dir -1), scrolling its header to the top. It returns
+// false when there's none that way.
+func (m *Model) NextHunk(dir int) bool {
+	line := m.currentLine()
+	target := -1
+	for _, start := range m.hunks {
+		change := m.firstChange(start)
+		if (dir > 0 && change > line) || (dir < 0 && change < line) {
+			target = start
+			if dir > 0 {
+				break
+			}
+		}
+	}
+	if target < 0 {
+		return false
+	}
+	m.moveTo(target, m.firstChange(target))
+	return true
+}
+
+// NextFile moves the cursor to the headers of the next file (dir 1) or the
+// previous one (dir -1) in a diff of several files. It returns false when
+// there's none that way.
+func (m *Model) NextFile(dir int) bool {
+	line := m.currentLine()
+	target := -1
+	for _, start := range m.files {
+		if (dir > 0 && start > line) || (dir < 0 && start < line) {
+			target = start
+			if dir > 0 {
+				break
+			}
+		}
+	}
+	if target < 0 {
+		return false
+	}
+	m.moveTo(target, target)
+	return true
+}
+
+// moveTo scrolls line top to the top of the view and puts the cursor on
+// line cursor.
+func (m *Model) moveTo(top, cursor int) {
+	m.cursor = cursor
+	m.render()
+	m.viewport.SetYOffset(m.rowOfLine(top))
+}
+
+// Location returns the file of the diff and the line (0-indexed) in its new
+// version for the current line. A removed line maps to where it was.
+func (m Model) Location() (path string, line int) {
+	if len(m.parsed) == 0 {
+		return m.path, 0
+	}
+	cur := m.currentLine()
+	path = m.fileOf(cur)
+	for i := cur; i >= 0; i-- {
+		l := m.parsed[i]
+		if l.new > 0 {
+			if i != cur {
+				return path, l.new // The line after the last one kept
+			}
+			return path, l.new - 1
+		}
+		if l.kind == lineHunk {
+			h, _ := parseHunk(m.lines[i])
+			return path, max(h.newStart-1, 0)
+		}
+		if !l.isCode() && l.kind != lineNoNewline {
+			break
+		}
+	}
+	return path, 0
+}
+
+// updateNav handles the keys that move around the diff: n/p for the
+// next/previous hunk, ]/[ for the next/previous file, and s to switch
+// between the unified and the split view.
+func (m *Model) updateNav(msg tea.KeyPressMsg) bool {
+	switch msg.String() {
+	case "s":
+		m.ToggleSplit()
+	case "n":
+		m.NextHunk(1)
+	case "p":
+		m.NextHunk(-1)
+	case "]":
+		m.NextFile(1)
+	case "[":
+		m.NextFile(-1)
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const twoFiles = `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,3 +1,3 @@
+ package a
+-var x = 1
++var x = 2
+ var y = 3
+@@ -20,2 +20,3 @@ func f() {
+ 	one()
++	two()
+ }
+diff --git a/b.go b/b.go
+--- a/b.go
++++ b/b.go
+@@ -5,2 +5,1 @@
+-	gone()
+ 	kept()`
+
+var (
+	keyN = tea.KeyPressMsg{Code: 'n', Text: "n"}
+	keyP = tea.KeyPressMsg{Code: 'p', Text: "p"}
+)
+
+func TestHunkNavigation(t *testing.T) {
+	m := New().SetSize(80, 5).Focus()
+	m.SetContent(twoFiles, "/repo/a.go")
+	assert.Equal(t, []int{3, 8, 15}, m.hunks)
+	assert.Equal(t, []int{0, 12}, m.files)
+
+	current, total := m.HunkPosition()
+	assert.Equal(t, 0, current, "above the first hunk")
+	assert.Equal(t, 3, total)
+
+	m, _ = m.Update(keyN)
+	assert.Equal(t, 5, m.cursor, "on the first change")
+	assert.Equal(t, 3, m.viewport.YOffset(), "hunk header at the top")
+	current, _ = m.HunkPosition()
+	assert.Equal(t, 1, current)
+
+	m, _ = m.Update(keyN)
+	assert.Equal(t, 10, m.cursor)
+	m, _ = m.Update(keyN)
+	assert.Equal(t, 16, m.cursor)
+	current, _ = m.HunkPosition()
+	assert.Equal(t, 3, current)
+
+	m, _ = m.Update(keyN)
+	assert.Equal(t, 16, m.cursor, "no hunk after the last")
+
+	m, _ = m.Update(keyP)
+	assert.Equal(t, 10, m.cursor)
+
+	// Files
+	m, _ = m.Update(tea.KeyPressMsg{Code: ']', Text: "]"})
+	assert.Equal(t, 12, m.cursor)
+	m, _ = m.Update(tea.KeyPressMsg{Code: '[', Text: "["})
+	assert.Equal(t, 0, m.cursor)
+
+	// Split view moves by rows of its own
+	m = m.SetSize(splitWidth, 5)
+	require.True(t, m.IsSplit())
+	m, _ = m.Update(keyN)
+	m, _ = m.Update(keyN)
+	assert.Equal(t, 10, m.cursor)
+	assert.Equal(t, 7, m.viewport.YOffset(), "the removed and added line share a row")
+}
+
+func TestLocation(t *testing.T) {
+	m := New().SetSize(80, 30).Focus()
+	m.SetContent(twoFiles, "/repo/a.go")
+
+	at := func(line int) int {
+		m.cursor = line
+		path, l := m.Location()
+		assert.Equal(t, "/repo/a.go", path)
+		return l
+	}
+	assert.Equal(t, 0, at(4), "context line")
+	assert.Equal(t, 1, at(5), "removed line is where it was")
+	assert.Equal(t, 1, at(6), "added line")
+	assert.Equal(t, 19, at(8), "hunk header")
+	assert.Equal(t, 20, at(10))
+	assert.Equal(t, 0, at(1), "header")
+}
+
+func TestUnifiedLineNumbers(t *testing.T) {
+	m := New().SetSize(80, 30)
+	m.SetContent(twoFiles, "/repo/a.go")
+
+	rows := strings.Split(ansi.Strip(m.View()), "\n")
+	assert.Equal(t, "          │ diff --git a/a.go b/a.go", strings.TrimRight(rows[0], " "))
+	assert.Equal(t, "   1    1 │  package a", strings.TrimRight(rows[4], " "))
+	assert.Equal(t, "   2      │ -var x = 1", strings.TrimRight(rows[5], " "))
+	assert.Equal(t, "        2 │ +var x = 2", strings.TrimRight(rows[6], " "))
+	assert.Equal(t, "       21 │ +    two()", strings.TrimRight(rows[10], " "))
+}
+
+func TestClickSetsCursor(t *testing.T) {
+	m := New().SetSize(80, 30).Focus()
+	m.SetContent(twoFiles, "/repo/a.go")
+
+	// Row 7 of the pane is line 6, below the title
+	m, _ = m.Update(tea.MouseClickMsg{X: 20, Y: 7, Button: tea.MouseLeft})
+	assert.Equal(t, 6, m.cursor)
+	_, line := m.Location()
+	assert.Equal(t, 1, line)
+}
+
+func TestFiles(t *testing.T) {
+	m := New().SetSize(80, 5).Focus()
+	m.SetContent(twoFiles+"\n", "/repo")
+	m.SetRoot("/repo")
+	assert.Equal(t, []string{"a.go", "b.go"}, m.Files())
+	assert.Equal(t, []string{"a.go", "b.go"}, FileNames(twoFiles+"\n"))
+	assert.Equal(t, 0, m.FileIndex())
+
+	m.GotoFile(1)
+	assert.Equal(t, 1, m.FileIndex())
+	path, line := m.Location()
+	assert.Equal(t, "/repo/b.go", path)
+	assert.Equal(t, 0, line, "the headers are on no line")
+}
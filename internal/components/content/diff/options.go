@@ -0,0 +1,160 @@
+package diff
+
+import (
+	"strings"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/avitaltamir/vibecommander/internal/git"
+	"github.com/avitaltamir/vibecommander/internal/theme"
+)
+
+// contextSteps are the numbers of context lines + and - step through.
+var contextSteps = []int{1, git.DefaultContext, 5, 10, 25, 100}
+
+// OptionsChangedMsg is sent when the options the diff is made with changed,
+// so whoever loaded it loads it again.
+type OptionsChangedMsg struct {
+	Options git.DiffOptions
+}
+
+// newBaseInput creates the prompt for the ref to diff against.
+func newBaseInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "branch, tag or commit"
+	ti.CharLimit = 64
+	ti.SetWidth(24)
+	return ti
+}
+
+// Options returns the options the diff is made with.
+func (m Model) Options() git.DiffOptions {
+	return m.options
+}
+
+// SetOptions sets the options the diff is made with.
+func (m *Model) SetOptions(opts git.DiffOptions) {
+	m.options = opts
+}
+
+// Prompting reports whether the prompt for the ref to diff against is open.
+func (m Model) Prompting() bool {
+	return m.askingBase
+}
+
+// updateOptions handles the keys that change how the diff is made: w cycles
+// through ignoring all or trailing whitespace, B ignores blank lines, + and
+// - widen and narrow the context, F shows whole functions, and r asks for a
+// ref to diff against. It returns false for other keys (b and f page).
+func (m *Model) updateOptions(msg tea.KeyPressMsg) (bool, tea.Cmd) {
+	opts := m.options
+	switch msg.String() {
+	case "w":
+		opts.Whitespace = (opts.Whitespace + 1) % (git.WhitespaceTrailing + 1)
+	case "B":
+		opts.IgnoreBlankLines = !opts.IgnoreBlankLines
+	case "+", "=":
+		opts.Context = stepContext(opts.Context, 1)
+	case "-":
+		opts.Context = stepContext(opts.Context, -1)
+	case "F":
+		opts.FunctionContext = !opts.FunctionContext
+	case "r":
+		m.askingBase = true
+		m.baseInput.SetValue(opts.Base)
+		m.baseInput.Focus()
+		return true, textinput.Blink
+	default:
+		return false, nil
+	}
+	if opts == m.options {
+		return true, nil
+	}
+	m.options = opts
+	return true, m.optionsChanged()
+}
+
+// updateBase handles keys while the prompt for the ref to diff against is
+// open. An empty ref goes back to diffing against the index.
+func (m Model) updateBase(msg tea.KeyPressMsg) (Model, tea.Cmd) {
+	switch msg.Key().Code {
+	case tea.KeyEscape:
+		m.askingBase = false
+		m.baseErr = nil
+		m.baseInput.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		m.askingBase = false
+		m.baseErr = nil
+		m.baseInput.Blur()
+		base := strings.TrimSpace(m.baseInput.Value())
+		if base == m.options.Base {
+			return m, nil
+		}
+		m.prevBase, m.options.Base = m.options.Base, base
+		return m, m.optionsChanged()
+	}
+	var cmd tea.Cmd
+	m.baseInput, cmd = m.baseInput.Update(msg)
+	return m, cmd
+}
+
+// rejectBase opens the prompt again on a ref that isn't a commit, showing
+// why, and goes back to diffing against the base from before.
+func (m *Model) rejectBase(err error) tea.Cmd {
+	m.baseErr = err
+	m.askingBase = true
+	m.baseInput.SetValue(m.options.Base)
+	m.baseInput.Focus()
+	m.options.Base, m.prevBase = m.prevBase, ""
+	return tea.Batch(textinput.Blink, m.optionsChanged())
+}
+
+// optionsChanged asks for the diff to be loaded again with the options.
+func (m Model) optionsChanged() tea.Cmd {
+	opts := m.options
+	return func() tea.Msg {
+		return OptionsChangedMsg{Options: opts}
+	}
+}
+
+// stepContext returns the next number of context lines up (dir 1) or down
+// (dir -1) from n, where 0 is git's default.
+func stepContext(n, dir int) int {
+	if n == 0 {
+		n = git.DefaultContext
+	}
+	if dir > 0 {
+		for _, step := range contextSteps {
+			if step > n {
+				return step
+			}
+		}
+		return n
+	}
+	for i := len(contextSteps) - 1; i >= 0; i-- {
+		if contextSteps[i] < n {
+			return contextSteps[i]
+		}
+	}
+	return n
+}
+
+// renderBaseBar renders the prompt for the ref to diff against.
+func (m Model) renderBaseBar(width int) string {
+	prefix := lipgloss.NewStyle().
+		Foreground(theme.CyberCyan).
+		Bold(true).
+		Render("Diff against: ")
+	bar := prefix + m.baseInput.View()
+	if m.baseErr != nil {
+		bar += lipgloss.NewStyle().Foreground(theme.ColorError).Render("  " + m.baseErr.Error())
+	}
+	return lipgloss.NewStyle().
+		Background(lipgloss.Color("236")).
+		Width(width).
+		Render(ansi.Truncate(bar, width, "…"))
+}
@@ -0,0 +1,82 @@
+package diff
+
+import (
+	"fmt"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/avitaltamir/vibecommander/internal/git"
+)
+
+func TestStepContext(t *testing.T) {
+	assert.Equal(t, 5, stepContext(0, 1))
+	assert.Equal(t, 1, stepContext(0, -1))
+	assert.Equal(t, 1, stepContext(1, -1))
+	assert.Equal(t, 100, stepContext(100, 1))
+	assert.Equal(t, 10, stepContext(7, 1))
+}
+
+// press sends a key and returns the options it asked to load the diff with,
+// if any.
+func press(t *testing.T, m Model, msg tea.KeyPressMsg) (Model, *git.DiffOptions) {
+	t.Helper()
+	m, cmd := m.Update(msg)
+	if cmd == nil {
+		return m, nil
+	}
+	changed, ok := cmd().(OptionsChangedMsg)
+	if !ok {
+		return m, nil
+	}
+	return m, &changed.Options
+}
+
+func TestOptionKeys(t *testing.T) {
+	m := New().SetSize(80, 10).Focus()
+	m.SetContent(twoFiles, "/repo/a.go")
+
+	m, opts := press(t, m, tea.KeyPressMsg{Code: 'w', Text: "w"})
+	require.NotNil(t, opts)
+	assert.Equal(t, git.WhitespaceIgnored, opts.Whitespace)
+	m, opts = press(t, m, tea.KeyPressMsg{Code: 'w', Text: "w"})
+	assert.Equal(t, git.WhitespaceTrailing, opts.Whitespace)
+
+	m, opts = press(t, m, tea.KeyPressMsg{Code: 'B', Text: "B"})
+	assert.True(t, opts.IgnoreBlankLines)
+	m, opts = press(t, m, tea.KeyPressMsg{Code: '+', Text: "+"})
+	assert.Equal(t, 5, opts.Context)
+	m, opts = press(t, m, tea.KeyPressMsg{Code: 'F', Text: "F"})
+	assert.True(t, opts.FunctionContext)
+	assert.Equal(t, *opts, m.Options())
+
+	// r asks for the ref to diff against
+	m, opts = press(t, m, tea.KeyPressMsg{Code: 'r', Text: "r"})
+	assert.Nil(t, opts)
+	assert.True(t, m.Prompting())
+	assert.Contains(t, ansi.Strip(m.View()), "Diff against:")
+	for _, r := range "main" {
+		m, _ = press(t, m, tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+	m, opts = press(t, m, tea.KeyPressMsg{Code: tea.KeyEnter})
+	require.NotNil(t, opts)
+	assert.Equal(t, "main", opts.Base)
+	assert.False(t, m.Prompting())
+
+	// A ref that isn't a commit opens the prompt again, with the error, and
+	// the diff goes back to the base from before
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'r', Text: "r"})
+	for _, r := range "nope" {
+		m, _ = m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	err := fmt.Errorf("%q: %w", "mainnope", git.ErrUnknownCommit)
+	m, cmd := m.Update(DiffLoadedMsg{Path: "/repo/a.go", Err: err})
+	require.NotNil(t, cmd)
+	assert.True(t, m.Prompting())
+	assert.Equal(t, "main", m.Options().Base)
+	assert.Contains(t, ansi.Strip(m.View()), "not a branch")
+}
@@ -0,0 +1,161 @@
+package diff
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hunkHeader matches the start of a hunk: @@ -old,count +new,count @@.
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// hunk is where a hunk's lines are in the old and new file. Lines are
+// 1-indexed, as in the header.
+type hunk struct {
+	oldStart, oldCount int
+	newStart, newCount int
+}
+
+// parseHunk parses a hunk header. A missing count means one line.
+func parseHunk(line string) (hunk, bool) {
+	sub := hunkHeader.FindStringSubmatch(line)
+	if sub == nil {
+		return hunk{}, false
+	}
+	num := func(s string) int {
+		if s == "" {
+			return 1
+		}
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	return hunk{
+		oldStart: num(sub[1]),
+		oldCount: num(sub[2]),
+		newStart: num(sub[3]),
+		newCount: num(sub[4]),
+	}, true
+}
+
+// lineKind is what a line of a diff is.
+type lineKind int
+
+const (
+	lineHeader    lineKind = iota // diff, index, ---, +++ and the like
+	lineHunk                      // @@ -a,b +c,d @@
+	lineContext                   // Unchanged
+	lineRemoved                   // -
+	lineAdded                     // +
+	lineNoNewline                 // \ No newline at end of file
+)
+
+// diffLine is a line of a diff, parsed.
+type diffLine struct {
+	kind lineKind
+	old  int    // Line in the old file (1-indexed), or 0 when it isn't there
+	new  int    // Line in the new file (1-indexed), or 0 when it isn't there
+	file string // File it belongs to, from the diff --git and ---/+++ headers
+}
+
+// prefix returns the character a line of code starts with.
+func (l diffLine) prefix() byte {
+	switch l.kind {
+	case lineRemoved:
+		return '-'
+	case lineAdded:
+		return '+'
+	}
+	return ' '
+}
+
+// isCode reports whether the line is a line of one of the files.
+func (l diffLine) isCode() bool {
+	return l.kind == lineContext || l.kind == lineRemoved || l.kind == lineAdded
+}
+
+// parseDiff works out what each line of a diff is and where it is in the old
+// and new file. Hunks are read by their counts, so a removed "-- x" isn't
+// mistaken for a header. path names the file when the diff doesn't.
+func parseDiff(path string, lines []string) []diffLine {
+	parsed := make([]diffLine, len(lines))
+	file := path
+	var left hunk // Lines left in the current hunk, and where the next ones are
+
+	for i, line := range lines {
+		parsed[i].file = file
+		if left.oldCount > 0 || left.newCount > 0 {
+			prefix := byte(' ')
+			if line != "" {
+				prefix = line[0]
+			}
+			switch prefix {
+			case ' ':
+				parsed[i] = diffLine{kind: lineContext, old: left.oldStart, new: left.newStart, file: file}
+				left.oldStart++
+				left.newStart++
+				left.oldCount--
+				left.newCount--
+				continue
+			case '-':
+				parsed[i] = diffLine{kind: lineRemoved, old: left.oldStart, file: file}
+				left.oldStart++
+				left.oldCount--
+				continue
+			case '+':
+				parsed[i] = diffLine{kind: lineAdded, new: left.newStart, file: file}
+				left.newStart++
+				left.newCount--
+				continue
+			case '\\':
+				parsed[i].kind = lineNoNewline
+				continue
+			}
+			left = hunk{}
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			// Named again by ---/+++, unless it's binary or only renamed
+			if j := strings.LastIndex(line, " b/"); j >= 0 {
+				file = line[j+3:]
+			}
+			parsed[i].file = file
+		case strings.HasPrefix(line, "--- "):
+			if name := diffFileName(line); name != "" {
+				file = name
+			}
+		case strings.HasPrefix(line, "+++ "):
+			if name := diffFileName(line); name != "" {
+				file = name
+			}
+		default:
+			if h, ok := parseHunk(line); ok {
+				parsed[i].kind = lineHunk
+				left = h
+				continue
+			}
+			if line == `\ No newline at end of file` {
+				parsed[i].kind = lineNoNewline // After a hunk's last line
+				continue
+			}
+		}
+		parsed[i] = diffLine{kind: lineHeader, file: file}
+	}
+	return parsed
+}
+
+// diffFileName returns the file named by a ---/+++ header, or "" for
+// /dev/null.
+func diffFileName(line string) string {
+	name := line[4:]
+	if i := strings.IndexByte(name, '\t'); i >= 0 {
+		name = name[:i] // Some diffs follow the name with a timestamp
+	}
+	if name == "/dev/null" {
+		return ""
+	}
+	if len(name) > 2 && (name[:2] == "a/" || name[:2] == "b/") {
+		name = name[2:]
+	}
+	return name
+}
@@ -0,0 +1,45 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHunk(t *testing.T) {
+	h, ok := parseHunk("@@ -1,4 +1,5 @@ func main()")
+	require.True(t, ok)
+	assert.Equal(t, hunk{oldStart: 1, oldCount: 4, newStart: 1, newCount: 5}, h)
+
+	h, ok = parseHunk("@@ -10 +10,2 @@")
+	require.True(t, ok)
+	assert.Equal(t, hunk{oldStart: 10, oldCount: 1, newStart: 10, newCount: 2}, h)
+
+	_, ok = parseHunk("@@@ -1 +1 @@@")
+	assert.False(t, ok)
+}
+
+func TestParseDiff(t *testing.T) {
+	lines := strings.Split(goDiff, "\n")
+	parsed := parseDiff("", lines)
+
+	want := []diffLine{
+		{kind: lineHeader, file: "main.go"},
+		{kind: lineHeader, file: "main.go"},
+		{kind: lineHeader, file: "main.go"},
+		{kind: lineHeader, file: "main.go"},
+		{kind: lineHunk, file: "main.go"},
+		{kind: lineContext, old: 1, new: 1, file: "main.go"},
+		{kind: lineContext, old: 2, new: 2, file: "main.go"},
+		{kind: lineRemoved, old: 3, file: "main.go"},
+		{kind: lineAdded, new: 3, file: "main.go"},
+		{kind: lineRemoved, old: 4, file: "main.go"},
+		{kind: lineHunk, file: "main.go"},
+		{kind: lineRemoved, old: 10, file: "main.go"},
+		{kind: lineAdded, new: 10, file: "main.go"},
+		{kind: lineAdded, new: 11, file: "main.go"},
+	}
+	assert.Equal(t, want, parsed)
+}
@@ -0,0 +1,154 @@
+package diff
+
+import (
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/avitaltamir/vibecommander/internal/theme"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// splitWidth is how wide the pane must be for diffs to be split
+// automatically, so each side still fits a typical line of code.
+const splitWidth = 160
+
+// Layout is how a diff is laid out.
+type Layout int
+
+const (
+	LayoutAuto    Layout = iota // Split when the pane is at least splitWidth wide
+	LayoutUnified               // Removed and added lines one after another
+	LayoutSplit                 // Old on the left, new on the right
+)
+
+// IsSplit reports whether the diff is shown side by side.
+func (m Model) IsSplit() bool {
+	switch m.layout {
+	case LayoutUnified:
+		return false
+	case LayoutSplit:
+		return true
+	}
+	w, _ := m.Size()
+	return w >= splitWidth
+}
+
+// ToggleSplit switches between the unified and the split view. The choice
+// sticks, whatever the width of the pane.
+func (m *Model) ToggleSplit() {
+	if m.IsSplit() {
+		m.layout = LayoutUnified
+	} else {
+		m.layout = LayoutSplit
+	}
+	if m.diff != "" {
+		top := m.lineOfRow(m.viewport.YOffset())
+		m.render()
+		m.viewport.SetYOffset(m.rowOfLine(top))
+	}
+}
+
+// splitRow is a row of the split view: a line on each side, or one line
+// spanning both. Each is a line of the diff, or -1 for none.
+type splitRow struct {
+	left, right int
+	span        int
+}
+
+// splitRows lays out the split view. Removed lines are paired with the added
+// ones following them, leaving a side empty where the other has more.
+// Unchanged lines are on both sides; headers span them.
+func splitRows(parsed []diffLine) []splitRow {
+	var rows []splitRow
+	var removed, added []int
+	flush := func() {
+		for k := 0; k < max(len(removed), len(added)); k++ {
+			row := splitRow{left: -1, right: -1, span: -1}
+			if k < len(removed) {
+				row.left = removed[k]
+			}
+			if k < len(added) {
+				row.right = added[k]
+			}
+			rows = append(rows, row)
+		}
+		removed, added = nil, nil
+	}
+
+	for i, l := range parsed {
+		switch l.kind {
+		case lineRemoved:
+			if len(added) > 0 {
+				flush()
+			}
+			removed = append(removed, i)
+		case lineAdded:
+			added = append(added, i)
+		case lineContext:
+			flush()
+			rows = append(rows, splitRow{left: i, right: i, span: -1})
+		default:
+			flush()
+			rows = append(rows, splitRow{left: -1, right: -1, span: i})
+		}
+	}
+	flush()
+	return rows
+}
+
+// line returns the line of the diff a row stands for: the new side's when
+// there is one.
+func (r splitRow) line() int {
+	switch {
+	case r.span >= 0:
+		return r.span
+	case r.right >= 0:
+		return r.right
+	}
+	return r.left
+}
+
+// renderSplit renders the diff side by side: old lines on the left, new ones
+// on the right, numbered as in their files, with filler where a side has no
+// line.
+func (m Model) renderSplit() string {
+	code := m.code
+	w, _ := m.Size()
+	leftWidth := max((w-1)/2, 1)
+	rightWidth := max(w-1-leftWidth, 1)
+	sep := lipgloss.NewStyle().Foreground(theme.DimPurple).Render("│")
+
+	rows := make([]string, len(m.split))
+	for r, row := range m.split {
+		if row.span >= 0 {
+			rows[r] = ansi.Truncate(styleLine(m.lines[row.span], m.parsed[row.span], code[row.span]), w, "")
+			continue
+		}
+		current := m.cursor >= 0 && (row.left == m.cursor || row.right == m.cursor)
+		left, right := renderFiller(leftWidth), renderFiller(rightWidth)
+		if row.left >= 0 {
+			left = renderHalf(m.parsed[row.left].old, current, m.parsed[row.left], code[row.left], leftWidth)
+		}
+		if row.right >= 0 {
+			right = renderHalf(m.parsed[row.right].new, current, m.parsed[row.right], code[row.right], rightWidth)
+		}
+		rows[r] = left + sep + right
+	}
+	return strings.Join(rows, "\n")
+}
+
+// renderHalf renders a line on one side of the split view, numbered num and
+// cut or padded to width.
+func renderHalf(num int, current bool, l diffLine, c codeLine, width int) string {
+	s := lineNumber(num, current) + " " + renderCode(l.prefix(), c)
+	s = ansi.Truncate(s, width, "")
+	return s + strings.Repeat(" ", max(width-ansi.StringWidth(s), 0))
+}
+
+// renderFiller fills the side of the split view that has no line where the
+// other side has one.
+func renderFiller(width int) string {
+	return lipgloss.NewStyle().
+		Foreground(theme.DimPurple).
+		Render(strings.Repeat("╱", width))
+}
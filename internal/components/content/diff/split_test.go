@@ -0,0 +1,60 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitAuto(t *testing.T) {
+	m := New().SetSize(100, 20)
+	assert.False(t, m.IsSplit(), "too narrow")
+
+	m = m.SetSize(splitWidth, 20)
+	assert.True(t, m.IsSplit())
+}
+
+func TestRenderSplit(t *testing.T) {
+	m := New().SetSize(120, 20).Focus()
+	m.SetContent(goDiff, "main.go")
+	require.False(t, m.IsSplit())
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: 's', Text: "s"})
+	require.True(t, m.IsSplit())
+
+	rows := strings.Split(ansi.Strip(m.View()), "\n")
+	require.GreaterOrEqual(t, len(rows), 13)
+
+	// Headers span both sides
+	assert.Equal(t, "diff --git a/main.go b/main.go", strings.TrimSpace(rows[0]))
+
+	// Unchanged lines show on both sides with their own numbers
+	left, right, ok := strings.Cut(rows[5], "│")
+	require.True(t, ok)
+	assert.Equal(t, "   1  package main", strings.TrimRight(left, " "))
+	assert.Equal(t, "   1  package main", strings.TrimRight(right, " "))
+	assert.Len(t, []rune(left), 59)
+
+	// A removed line faces the added line replacing it
+	left, right, _ = strings.Cut(rows[7], "│")
+	assert.Equal(t, `   3 -func run() { fmt.Println("hi") }`, strings.TrimRight(left, " "))
+	assert.Equal(t, `   3 +func run() { fmt.Println("hello") }`, strings.TrimRight(right, " "))
+
+	// Extra lines on one side face filler
+	left, right, _ = strings.Cut(rows[8], "│")
+	assert.Equal(t, "   4 --- removed, not a header", strings.TrimRight(left, " "))
+	assert.Equal(t, strings.Repeat("╱", 60), right)
+
+	left, right, _ = strings.Cut(rows[11], "│")
+	assert.Equal(t, strings.Repeat("╱", 59), left)
+	assert.Equal(t, "  11 +x := 1", strings.TrimRight(right, " "))
+
+	// Toggling again sticks to unified, even when wide
+	m, _ = m.Update(tea.KeyPressMsg{Code: 's', Text: "s"})
+	m = m.SetSize(splitWidth, 20)
+	assert.False(t, m.IsSplit())
+}
@@ -0,0 +1,182 @@
+package diff
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxWordTokens is how many words a line can have for a word diff; longer
+	// lines only have their common start and end trimmed.
+	maxWordTokens = 400
+
+	// minShared is how much of a pair of lines must be the same for their
+	// changes to be emphasized. Below it they're just different lines.
+	minShared = 0.4
+)
+
+// byteRange is a range [start, end) of bytes in a line.
+type byteRange struct {
+	start, end int
+}
+
+// words splits s into runs of letters, digits and underscores, runs of
+// spaces, and single other characters, returning where each starts. The
+// last entry is len(s).
+func words(s string) []int {
+	var bounds []int
+	kind := -1
+	for i, r := range s {
+		k := runeKind(r)
+		if k != kind || k == 2 {
+			bounds = append(bounds, i)
+		}
+		kind = k
+	}
+	return append(bounds, len(s))
+}
+
+// runeKind sorts runes into words (0), spaces (1) and anything else (2).
+func runeKind(r rune) int {
+	switch {
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 0
+	case unicode.IsSpace(r):
+		return 1
+	}
+	return 2
+}
+
+// wordDiff returns the ranges of old and new that changed, word by word. It
+// returns nil for both when the lines have too little in common for that to
+// help.
+func wordDiff(old, new string) (oldChanged, newChanged []byteRange) {
+	if old == new {
+		return nil, nil
+	}
+
+	// The common start and end never change
+	prefix := 0
+	for prefix < len(old) && prefix < len(new) && old[prefix] == new[prefix] {
+		prefix++
+	}
+	for prefix > 0 && prefix < len(old) && !utf8.RuneStart(old[prefix]) {
+		prefix--
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(new)-prefix &&
+		old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+	for suffix > 0 && !utf8.RuneStart(old[len(old)-suffix]) {
+		suffix--
+	}
+
+	// Widen to whole words, so a changed name is emphasized as a whole
+	prefix = min(wordStart(old, prefix), wordStart(new, prefix))
+	suffix = min(len(old)-wordEnd(old, len(old)-suffix), len(new)-wordEnd(new, len(new)-suffix))
+
+	oldMid, newMid := old[prefix:len(old)-suffix], new[prefix:len(new)-suffix]
+	oldBounds, newBounds := words(oldMid), words(newMid)
+
+	var oldSame, newSame []bool
+	if len(oldBounds) > maxWordTokens || len(newBounds) > maxWordTokens {
+		oldSame = make([]bool, len(oldBounds)-1)
+		newSame = make([]bool, len(newBounds)-1)
+	} else {
+		oldSame, newSame = commonWords(oldMid, oldBounds, newMid, newBounds)
+	}
+
+	shared := prefix + suffix
+	for i, same := range oldSame {
+		if same {
+			shared += oldBounds[i+1] - oldBounds[i]
+		}
+	}
+	if float64(2*shared) < minShared*float64(len(old)+len(new)) {
+		return nil, nil
+	}
+
+	return changedRanges(oldBounds, oldSame, prefix), changedRanges(newBounds, newSame, prefix)
+}
+
+// wordStart moves i back to the start of the word it's in.
+func wordStart(s string, i int) int {
+	for i > 0 && i < len(s) {
+		r, _ := utf8.DecodeRuneInString(s[i:])
+		before, size := utf8.DecodeLastRuneInString(s[:i])
+		if runeKind(r) != 0 || runeKind(before) != 0 {
+			break
+		}
+		i -= size
+	}
+	return i
+}
+
+// wordEnd moves i forward to the end of the word it's in.
+func wordEnd(s string, i int) int {
+	for i > 0 && i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		before, _ := utf8.DecodeLastRuneInString(s[:i])
+		if runeKind(r) != 0 || runeKind(before) != 0 {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// commonWords finds the longest common run of words of a and b, reporting
+// which words of each are in it.
+func commonWords(a string, aBounds []int, b string, bBounds []int) (aSame, bSame []bool) {
+	n, m := len(aBounds)-1, len(bBounds)-1
+	word := func(s string, bounds []int, i int) string { return s[bounds[i]:bounds[i+1]] }
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:], b[j:]
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if word(a, aBounds, i) == word(b, bBounds, j) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	aSame, bSame = make([]bool, n), make([]bool, m)
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case word(a, aBounds, i) == word(b, bBounds, j):
+			aSame[i], bSame[j] = true, true
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return aSame, bSame
+}
+
+// changedRanges merges the words that aren't the same into ranges, shifted
+// by offset.
+func changedRanges(bounds []int, same []bool, offset int) []byteRange {
+	var ranges []byteRange
+	for i, s := range same {
+		if s {
+			continue
+		}
+		start, end := bounds[i]+offset, bounds[i+1]+offset
+		if n := len(ranges); n > 0 && ranges[n-1].end == start {
+			ranges[n-1].end = end
+		} else {
+			ranges = append(ranges, byteRange{start, end})
+		}
+	}
+	return ranges
+}
@@ -0,0 +1,65 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// changedText returns the parts of s in ranges.
+func changedText(s string, ranges []byteRange) []string {
+	var out []string
+	for _, r := range ranges {
+		out = append(out, s[r.start:r.end])
+	}
+	return out
+}
+
+func TestWordDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		wantOld  []string
+		wantNew  []string
+	}{
+		{
+			name:    "renamed word is emphasized whole",
+			old:     "\treturn fooBar(x)",
+			new:     "\treturn fooBaz(x)",
+			wantOld: []string{"fooBar"},
+			wantNew: []string{"fooBaz"},
+		},
+		{
+			name:    "inserted argument",
+			old:     "call(a)",
+			new:     "call(a, b)",
+			wantNew: []string{", b"},
+		},
+		{
+			name:    "changes in two places",
+			old:     `log.Printf("%d items", n)`,
+			new:     `log.Errorf("%d items", count)`,
+			wantOld: []string{"Printf", "n"},
+			wantNew: []string{"Errorf", "count"},
+		},
+		{
+			name: "unrelated lines",
+			old:  "x := compute(a, b, c)",
+			new:  "// TODO: remove this",
+		},
+		{
+			name:    "multi-byte characters",
+			old:     `s := "héllo wörld"`,
+			new:     `s := "héllo world"`,
+			wantOld: []string{"wörld"},
+			wantNew: []string{"world"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldChanged, newChanged := wordDiff(tt.old, tt.new)
+			assert.Equal(t, tt.wantOld, changedText(tt.old, oldChanged))
+			assert.Equal(t, tt.wantNew, changedText(tt.new, newChanged))
+		})
+	}
+}
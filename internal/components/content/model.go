@@ -2,17 +2,26 @@ package content
 
 import (
 	"context"
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/avitaltamir/vibecommander/internal/compare"
 	"github.com/avitaltamir/vibecommander/internal/components"
 	"github.com/avitaltamir/vibecommander/internal/components/content/diff"
+	"github.com/avitaltamir/vibecommander/internal/components/content/player"
+	"github.com/avitaltamir/vibecommander/internal/components/content/review"
 	"github.com/avitaltamir/vibecommander/internal/components/content/viewer"
 	"github.com/avitaltamir/vibecommander/internal/components/terminal"
+	"github.com/avitaltamir/vibecommander/internal/filetype"
 	"github.com/avitaltamir/vibecommander/internal/git"
+	"github.com/avitaltamir/vibecommander/internal/outline"
 	"github.com/avitaltamir/vibecommander/internal/theme"
 )
 
@@ -24,6 +33,8 @@ const (
 	ModeDiff
 	ModeTerminal
 	ModeAI
+	ModeReview
+	ModePlayer
 )
 
 // ContentSource identifies a source of content in the panel.
@@ -33,7 +44,7 @@ type ContentSource int
 
 const (
 	SourceNone ContentSource = iota
-	SourceFile               // File viewer or diff
+	SourceFile               // File viewer, diff, review or replay
 	SourceAI                 // AI terminal
 )
 
@@ -56,6 +67,10 @@ func (m Mode) String() string {
 		return "TERMINAL"
 	case ModeAI:
 		return "AI ASSISTANT"
+	case ModeReview:
+		return "REVIEW"
+	case ModePlayer:
+		return "REPLAY"
 	default:
 		return "UNKNOWN"
 	}
@@ -71,23 +86,46 @@ type (
 	// OpenFileMsg requests opening a file in the viewer.
 	OpenFileMsg struct {
 		Path string
+		Line int // 0-indexed line to scroll to
 	}
 
 	// LaunchAIMsg requests launching the AI assistant.
 	LaunchAIMsg struct {
 		Command string   // e.g., "claude"
 		Args    []string // e.g., []string{}
+		Record  string   // asciicast file to record the session to, if any
 	}
 
 	// FileWithDiffMsg is sent after checking if a file has a diff.
 	FileWithDiffMsg struct {
-		Path    string
-		Diff    string
-		Content string
-		HasDiff bool
-		Err     error
+		Path     string
+		Diff     string
+		Content  string
+		Line     int       // Line requested by OpenFileMsg
+		ModTime  time.Time // Modification time of the file when read
+		Size     int64
+		Large    bool              // Too large to read whole - the viewer streams it
+		Binary   bool              // Binary content - shown as hex, never diffed
+		Kind     string            // Detected file type
+		Encoding filetype.Encoding // How the file stores Content
+		HasDiff  bool
+		Err      error
 	}
 
+	// ThemeChangedMsg is sent when the theme changes, so rendered content
+	// picks up the new colors.
+	ThemeChangedMsg struct{}
+
+	// CompareMsg requests diffing two files or two directories, which
+	// needn't be in git. Skip leaves paths out of a directory comparison.
+	CompareMsg struct {
+		Left, Right string
+		Skip        compare.SkipFunc
+	}
+
+	// OpenReviewMsg requests reviewing every changed file.
+	OpenReviewMsg struct{}
+
 	// SwitchSourceMsg requests switching to a different content source.
 	// Used when clicking on headers in the dual-header display.
 	SwitchSourceMsg struct {
@@ -104,12 +142,17 @@ type Model struct {
 	viewer   viewer.Model
 	terminal terminal.Model
 	diff     diff.Model
+	review   review.Model
+	player   player.Model
 
 	currentPath string
 	aiCommand   string // Stores the AI command name (e.g., "claude", "aider")
 	gitProvider git.Provider
 	theme       *theme.Theme
 
+	// The two sides shown in the diff view when it's a comparison
+	comparing *comparison
+
 	// Track content sources for dual-header display
 	// These allow showing both headers even when only one content is active
 	hasFileContent bool // True if a file has been loaded
@@ -126,6 +169,8 @@ func New() Model {
 		viewer:   viewer.New(),
 		terminal: terminal.New(),
 		diff:     diff.New(),
+		review:   review.New(),
+		player:   player.New(),
 		theme:    theme.DefaultTheme(),
 	}
 }
@@ -133,6 +178,24 @@ func New() Model {
 // SetGitProvider sets the git provider for diff functionality.
 func (m *Model) SetGitProvider(provider git.Provider) {
 	m.gitProvider = provider
+	m.review.SetGitProvider(provider)
+}
+
+// SetWorkDir sets the directory git runs in, which the files under review
+// are relative to.
+func (m *Model) SetWorkDir(dir string) {
+	m.review.SetRoot(dir)
+}
+
+// SetReviewed sets the files marked reviewed, as returned by Reviewed.
+func (m *Model) SetReviewed(reviewed map[string]string) {
+	m.review.SetReviewed(reviewed)
+}
+
+// Reviewed returns the fingerprints of the files marked reviewed, by path
+// relative to the working directory.
+func (m *Model) Reviewed() map[string]string {
+	return m.review.Reviewed()
 }
 
 // ensureActiveComponentSized ensures the currently active component has correct dimensions
@@ -145,6 +208,10 @@ func (m *Model) ensureActiveComponentSized() {
 		m.viewer = m.viewer.SetSize(m.lastWidth, m.lastContentHeight)
 	case ModeDiff:
 		m.diff = m.diff.SetSize(m.lastWidth, m.lastContentHeight)
+	case ModeReview:
+		m.review = m.review.SetSize(m.lastWidth, m.lastContentHeight)
+	case ModePlayer:
+		m.player = m.player.SetSize(m.lastWidth, m.lastContentHeight)
 	case ModeTerminal, ModeAI:
 		m.terminal = m.terminal.SetSize(m.lastWidth, m.lastContentHeight)
 	}
@@ -194,18 +261,23 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		return m, tea.Batch(cmds...)
 
 	case OpenFileMsg:
+		if filepath.Ext(msg.Path) == ".cast" {
+			cmd := m.openRecording(msg.Path)
+			return m, cmd
+		}
 		m.currentPath = msg.Path
+		m.comparing = nil
 		m.hasFileContent = true
 		// Check if file has git changes - if so, show diff
 		if m.gitProvider != nil {
-			return m, m.loadFileWithDiffCheck(msg.Path)
+			return m, m.loadFileWithDiffCheck(msg.Path, msg.Line)
 		}
 		if m.mode != ModeViewer {
 			m.lastMode = m.mode
 			m.mode = ModeViewer
 			m.ensureActiveComponentSized()
 		}
-		return m, viewer.LoadFile(msg.Path)
+		return m, viewer.LoadFileAt(msg.Path, msg.Line)
 
 	case LaunchAIMsg:
 		if m.mode != ModeAI {
@@ -224,19 +296,107 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		// Start the AI command
 		var cmd tea.Cmd
 		m.terminal, cmd = m.terminal.Update(terminal.StartMsg{
-			Cmd:  msg.Command,
-			Args: msg.Args,
+			Cmd:    msg.Command,
+			Args:   msg.Args,
+			Record: msg.Record,
 		})
 		cmds = append(cmds, cmd)
 		return m, tea.Batch(cmds...)
 
-	case viewer.FileLoadedMsg:
+	case CompareMsg:
+		c := comparison{left: msg.Left, right: msg.Right, skip: msg.Skip}
+		m.comparing = &c
+		m.currentPath = msg.Right
+		m.hasFileContent = true
+		if m.mode != ModeDiff {
+			m.lastMode = m.mode
+			m.mode = ModeDiff
+			m.ensureActiveComponentSized()
+		}
+		m.diff.SetRoot(c.dir())
+		return m, loadComparison(c, m.diff.Options())
+
+	case OpenReviewMsg:
+		m.hasFileContent = true
+		if m.mode != ModeReview {
+			if m.Focused() {
+				m = m.Blur()
+				m.lastMode, m.mode = m.mode, ModeReview
+				m, _ = m.Focus()
+			} else {
+				m.lastMode, m.mode = m.mode, ModeReview
+			}
+			m.ensureActiveComponentSized()
+		}
+		return m, m.review.Load()
+
+	case diff.OptionsChangedMsg:
+		// The review loads its own diff; otherwise it's the file's
+		if m.mode == ModeReview {
+			var cmd tea.Cmd
+			m.review, cmd = m.review.Update(msg)
+			return m, cmd
+		}
+		if m.comparing != nil {
+			return m, loadComparison(*m.comparing, msg.Options)
+		}
+		if m.gitProvider == nil || m.currentPath == "" {
+			return m, nil
+		}
+		return m, m.loadDiff(m.currentPath, msg.Options)
+
+	case review.LoadedMsg, review.StagedMsg:
+		var cmd tea.Cmd
+		m.review, cmd = m.review.Update(msg)
+		return m, cmd
+
+	case ThemeChangedMsg:
+		m.viewer.Refresh()
+		m.diff.Refresh()
+		m.review.Refresh()
+		return m, nil
+
+	case viewer.FileLoadedMsg, viewer.FileSavedMsg, viewer.IndexMsg, viewer.StreamSearchMsg, viewer.HexSearchMsg:
 		// Route to viewer
 		var cmd tea.Cmd
 		m.viewer, cmd = m.viewer.Update(msg)
 		cmds = append(cmds, cmd)
 		return m, tea.Batch(cmds...)
 
+	case tea.KeyPressMsg:
+		// 'e' in the diff view edits the file itself, Enter views it, both at
+		// the line the diff is on
+		if m.mode == ModeDiff && m.Focused() && m.currentPath != "" && !m.diff.Prompting() && (msg.String() == "e" || msg.String() == "enter") {
+			path, line := m.diff.Location()
+			if isDir(path) {
+				return m, nil // A line of a directory comparison's listing
+			}
+			m.comparing = nil
+			m.lastMode = m.mode
+			m.mode = ModeViewer
+			m.ensureActiveComponentSized()
+			m.diff = m.diff.Blur()
+			m.viewer = m.viewer.Focus()
+			if msg.String() == "enter" {
+				return m, viewer.LoadFileAt(path, line)
+			}
+			return m, viewer.EditFileAt(path, line)
+		}
+		// So do they in the review, for the file in view
+		if m.mode == ModeReview && m.Focused() && m.review.HasContent() && !m.review.Prompting() && (msg.String() == "e" || msg.String() == "enter") {
+			path, line := m.review.Location()
+			m.currentPath = path
+			m.lastMode = m.mode
+			m.mode = ModeViewer
+			m.ensureActiveComponentSized()
+			m.review = m.review.Blur()
+			m.viewer = m.viewer.Focus()
+			if msg.String() == "enter" {
+				return m, viewer.LoadFileAt(path, line)
+			}
+			return m, viewer.EditFileAt(path, line)
+		}
+
 	case diff.DiffLoadedMsg:
 		// Route to diff viewer
 		var cmd tea.Cmd
@@ -266,6 +426,8 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				m.mode = ModeDiff
 				m.ensureActiveComponentSized()
 			}
+			m.comparing = nil
+			m.diff.SetRoot("")
 			m.diff.SetContent(msg.Diff, msg.Path)
 			return m, nil
 		}
@@ -277,11 +439,24 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		}
 		var cmd tea.Cmd
 		m.viewer, cmd = m.viewer.Update(viewer.FileLoadedMsg{
-			Path:    msg.Path,
-			Content: msg.Content,
+			Path:     msg.Path,
+			Content:  msg.Content,
+			Line:     msg.Line,
+			ModTime:  msg.ModTime,
+			Size:     msg.Size,
+			Large:    msg.Large,
+			Binary:   msg.Binary,
+			Kind:     msg.Kind,
+			Encoding: msg.Encoding,
 		})
 		return m, cmd
 
+	case player.LoadedMsg, player.TickMsg:
+		// Playback goes on while something else is shown
+		var cmd tea.Cmd
+		m.player, cmd = m.player.Update(msg)
+		return m, cmd
+
 	case terminal.OutputMsg, terminal.ExitMsg:
 		// Route to terminal and continue reading
 		var cmd tea.Cmd
@@ -310,6 +485,10 @@ func (m Model) routeMessage(msg tea.Msg) (Model, tea.Cmd) {
 		m.viewer, cmd = m.viewer.Update(msg)
 	case ModeDiff:
 		m.diff, cmd = m.diff.Update(msg)
+	case ModeReview:
+		m.review, cmd = m.review.Update(msg)
+	case ModePlayer:
+		m.player, cmd = m.player.Update(msg)
 	case ModeTerminal, ModeAI:
 		m.terminal, cmd = m.terminal.Update(msg)
 	}
@@ -327,11 +506,16 @@ func (m Model) View() string {
 	// Render the title - show filename in viewer/diff mode if file is loaded
 	titleText := m.mode.String()
 	if (m.mode == ModeViewer || m.mode == ModeDiff) && m.currentPath != "" {
-		prefix := ""
+		titleText = filepath.Base(m.currentPath)
 		if m.mode == ModeDiff {
-			prefix = "DIFF: "
+			titleText = "DIFF: " + m.diffTitle()
 		}
-		titleText = prefix + filepath.Base(m.currentPath)
+	}
+	if m.mode == ModeReview {
+		titleText = "REVIEW: " + m.review.Title()
+	}
+	if m.mode == ModePlayer {
+		titleText = "REPLAY: " + m.player.Title()
 	}
 	title := theme.RenderTitle(titleText, m.Focused())
 
@@ -344,6 +528,10 @@ func (m Model) View() string {
 		content = m.viewer.View()
 	case ModeDiff:
 		content = m.diff.View()
+	case ModeReview:
+		content = m.review.View()
+	case ModePlayer:
+		content = m.player.View()
 	case ModeTerminal, ModeAI:
 		content = m.terminal.View()
 	}
@@ -385,6 +573,44 @@ func (m Model) CurrentPath() string {
 	return m.currentPath
 }
 
+// CurrentLine returns the first visible line (0-indexed) of the viewer, or 0
+// when another view is active.
+func (m *Model) CurrentLine() int {
+	if m.mode == ModeViewer {
+		return m.viewer.TopLine()
+	}
+	return 0
+}
+
+// CursorPosition returns the viewer's line and column (0-indexed) of
+// interest, see viewer.Model.CursorPosition. In the diff view it is the line
+// of the file's new version the diff is on, and 0, 0 in other views.
+func (m *Model) CursorPosition() (line, col int) {
+	switch m.mode {
+	case ModeViewer:
+		return m.viewer.CursorPosition()
+	case ModeDiff:
+		if path, line := m.diff.Location(); path == m.currentPath {
+			return line, 0
+		}
+	}
+	return 0, 0
+}
+
+// Outline returns the symbols of the file in the viewer, or nil when another
+// view is active.
+func (m *Model) Outline() []outline.Symbol {
+	if m.mode == ModeViewer {
+		return m.viewer.Outline()
+	}
+	return nil
+}
+
+// GotoLine scrolls the viewer to line (0-indexed) without reloading the file.
+func (m *Model) GotoLine(line int) {
+	m.viewer.GotoLine(line)
+}
+
 // Focus gives focus to this component.
 func (m Model) Focus() (Model, tea.Cmd) {
 	m.Base.Focus()
@@ -396,6 +622,10 @@ func (m Model) Focus() (Model, tea.Cmd) {
 		m.viewer = m.viewer.Focus()
 	case ModeDiff:
 		m.diff = m.diff.Focus()
+	case ModeReview:
+		m.review = m.review.Focus()
+	case ModePlayer:
+		m.player = m.player.Focus()
 	case ModeTerminal, ModeAI:
 		m.terminal, cmd = m.terminal.Focus()
 	}
@@ -413,6 +643,10 @@ func (m Model) Blur() Model {
 		m.viewer = m.viewer.Blur()
 	case ModeDiff:
 		m.diff = m.diff.Blur()
+	case ModeReview:
+		m.review = m.review.Blur()
+	case ModePlayer:
+		m.player = m.player.Blur()
 	case ModeTerminal, ModeAI:
 		m.terminal = m.terminal.Blur()
 	}
@@ -436,12 +670,18 @@ func (m Model) SetSize(width, height int) Model {
 		m.viewer = m.viewer.SetSize(width, contentHeight)
 		m.terminal = m.terminal.SetSize(width, contentHeight)
 		m.diff = m.diff.SetSize(width, contentHeight)
+		m.review = m.review.SetSize(width, contentHeight)
+		m.player = m.player.SetSize(width, contentHeight)
 	} else {
 		switch m.mode {
 		case ModeViewer:
 			m.viewer = m.viewer.SetSize(width, contentHeight)
 		case ModeDiff:
 			m.diff = m.diff.SetSize(width, contentHeight)
+		case ModeReview:
+			m.review = m.review.SetSize(width, contentHeight)
+		case ModePlayer:
+			m.player = m.player.SetSize(width, contentHeight)
 		case ModeTerminal, ModeAI:
 			m.terminal = m.terminal.SetSize(width, contentHeight)
 		}
@@ -461,49 +701,117 @@ func (m Model) ScrollPercent() float64 {
 		return m.viewer.ScrollPercent()
 	case ModeDiff:
 		return m.diff.ScrollPercent()
+	case ModeReview:
+		return m.review.ScrollPercent()
+	case ModePlayer:
+		return m.player.ScrollPercent()
 	default:
 		return 0
 	}
 }
 
 // loadFileWithDiffCheck loads a file and checks if it has git changes.
-func (m Model) loadFileWithDiffCheck(path string) tea.Cmd {
+func (m Model) loadFileWithDiffCheck(path string, line int) tea.Cmd {
 	return func() tea.Msg {
-		// Read the file content
-		fileContent, err := readFile(path)
+		// Stat first: a write racing the read then shows up as a conflict on save
+		info, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) && m.gitProvider != nil {
+			// Deleted: show what it was, all removed
+			if diffContent, diffErr := m.gitProvider.GetDiff(context.Background(), path, m.diff.Options()); diffErr == nil && diffContent != "" {
+				return FileWithDiffMsg{Path: path, Diff: diffContent, Line: line, HasDiff: true}
+			}
+		}
 		if err != nil {
 			return FileWithDiffMsg{Path: path, Err: err}
 		}
+		modTime := info.ModTime()
+		kind, class, err := viewer.Classify(path, info)
+		switch {
+		case err != nil:
+			return FileWithDiffMsg{Path: path, Err: err}
+		case class == viewer.ClassBinary:
+			// Kept out of the diff view and the highlighter
+			return FileWithDiffMsg{Path: path, Line: line, ModTime: modTime, Size: info.Size(), Binary: true, Kind: kind.Kind}
+		}
 
-		// Check for git diff
-		if m.gitProvider != nil {
-			diffContent, err := m.gitProvider.GetDiff(context.Background(), path)
+		// Check for git diff, large files included (git diffs UTF-16 as binary)
+		if m.gitProvider != nil && kind.Encoding == filetype.UTF8 {
+			diffContent, err := m.gitProvider.GetDiff(context.Background(), path, m.diff.Options())
 			if err == nil && diffContent != "" {
 				return FileWithDiffMsg{
 					Path:    path,
 					Diff:    diffContent,
-					Content: fileContent,
+					Line:    line,
+					ModTime: modTime,
 					HasDiff: true,
 				}
 			}
 		}
 
+		if class == viewer.ClassLarge {
+			// Unchanged - the viewer streams it rather than read it whole
+			return FileWithDiffMsg{Path: path, Line: line, ModTime: modTime, Size: info.Size(), Large: true}
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return FileWithDiffMsg{Path: path, Err: err}
+		}
+		fileContent := kind.Encoding.Decode(content)
+
 		// No diff - return content for normal viewing
 		return FileWithDiffMsg{
-			Path:    path,
-			Content: fileContent,
-			HasDiff: false,
+			Path:     path,
+			Content:  fileContent,
+			Line:     line,
+			ModTime:  modTime,
+			Size:     info.Size(),
+			Encoding: kind.Encoding,
+			HasDiff:  false,
 		}
 	}
 }
 
-// readFile reads a file and returns its content.
-func readFile(path string) (string, error) {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return "", err
+// loadDiff loads the diff of a file made with opts.
+func (m *Model) loadDiff(path string, opts git.DiffOptions) tea.Cmd {
+	provider := m.gitProvider
+	return func() tea.Msg {
+		d, err := provider.GetDiff(context.Background(), path, opts)
+		return diff.DiffLoadedMsg{Path: path, Diff: d, Err: err}
+	}
+}
+
+// openRecording replays an asciicast recording in the player.
+func (m *Model) openRecording(path string) tea.Cmd {
+	m.currentPath = path
+	m.comparing = nil
+	m.hasFileContent = true
+	if m.mode != ModePlayer {
+		if m.Focused() {
+			*m = m.Blur()
+			m.lastMode, m.mode = m.mode, ModePlayer
+			*m, _ = m.Focus()
+		} else {
+			m.lastMode, m.mode = m.mode, ModePlayer
+		}
+		m.ensureActiveComponentSized()
 	}
-	return string(content), nil
+	return m.player.Open(path)
+}
+
+// Recording returns the file the AI session is being recorded to, or "".
+func (m *Model) Recording() string {
+	return m.terminal.Recording()
+}
+
+// StopRecording ends the recording of the AI session, if there is one.
+func (m *Model) StopRecording() {
+	m.terminal.StopRecording()
+}
+
+// StopTerminal stops the process in the terminal, ending its recording.
+func (m *Model) StopTerminal() {
+	m.terminal.Stop()
 }
 
 // IsTerminalRunning returns true if the terminal is running a process.
@@ -528,6 +836,10 @@ func (m Model) ContentView() string {
 		return m.viewer.View()
 	case ModeDiff:
 		return m.diff.View()
+	case ModeReview:
+		return m.review.View()
+	case ModePlayer:
+		return m.player.View()
 	case ModeTerminal, ModeAI:
 		return m.terminal.View()
 	default:
@@ -535,6 +847,63 @@ func (m Model) ContentView() string {
 	}
 }
 
+// IsEditing reports whether the viewer is shown in edit mode.
+func (m *Model) IsEditing() bool {
+	return m.mode == ModeViewer && m.viewer.IsEditing()
+}
+
+// IsModified reports whether the viewer holds unsaved edits, even while
+// another view is shown.
+func (m *Model) IsModified() bool {
+	return m.viewer.Modified()
+}
+
+// fileTitle returns the file name with a marker for unsaved edits.
+func (m *Model) fileTitle() string {
+	title := filepath.Base(m.currentPath)
+	if m.viewer.Modified() {
+		title += " [+]"
+	}
+	if col := m.viewer.ColumnOffset(); col > 0 {
+		title += " · col " + strconv.Itoa(col+1) + "→"
+	}
+	return title
+}
+
+// diffTitle returns the file name for the title in diff mode, with how the
+// diff is laid out and which hunk is in view.
+func (m *Model) diffTitle() string {
+	title := filepath.Base(m.currentPath)
+	if m.comparing != nil {
+		title = m.comparing.title()
+	}
+	if m.diff.IsSplit() {
+		title += " · split"
+	}
+	if opts := m.diff.Options().String(); opts != "" {
+		title += " · " + opts
+	}
+	if current, total := m.diff.HunkPosition(); total > 1 {
+		if current > 0 {
+			title += " · hunk " + strconv.Itoa(current) + "/" + strconv.Itoa(total)
+		} else {
+			title += " · " + strconv.Itoa(total) + " hunks"
+		}
+	}
+	return title
+}
+
+// CanPreview reports whether the viewer shows a file with a rendered view
+// (Markdown, JSON, YAML, CSV or TSV), which can be toggled with the source.
+func (m *Model) CanPreview() bool {
+	return m.mode == ModeViewer && m.viewer.CanPreview()
+}
+
+// IsPreview reports whether the viewer shows a rendered view.
+func (m *Model) IsPreview() bool {
+	return m.mode == ModeViewer && m.viewer.IsPreview()
+}
+
 // HasActiveSearch returns whether the viewer has an active search.
 func (m Model) HasActiveSearch() bool {
 	if m.mode == ModeViewer {
@@ -548,18 +917,24 @@ func (m Model) TitleInfo() (title string, scrollPercent float64) {
 	switch m.mode {
 	case ModeViewer:
 		if m.currentPath != "" {
-			title = filepath.Base(m.currentPath)
+			title = m.fileTitle()
 		} else {
 			title = "VIEWER"
 		}
 		scrollPercent = m.viewer.ScrollPercent()
 	case ModeDiff:
 		if m.currentPath != "" {
-			title = filepath.Base(m.currentPath)
+			title = m.diffTitle()
 		} else {
 			title = "DIFF"
 		}
 		scrollPercent = m.diff.ScrollPercent()
+	case ModeReview:
+		title = "REVIEW: " + m.review.Title()
+		scrollPercent = m.review.ScrollPercent()
+	case ModePlayer:
+		title = "REPLAY: " + m.player.Title()
+		scrollPercent = m.player.ScrollPercent()
 	case ModeAI:
 		title = m.AICommandName()
 		scrollPercent = -1 // Don't show scroll for terminal
@@ -583,10 +958,14 @@ func (m Model) SourcesInfo() []SourceInfo {
 	if m.hasFileContent {
 		fileInfo := SourceInfo{
 			Source:   SourceFile,
-			IsActive: m.mode == ModeViewer || m.mode == ModeDiff,
+			IsActive: m.mode == ModeViewer || m.mode == ModeDiff || m.mode == ModeReview || m.mode == ModePlayer,
 		}
-		if m.currentPath != "" {
-			fileInfo.Title = filepath.Base(m.currentPath)
+		if m.mode == ModeReview {
+			fileInfo.Title = "REVIEW: " + m.review.Title()
+		} else if m.mode == ModePlayer {
+			fileInfo.Title = "REPLAY: " + m.player.Title()
+		} else if m.currentPath != "" {
+			fileInfo.Title = m.fileTitle()
 		} else {
 			fileInfo.Title = "VIEWER"
 		}
@@ -594,6 +973,10 @@ func (m Model) SourcesInfo() []SourceInfo {
 			fileInfo.ScrollPercent = m.viewer.ScrollPercent()
 		} else if m.mode == ModeDiff {
 			fileInfo.ScrollPercent = m.diff.ScrollPercent()
+		} else if m.mode == ModeReview {
+			fileInfo.ScrollPercent = m.review.ScrollPercent()
+		} else if m.mode == ModePlayer {
+			fileInfo.ScrollPercent = m.player.ScrollPercent()
 		} else {
 			fileInfo.ScrollPercent = -1
 		}
@@ -618,7 +1001,7 @@ func (m Model) SourcesInfo() []SourceInfo {
 // ActiveSource returns the currently active content source.
 func (m Model) ActiveSource() ContentSource {
 	switch m.mode {
-	case ModeViewer, ModeDiff:
+	case ModeViewer, ModeDiff, ModeReview, ModePlayer:
 		return SourceFile
 	case ModeAI, ModeTerminal:
 		return SourceAI
@@ -1,11 +1,18 @@
 package content
 
 import (
+	"bytes"
+	"context"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	tea "charm.land/bubbletea/v2"
 	"github.com/avitaltamir/vibecommander/internal/components/content/viewer"
+	"github.com/avitaltamir/vibecommander/internal/git"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -375,3 +382,249 @@ func TestActiveSource(t *testing.T) {
 		assert.Equal(t, SourceAI, m.ActiveSource())
 	})
 }
+
+// diffProvider is a git provider that reports a diff for every file.
+type diffProvider struct {
+	git.Provider
+}
+
+func (diffProvider) GetDiff(context.Context, string, git.DiffOptions) (string, error) {
+	return "diff --git a/f b/f\n@@ -1 +1 @@\n-a\n+b\n", nil
+}
+
+func (p diffProvider) GetChanges(ctx context.Context, _ git.ChangeScope, _ string, opts git.DiffOptions) (string, error) {
+	return p.GetDiff(ctx, "", opts)
+}
+
+func TestBinaryFilesSkipDiff(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "image.png")
+	require.NoError(t, os.WriteFile(bin, []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), 0644))
+	text := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(text, []byte("package main\n"), 0644))
+
+	m := New().SetSize(80, 24)
+	m.SetGitProvider(diffProvider{})
+
+	msg := m.loadFileWithDiffCheck(bin, 0)().(FileWithDiffMsg)
+	assert.True(t, msg.Binary)
+	assert.False(t, msg.HasDiff)
+	assert.Equal(t, "PNG image", msg.Kind)
+	assert.Empty(t, msg.Content)
+
+	m, _ = m.Update(msg)
+	assert.Equal(t, ModeViewer, m.Mode())
+	assert.Contains(t, m.View(), "PNG image")
+
+	msg = m.loadFileWithDiffCheck(text, 0)().(FileWithDiffMsg)
+	assert.False(t, msg.Binary)
+	assert.True(t, msg.HasDiff)
+}
+
+func TestLargeFilesDiff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "package-lock.json")
+	require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte("{}\n"), viewer.LargeFileSize), 0644))
+
+	// Changed: diffed like any other file
+	m := New().SetSize(80, 24)
+	m.SetGitProvider(diffProvider{})
+	msg := m.loadFileWithDiffCheck(path, 0)().(FileWithDiffMsg)
+	assert.True(t, msg.HasDiff)
+	assert.False(t, msg.Large)
+
+	// Unchanged: streamed, not read
+	m = New().SetSize(80, 24)
+	msg = m.loadFileWithDiffCheck(path, 0)().(FileWithDiffMsg)
+	assert.False(t, msg.HasDiff)
+	assert.True(t, msg.Large)
+	assert.Empty(t, msg.Content)
+}
+
+func TestTitleShowsColumn(t *testing.T) {
+	m := New().SetSize(40, 10)
+	m, _ = m.Update(OpenFileMsg{Path: "/src/wide.txt"})
+	m, _ = m.Update(viewer.FileLoadedMsg{Path: "/src/wide.txt", Content: strings.Repeat("x", 200)})
+	m, _ = m.Focus()
+
+	title, _ := m.TitleInfo()
+	assert.Equal(t, "wide.txt", title)
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyRight})
+	title, _ = m.TitleInfo()
+	assert.Equal(t, "wide.txt · col 9→", title)
+}
+
+func TestTitleShowsSplitDiff(t *testing.T) {
+	m := New().SetSize(100, 20)
+	m, _ = m.Update(OpenFileMsg{Path: "/src/main.go"})
+	m, _ = m.Update(FileWithDiffMsg{Path: "/src/main.go", Diff: "@@ -1 +1 @@\n-a\n+b", HasDiff: true})
+	m, _ = m.Focus()
+
+	title, _ := m.TitleInfo()
+	assert.Equal(t, "main.go", title)
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: 's', Text: "s"})
+	title, _ = m.TitleInfo()
+	assert.Equal(t, "main.go · split", title)
+}
+
+func TestDiffHunksAndOpenAtLine(t *testing.T) {
+	d := "@@ -1,2 +1,2 @@\n a\n-b\n+B\n@@ -10 +10,2 @@\n c\n+d"
+	m := New().SetSize(100, 20)
+	m, _ = m.Update(OpenFileMsg{Path: "/src/main.go"})
+	m, _ = m.Update(FileWithDiffMsg{Path: "/src/main.go", Diff: d, HasDiff: true})
+	m, _ = m.Focus()
+
+	title, _ := m.TitleInfo()
+	assert.Equal(t, "main.go · hunk 1/2", title)
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'n', Text: "n"})
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'n', Text: "n"})
+	title, _ = m.TitleInfo()
+	assert.Equal(t, "main.go · hunk 2/2", title)
+
+	m, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	assert.Equal(t, ModeViewer, m.Mode())
+	assert.NotNil(t, cmd)
+}
+
+func TestReviewMode(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "f"), []byte("b\n"), 0644))
+
+	m := New().SetSize(100, 24)
+	m.SetGitProvider(diffProvider{})
+	m.SetWorkDir(dir)
+	m, _ = m.Focus()
+
+	m, cmd := m.Update(OpenReviewMsg{})
+	require.NotNil(t, cmd)
+	assert.Equal(t, ModeReview, m.Mode())
+	m, _ = m.Update(cmd())
+
+	title, _ := m.TitleInfo()
+	assert.Equal(t, "REVIEW: all changes · 0/1 reviewed", title)
+	assert.Equal(t, SourceFile, m.ActiveSource())
+
+	// Enter opens the file under review in the viewer
+	m, cmd = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	assert.Equal(t, ModeViewer, m.Mode())
+	assert.Equal(t, filepath.Join(dir, "f"), m.CurrentPath())
+}
+
+func TestDeletedFileShowsDiff(t *testing.T) {
+	gone := filepath.Join(t.TempDir(), "gone.go")
+
+	m := New().SetSize(80, 24)
+	m.SetGitProvider(diffProvider{})
+	msg := m.loadFileWithDiffCheck(gone, 0)().(FileWithDiffMsg)
+	require.NoError(t, msg.Err)
+	assert.True(t, msg.HasDiff)
+
+	m, _ = m.Update(msg)
+	assert.Equal(t, ModeDiff, m.Mode())
+
+	// Without git it's still an error
+	m = New().SetSize(80, 24)
+	msg = m.loadFileWithDiffCheck(gone, 0)().(FileWithDiffMsg)
+	assert.Error(t, msg.Err)
+}
+
+func TestDiffOptionsReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "f")
+	require.NoError(t, os.WriteFile(path, []byte("b\n"), 0644))
+
+	m := New().SetSize(100, 24)
+	m.SetGitProvider(diffProvider{})
+	m, cmd := m.Update(OpenFileMsg{Path: path})
+	m, _ = m.Update(cmd())
+	require.Equal(t, ModeDiff, m.Mode())
+	m, _ = m.Focus()
+
+	m, cmd = m.Update(tea.KeyPressMsg{Code: 'w', Text: "w"})
+	require.NotNil(t, cmd)
+	m, cmd = m.Update(cmd())
+	require.NotNil(t, cmd, "the diff is loaded again")
+	m, _ = m.Update(cmd())
+
+	title, _ := m.TitleInfo()
+	assert.Equal(t, "f · no whitespace", title)
+}
+
+func TestCompareFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	left := filepath.Join(dir, "foo.go")
+	right := filepath.Join(dir, "foo_new.go")
+	require.NoError(t, os.WriteFile(left, []byte("package foo\n"), 0644))
+	require.NoError(t, os.WriteFile(right, []byte("package bar\n"), 0644))
+
+	m := New().SetSize(100, 24)
+	m, cmd := m.Update(CompareMsg{Left: left, Right: right})
+	require.NotNil(t, cmd)
+	m, _ = m.Update(cmd())
+	assert.Equal(t, ModeDiff, m.Mode())
+
+	title, _ := m.TitleInfo()
+	assert.Equal(t, "foo.go ↔ foo_new.go", title)
+	assert.Contains(t, ansi.Strip(m.View()), "+package bar")
+}
+
+func TestCompareDirs(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	left := filepath.Join(dir, "old", "app")
+	right := filepath.Join(dir, "new", "app")
+	require.NoError(t, os.MkdirAll(left, 0755))
+	require.NoError(t, os.MkdirAll(right, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(left, "same.txt"), []byte("x\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(right, "same.txt"), []byte("x\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(left, "main.go"), []byte("a\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(right, "main.go"), []byte("b\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(left, "gone.txt"), []byte("g\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(right, "added.txt"), []byte("n\n"), 0644))
+
+	c := comparison{left: left, right: right}
+	assert.Equal(t, "old/app ↔ new/app", c.title())
+	assert.Equal(t, dir, c.dir())
+
+	text, err := c.diff(context.Background(), git.DiffOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, text, "1 differ, 1 only left, 1 only right")
+	assert.Contains(t, text, "main.go")
+	assert.Contains(t, text, "gone.txt")
+	assert.Contains(t, text, "added.txt")
+	assert.NotContains(t, text, "same.txt")
+	assert.Contains(t, text, "+b")
+}
+
+func TestOpenRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.cast")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 2, "width": 20, "height": 3, "title": "claude"}
+[0.5, "o", "hello"]
+`), 0644))
+
+	m := New().SetSize(80, 24)
+	m.SetGitProvider(diffProvider{})
+	m, _ = m.Focus()
+	m, cmd := m.Update(OpenFileMsg{Path: path})
+	require.NotNil(t, cmd)
+	assert.Equal(t, ModePlayer, m.Mode())
+	assert.Equal(t, ModePlayer.String(), "REPLAY")
+
+	m, cmd = m.Update(cmd())
+	assert.NotNil(t, cmd, "starts playing")
+	title, _ := m.TitleInfo()
+	assert.Equal(t, "REPLAY: run.cast · claude", title)
+	assert.Equal(t, SourceFile, m.ActiveSource())
+
+	// Seeking to the end shows what was recorded
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnd})
+	assert.Contains(t, ansi.Strip(m.View()), "hello")
+}
@@ -0,0 +1,357 @@
+// Package player replays asciicast recordings of terminal sessions in the
+// content pane, with play/pause, speed control and seeking.
+package player
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/avitaltamir/vibecommander/internal/asciicast"
+	"github.com/avitaltamir/vibecommander/internal/components"
+	"github.com/avitaltamir/vibecommander/internal/theme"
+	"github.com/avitaltamir/vibecommander/internal/vt"
+)
+
+const (
+	// frameInterval is how often the screen is updated while playing.
+	frameInterval = 40 * time.Millisecond
+
+	// seekStep and longSeekStep are how far ←/→ and ↑/↓ move, in seconds.
+	seekStep     = 5
+	longSeekStep = 30
+)
+
+// speeds are the playback speeds + and - step through.
+var speeds = []float64{0.25, 0.5, 1, 2, 4, 8, 16}
+
+// normalSpeed is the index of 1× in speeds.
+const normalSpeed = 2
+
+// Messages
+type (
+	// LoadedMsg is sent when a recording has been read.
+	LoadedMsg struct {
+		Path      string
+		Recording asciicast.Recording
+		Err       error
+	}
+
+	// TickMsg advances playback; ticks from a loop since stopped are ignored.
+	TickMsg struct {
+		seq int
+	}
+)
+
+// Model is the player for one recording.
+type Model struct {
+	components.Base
+
+	path   string
+	rec    asciicast.Recording
+	err    error
+	loaded bool
+
+	vt      *vt.Terminal // The screen as of pos
+	next    int          // Next event to play
+	pos     float64      // Playback position, in seconds
+	playing bool
+	speed   int       // Index into speeds
+	seq     int       // Current tick loop
+	last    time.Time // When pos last advanced
+}
+
+// New creates a player with nothing loaded.
+func New() Model {
+	return Model{speed: normalSpeed}
+}
+
+// Open loads the recording at path, which starts playing once read.
+func (m *Model) Open(path string) tea.Cmd {
+	m.path = path
+	m.rec = asciicast.Recording{}
+	m.err = nil
+	m.loaded = false
+	m.playing = false
+	m.seq++
+	return func() tea.Msg {
+		rec, err := asciicast.Load(path)
+		return LoadedMsg{Path: path, Recording: rec, Err: err}
+	}
+}
+
+// Path returns the file of the recording.
+func (m Model) Path() string {
+	return m.path
+}
+
+// Title returns the name of the recording and of the command recorded.
+func (m Model) Title() string {
+	title := filepath.Base(m.path)
+	if m.rec.Header.Title != "" {
+		title += " · " + m.rec.Header.Title
+	}
+	return title
+}
+
+// Playing reports whether the recording is playing.
+func (m Model) Playing() bool {
+	return m.playing
+}
+
+// Position returns the playback position in seconds.
+func (m Model) Position() float64 {
+	return m.pos
+}
+
+// Duration returns the length of the recording in seconds.
+func (m Model) Duration() float64 {
+	return m.rec.Duration()
+}
+
+// Speed returns how many times faster than recorded it plays.
+func (m Model) Speed() float64 {
+	return speeds[m.speed]
+}
+
+// ScrollPercent returns how far into the recording playback is.
+func (m Model) ScrollPercent() float64 {
+	if d := m.Duration(); d > 0 {
+		return m.pos / d * 100
+	}
+	return 0
+}
+
+// Init initializes the player.
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages.
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case LoadedMsg:
+		if msg.Path != m.path {
+			return m, nil // Another recording was opened since
+		}
+		m.loaded = true
+		m.err = msg.Err
+		m.rec = msg.Recording
+		m.rewind()
+		if m.err != nil {
+			return m, nil
+		}
+		return m, m.play()
+
+	case TickMsg:
+		if msg.seq != m.seq || !m.playing {
+			return m, nil
+		}
+		now := time.Now()
+		m.advance(m.pos + now.Sub(m.last).Seconds()*speeds[m.speed])
+		m.last = now
+		if m.pos >= m.Duration() {
+			m.playing = false
+			return m, nil
+		}
+		return m, m.tick()
+
+	case tea.KeyPressMsg:
+		if !m.Focused() || !m.loaded || m.err != nil {
+			return m, nil
+		}
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+// handleKey handles the playback keys: Space plays and pauses, ←/→ and ↑/↓
+// seek, Home/End and 0-9 jump, and + and - change the speed.
+func (m Model) handleKey(msg tea.KeyPressMsg) (Model, tea.Cmd) {
+	switch s := msg.String(); s {
+	case "space", " ":
+		if m.playing {
+			m.pause()
+			return m, nil
+		}
+		return m, m.play()
+	case "left", "h":
+		m.seek(m.pos - seekStep)
+	case "right", "l":
+		m.seek(m.pos + seekStep)
+	case "down", "j":
+		m.seek(m.pos - longSeekStep)
+	case "up", "k":
+		m.seek(m.pos + longSeekStep)
+	case "home", "g":
+		m.seek(0)
+	case "end", "G":
+		m.seek(m.Duration())
+	case "+", "=":
+		m.speed = min(m.speed+1, len(speeds)-1)
+	case "-":
+		m.speed = max(m.speed-1, 0)
+	default:
+		if len(s) == 1 && s[0] >= '0' && s[0] <= '9' {
+			m.seek(m.Duration() * float64(s[0]-'0') / 10)
+		}
+	}
+	return m, nil
+}
+
+// play starts playing, from the start when at the end.
+func (m *Model) play() tea.Cmd {
+	if m.pos >= m.Duration() {
+		m.seek(0)
+	}
+	m.playing = true
+	m.seq++
+	m.last = time.Now()
+	return m.tick()
+}
+
+// pause stops playing where it is.
+func (m *Model) pause() {
+	m.playing = false
+	m.seq++
+}
+
+// tick schedules the next frame.
+func (m Model) tick() tea.Cmd {
+	seq := m.seq
+	return tea.Tick(frameInterval, func(time.Time) tea.Msg {
+		return TickMsg{seq: seq}
+	})
+}
+
+// seek moves playback to t seconds in. Going back replays from the start,
+// since a terminal's screen can't be rewound.
+func (m *Model) seek(t float64) {
+	t = max(0, min(t, m.Duration()))
+	if t < m.pos {
+		m.rewind()
+	}
+	m.advance(t)
+	m.last = time.Now()
+}
+
+// rewind goes back to the start with a blank screen.
+func (m *Model) rewind() {
+	cols, rows := m.rec.Header.Width, m.rec.Header.Height
+	if cols <= 0 || rows <= 0 {
+		cols, rows = 80, 24
+	}
+	m.vt = vt.New(cols, rows)
+	m.vt.SetMaxScrollback(0) // Only the screen is shown
+	m.next = 0
+	m.pos = 0
+}
+
+// advance plays the events up to t seconds in.
+func (m *Model) advance(t float64) {
+	for ; m.next < len(m.rec.Events) && m.rec.Events[m.next].Time <= t; m.next++ {
+		e := m.rec.Events[m.next]
+		if e.Type == asciicast.Output {
+			m.vt.Write([]byte(e.Data))
+		} else if cols, rows, ok := e.Size(); ok {
+			m.vt.Resize(cols, rows)
+		}
+	}
+	m.pos = min(t, m.Duration())
+}
+
+// Focus gives focus to this component.
+func (m Model) Focus() Model {
+	m.Base.Focus()
+	return m
+}
+
+// Blur removes focus from this component.
+func (m Model) Blur() Model {
+	m.Base.Blur()
+	return m
+}
+
+// SetSize updates the component's dimensions.
+func (m Model) SetSize(width, height int) Model {
+	m.Base.SetSize(width, height)
+	return m
+}
+
+// View renders the screen as of the playback position, with the controls
+// below it. A screen bigger than the pane is cut to its bottom left, where
+// the prompt usually is.
+func (m Model) View() string {
+	w, h := m.Size()
+	if w == 0 || h == 0 {
+		return ""
+	}
+	switch {
+	case m.err != nil:
+		return lipgloss.NewStyle().Foreground(theme.NeonRed).Bold(true).Render("Error: " + m.err.Error())
+	case !m.loaded:
+		return lipgloss.NewStyle().
+			Width(w).
+			Height(h).
+			Foreground(theme.MutedLavender).
+			Align(lipgloss.Center, lipgloss.Center).
+			Render("Loading recording...")
+	}
+
+	lines := strings.Split(m.vt.Screen(vt.RenderOptions{}), "\n")
+	if len(lines) > h-1 {
+		lines = lines[len(lines)-(h-1):]
+	}
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, w, "")
+	}
+	for len(lines) < h-1 {
+		lines = append(lines, "")
+	}
+	return strings.Join(append(lines, m.renderControls(w)), "\n")
+}
+
+// renderControls renders whether it's playing, the position, the speed and
+// a progress bar.
+func (m Model) renderControls(width int) string {
+	state := "▶"
+	if !m.playing {
+		state = "⏸"
+	}
+	info := fmt.Sprintf(" %s %s / %s  %s× ", state, formatTime(m.pos), formatTime(m.Duration()), formatSpeed(m.Speed()))
+	infoStyle := lipgloss.NewStyle().Foreground(theme.CyberCyan).Bold(true)
+
+	bar := ""
+	if barWidth := width - ansi.StringWidth(info) - 1; barWidth > 0 {
+		filled := 0
+		if d := m.Duration(); d > 0 {
+			filled = int(float64(barWidth) * m.pos / d)
+		}
+		bar = lipgloss.NewStyle().Foreground(theme.CyberCyan).Render(strings.Repeat("━", filled)) +
+			lipgloss.NewStyle().Foreground(theme.MutedLavender).Render(strings.Repeat("─", barWidth-filled))
+	}
+
+	return lipgloss.NewStyle().
+		Background(lipgloss.Color("236")).
+		Width(width).
+		Render(infoStyle.Render(info) + bar)
+}
+
+// formatTime formats seconds as m:ss, or h:mm:ss from an hour.
+func formatTime(secs float64) string {
+	s := int(secs)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+// formatSpeed formats a speed without trailing zeros: 0.25, 0.5, 2.
+func formatSpeed(speed float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", speed), "0"), ".")
+}
@@ -0,0 +1,130 @@
+package player
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cast = `{"version": 2, "width": 20, "height": 3, "title": "claude"}
+[1.0, "o", "first\r\n"]
+[5.0, "o", "second\r\n"]
+[9.0, "r", "30x3"]
+[10.0, "o", "third"]
+`
+
+// newLoaded returns a focused player that has read the recording above.
+func newLoaded(t *testing.T) Model {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "run.cast")
+	require.NoError(t, os.WriteFile(path, []byte(cast), 0o644))
+
+	m := New().SetSize(40, 5).Focus()
+	cmd := m.Open(path)
+	m, tick := m.Update(cmd())
+	require.NotNil(t, tick, "starts playing")
+	return m
+}
+
+func press(m Model, s string) Model {
+	var msg tea.KeyPressMsg
+	switch s {
+	case "left":
+		msg = tea.KeyPressMsg{Code: tea.KeyLeft}
+	case "right":
+		msg = tea.KeyPressMsg{Code: tea.KeyRight}
+	case "space":
+		msg = tea.KeyPressMsg{Code: tea.KeySpace, Text: " "}
+	case "end":
+		msg = tea.KeyPressMsg{Code: tea.KeyEnd}
+	default:
+		msg = tea.KeyPressMsg{Code: rune(s[0]), Text: s}
+	}
+	m, _ = m.Update(msg)
+	return m
+}
+
+func TestPlayer(t *testing.T) {
+	m := newLoaded(t)
+	assert.True(t, m.Playing())
+	assert.Equal(t, 10.0, m.Duration())
+	assert.Equal(t, "run.cast · claude", m.Title())
+
+	m = press(m, "space")
+	assert.False(t, m.Playing())
+}
+
+func TestPlayerControls(t *testing.T) {
+	// The screen is shared between copies, so each starts from its own
+	paused := func(t *testing.T) Model {
+		return press(newLoaded(t), "space")
+	}
+
+	t.Run("seek forward and back", func(t *testing.T) {
+		m := press(paused(t), "right")
+		m = press(m, "right")
+		assert.Equal(t, 10.0, m.Position())
+		view := ansi.Strip(m.View())
+		assert.Contains(t, view, "second")
+		assert.Contains(t, view, "third")
+		assert.Contains(t, view, "0:10 / 0:10")
+
+		m = press(m, "left")
+		assert.Equal(t, 5.0, m.Position())
+		view = ansi.Strip(m.View())
+		assert.Contains(t, view, "second")
+		assert.NotContains(t, view, "third", "replayed from the start")
+	})
+
+	t.Run("jump to a tenth", func(t *testing.T) {
+		m := press(paused(t), "3")
+		assert.Equal(t, 3.0, m.Position())
+		assert.NotContains(t, ansi.Strip(m.View()), "second")
+	})
+
+	t.Run("speed", func(t *testing.T) {
+		m := press(paused(t), "+")
+		assert.Equal(t, 2.0, m.Speed())
+		for range 10 {
+			m = press(m, "+")
+		}
+		assert.Equal(t, 16.0, m.Speed())
+		for range 10 {
+			m = press(m, "-")
+		}
+		assert.Equal(t, 0.25, m.Speed())
+		assert.Contains(t, ansi.Strip(m.View()), "0.25×")
+	})
+
+	t.Run("play again from the end", func(t *testing.T) {
+		m := press(paused(t), "end")
+		m = press(m, "space")
+		assert.True(t, m.Playing())
+		assert.Equal(t, 0.0, m.Position())
+	})
+}
+
+func TestPlayerTicks(t *testing.T) {
+	m := newLoaded(t)
+
+	// A tick from a loop that was stopped does nothing
+	stale := TickMsg{seq: m.seq - 1}
+	m, cmd := m.Update(stale)
+	assert.Nil(t, cmd)
+
+	m, cmd = m.Update(TickMsg{seq: m.seq})
+	assert.NotNil(t, cmd, "keeps playing")
+}
+
+func TestPlayerLoadError(t *testing.T) {
+	m := New().SetSize(40, 5)
+	cmd := m.Open(filepath.Join(t.TempDir(), "missing.cast"))
+	m, _ = m.Update(cmd())
+	assert.Contains(t, m.View(), "Error")
+	assert.False(t, m.Playing())
+}
@@ -0,0 +1,584 @@
+// Package review shows every changed file of a repository as one diff, with
+// an index of the files beside it, to read through the changes file by file
+// and mark each as reviewed.
+package review
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/avitaltamir/vibecommander/internal/components"
+	"github.com/avitaltamir/vibecommander/internal/components/content/diff"
+	"github.com/avitaltamir/vibecommander/internal/git"
+	"github.com/avitaltamir/vibecommander/internal/theme"
+)
+
+const (
+	// indexWidth is the widest the file index gets.
+	indexWidth = 32
+
+	// minWidth is how wide the pane must be to show the file index at all.
+	minWidth = 60
+
+	// deleted is the fingerprint of a file that's gone.
+	deleted = "-"
+)
+
+// Messages
+type (
+	// LoadedMsg is sent when the changes to review have been loaded.
+	LoadedMsg struct {
+		Scope git.ChangeScope
+		Since string
+		Diff  string
+		// Prints are the fingerprints of the files in the diff and the ones
+		// marked reviewed, by path relative to the root
+		Prints map[string]string
+		Err    error
+	}
+
+	// StagedMsg is sent when a file approved with 'a' has been staged.
+	StagedMsg struct {
+		Path string
+		Err  error
+	}
+)
+
+// Model is the review view: the diff of every changed file, with an index of
+// the files on the left.
+type Model struct {
+	components.Base
+
+	diff     diff.Model
+	provider git.Provider
+	root     string
+
+	scope  git.ChangeScope
+	since  string // Commit for git.ChangesSince
+	files  []string
+	err    error
+	loaded bool
+
+	// reviewed holds the fingerprint of each file when it was marked
+	// reviewed, by path relative to the root. A file whose fingerprint
+	// differs has changed since and isn't reviewed anymore.
+	reviewed map[string]string
+	prints   map[string]string // Current fingerprints, from the last load
+
+	asking    bool // The since-commit prompt is open
+	input     textinput.Model
+	promptErr error           // Why the commit last entered was rejected
+	prevScope git.ChangeScope // Scope to go back to if it's rejected
+	prevSince string
+}
+
+// New creates a new review model.
+func New() Model {
+	ti := textinput.New()
+	ti.Placeholder = "commit"
+	ti.CharLimit = 64
+	ti.SetWidth(24)
+	return Model{
+		diff:     diff.New(),
+		reviewed: make(map[string]string),
+		input:    ti,
+	}
+}
+
+// SetGitProvider sets the provider the changes are read and staged with.
+func (m *Model) SetGitProvider(provider git.Provider) {
+	m.provider = provider
+}
+
+// SetRoot sets the directory the changed files are relative to: where the
+// provider runs git.
+func (m *Model) SetRoot(root string) {
+	m.root = root
+	m.diff.SetRoot(root)
+}
+
+// SetReviewed sets the fingerprints of the files marked reviewed, by path
+// relative to the root, as returned by Reviewed.
+func (m *Model) SetReviewed(reviewed map[string]string) {
+	m.reviewed = make(map[string]string, len(reviewed))
+	for path, print := range reviewed {
+		m.reviewed[path] = print
+	}
+}
+
+// Reviewed returns the fingerprints of the files marked reviewed, by path
+// relative to the root.
+func (m Model) Reviewed() map[string]string {
+	if len(m.reviewed) == 0 {
+		return nil
+	}
+	reviewed := make(map[string]string, len(m.reviewed))
+	for path, print := range m.reviewed {
+		reviewed[path] = print
+	}
+	return reviewed
+}
+
+// Load loads the changes in the current scope.
+func (m Model) Load() tea.Cmd {
+	if m.provider == nil {
+		return nil
+	}
+	provider, root, scope, since, opts := m.provider, m.root, m.scope, m.since, m.diff.Options()
+	marked := make([]string, 0, len(m.reviewed))
+	for path := range m.reviewed {
+		marked = append(marked, path)
+	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		text, err := provider.GetChanges(ctx, scope, since, opts)
+		if err != nil {
+			return LoadedMsg{Scope: scope, Since: since, Err: err}
+		}
+		prints := make(map[string]string)
+		for _, path := range append(diff.FileNames(text), marked...) {
+			if _, ok := prints[path]; !ok {
+				prints[path] = fingerprint(filepath.Join(root, filepath.FromSlash(path)))
+			}
+		}
+		return LoadedMsg{Scope: scope, Since: since, Diff: text, Prints: prints}
+	}
+}
+
+// fingerprint returns a hash of a file's content, or deleted when it's gone.
+func fingerprint(path string) string {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return deleted
+	}
+	h := fnv.New64a()
+	if err == nil {
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Init initializes the review model.
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages.
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case LoadedMsg:
+		if msg.Scope != m.scope || msg.Since != m.since {
+			return m, nil // Loaded for a scope since left
+		}
+		if errors.Is(msg.Err, git.ErrUnknownCommit) && m.scope == git.ChangesSince {
+			return m, m.rejectSince(msg.Err)
+		}
+		m.loaded = true
+		m.err = msg.Err
+		if msg.Err != nil {
+			m.files = nil
+			m.diff.Clear()
+			m.diff = m.diff.SetSize(m.diffSize())
+			return m, nil
+		}
+		m.prints = msg.Prints
+		for path, print := range m.reviewed {
+			if p, ok := m.prints[path]; ok && p != print {
+				delete(m.reviewed, path) // Changed since it was reviewed
+			}
+		}
+		current := m.currentFile()
+		m.diff.SetContent(msg.Diff, m.root)
+		m.files = m.diff.Files()
+		// Stay on the file in view when reloading
+		for k, path := range m.files {
+			if path == current && current != "" {
+				m.diff.GotoFile(k)
+				break
+			}
+		}
+		return m, nil
+
+	case diff.OptionsChangedMsg:
+		return m, m.Load()
+
+	case StagedMsg:
+		if msg.Err != nil {
+			return m, nil
+		}
+		return m, m.Load()
+
+	case tea.MouseClickMsg:
+		if x := msg.Mouse().X; x < m.indexWidth() {
+			k := msg.Mouse().Y - 1 + m.indexOffset()
+			if k >= 0 && k < len(m.files) {
+				m.diff.GotoFile(k)
+			}
+			return m, nil
+		}
+		click := msg
+		click.X -= m.indexWidth() + 1
+		m.diff, cmd = m.diff.Update(click)
+		return m, cmd
+
+	case tea.KeyPressMsg:
+		if !m.Focused() {
+			return m, nil
+		}
+		if m.asking {
+			return m.updatePrompt(msg)
+		}
+		switch msg.String() {
+		case "space":
+			m.toggle()
+			return m, nil
+		case "a":
+			return m, m.approve()
+		case "v":
+			return m, m.cycleScope()
+		case "c":
+			m.asking = true
+			m.input.SetValue(m.since)
+			m.input.Focus()
+			return m, textinput.Blink
+		case "r":
+			return m, m.Load()
+		}
+	}
+
+	m.diff, cmd = m.diff.Update(msg)
+	return m, cmd
+}
+
+// updatePrompt handles keys while the since-commit prompt is open.
+func (m Model) updatePrompt(msg tea.KeyPressMsg) (Model, tea.Cmd) {
+	switch msg.Key().Code {
+	case tea.KeyEscape:
+		m.asking = false
+		m.promptErr = nil
+		m.input.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		m.asking = false
+		m.promptErr = nil
+		m.input.Blur()
+		since := strings.TrimSpace(m.input.Value())
+		if since == "" {
+			return m, nil
+		}
+		m.prevScope, m.prevSince = m.scope, m.since
+		m.scope, m.since = git.ChangesSince, since
+		return m, m.Load()
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// rejectSince opens the prompt again on a commit that doesn't exist, showing
+// why, and goes back to the changes in view before.
+func (m *Model) rejectSince(err error) tea.Cmd {
+	m.promptErr = err
+	m.asking = true
+	m.input.SetValue(m.since)
+	m.input.Focus()
+	m.scope, m.since = m.prevScope, m.prevSince
+	m.prevScope, m.prevSince = git.ChangesAll, ""
+	return tea.Batch(textinput.Blink, m.Load())
+}
+
+// cycleScope moves on to the next of all, unstaged and staged changes.
+func (m *Model) cycleScope() tea.Cmd {
+	switch m.scope {
+	case git.ChangesAll:
+		m.scope = git.ChangesUnstaged
+	case git.ChangesUnstaged:
+		m.scope = git.ChangesStaged
+	default:
+		m.scope = git.ChangesAll
+	}
+	m.since = ""
+	return m.Load()
+}
+
+// currentFile returns the file in view, relative to the root, or "" for none.
+func (m Model) currentFile() string {
+	if len(m.files) == 0 {
+		return ""
+	}
+	return m.files[max(m.diff.FileIndex(), 0)]
+}
+
+// IsReviewed reports whether a file, relative to the root, is marked
+// reviewed and hasn't changed since.
+func (m Model) IsReviewed(path string) bool {
+	print, ok := m.reviewed[path]
+	return ok && print == m.prints[path]
+}
+
+// Progress returns how many of the files are reviewed, and how many there
+// are.
+func (m Model) Progress() (reviewed, total int) {
+	for _, path := range m.files {
+		if m.IsReviewed(path) {
+			reviewed++
+		}
+	}
+	return reviewed, len(m.files)
+}
+
+// toggle marks the file in view reviewed, moving on to the next one not
+// reviewed, or unmarks it.
+func (m *Model) toggle() {
+	path := m.currentFile()
+	if path == "" {
+		return
+	}
+	if m.IsReviewed(path) {
+		delete(m.reviewed, path)
+		return
+	}
+	m.mark(path)
+}
+
+// approve marks the file in view reviewed and stages it.
+func (m *Model) approve() tea.Cmd {
+	path := m.currentFile()
+	if path == "" || m.provider == nil {
+		return nil
+	}
+	m.mark(path)
+	provider, abs := m.provider, filepath.Join(m.root, filepath.FromSlash(path))
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return StagedMsg{Path: abs, Err: provider.Stage(ctx, abs)}
+	}
+}
+
+// mark marks a file reviewed and moves on to the next file not reviewed.
+func (m *Model) mark(path string) {
+	m.reviewed[path] = m.prints[path]
+	current := max(m.diff.FileIndex(), 0)
+	for k := 1; k < len(m.files); k++ {
+		next := (current + k) % len(m.files)
+		if !m.IsReviewed(m.files[next]) {
+			m.diff.GotoFile(next)
+			return
+		}
+	}
+}
+
+// Title returns what's reviewed and how far along the review is.
+func (m Model) Title() string {
+	title := m.scope.String()
+	if m.scope == git.ChangesSince {
+		title += " " + m.since
+	}
+	if done, total := m.Progress(); total > 0 {
+		title += " · " + strconv.Itoa(done) + "/" + strconv.Itoa(total) + " reviewed"
+	}
+	if opts := m.diff.Options(); opts.String() != "" {
+		title += " · " + opts.String()
+	}
+	if m.diff.IsSplit() {
+		title += " · split"
+	}
+	return title
+}
+
+// Location returns the file and line (0-indexed) the diff is on, for opening
+// it in the viewer.
+func (m Model) Location() (path string, line int) {
+	return m.diff.Location()
+}
+
+// Prompting reports whether a prompt is open, taking the keys typed.
+func (m Model) Prompting() bool {
+	return m.asking || m.diff.Prompting()
+}
+
+// HasContent reports whether there are changes shown.
+func (m Model) HasContent() bool {
+	return len(m.files) > 0
+}
+
+// Scope returns which changes are reviewed.
+func (m Model) Scope() git.ChangeScope {
+	return m.scope
+}
+
+// Refresh re-renders the diff, e.g. after the theme changed.
+func (m *Model) Refresh() {
+	m.diff.Refresh()
+}
+
+// ScrollPercent returns the scroll position of the diff.
+func (m Model) ScrollPercent() float64 {
+	return m.diff.ScrollPercent()
+}
+
+// Focus gives focus to this component.
+func (m Model) Focus() Model {
+	m.Base.Focus()
+	m.diff = m.diff.Focus()
+	return m
+}
+
+// Blur removes focus from this component.
+func (m Model) Blur() Model {
+	m.Base.Blur()
+	m.diff = m.diff.Blur()
+	return m
+}
+
+// SetSize updates the component's dimensions.
+func (m Model) SetSize(width, height int) Model {
+	m.Base.SetSize(width, height)
+	m.diff = m.diff.SetSize(m.diffSize())
+	return m
+}
+
+// indexWidth returns how wide the file index is, 0 when the pane is too
+// narrow for it.
+func (m Model) indexWidth() int {
+	w, _ := m.Size()
+	if w < minWidth {
+		return 0
+	}
+	return min(indexWidth, w/4)
+}
+
+// diffSize returns the size of the diff beside the index.
+func (m Model) diffSize() (width, height int) {
+	w, h := m.Size()
+	if iw := m.indexWidth(); iw > 0 {
+		w -= iw + 1
+	}
+	return max(w, 1), h
+}
+
+// indexOffset returns the first file shown in the index, so the file in view
+// is always in it.
+func (m Model) indexOffset() int {
+	_, h := m.Size()
+	current := max(m.diff.FileIndex(), 0)
+	return max(current-h+1, 0)
+}
+
+// View renders the review view.
+func (m Model) View() string {
+	w, h := m.Size()
+	if w == 0 || h == 0 {
+		return ""
+	}
+
+	var body string
+	switch {
+	case m.err != nil:
+		body = lipgloss.NewStyle().Foreground(theme.NeonRed).Bold(true).Render("Error: " + m.err.Error())
+	case !m.loaded:
+		body = m.renderPlaceholder("Loading changes...")
+	case len(m.files) == 0:
+		body = m.renderPlaceholder(m.emptyText())
+	default:
+		body = m.diff.View()
+	}
+
+	if iw := m.indexWidth(); iw > 0 {
+		sep := lipgloss.NewStyle().
+			Foreground(theme.DimPurple).
+			Render(strings.TrimSuffix(strings.Repeat("│\n", h), "\n"))
+		body = lipgloss.JoinHorizontal(lipgloss.Top, m.renderIndex(iw, h), sep, body)
+	}
+
+	if m.asking {
+		lines := strings.Split(body, "\n")
+		if len(lines) >= h {
+			lines = lines[:h-1]
+		}
+		body = strings.Join(append(lines, m.renderPrompt(w)), "\n")
+	}
+	return body
+}
+
+// emptyText says there's nothing to review in the scope.
+func (m Model) emptyText() string {
+	switch m.scope {
+	case git.ChangesUnstaged, git.ChangesStaged:
+		return "No " + m.scope.String() + " changes to review"
+	case git.ChangesSince:
+		return "No changes since " + m.since + " to review"
+	}
+	return "No changes to review"
+}
+
+// renderIndex renders the list of files, a check beside each one reviewed
+// and the one in view highlighted.
+func (m Model) renderIndex(width, height int) string {
+	current := m.diff.FileIndex()
+	rows := make([]string, 0, height)
+	for k := m.indexOffset(); k < len(m.files) && len(rows) < height; k++ {
+		path := m.files[k]
+		mark := lipgloss.NewStyle().Foreground(theme.MutedLavender).Render("○ ")
+		if m.IsReviewed(path) {
+			mark = lipgloss.NewStyle().Foreground(theme.MatrixGreen).Render("✓ ")
+		}
+		style := lipgloss.NewStyle().Foreground(theme.MutedLavender)
+		if k == max(current, 0) {
+			style = lipgloss.NewStyle().Foreground(theme.CyberCyan).Bold(true)
+		}
+		rows = append(rows, mark+style.Render(truncateLeft(path, width-2)))
+	}
+	return lipgloss.NewStyle().Width(width).Height(height).MaxHeight(height).Render(strings.Join(rows, "\n"))
+}
+
+// truncateLeft cuts the start off a path too wide, keeping its file name.
+func truncateLeft(path string, width int) string {
+	if ansi.StringWidth(path) <= width {
+		return path
+	}
+	return ansi.TruncateLeft(path, ansi.StringWidth(path)-width+1, "…")
+}
+
+// renderPrompt renders the since-commit prompt.
+func (m Model) renderPrompt(width int) string {
+	prefix := lipgloss.NewStyle().
+		Foreground(theme.CyberCyan).
+		Bold(true).
+		Render("Since: ")
+	bar := prefix + m.input.View()
+	if m.promptErr != nil {
+		bar += lipgloss.NewStyle().Foreground(theme.ColorError).Render("  " + m.promptErr.Error())
+	}
+	return lipgloss.NewStyle().
+		Background(lipgloss.Color("236")).
+		Width(width).
+		Render(ansi.Truncate(bar, width, "…"))
+}
+
+func (m Model) renderPlaceholder(text string) string {
+	w, h := m.diffSize()
+	return lipgloss.NewStyle().
+		Width(w).
+		Height(h).
+		Foreground(theme.MutedLavender).
+		Align(lipgloss.Center, lipgloss.Center).
+		Render(text)
+}
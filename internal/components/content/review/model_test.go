@@ -0,0 +1,189 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/avitaltamir/vibecommander/internal/git"
+)
+
+const changes = `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,2 +1,2 @@
+ package a
+-var x = 1
++var x = 2
+diff --git a/b.go b/b.go
+--- a/b.go
++++ b/b.go
+@@ -1,2 +1,2 @@
+ package b
+-var y = 1
++var y = 2`
+
+// fakeProvider serves changes and records what's staged.
+type fakeProvider struct {
+	git.Provider
+	scopes []git.ChangeScope
+	staged []string
+}
+
+func (p *fakeProvider) GetChanges(_ context.Context, scope git.ChangeScope, since string, _ git.DiffOptions) (string, error) {
+	p.scopes = append(p.scopes, scope)
+	if scope == git.ChangesSince && since == "nope" {
+		return "", fmt.Errorf("%q: %w", since, git.ErrUnknownCommit)
+	}
+	if scope == git.ChangesStaged {
+		return "", nil
+	}
+	return changes, nil
+}
+
+func (p *fakeProvider) Stage(_ context.Context, path string) error {
+	p.staged = append(p.staged, path)
+	return nil
+}
+
+var (
+	keySpace = tea.KeyPressMsg{Code: tea.KeySpace, Text: " "}
+	keyA     = tea.KeyPressMsg{Code: 'a', Text: "a"}
+	keyV     = tea.KeyPressMsg{Code: 'v', Text: "v"}
+)
+
+// newReview returns a review of the changes to a.go and b.go in a temporary
+// directory.
+func newReview(t *testing.T) (Model, *fakeProvider, string) {
+	t.Helper()
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.go"), []byte("package a\nvar x = 2\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "b.go"), []byte("package b\nvar y = 2\n"), 0o644))
+
+	provider := &fakeProvider{}
+	m := New().SetSize(100, 20).Focus()
+	m.SetGitProvider(provider)
+	m.SetRoot(root)
+	m = load(t, m)
+	return m, provider, root
+}
+
+// load runs the load and feeds its result back in.
+func load(t *testing.T, m Model) Model {
+	t.Helper()
+	cmd := m.Load()
+	require.NotNil(t, cmd)
+	m, _ = m.Update(cmd())
+	return m
+}
+
+func TestLoadListsFiles(t *testing.T) {
+	m, _, root := newReview(t)
+	assert.Equal(t, []string{"a.go", "b.go"}, m.files)
+	assert.Equal(t, "all changes · 0/2 reviewed", m.Title())
+
+	view := ansi.Strip(m.View())
+	assert.Contains(t, view, "○ a.go")
+	assert.Contains(t, view, "○ b.go")
+
+	path, line := m.Location()
+	assert.Equal(t, filepath.Join(root, "a.go"), path)
+	assert.Equal(t, 0, line)
+}
+
+func TestToggleReviewed(t *testing.T) {
+	m, _, root := newReview(t)
+
+	m, _ = m.Update(keySpace)
+	assert.True(t, m.IsReviewed("a.go"))
+	assert.Equal(t, "b.go", m.currentFile(), "moves on to the next file not reviewed")
+	assert.Equal(t, "all changes · 1/2 reviewed", m.Title())
+	assert.Contains(t, ansi.Strip(m.View()), "✓ a.go")
+
+	// Still reviewed after reloading, until the file changes
+	m = load(t, m)
+	assert.True(t, m.IsReviewed("a.go"))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.go"), []byte("package a\nvar x = 3\n"), 0o644))
+	m = load(t, m)
+	assert.False(t, m.IsReviewed("a.go"))
+	assert.Empty(t, m.Reviewed())
+}
+
+func TestReviewedPersists(t *testing.T) {
+	m, provider, root := newReview(t)
+	m, _ = m.Update(keySpace)
+	saved := m.Reviewed()
+	require.Contains(t, saved, "a.go")
+
+	again := New().SetSize(100, 20)
+	again.SetGitProvider(provider)
+	again.SetRoot(root)
+	again.SetReviewed(saved)
+	again = load(t, again)
+	assert.True(t, again.IsReviewed("a.go"))
+	assert.False(t, again.IsReviewed("b.go"))
+}
+
+func TestApproveStages(t *testing.T) {
+	m, provider, root := newReview(t)
+
+	m, cmd := m.Update(keyA)
+	require.NotNil(t, cmd)
+	assert.True(t, m.IsReviewed("a.go"))
+
+	msg := cmd()
+	assert.Equal(t, StagedMsg{Path: filepath.Join(root, "a.go")}, msg)
+	assert.Equal(t, []string{filepath.Join(root, "a.go")}, provider.staged)
+
+	// Staging reloads the review
+	_, cmd = m.Update(msg)
+	assert.NotNil(t, cmd)
+}
+
+func TestCycleScope(t *testing.T) {
+	m, provider, _ := newReview(t)
+
+	m, cmd := m.Update(keyV)
+	require.NotNil(t, cmd)
+	m, _ = m.Update(cmd())
+	assert.Equal(t, git.ChangesUnstaged, m.Scope())
+
+	m, cmd = m.Update(keyV)
+	m, _ = m.Update(cmd())
+	assert.Equal(t, git.ChangesStaged, m.Scope())
+	assert.False(t, m.HasContent())
+	assert.Contains(t, ansi.Strip(m.View()), "No staged changes to review")
+
+	assert.Equal(t, []git.ChangeScope{git.ChangesAll, git.ChangesUnstaged, git.ChangesStaged}, provider.scopes)
+}
+
+func TestSinceRejected(t *testing.T) {
+	m, _, _ := newReview(t)
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'c', Text: "c"})
+	for _, r := range "nope" {
+		m, _ = m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+	m, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	m, _ = m.Update(cmd())
+
+	// The prompt opens again with the error, over the changes from before
+	assert.True(t, m.Prompting())
+	assert.Equal(t, git.ChangesAll, m.Scope())
+	assert.Contains(t, ansi.Strip(m.View()), "not a branch")
+	assert.Contains(t, ansi.Strip(m.View()), "a.go")
+}
+
+func TestClickIndexGoesToFile(t *testing.T) {
+	m, _, _ := newReview(t)
+	m, _ = m.Update(tea.MouseClickMsg{X: 2, Y: 2, Button: tea.MouseLeft})
+	assert.Equal(t, "b.go", m.currentFile())
+}
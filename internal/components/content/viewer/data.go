@@ -0,0 +1,535 @@
+package viewer
+
+import (
+	"path/filepath"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/avitaltamir/vibecommander/internal/theme"
+)
+
+// dataFormat is a structured file format with a rendered view.
+type dataFormat int
+
+const (
+	formatNone dataFormat = iota
+	formatJSON
+	formatYAML
+	formatCSV
+	formatTSV
+)
+
+// dataFormatOf returns the structured format of path, by extension.
+func dataFormatOf(path string) dataFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".jsonl", ".ndjson", ".geojson":
+		return formatJSON
+	case ".yaml", ".yml":
+		return formatYAML
+	case ".csv":
+		return formatCSV
+	case ".tsv", ".tab":
+		return formatTSV
+	}
+	return formatNone
+}
+
+// parseError is where a structured file failed to parse.
+type parseError struct {
+	format    string // "JSON", "YAML", "CSV" or "TSV"
+	line, col int    // 0-indexed; -1 when unknown
+	msg       string
+}
+
+// location describes where the error is, e.g. "line 3, column 7".
+func (e *parseError) location() string {
+	switch {
+	case e.line < 0:
+		return ""
+	case e.col < 0:
+		return "line " + itoa(e.line+1)
+	default:
+		return "line " + itoa(e.line+1) + ", column " + itoa(e.col+1)
+	}
+}
+
+// dataView is a JSON or YAML file shown as a tree, or a CSV or TSV file
+// shown as a table.
+type dataView struct {
+	root  *treeNode // Trees only
+	rows  []treeRow // Trees only: the nodes shown
+	table *table    // Tables only
+
+	cursor  int // Selected row
+	top     int // First row shown
+	message string
+}
+
+// parseData parses a JSON, YAML, CSV or TSV file for the rendered view. When
+// that fails the source is shown with the error instead.
+func (m *Model) parseData() {
+	m.data = dataView{}
+	m.parseErr = nil
+	defer m.sizeViewport()
+
+	if !m.preview || m.streaming || m.binary || m.err != nil || strings.TrimSpace(m.content) == "" {
+		return
+	}
+	var err *parseError
+	switch format := dataFormatOf(m.path); format {
+	case formatJSON:
+		m.data.root, err = parseJSON(m.content)
+	case formatYAML:
+		m.data.root, err = parseYAML(m.content)
+	case formatCSV, formatTSV:
+		m.data.table, err = parseTable(m.content, format == formatTSV)
+	}
+	if err != nil {
+		m.data = dataView{}
+		m.parseErr = err
+		return
+	}
+	if m.data.root != nil {
+		m.data.rows = flattenTree(m.data.root)
+	}
+}
+
+// structured reports whether the file is shown as a tree or table.
+func (m Model) structured() bool {
+	return m.preview && !m.editing && (m.data.root != nil || m.data.table != nil)
+}
+
+// sizeViewport fits the viewport to the pane, less the parse error bar.
+func (m *Model) sizeViewport() {
+	if !m.ready {
+		return
+	}
+	_, h := m.Size()
+	if m.parseErr != nil {
+		h--
+	}
+	m.viewport.SetHeight(max(h, 1))
+}
+
+// dataCount returns the number of rows in the tree or table.
+func (m Model) dataCount() int {
+	if m.data.table != nil {
+		return len(m.data.table.records)
+	}
+	return len(m.data.rows)
+}
+
+// dataHeight returns the rows shown, less the table header and status bar.
+func (m Model) dataHeight() int {
+	_, h := m.Size()
+	if m.data.table != nil {
+		h -= 2
+	}
+	return max(h-1, 1)
+}
+
+// dataLine returns the source line of row.
+func (m Model) dataLine(row int) int {
+	if row < 0 || row >= m.dataCount() {
+		return 0
+	}
+	if t := m.data.table; t != nil {
+		return t.lines[t.order[row]]
+	}
+	return m.data.rows[row].node.line
+}
+
+// moveData selects row, scrolling to keep it in view.
+func (m *Model) moveData(row int) {
+	d := &m.data
+	h := m.dataHeight()
+	d.cursor = clampInt(row, 0, m.dataCount()-1)
+	if d.cursor < d.top {
+		d.top = d.cursor
+	}
+	if d.cursor >= d.top+h {
+		d.top = d.cursor - h + 1
+	}
+	d.top = clampInt(d.top, 0, max(m.dataCount()-h, 0))
+}
+
+// gotoDataLine selects the row for source line: the last one at or before
+// it, in display order for a table sorted by a column.
+func (m *Model) gotoDataLine(line int) {
+	best, bestLine := 0, -1
+	for i := 0; i < m.dataCount(); i++ {
+		if l := m.dataLine(i); l <= line && l > bestLine {
+			best, bestLine = i, l
+		}
+	}
+	m.moveData(best)
+	// Show the row at the top, as for source lines
+	m.data.top = clampInt(m.data.cursor, 0, max(m.dataCount()-m.dataHeight(), 0))
+}
+
+// dataText returns the rows as plain text, for searching.
+func (m Model) dataText() []string {
+	if t := m.data.table; t != nil {
+		all := make([]int, len(t.header))
+		for c := range all {
+			all[c] = c
+		}
+		lines := make([]string, len(t.records))
+		for i := range lines {
+			lines[i] = t.rowText(i, all)
+		}
+		return lines
+	}
+	lines := make([]string, len(m.data.rows))
+	for i, r := range m.data.rows {
+		lines[i] = r.label()
+	}
+	return lines
+}
+
+// refreshTree lists the tree's rows again after folding, keeping the same
+// node selected and the search matches in step.
+func (m *Model) refreshTree(selected *treeNode) {
+	m.data.rows = flattenTree(m.data.root)
+	row := 0
+	for i, r := range m.data.rows {
+		if r.node == selected {
+			row = i
+			break
+		}
+	}
+	m.redoSearch()
+	m.moveData(row)
+}
+
+// redoSearch searches again after the rows changed, keeping matches in step
+// with what's shown.
+func (m *Model) redoSearch() {
+	if m.searchQuery == "" {
+		return
+	}
+	current := m.currentMatch
+	cursor := m.data.cursor
+	m.performSearch(m.searchQuery)
+	m.currentMatch = min(current, len(m.matchLines)-1)
+	m.data.cursor = cursor
+}
+
+// updateData handles keys for a tree or table.
+func (m Model) updateData(msg tea.KeyPressMsg) (Model, tea.Cmd) {
+	keys := m.viewport.KeyMap
+	d := &m.data
+	h := m.dataHeight()
+	d.message = ""
+
+	switch {
+	case key.Matches(msg, keys.Down):
+		m.moveData(d.cursor + 1)
+	case key.Matches(msg, keys.Up):
+		m.moveData(d.cursor - 1)
+	case key.Matches(msg, keys.PageDown):
+		d.top += h
+		m.moveData(d.cursor + h)
+	case key.Matches(msg, keys.PageUp):
+		d.top -= h
+		m.moveData(d.cursor - h)
+	case key.Matches(msg, keys.HalfPageDown):
+		m.moveData(d.cursor + h/2)
+	case key.Matches(msg, keys.HalfPageUp):
+		m.moveData(d.cursor - h/2)
+	case msg.String() == "home" || msg.String() == "g":
+		m.moveData(0)
+	case msg.String() == "end" || msg.String() == "G":
+		m.moveData(m.dataCount() - 1)
+	case d.table != nil:
+		m.updateTable(msg)
+	default:
+		m.updateTree(msg)
+	}
+	return m, nil
+}
+
+// updateTree handles folding and path copying in a tree.
+func (m *Model) updateTree(msg tea.KeyPressMsg) {
+	d := &m.data
+	if d.cursor >= len(d.rows) {
+		return
+	}
+	n := d.rows[d.cursor].node
+
+	switch msg.String() {
+	case "right", "l":
+		if n.container() && n.collapsed {
+			n.collapsed = false
+			m.refreshTree(n)
+		} else if n.container() && len(n.children) > 0 {
+			m.moveData(d.cursor + 1)
+		}
+	case "left", "h":
+		if n.container() && !n.collapsed && len(n.children) > 0 {
+			n.collapsed = true
+			m.refreshTree(n)
+		} else if d.rows[d.cursor].depth > 0 {
+			m.refreshTree(n.parent)
+		}
+	case "enter", "space":
+		if n.container() && len(n.children) > 0 {
+			n.collapsed = !n.collapsed
+			m.refreshTree(n)
+		}
+	case "-":
+		d.root.setCollapsed(true)
+		top := n
+		for top.parent != nil && top.parent != d.root {
+			top = top.parent
+		}
+		m.refreshTree(top)
+	case "+", "=":
+		d.root.setCollapsed(false)
+		m.refreshTree(n)
+	case "y":
+		m.copyData(n.path())
+	}
+}
+
+// updateTable handles column moves, sorting and cell copying in a table.
+func (m *Model) updateTable(msg tea.KeyPressMsg) {
+	d := &m.data
+	t := d.table
+
+	switch msg.String() {
+	case "right", "l":
+		t.col++
+	case "left", "h":
+		t.col--
+	case "shift+right", "L", "$":
+		t.col = len(t.widths) - 1
+	case "shift+left", "H", "0":
+		t.col = 0
+	case "s":
+		record := -1
+		if d.cursor < len(t.order) {
+			record = t.order[d.cursor]
+		}
+		t.cycleSort()
+		m.redoSearch()
+		for i, r := range t.order {
+			if r == record {
+				m.moveData(i)
+			}
+		}
+	case "y":
+		if d.cursor < len(t.order) {
+			m.copyData(cellAt(t.records[t.order[d.cursor]], t.col))
+		}
+	}
+	w, _ := m.Size()
+	t.scrollToColumn(m.tableWidth(w))
+}
+
+// copyData puts text on the clipboard and says so in the status bar.
+func (m *Model) copyData(text string) {
+	if err := clipboard.WriteAll(text); err != nil {
+		m.data.message = "Copy failed: " + err.Error()
+		return
+	}
+	m.data.message = "Copied " + text
+}
+
+// tableGutter returns the width of the record numbers in front of a table.
+func (m Model) tableGutter() int {
+	return max(len(itoa(len(m.data.table.records))), 3) + 1
+}
+
+// tableWidth returns the width left for a table's cells in a pane of width.
+func (m Model) tableWidth(width int) int {
+	return width - m.tableGutter() - 2
+}
+
+// renderData renders the tree or table with a status bar.
+func (m Model) renderData() string {
+	w, _ := m.Size()
+	h := m.dataHeight()
+	d := m.data
+
+	matches := make(map[int]bool)
+	for _, ln := range m.matchLines {
+		matches[ln] = true
+	}
+	currentMatch := -1
+	if m.currentMatch >= 0 && m.currentMatch < len(m.matchLines) {
+		currentMatch = m.matchLines[m.currentMatch]
+	}
+	cursorStyle := lipgloss.NewStyle().Background(theme.BgSelection).Foreground(theme.PureWhite).Bold(true)
+
+	var rows []string
+	var cols []int
+	if t := d.table; t != nil {
+		cols = t.visibleColumns(m.tableWidth(w))
+		rows = append(rows, m.renderTableHeader(cols, w)...)
+	}
+	for i := d.top; i < d.top+h && i < m.dataCount(); i++ {
+		var plain, styled string
+		if t := d.table; t != nil {
+			plain = t.rowText(i, cols)
+			styled = m.renderTableRow(i, cols)
+		} else {
+			plain = d.rows[i].label()
+			styled = d.rows[i].render()
+		}
+
+		switch {
+		case i == d.cursor && !matches[i]:
+			styled = cursorStyle.Render(padRight(ansi.Truncate(plain, w-m.dataIndent(), "…"), w-m.dataIndent()))
+		case matches[i]:
+			styled = m.highlightMatchesInLine(plain, i == currentMatch)
+		}
+		rows = append(rows, ansi.Truncate(m.dataPrefix(i)+styled, w, "…"))
+	}
+	_, fullHeight := m.Size()
+	for len(rows) < fullHeight-1 {
+		rows = append(rows, "")
+	}
+
+	if m.prompting() {
+		rows = append(rows, m.renderPromptBar(w))
+	} else {
+		rows = append(rows, m.renderDataBar(w))
+	}
+	return strings.Join(rows, "\n")
+}
+
+// dataIndent returns the width of what's in front of each row.
+func (m Model) dataIndent() int {
+	if m.data.table != nil {
+		return m.tableGutter() + 2
+	}
+	return 1
+}
+
+// dataPrefix returns what's in front of row: the record number in a table,
+// a margin in a tree.
+func (m Model) dataPrefix(row int) string {
+	if t := m.data.table; t != nil {
+		num := lipgloss.NewStyle().Foreground(theme.MutedLavender).Render(padLeft(t.order[row]+1, m.tableGutter()))
+		return num + lipgloss.NewStyle().Foreground(theme.DimPurple).Render("│") + " "
+	}
+	return " "
+}
+
+// renderTableHeader renders the header row and the rule below it, which stay
+// put while the rows scroll.
+func (m Model) renderTableHeader(cols []int, width int) []string {
+	t := m.data.table
+	nameStyle := lipgloss.NewStyle().Foreground(theme.CyberCyan).Bold(true)
+	currentStyle := lipgloss.NewStyle().Foreground(theme.MagentaBlaze).Bold(true).Underline(true)
+	ruleStyle := lipgloss.NewStyle().Foreground(theme.DimPurple)
+
+	names := make([]string, len(cols))
+	rules := make([]string, len(cols))
+	for k, c := range cols {
+		style := nameStyle
+		if c == t.col {
+			style = currentStyle
+		}
+		names[k] = style.Render(t.headerCell(c))
+		rules[k] = strings.Repeat("─", t.widths[c])
+	}
+	gutter := m.tableGutter()
+	header := strings.Repeat(" ", gutter) + ruleStyle.Render("│") + " " + strings.Join(names, ruleStyle.Render(" │ "))
+	rule := strings.Repeat("─", gutter) + "┼─" + strings.Join(rules, "─┼─")
+	return []string{ansi.Truncate(header, width, "…"), ruleStyle.Render(ansi.Truncate(rule, width, ""))}
+}
+
+// renderTableRow renders the cells of display row i, dimming separators.
+func (m Model) renderTableRow(i int, cols []int) string {
+	t := m.data.table
+	rec := t.records[t.order[i]]
+	numberStyle := lipgloss.NewStyle().Foreground(theme.ElectricYellow)
+	textStyle := lipgloss.NewStyle().Foreground(theme.PureWhite)
+	sep := lipgloss.NewStyle().Foreground(theme.DimPurple).Render(" │ ")
+
+	cells := make([]string, len(cols))
+	for k, c := range cols {
+		style := textStyle
+		if t.numeric[c] {
+			style = numberStyle
+		}
+		cells[k] = style.Render(t.formatCell(cellAt(rec, c), c))
+	}
+	return strings.Join(cells, sep)
+}
+
+// renderDataBar renders the status bar of a tree or table: the selected
+// path or cell, key hints and messages.
+func (m Model) renderDataBar(width int) string {
+	d := m.data
+	var info string
+	if t := d.table; t != nil {
+		info = " row " + itoa(d.cursor+1) + "/" + itoa(len(t.records))
+		if len(t.records) == 0 {
+			info = " no rows"
+		}
+		info += " · column " + itoa(t.col+1) + "/" + itoa(len(t.header)) + " " + cellText(t.header[t.col]) +
+			" · s:sort y:copy cell"
+	} else if d.cursor < len(d.rows) {
+		info = " " + d.rows[d.cursor].node.path() + " · y:copy path  -/+:fold all"
+	}
+	if m.searchQuery != "" {
+		info += " · " + itoa(len(m.matchLines)) + " matches"
+	}
+	if d.message != "" {
+		info = " " + d.message
+	}
+	return lipgloss.NewStyle().
+		Foreground(theme.MutedLavender).
+		Background(lipgloss.Color("236")).
+		Width(width).
+		Render(ansi.Truncate(info, width, "…"))
+}
+
+// renderParseBar renders why the rendered view fell back to source, and
+// where in the file.
+func (m Model) renderParseBar(width int) string {
+	e := m.parseErr
+	text := " Invalid " + e.format
+	if loc := e.location(); loc != "" {
+		text += " at " + loc
+	}
+	text += ": " + e.msg
+	return lipgloss.NewStyle().
+		Foreground(theme.NeonRed).
+		Background(lipgloss.Color("236")).
+		Width(width).
+		Render(ansi.Truncate(text, width, "…"))
+}
+
+// dataMouse scrolls a tree or table with the wheel and selects the row
+// clicked.
+func (m Model) dataMouse(msg tea.Msg) Model {
+	switch msg := msg.(type) {
+	case tea.MouseWheelMsg:
+		h := m.dataHeight()
+		switch msg.Mouse().Button {
+		case tea.MouseWheelUp:
+			m.data.top = max(m.data.top-3, 0)
+		case tea.MouseWheelDown:
+			m.data.top = clampInt(m.data.top+3, 0, max(m.dataCount()-h, 0))
+		}
+		m.data.cursor = clampInt(m.data.cursor, m.data.top, m.data.top+h-1)
+	case tea.MouseClickMsg:
+		row := msg.Mouse().Y - 1 // Panel border
+		if m.data.table != nil {
+			row -= 2 // Header and rule
+		}
+		if row >= 0 && row < m.dataHeight() && m.data.top+row < m.dataCount() {
+			m.moveData(m.data.top + row)
+		}
+	}
+	return m
+}
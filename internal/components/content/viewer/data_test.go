@@ -0,0 +1,137 @@
+package viewer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var keyM = tea.KeyPressMsg{Code: 'm', Text: "m"}
+
+// dataModel opens a file with the given name and content in the rendered
+// view.
+func dataModel(t *testing.T, name, content string, height int) Model {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	m := New().SetSize(60, height).Focus()
+	m, _ = m.Update(loadFile(path, 0, false))
+	require.True(t, m.CanPreview())
+	return press(m, keyM)
+}
+
+func TestTreeView(t *testing.T) {
+	m := dataModel(t, "pod.json", `{
+  "metadata": {"name": "web"},
+  "spec": {
+    "containers": [
+      {"image": "nginx"}
+    ]
+  }
+}
+`, 10)
+	require.True(t, m.IsPreview())
+	view := ansi.Strip(m.View())
+	assert.Contains(t, view, "▾ metadata {1 key}")
+	assert.Contains(t, view, ".metadata · y:copy path")
+
+	// Down to the image, whose path shows in the status bar
+	m = press(m, keyDown, keyDown, keyDown, keyDown, keyDown)
+	assert.Contains(t, ansi.Strip(m.View()), ".spec.containers[0].image ·")
+	assert.Equal(t, 4, m.TopLine(), "positions are source lines")
+
+	// Left goes to the parent, then folds it
+	m = press(m, keyLeft, keyLeft)
+	view = ansi.Strip(m.View())
+	assert.Contains(t, view, "▸ [0] {1 key}")
+	assert.NotContains(t, view, "image")
+
+	m = press(m, tea.KeyPressMsg{Code: '-', Text: "-"})
+	view = ansi.Strip(m.View())
+	assert.Contains(t, view, "▸ spec {1 key}")
+	assert.Contains(t, view, " .spec ·", "the cursor moves to the folded top level")
+
+	m = press(m, tea.KeyPressMsg{Code: '+', Text: "+"})
+	assert.Contains(t, ansi.Strip(m.View()), "image")
+
+	// Jumps land on the row for a source line
+	m.GotoLine(3)
+	assert.Equal(t, 3, m.TopLine())
+
+	// Search matches rows, moving the cursor
+	m = press(m, tea.KeyPressMsg{Code: '/', Text: "/"})
+	m = typeText(m, "nginx")
+	m = press(m, keyEnter)
+	line, _ := m.CursorPosition()
+	assert.Equal(t, 4, line)
+
+	// Back to source
+	m = press(m, keyEsc, keyM)
+	assert.False(t, m.IsPreview())
+	assert.Contains(t, ansi.Strip(m.View()), `"metadata": {"name": "web"}`)
+}
+
+func TestTreeFallsBackOnParseError(t *testing.T) {
+	m := dataModel(t, "bad.json", "{\n  \"a\": 1\n  \"b\": 2\n}\n", 10)
+	assert.False(t, m.IsPreview())
+	view := ansi.Strip(m.View())
+	assert.Contains(t, view, `"b": 2`, "the source is shown")
+	assert.Contains(t, view, "Invalid JSON at line 3, column 3")
+	assert.Len(t, strings.Split(m.View(), "\n"), 10)
+
+	// Fixing the file shows the tree
+	m = press(m, tea.KeyPressMsg{Code: 'e', Text: "e"})
+	require.True(t, m.IsEditing())
+	m = press(m, keyDown, keyEnd)
+	m = typeText(m, ",")
+	m, cmd := m.Update(ctrl('s'))
+	m = run(m, cmd)
+	m = press(m, keyEsc)
+	assert.True(t, m.IsPreview())
+	assert.Contains(t, ansi.Strip(m.View()), "b: 2")
+}
+
+func TestTableView(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("city,population,country\n")
+	for i := 0; i < 20; i++ {
+		b.WriteString("city" + itoa(i) + "," + itoa((i*7)%20*1000) + ",land\n")
+	}
+	m := dataModel(t, "cities.csv", b.String(), 10)
+	require.True(t, m.IsPreview())
+
+	view := ansi.Strip(m.View())
+	assert.Contains(t, view, "city   │ population   │ country")
+	assert.Contains(t, view, "row 1/20 · column 1/3 city")
+
+	// The header stays while rows scroll
+	m = press(m, tea.KeyPressMsg{Code: 'G', Text: "G"})
+	view = ansi.Strip(m.View())
+	assert.Contains(t, view, "city   │ population   │ country")
+	assert.Contains(t, view, "city19")
+	assert.NotContains(t, view, "city0 ")
+	assert.Equal(t, 20, m.TopLine(), "the last record is on line 21")
+
+	// Sorting by population, descending; the same record stays selected
+	m = press(m, keyRight)
+	m = press(m, tea.KeyPressMsg{Code: 's', Text: "s"}, tea.KeyPressMsg{Code: 's', Text: "s"})
+	assert.Contains(t, ansi.Strip(m.View()), "row 7/20 · column 2/3 population")
+	m = press(m, tea.KeyPressMsg{Code: 'g', Text: "g"})
+	view = ansi.Strip(m.View())
+	assert.Contains(t, view, "population ▼")
+	rows := strings.Split(view, "\n")
+	assert.Contains(t, rows[2], "  18│ city17 │        19000")
+
+	// Narrow panes scroll columns
+	m = m.SetSize(30, 10)
+	m = press(m, keyRight)
+	view = ansi.Strip(m.View())
+	assert.Contains(t, view, "country")
+	assert.NotContains(t, view, "city ")
+}
@@ -0,0 +1,997 @@
+package viewer
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/alecthomas/chroma/v2"
+	"github.com/atotto/clipboard"
+	"github.com/avitaltamir/vibecommander/internal/filetype"
+	"github.com/avitaltamir/vibecommander/internal/selection"
+	"github.com/avitaltamir/vibecommander/internal/syntax"
+	"github.com/avitaltamir/vibecommander/internal/theme"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// ErrConflict is reported when saving a file that changed on disk since it
+// was loaded.
+var ErrConflict = errors.New("file changed on disk since it was opened")
+
+// tabWidth is how many columns a tab takes in edit mode.
+const tabWidth = 4
+
+// editContext is how many lines above the visible window are highlighted
+// with it, so constructs that start off-screen (e.g. block comments) still
+// color correctly without highlighting the whole file on every keystroke.
+const editContext = 200
+
+// change is one undoable edit: removed was replaced by inserted at start.
+type change struct {
+	start    selection.Position
+	removed  string
+	inserted string
+	cursor   selection.Position // Cursor before the change
+}
+
+// editor holds the buffer and state of edit mode. Columns count runes, so
+// multi-byte characters are never split.
+type editor struct {
+	lines  []string
+	cr     []bool // Whether each line ends in \r\n; nil when none does
+	cursor selection.Position
+	goal   int // Display column kept while moving up and down (-1 = none)
+	top    int // First visible line
+	left   int // First visible display column
+
+	undo  []change
+	redo  []change
+	saved int  // len(undo) at the last save; -1 when that state is unreachable
+	merge bool // Whether the next edit may join the last change
+
+	modTime time.Time // Modification time of the file when loaded or saved
+	lexer   chroma.Lexer
+	clip    string // Fallback when the system clipboard is unavailable
+
+	confirmDiscard bool // Esc was pressed once with unsaved changes
+	conflict       bool // The last save found the file changed on disk
+	message        string
+	messageErr     bool
+}
+
+// newEditor creates an editor for content.
+func newEditor(content string, modTime time.Time, lexer chroma.Lexer) editor {
+	lines := strings.Split(content, "\n")
+	var cr []bool
+	if strings.Contains(content, "\r\n") {
+		// Endings are kept per line, so saving a file that mixes them
+		// only changes the lines edited
+		cr = make([]bool, len(lines))
+		for i, line := range lines[:len(lines)-1] {
+			lines[i], cr[i] = strings.CutSuffix(line, "\r")
+		}
+	}
+	return editor{
+		lines:   lines,
+		cr:      cr,
+		goal:    -1,
+		modTime: modTime,
+		lexer:   lexer,
+	}
+}
+
+// String returns the buffer with the file's original line endings.
+func (e *editor) String() string {
+	if e.cr == nil {
+		return strings.Join(e.lines, "\n")
+	}
+	var b strings.Builder
+	for i, line := range e.lines {
+		if i > 0 {
+			if e.cr[i-1] {
+				b.WriteByte('\r')
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// modified reports whether the buffer differs from the last save.
+func (e *editor) modified() bool {
+	return len(e.undo) != e.saved
+}
+
+// text returns the text between start and end.
+func (e *editor) text(start, end selection.Position) string {
+	first := e.lines[start.Line]
+	if start.Line == end.Line {
+		return first[byteIndex(first, start.Column):byteIndex(first, end.Column)]
+	}
+
+	var b strings.Builder
+	b.WriteString(first[byteIndex(first, start.Column):])
+	for i := start.Line + 1; i < end.Line; i++ {
+		b.WriteByte('\n')
+		b.WriteString(e.lines[i])
+	}
+	last := e.lines[end.Line]
+	b.WriteByte('\n')
+	b.WriteString(last[:byteIndex(last, end.Column)])
+	return b.String()
+}
+
+// replace swaps the text between start and end for text and returns the
+// position just after the inserted text.
+func (e *editor) replace(start, end selection.Position, text string) selection.Position {
+	first := e.lines[start.Line]
+	last := e.lines[end.Line]
+	prefix := first[:byteIndex(first, start.Column)]
+	suffix := last[byteIndex(last, end.Column):]
+
+	inserted := strings.Split(text, "\n")
+	after := endOf(start, text)
+	inserted[0] = prefix + inserted[0]
+	inserted[len(inserted)-1] += suffix
+
+	// New line breaks take the ending of the line they're typed in; the last
+	// line keeps the one it had
+	var cr []bool
+	if e.cr != nil {
+		cr = make([]bool, len(inserted))
+		for i := range cr {
+			cr[i] = e.cr[start.Line]
+		}
+		cr[len(cr)-1] = e.cr[end.Line]
+	}
+
+	// Only reallocate the line slice when the line count changes
+	removed := end.Line - start.Line + 1
+	if len(inserted) == removed {
+		copy(e.lines[start.Line:], inserted)
+		if cr != nil {
+			copy(e.cr[start.Line:], cr)
+		}
+		return after
+	}
+	lines := make([]string, 0, len(e.lines)-removed+len(inserted))
+	lines = append(lines, e.lines[:start.Line]...)
+	lines = append(lines, inserted...)
+	lines = append(lines, e.lines[end.Line+1:]...)
+	e.lines = lines
+	if cr != nil {
+		e.cr = slices.Concat(e.cr[:start.Line], cr, e.cr[end.Line+1:])
+	}
+	return after
+}
+
+// apply makes an undoable edit and moves the cursor after it. Consecutive
+// typing and deleting join the previous change, so undo works in runs
+// rather than one character at a time.
+func (e *editor) apply(start, end selection.Position, text string) {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+	removed := e.text(start, end)
+	if removed == "" && text == "" {
+		return
+	}
+
+	c := change{start: start, removed: removed, inserted: text, cursor: e.cursor}
+	e.cursor = e.replace(start, end, text)
+	e.goal = -1
+
+	e.redo = nil
+	if e.saved > len(e.undo) {
+		e.saved = -1
+	}
+	// Never join a change the last save includes
+	if e.merge && len(e.undo) > e.saved && e.join(c) {
+		return
+	}
+	e.undo = append(e.undo, c)
+	e.merge = !strings.Contains(text, "\n")
+}
+
+// join merges c into the last change when it continues it.
+func (e *editor) join(c change) bool {
+	last := &e.undo[len(e.undo)-1]
+	switch {
+	case c.removed == "" && !strings.Contains(c.inserted, "\n") &&
+		endOf(last.start, last.inserted) == c.start:
+		// Typing on
+		last.inserted += c.inserted
+	case c.inserted == "" && last.inserted == "" && endOf(c.start, c.removed) == last.start:
+		// Backspacing on
+		last.start = c.start
+		last.removed = c.removed + last.removed
+	case c.inserted == "" && last.inserted == "" && c.start == last.start:
+		// Deleting forward
+		last.removed += c.removed
+	default:
+		return false
+	}
+	return true
+}
+
+// undoChange reverts the last change.
+func (e *editor) undoChange() bool {
+	if len(e.undo) == 0 {
+		return false
+	}
+	c := e.undo[len(e.undo)-1]
+	e.undo = e.undo[:len(e.undo)-1]
+	e.replace(c.start, endOf(c.start, c.inserted), c.removed)
+	e.redo = append(e.redo, c)
+	e.cursor = c.cursor
+	e.goal = -1
+	e.merge = false
+	return true
+}
+
+// redoChange re-applies the last undone change.
+func (e *editor) redoChange() bool {
+	if len(e.redo) == 0 {
+		return false
+	}
+	c := e.redo[len(e.redo)-1]
+	e.redo = e.redo[:len(e.redo)-1]
+	e.cursor = e.replace(c.start, endOf(c.start, c.removed), c.inserted)
+	e.undo = append(e.undo, c)
+	e.goal = -1
+	e.merge = false
+	return true
+}
+
+// lineLen returns the length of line i in runes.
+func (e *editor) lineLen(i int) int {
+	return utf8.RuneCountInString(e.lines[i])
+}
+
+// end returns the position after the last character.
+func (e *editor) end() selection.Position {
+	last := len(e.lines) - 1
+	return selection.Position{Line: last, Column: e.lineLen(last)}
+}
+
+// prev returns the position one character before p, skipping combining
+// marks so the cursor never lands inside a character.
+func (e *editor) prev(p selection.Position) selection.Position {
+	if p.Column == 0 {
+		if p.Line == 0 {
+			return p
+		}
+		return selection.Position{Line: p.Line - 1, Column: e.lineLen(p.Line - 1)}
+	}
+	runes := []rune(e.lines[p.Line])
+	p.Column--
+	for p.Column > 0 && runeWidth(runes[p.Column]) == 0 {
+		p.Column--
+	}
+	return p
+}
+
+// next returns the position one character after p.
+func (e *editor) next(p selection.Position) selection.Position {
+	runes := []rune(e.lines[p.Line])
+	if p.Column >= len(runes) {
+		if p.Line == len(e.lines)-1 {
+			return p
+		}
+		return selection.Position{Line: p.Line + 1}
+	}
+	p.Column++
+	for p.Column < len(runes) && runeWidth(runes[p.Column]) == 0 {
+		p.Column++
+	}
+	return p
+}
+
+// wordLeft returns the start of the word before p.
+func (e *editor) wordLeft(p selection.Position) selection.Position {
+	if p.Column == 0 {
+		return e.prev(p)
+	}
+	runes := []rune(e.lines[p.Line])
+	col := p.Column
+	for col > 0 && unicode.IsSpace(runes[col-1]) {
+		col--
+	}
+	if col > 0 {
+		word := isWordRune(runes[col-1])
+		for col > 0 && !unicode.IsSpace(runes[col-1]) && isWordRune(runes[col-1]) == word {
+			col--
+		}
+	}
+	return selection.Position{Line: p.Line, Column: col}
+}
+
+// wordRight returns the end of the word after p.
+func (e *editor) wordRight(p selection.Position) selection.Position {
+	runes := []rune(e.lines[p.Line])
+	if p.Column >= len(runes) {
+		return e.next(p)
+	}
+	col := p.Column
+	for col < len(runes) && unicode.IsSpace(runes[col]) {
+		col++
+	}
+	if col < len(runes) {
+		word := isWordRune(runes[col])
+		for col < len(runes) && !unicode.IsSpace(runes[col]) && isWordRune(runes[col]) == word {
+			col++
+		}
+	}
+	return selection.Position{Line: p.Line, Column: col}
+}
+
+// home returns the first non-blank column of the line, or column 0 when p
+// is already there.
+func (e *editor) home(p selection.Position) selection.Position {
+	indent := utf8.RuneCountInString(leadingSpace(e.lines[p.Line]))
+	if p.Column == indent {
+		indent = 0
+	}
+	return selection.Position{Line: p.Line, Column: indent}
+}
+
+// vertical moves the cursor n lines, keeping its display column.
+func (e *editor) vertical(n int) {
+	if e.goal < 0 {
+		e.goal = displayCol(e.lines[e.cursor.Line], e.cursor.Column)
+	}
+	line := clampInt(e.cursor.Line+n, 0, len(e.lines)-1)
+	e.cursor = selection.Position{Line: line, Column: columnAt(e.lines[line], e.goal)}
+}
+
+// scrollToCursor scrolls so the cursor is inside a rows x cols window.
+func (e *editor) scrollToCursor(rows, cols int) {
+	if rows < 1 {
+		rows = 1
+	}
+	if e.cursor.Line < e.top {
+		e.top = e.cursor.Line
+	} else if e.cursor.Line >= e.top+rows {
+		e.top = e.cursor.Line - rows + 1
+	}
+
+	if cols < 1 {
+		cols = 1
+	}
+	x := displayCol(e.lines[e.cursor.Line], e.cursor.Column)
+	if x < e.left {
+		e.left = x
+	} else if x >= e.left+cols {
+		e.left = x - cols + 1
+	}
+}
+
+// highlight returns the highlighted lines from up to to (exclusive), with
+// tabs expanded.
+func (e *editor) highlight(from, to int) []string {
+	start := from - editContext
+	if start < 0 {
+		start = 0
+	}
+	out := strings.Split(syntax.Highlight(e.lexer, strings.Join(e.lines[start:to], "\n")), "\n")
+
+	lines := make([]string, 0, to-from)
+	for i := from; i < to; i++ {
+		line := e.lines[i]
+		if i-start < len(out) {
+			line = out[i-start]
+		}
+		lines = append(lines, strings.ReplaceAll(line, "\t", strings.Repeat(" ", tabWidth)))
+	}
+	return lines
+}
+
+// saveFile writes text to path in its encoding unless the file's
+// modification time no longer matches modTime; force skips that check.
+func saveFile(path, text string, enc filetype.Encoding, modTime time.Time, undo int, force bool) FileSavedMsg {
+	info, err := os.Stat(path)
+	if err != nil {
+		return FileSavedMsg{Path: path, Err: err}
+	}
+	if !force && !info.ModTime().Equal(modTime) {
+		return FileSavedMsg{Path: path, Err: ErrConflict}
+	}
+
+	// Write in place to keep the file's inode, permissions and links
+	if err := os.WriteFile(path, enc.Encode(text), info.Mode().Perm()); err != nil {
+		return FileSavedMsg{Path: path, Err: err}
+	}
+	info, err = os.Stat(path)
+	if err != nil {
+		return FileSavedMsg{Path: path, Err: err}
+	}
+	return FileSavedMsg{Path: path, Content: text, ModTime: info.ModTime(), Undo: undo}
+}
+
+// StartEdit switches to edit mode with the cursor on the first visible line.
+func (m *Model) StartEdit() bool {
+	if m.editing || m.path == "" || m.err != nil {
+		return false
+	}
+	if m.streaming {
+		m.stream.message = "too large to edit here - Alt+E opens $EDITOR"
+		return false
+	}
+	if m.binary {
+		m.hex.message = "binary files can't be edited"
+		return false
+	}
+
+	m.clearSearch()
+	m.selection.ClearSelection()
+	m.edit = newEditor(m.content, m.modTime, syntax.LexerFor(m.path, m.content))
+	line := clampInt(m.TopLine(), 0, len(m.edit.lines)-1)
+	m.edit.cursor = selection.Position{Line: line}
+	m.edit.top = line
+	m.editing = true
+	return true
+}
+
+// stopEdit leaves edit mode. Unsaved changes are dropped.
+func (m *Model) stopEdit() {
+	top := m.edit.top
+	m.editing = false
+	m.edit = editor{}
+	m.selection.ClearSelection()
+	m.parseData() // The rendered view shows what was saved
+	m.loadOutline()
+	m.viewport.SetContent(m.renderContent())
+	m.GotoLine(top)
+}
+
+// IsEditing reports whether the viewer is in edit mode.
+func (m Model) IsEditing() bool {
+	return m.editing
+}
+
+// Modified reports whether there are unsaved edits.
+func (m Model) Modified() bool {
+	return m.editing && m.edit.modified()
+}
+
+// editSize returns the rows and text columns available in edit mode.
+func (m Model) editSize() (rows, cols int) {
+	w, h := m.Size()
+	return h - 1, w - lineNumberWidth // Status bar and line numbers
+}
+
+// updateEdit handles a key press in edit mode.
+func (m Model) updateEdit(msg tea.KeyPressMsg) (Model, tea.Cmd) {
+	e := &m.edit
+	e.message = ""
+	k := msg.String()
+
+	if k != "esc" {
+		e.confirmDiscard = false
+	}
+
+	switch k {
+	case "esc":
+		switch {
+		case m.selection.HasVisibleSelection():
+			m.selection.ClearSelection()
+		case e.modified() && !e.confirmDiscard:
+			e.confirmDiscard = true
+			e.setMessage("Unsaved changes - Esc again to discard, Ctrl+S to save", true)
+		default:
+			m.stopEdit()
+		}
+		return m, nil
+
+	case "ctrl+s":
+		force := e.conflict
+		e.conflict = false
+		e.merge = false // Later typing must not join a change being saved
+		path, text, enc, modTime, undo := m.path, e.String(), m.encoding, e.modTime, len(e.undo)
+		return m, func() tea.Msg {
+			return saveFile(path, text, enc, modTime, undo, force)
+		}
+
+	case "ctrl+z":
+		if !e.undoChange() {
+			e.setMessage("Nothing to undo", false)
+		}
+		m.selection.ClearSelection()
+
+	case "ctrl+y", "ctrl+shift+z":
+		if !e.redoChange() {
+			e.setMessage("Nothing to redo", false)
+		}
+		m.selection.ClearSelection()
+
+	case "ctrl+a":
+		e.cursor = e.end()
+		m.selection.SetRange(selection.Position{}, e.cursor)
+		e.merge = false
+
+	case "ctrl+c":
+		if m.selection.HasSelection() {
+			e.copy(m.selectedText())
+		}
+
+	case "ctrl+x":
+		start, end := m.selectionOrLine()
+		e.copy(e.text(start, end))
+		e.merge = false
+		e.apply(start, end, "")
+		m.selection.ClearSelection()
+
+	case "ctrl+v":
+		text, err := clipboard.ReadAll()
+		if err != nil || text == "" {
+			text = e.clip
+		}
+		m.insert(text)
+		e.merge = false
+
+	case "enter":
+		indent := leadingSpace(e.lines[e.cursor.Line])
+		if n := utf8.RuneCountInString(indent); n > e.cursor.Column {
+			indent = string([]rune(indent)[:e.cursor.Column])
+		}
+		m.insert("\n" + indent)
+
+	case "tab":
+		m.insert("\t")
+
+	case "backspace":
+		m.deleteTo(e.prev(e.cursor))
+
+	case "alt+backspace", "ctrl+w":
+		m.deleteTo(e.wordLeft(e.cursor))
+
+	case "delete", "ctrl+d":
+		m.deleteTo(e.next(e.cursor))
+
+	default:
+		if !m.moveCursor(k) {
+			key := msg.Key()
+			if key.Text == "" || key.Mod&(tea.ModCtrl|tea.ModAlt) != 0 {
+				return m, nil
+			}
+			m.insert(key.Text)
+		}
+	}
+
+	rows, cols := m.editSize()
+	e.scrollToCursor(rows, cols)
+	return m, nil
+}
+
+// moveCursor handles the motion keys; with shift they extend the selection.
+func (m *Model) moveCursor(k string) bool {
+	e := &m.edit
+	extend := strings.Contains(k, "shift+")
+	base := strings.Replace(k, "shift+", "", 1)
+	rows, _ := m.editSize()
+
+	from := e.cursor
+	switch base {
+	case "left":
+		e.cursor = e.prev(e.cursor)
+	case "right":
+		e.cursor = e.next(e.cursor)
+	case "ctrl+left", "alt+left":
+		e.cursor = e.wordLeft(e.cursor)
+	case "ctrl+right", "alt+right":
+		e.cursor = e.wordRight(e.cursor)
+	case "up":
+		e.vertical(-1)
+	case "down":
+		e.vertical(1)
+	case "pgup":
+		e.vertical(-rows)
+	case "pgdown":
+		e.vertical(rows)
+	case "home":
+		e.cursor = e.home(e.cursor)
+	case "end":
+		e.cursor.Column = e.lineLen(e.cursor.Line)
+	case "ctrl+home":
+		e.cursor = selection.Position{}
+	case "ctrl+end":
+		e.cursor = e.end()
+	default:
+		return false
+	}
+
+	if base != "up" && base != "down" && base != "pgup" && base != "pgdown" {
+		e.goal = -1
+	}
+	e.merge = false
+
+	if !extend {
+		m.selection.ClearSelection()
+		return true
+	}
+	anchor := from
+	if m.selection.HasSelection() {
+		anchor = m.selection.Selection.Start
+	}
+	m.selection.SetRange(anchor, e.cursor)
+	return true
+}
+
+// insert replaces the selection, if any, with text.
+func (m *Model) insert(text string) {
+	if text == "" {
+		return
+	}
+	start, end := m.edit.cursor, m.edit.cursor
+	if m.selection.HasSelection() {
+		start, end = m.selection.Range()
+		m.edit.merge = false
+	}
+	m.edit.apply(start, end, text)
+	m.selection.ClearSelection()
+}
+
+// deleteTo deletes the selection, or the text between the cursor and p.
+func (m *Model) deleteTo(p selection.Position) {
+	start, end := m.edit.cursor, p
+	if m.selection.HasSelection() {
+		start, end = m.selection.Range()
+		m.edit.merge = false
+	} else if before(end, start) {
+		start, end = end, start
+	}
+	m.edit.apply(start, end, "")
+	m.selection.ClearSelection()
+}
+
+// selectionOrLine returns the selected range, or the cursor's whole line
+// (with its line break) when nothing is selected.
+func (m Model) selectionOrLine() (selection.Position, selection.Position) {
+	if m.selection.HasSelection() {
+		return m.selection.Range()
+	}
+	e := &m.edit
+	line := e.cursor.Line
+	if line < len(e.lines)-1 {
+		return selection.Position{Line: line}, selection.Position{Line: line + 1}
+	}
+	if line > 0 {
+		// Last line - take the break before it instead
+		return selection.Position{Line: line - 1, Column: e.lineLen(line - 1)}, selection.Position{Line: line, Column: e.lineLen(line)}
+	}
+	return selection.Position{}, selection.Position{Column: e.lineLen(0)}
+}
+
+// selectedText returns the selected part of the buffer, clamped in case the
+// buffer shrank since the selection was made.
+func (m Model) selectedText() string {
+	start, end := m.selection.Range()
+	return m.edit.text(m.edit.clamp(start), m.edit.clamp(end))
+}
+
+// copy puts text on the clipboard.
+func (e *editor) copy(text string) {
+	if text == "" {
+		return
+	}
+	e.clip = text
+	_ = clipboard.WriteAll(text)
+}
+
+// clamp limits p to the buffer.
+func (e *editor) clamp(p selection.Position) selection.Position {
+	p.Line = clampInt(p.Line, 0, len(e.lines)-1)
+	p.Column = clampInt(p.Column, 0, e.lineLen(p.Line))
+	return p
+}
+
+// setMessage shows a message in the edit status bar.
+func (e *editor) setMessage(text string, isError bool) {
+	e.message = text
+	e.messageErr = isError
+}
+
+// handleSaved records the result of a save.
+func (m *Model) handleSaved(msg FileSavedMsg) {
+	if msg.Path != m.path {
+		return
+	}
+	if msg.Err == nil {
+		m.content = msg.Content
+		m.modTime = msg.ModTime
+	}
+	if !m.editing {
+		return
+	}
+
+	e := &m.edit
+	switch {
+	case errors.Is(msg.Err, ErrConflict):
+		e.conflict = true
+		e.setMessage("File changed on disk - Ctrl+S again to overwrite it", true)
+	case msg.Err != nil:
+		e.setMessage("Save failed: "+msg.Err.Error(), true)
+	default:
+		e.modTime = msg.ModTime
+		e.saved = msg.Undo
+		e.setMessage("Saved", false)
+	}
+}
+
+// editMouse handles mouse input in edit mode.
+func (m Model) editMouse(msg tea.Msg) Model {
+	e := &m.edit
+	rows, cols := m.editSize()
+
+	switch msg := msg.(type) {
+	case tea.MouseWheelMsg:
+		switch msg.Mouse().Button {
+		case tea.MouseWheelUp:
+			e.top -= 3
+		case tea.MouseWheelDown:
+			e.top += 3
+		}
+		e.top = clampInt(e.top, 0, max(len(e.lines)-rows, 0))
+		return m
+
+	case tea.MouseClickMsg:
+		e.cursor = m.editPosition(msg.Mouse().X, msg.Mouse().Y)
+		m.selection.StartSelection(e.cursor.Line, e.cursor.Column)
+
+	case tea.MouseMotionMsg:
+		if !m.selection.Selection.Active {
+			return m
+		}
+		e.cursor = m.editPosition(msg.Mouse().X, msg.Mouse().Y)
+		m.selection.UpdateSelection(e.cursor.Line, e.cursor.Column)
+
+	case tea.MouseReleaseMsg:
+		if !m.selection.Selection.Active {
+			return m
+		}
+		e.cursor = m.editPosition(msg.Mouse().X, msg.Mouse().Y)
+		m.selection.UpdateSelection(e.cursor.Line, e.cursor.Column)
+		m.selection.EndSelection()
+	}
+
+	e.goal = -1
+	e.merge = false
+	e.scrollToCursor(rows, cols)
+	return m
+}
+
+// editPosition converts screen coordinates to a buffer position, accounting
+// for the panel border, line numbers and scrolling.
+func (m Model) editPosition(x, y int) selection.Position {
+	e := &m.edit
+	line := clampInt(y-1+e.top, 0, len(e.lines)-1)
+	col := columnAt(e.lines[line], x-1-lineNumberWidth+e.left)
+	return selection.Position{Line: line, Column: col}
+}
+
+// renderEdit renders the visible part of the buffer with the cursor, the
+// selection and a status bar.
+func (m Model) renderEdit() string {
+	e := &m.edit
+	w, _ := m.Size()
+	rows, cols := m.editSize()
+
+	end := e.top + rows
+	if end > len(e.lines) {
+		end = len(e.lines)
+	}
+	highlighted := e.highlight(e.top, end)
+
+	lineNumStyle := theme.DiffLineNumberStyle.UnsetWidth() // padLeft pads; a fixed width wraps long numbers
+	cursorLineNumStyle := lipgloss.NewStyle().Foreground(theme.CyberCyan).Bold(true)
+	sep := lipgloss.NewStyle().Foreground(theme.DimPurple).Render(" │ ")
+	cursorStyle := lipgloss.NewStyle().Background(theme.CyberCyan).Foreground(lipgloss.Color("0"))
+
+	var selStart, selEnd selection.Position
+	hasSelection := m.selection.HasVisibleSelection()
+	if hasSelection {
+		selStart, selEnd = m.selection.Range()
+	}
+
+	var b strings.Builder
+	for i := e.top; i < end; i++ {
+		raw := e.lines[i]
+		line := highlighted[i-e.top]
+		width := displayCol(raw, e.lineLen(i))
+
+		if hasSelection && i >= selStart.Line && i <= selEnd.Line {
+			from, to := 0, width+1 // Include the line break
+			if i == selStart.Line {
+				from = displayCol(raw, selStart.Column)
+			}
+			if i == selEnd.Line {
+				to = displayCol(raw, selEnd.Column)
+			}
+			if to > width {
+				line += " "
+			}
+			line = overlayRange(line, from, to)
+		}
+
+		numStyle := lineNumStyle
+		if i == e.cursor.Line {
+			numStyle = cursorLineNumStyle
+			if m.Focused() {
+				x := displayCol(raw, e.cursor.Column)
+				cell := " "
+				if e.cursor.Column < e.lineLen(i) {
+					r := []rune(raw)[e.cursor.Column]
+					cell = ansi.Strip(ansi.Cut(line, x, x+max(runeWidth(r), 1)))
+				}
+				line = ansi.Cut(line, 0, x) + cursorStyle.Render(cell) + ansi.Cut(line, x+ansi.StringWidth(cell), ansi.StringWidth(line))
+			}
+		}
+
+		b.WriteString(numStyle.Render(padLeft(i+1, 4)))
+		b.WriteString(sep)
+		b.WriteString(ansi.Cut(line, e.left, e.left+cols))
+		b.WriteString("\n")
+	}
+	for i := end - e.top; i < rows; i++ {
+		b.WriteString("\n")
+	}
+
+	b.WriteString(m.renderEditBar(w))
+	return b.String()
+}
+
+// renderEditBar renders the edit mode status bar.
+func (m Model) renderEditBar(width int) string {
+	e := &m.edit
+
+	tag := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("0")).
+		Background(theme.CyberCyan).
+		Bold(true).
+		Render(" EDIT ")
+
+	pos := " Ln " + itoa(e.cursor.Line+1) + ", Col " + itoa(displayCol(e.lines[e.cursor.Line], e.cursor.Column)+1)
+	if e.modified() {
+		pos += lipgloss.NewStyle().Foreground(theme.ElectricYellow).Render("  [+]")
+	}
+
+	var message string
+	if e.message != "" {
+		color := theme.MatrixGreen
+		if e.messageErr {
+			color = theme.NeonRed
+		}
+		message = "  " + lipgloss.NewStyle().Foreground(color).Render(e.message)
+	}
+
+	return lipgloss.NewStyle().
+		Background(lipgloss.Color("236")).
+		Width(width).
+		Render(ansi.Truncate(tag+pos+message, width, "…"))
+}
+
+// overlayRange paints the selection background over display columns
+// [from, to) of a highlighted line, keeping its syntax colors.
+func overlayRange(line string, from, to int) string {
+	lineWidth := ansi.StringWidth(line)
+	if from < 0 {
+		from = 0
+	}
+	if to > lineWidth {
+		to = lineWidth
+	}
+	if from >= to {
+		return line
+	}
+
+	var result strings.Builder
+
+	// Part before selection (preserves syntax highlighting)
+	if from > 0 {
+		result.WriteString(ansi.Cut(line, 0, from))
+	}
+
+	// Selected part - apply selection background on top of syntax colors
+	// We need to handle ANSI reset sequences inside the selected part,
+	// as they would clear our background. Replace resets with reset+background.
+	selectedPart := ansi.Cut(line, from, to)
+
+	// Selection background color from theme: #3D2D5E = rgb(61, 45, 94)
+	// ANSI 24-bit background: \x1b[48;2;R;G;Bm
+	selBg := "\x1b[48;2;61;45;94m"
+	selectedPart = strings.ReplaceAll(selectedPart, "\x1b[0m", "\x1b[0m"+selBg)
+	selectedPart = strings.ReplaceAll(selectedPart, "\x1b[m", "\x1b[m"+selBg)
+	result.WriteString(selection.SelectionStyle().Render(selectedPart))
+
+	// Part after selection (preserves syntax highlighting)
+	if to < lineWidth {
+		result.WriteString(ansi.Cut(line, to, lineWidth))
+	}
+
+	return result.String()
+}
+
+// endOf returns the position after text inserted at start.
+func endOf(start selection.Position, text string) selection.Position {
+	n := strings.Count(text, "\n")
+	if n == 0 {
+		return selection.Position{Line: start.Line, Column: start.Column + utf8.RuneCountInString(text)}
+	}
+	last := text[strings.LastIndexByte(text, '\n')+1:]
+	return selection.Position{Line: start.Line + n, Column: utf8.RuneCountInString(last)}
+}
+
+// before reports whether a comes before b.
+func before(a, b selection.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+}
+
+// byteIndex returns the byte offset of rune column col in s.
+func byteIndex(s string, col int) int {
+	i := 0
+	for n := 0; n < col && i < len(s); n++ {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return i
+}
+
+// runeWidth returns how many columns r takes on screen.
+func runeWidth(r rune) int {
+	if r == '\t' {
+		return tabWidth
+	}
+	return ansi.StringWidth(string(r))
+}
+
+// displayCol returns the screen column of rune column col in s.
+func displayCol(s string, col int) int {
+	x := 0
+	for i, r := range []rune(s) {
+		if i >= col {
+			break
+		}
+		x += runeWidth(r)
+	}
+	return x
+}
+
+// columnAt returns the rune column shown at screen column x of s.
+func columnAt(s string, x int) int {
+	col, width := 0, 0
+	for _, r := range s {
+		w := runeWidth(r)
+		if width+w > x && w > 0 {
+			break
+		}
+		width += w
+		col++
+	}
+	return col
+}
+
+// leadingSpace returns the indentation of s.
+func leadingSpace(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " \t"))]
+}
+
+// isWordRune reports whether r is part of an identifier.
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// clampInt restricts v to the range [lo, hi].
+func clampInt(v, lo, hi int) int {
+	if v > hi {
+		v = hi
+	}
+	if v < lo {
+		v = lo
+	}
+	return v
+}
@@ -0,0 +1,260 @@
+package viewer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/avitaltamir/vibecommander/internal/filetype"
+	"github.com/avitaltamir/vibecommander/internal/selection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// editModel loads content from a temp file and enters edit mode.
+func editModel(t *testing.T, content string) (Model, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "file.txt")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	m := New().SetSize(80, 20).Focus()
+	m, _ = m.Update(EditFileAt(path, 0)())
+	require.True(t, m.IsEditing())
+	return m, path
+}
+
+// press sends key presses to the model.
+func press(m Model, keys ...tea.KeyPressMsg) Model {
+	for _, k := range keys {
+		m, _ = m.Update(k)
+	}
+	return m
+}
+
+// typeText sends each rune of s as a key press.
+func typeText(m Model, s string) Model {
+	for _, r := range s {
+		m, _ = m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+	return m
+}
+
+var (
+	keyLeft      = tea.KeyPressMsg{Code: tea.KeyLeft}
+	keyRight     = tea.KeyPressMsg{Code: tea.KeyRight}
+	keyDown      = tea.KeyPressMsg{Code: tea.KeyDown}
+	keyEnd       = tea.KeyPressMsg{Code: tea.KeyEnd}
+	keyEnter     = tea.KeyPressMsg{Code: tea.KeyEnter}
+	keyBackspace = tea.KeyPressMsg{Code: tea.KeyBackspace}
+	keyEsc       = tea.KeyPressMsg{Code: tea.KeyEscape}
+	keyShiftEnd  = tea.KeyPressMsg{Code: tea.KeyEnd, Mod: tea.ModShift}
+)
+
+func ctrl(r rune) tea.KeyPressMsg {
+	return tea.KeyPressMsg{Code: r, Mod: tea.ModCtrl}
+}
+
+func TestEditUnicode(t *testing.T) {
+	m, _ := editModel(t, "héllo 世界\nnext")
+
+	m = press(m, keyEnd)
+	assert.Equal(t, selection.Position{Line: 0, Column: 8}, m.edit.cursor)
+
+	m = typeText(m, "!")
+	m = press(m, keyLeft, keyLeft, keyBackspace)
+	assert.Equal(t, "héllo 界!\nnext", m.edit.String())
+
+	// Backspace at the start of a line joins it to the previous one
+	m = press(m, keyDown, tea.KeyPressMsg{Code: tea.KeyHome}, keyBackspace)
+	assert.Equal(t, "héllo 界!next", m.edit.String())
+	assert.Equal(t, selection.Position{Line: 0, Column: 8}, m.edit.cursor)
+
+	t.Run("cursor skips combining marks", func(t *testing.T) {
+		m, _ := editModel(t, "e\u0301x")
+		m = press(m, keyRight)
+		assert.Equal(t, 2, m.edit.cursor.Column)
+		m = press(m, keyLeft)
+		assert.Equal(t, 0, m.edit.cursor.Column)
+	})
+}
+
+func TestEditUndoRedo(t *testing.T) {
+	m, _ := editModel(t, "one")
+	m = press(m, keyEnd)
+	m = typeText(m, " two")
+	m = press(m, keyEnter)
+	m = typeText(m, "three")
+	assert.Equal(t, "one two\nthree", m.edit.String())
+	assert.True(t, m.Modified())
+
+	// Typed runs undo as a whole
+	m = press(m, ctrl('z'))
+	assert.Equal(t, "one two\n", m.edit.String())
+	m = press(m, ctrl('z'), ctrl('z'))
+	assert.Equal(t, "one", m.edit.String())
+	assert.False(t, m.Modified())
+	assert.Equal(t, selection.Position{Line: 0, Column: 3}, m.edit.cursor)
+
+	m = press(m, ctrl('y'), ctrl('y'), ctrl('y'))
+	assert.Equal(t, "one two\nthree", m.edit.String())
+	assert.Equal(t, selection.Position{Line: 1, Column: 5}, m.edit.cursor)
+
+	t.Run("new edit drops redo", func(t *testing.T) {
+		m := press(m, ctrl('z'))
+		m = typeText(m, "x")
+		m = press(m, ctrl('y'))
+		assert.Equal(t, "one two\nx", m.edit.String())
+	})
+
+	t.Run("enter keeps indentation", func(t *testing.T) {
+		m, _ := editModel(t, "\tif x {")
+		m = press(m, keyEnd, keyEnter)
+		assert.Equal(t, "\tif x {\n\t", m.edit.String())
+	})
+}
+
+func TestEditCutPaste(t *testing.T) {
+	m, _ := editModel(t, "alpha\nbeta")
+
+	// Select "alpha" with shift and cut it
+	m = press(m, keyShiftEnd)
+	require.True(t, m.selection.HasSelection())
+	m = press(m, ctrl('x'))
+	assert.Equal(t, "\nbeta", m.edit.String())
+	assert.False(t, m.selection.HasSelection())
+
+	m = press(m, keyDown, keyEnd, ctrl('v'))
+	assert.Equal(t, "\nbetaalpha", m.edit.String())
+
+	t.Run("typing replaces the selection", func(t *testing.T) {
+		m := press(m, tea.KeyPressMsg{Code: tea.KeyLeft, Mod: tea.ModShift}, tea.KeyPressMsg{Code: tea.KeyLeft, Mod: tea.ModShift})
+		m = typeText(m, "Z")
+		assert.Equal(t, "\nbetaalpZ", m.edit.String())
+	})
+
+	t.Run("cut without a selection takes the line", func(t *testing.T) {
+		m, _ := editModel(t, "a\nb\nc")
+		m = press(m, keyDown, ctrl('x'))
+		assert.Equal(t, "a\nc", m.edit.String())
+		assert.Equal(t, "b\n", m.edit.clip)
+	})
+
+	t.Run("bracketed paste", func(t *testing.T) {
+		m, _ := editModel(t, "x")
+		m, _ = m.Update(tea.PasteMsg{Content: "1\r\n2"})
+		assert.Equal(t, "1\n2x", m.edit.String())
+	})
+}
+
+func TestEditSave(t *testing.T) {
+	m, path := editModel(t, "a\r\nb\r\n")
+	m = typeText(m, "x")
+
+	var cmd tea.Cmd
+	m, cmd = m.Update(ctrl('s'))
+	require.NotNil(t, cmd)
+	m, _ = m.Update(cmd())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "xa\r\nb\r\n", string(data), "line endings are kept")
+	assert.False(t, m.Modified())
+	assert.Equal(t, "xa\r\nb\r\n", m.Content())
+
+	t.Run("conflict when changed on disk", func(t *testing.T) {
+		m := typeText(m, "y")
+		require.NoError(t, os.WriteFile(path, []byte("external"), 0644))
+		future := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(path, future, future))
+
+		m, cmd := m.Update(ctrl('s'))
+		msg := cmd().(FileSavedMsg)
+		assert.ErrorIs(t, msg.Err, ErrConflict)
+		m, _ = m.Update(msg)
+		assert.True(t, m.Modified())
+
+		data, _ := os.ReadFile(path)
+		assert.Equal(t, "external", string(data))
+
+		// Saving again overwrites
+		m, cmd = m.Update(ctrl('s'))
+		m, _ = m.Update(cmd())
+		assert.False(t, m.Modified())
+		data, _ = os.ReadFile(path)
+		assert.Equal(t, "xya\r\nb\r\n", string(data))
+	})
+}
+
+func TestEditMixedLineEndings(t *testing.T) {
+	m, path := editModel(t, "a\r\nb\nc\r\n")
+	m = typeText(m, "x")
+	m, _ = m.Update(keyDown)
+	m, _ = m.Update(keyEnter) // Splits an LF line
+	assert.Equal(t, "xa\r\nb\n\nc\r\n", m.edit.String())
+	m, _ = m.Update(keyDown)
+	m, _ = m.Update(keyBackspace) // Joins the new line to a CRLF one
+	assert.Equal(t, "xa\r\nb\nc\r\n", m.edit.String())
+
+	m, cmd := m.Update(ctrl('s'))
+	require.NotNil(t, cmd)
+	m, _ = m.Update(cmd())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "xa\r\nb\nc\r\n", string(data), "only the edited line changes")
+	assert.False(t, m.Modified())
+}
+
+func TestEditUTF16(t *testing.T) {
+	m, path := editModel(t, string(filetype.UTF16LE.Encode("héllo\n")))
+	assert.Equal(t, "héllo\n", m.Content())
+
+	m = typeText(m, "x")
+	m, cmd := m.Update(ctrl('s'))
+	require.NotNil(t, cmd)
+	m, _ = m.Update(cmd())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, filetype.UTF16LE.Encode("xhéllo\n"), data, "saved as UTF-16")
+	assert.False(t, m.Modified())
+}
+
+func TestEditExit(t *testing.T) {
+	m, _ := editModel(t, "text")
+	m = typeText(m, "new ")
+
+	// First Esc only warns about unsaved edits
+	m = press(m, keyEsc)
+	assert.True(t, m.IsEditing())
+	m = press(m, keyEsc)
+	assert.False(t, m.IsEditing())
+	assert.False(t, m.Modified())
+	assert.Equal(t, "text", m.Content())
+
+	t.Run("e enters edit mode", func(t *testing.T) {
+		m := press(m, tea.KeyPressMsg{Code: 'e', Text: "e"})
+		assert.True(t, m.IsEditing())
+	})
+}
+
+func TestEditLargeFile(t *testing.T) {
+	lines := make([]string, 20000)
+	for i := range lines {
+		lines[i] = "func f() { return " + itoa(i) + " }"
+	}
+	m, _ := editModel(t, strings.Join(lines, "\n"))
+
+	m = press(m, ctrl('d'))
+	m.GotoLine(19998)
+	m = typeText(m, "// ")
+	view := m.View()
+
+	assert.Contains(t, view, "19999")
+	assert.Contains(t, view, "Ln 19999")
+	assert.Equal(t, "// func f() { return 19998 }", m.edit.lines[19998])
+	assert.Equal(t, "unc f() { return 0 }", m.edit.lines[0])
+}
@@ -0,0 +1,160 @@
+package viewer
+
+import (
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/avitaltamir/vibecommander/internal/outline"
+)
+
+// folding is the outline of the file and which of its regions are folded
+// in the source view.
+type folding struct {
+	symbols []outline.Symbol
+	regions []outline.Region // Sorted by start line
+	folded  map[int]int      // Start line of each folded region to its end
+}
+
+// loadOutline finds the symbols and foldable regions of the content,
+// unfolding everything.
+func (m *Model) loadOutline() {
+	m.fold = folding{}
+	if m.content != "" && !m.streaming && !m.binary {
+		m.fold.symbols = outline.Symbols(m.path, m.content)
+		m.fold.regions = outline.Regions(m.path, m.content)
+	}
+	m.layoutRows()
+}
+
+// Outline returns the functions, types and headings of the file.
+func (m Model) Outline() []outline.Symbol {
+	return m.fold.symbols
+}
+
+// foldable reports whether the file is shown as source, where regions fold.
+func (m Model) foldable() bool {
+	return m.content != "" && !m.editing && !m.streaming && !m.binary && !m.previewing() && !m.structured()
+}
+
+// refold works out which lines are shown and re-renders.
+func (m *Model) refold() {
+	m.layoutRows()
+	m.viewport.SetContent(m.renderContent())
+}
+
+// regionAt returns the innermost region containing line, preferring one
+// that starts there.
+func (m Model) regionAt(line int) (outline.Region, bool) {
+	var found outline.Region
+	ok := false
+	for _, r := range m.fold.regions {
+		if r.Start > line {
+			break
+		}
+		if line <= r.End && (!ok || r.Start > found.Start) {
+			found, ok = r, true
+		}
+	}
+	return found, ok
+}
+
+// toggleFold folds or unfolds the region at line, or the next region
+// starting on screen when line is in none.
+func (m *Model) toggleFold(line int) bool {
+	r, ok := m.regionAt(line)
+	if !ok {
+		bottom := m.lineOfRow(m.viewport.YOffset() + m.viewport.Height() - 1)
+		for _, next := range m.fold.regions {
+			if next.Start > line && next.Start <= bottom {
+				r, ok = next, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return false
+	}
+	if _, folded := m.fold.folded[r.Start]; folded {
+		delete(m.fold.folded, r.Start)
+	} else {
+		if m.fold.folded == nil {
+			m.fold.folded = make(map[int]int)
+		}
+		m.fold.folded[r.Start] = r.End
+	}
+	m.setFolds(r.Start)
+	return true
+}
+
+// toggleAllFolds unfolds everything if anything is folded, otherwise folds
+// every outermost region.
+func (m *Model) toggleAllFolds() {
+	top := m.TopLine()
+	if len(m.fold.folded) > 0 {
+		m.fold.folded = nil
+	} else {
+		m.fold.folded = make(map[int]int)
+		end := -1
+		for _, r := range m.fold.regions {
+			if r.Start > end {
+				m.fold.folded[r.Start] = r.End
+				end = r.End
+			}
+		}
+	}
+	m.setFolds(top)
+}
+
+// setFolds re-renders after the folds changed, keeping line on screen.
+func (m *Model) setFolds(line int) {
+	before := m.viewport.YOffset()
+	m.refold()
+	row := m.rowOfLine(line)
+	if row < before || row >= before+m.viewport.Height() {
+		m.viewport.SetYOffset(row)
+	} else {
+		m.viewport.SetYOffset(before)
+	}
+}
+
+// reveal unfolds the regions hiding line.
+func (m *Model) reveal(line int) {
+	changed := false
+	for start, end := range m.fold.folded {
+		if start < line && line <= end {
+			delete(m.fold.folded, start)
+			changed = true
+		}
+	}
+	if changed {
+		m.refold()
+	}
+}
+
+// foldedAt returns how many lines the fold starting at line hides.
+func (m Model) foldedAt(line int) int {
+	end, ok := m.fold.folded[line]
+	if !ok {
+		return 0
+	}
+	return end - line
+}
+
+// foldSuffix follows the first line of a fold.
+func foldSuffix(hidden int) string {
+	return " ⋯ " + itoa(hidden) + " lines"
+}
+
+// updateFold handles the folding keys: z toggles the fold at the top of the
+// view or the current match, Z folds or unfolds everything.
+func (m Model) updateFold(msg tea.KeyPressMsg) (Model, bool) {
+	switch msg.Key().Text {
+	case "z":
+		line, _ := m.CursorPosition()
+		m.toggleFold(line)
+		return m, true
+	case "Z":
+		m.toggleAllFolds()
+		return m, true
+	}
+	return m, false
+}
@@ -0,0 +1,96 @@
+package viewer
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	keyZ       = tea.KeyPressMsg{Code: 'z', Text: "z"}
+	keyFoldAll = tea.KeyPressMsg{Code: 'Z', Text: "Z", Mod: tea.ModShift}
+)
+
+const foldSource = `package demo
+
+func one() {
+	a()
+	b()
+}
+
+func two() {
+	if x {
+		c()
+	}
+}
+`
+
+func TestFold(t *testing.T) {
+	m := New().SetSize(60, 20).Focus()
+	m, _ = m.Update(FileLoadedMsg{Path: "/demo.go", Content: foldSource})
+
+	syms := m.Outline()
+	require.Len(t, syms, 2)
+	assert.Equal(t, "one", syms[0].Name)
+	assert.Equal(t, 7, syms[1].Line)
+
+	// z folds the first function on screen, as the top line is in none
+	m = press(m, keyZ)
+	view := ansi.Strip(m.View())
+	assert.Contains(t, view, "   3 ▸ func one() { ⋯ 3 lines")
+	assert.NotContains(t, view, "a()")
+	assert.Contains(t, view, "   7 │ ")
+
+	// Rows below the fold map back to their lines
+	assert.Equal(t, 7, m.lineOfRow(4))
+	assert.Equal(t, 2, m.rowOfLine(4), "hidden lines are on the fold's row")
+	line, _ := m.screenToTextPosition(10, 5)
+	assert.Equal(t, 7, line)
+
+	// Going to a folded line unfolds it
+	m.GotoLine(4)
+	assert.Contains(t, ansi.Strip(m.View()), "b()")
+	assert.Equal(t, 4, m.rowOfLine(4))
+
+	// Z folds every function, then unfolds them all
+	m = press(m, keyFoldAll)
+	view = ansi.Strip(m.View())
+	assert.Contains(t, view, "▸ func one() { ⋯ 3 lines")
+	assert.Contains(t, view, "▸ func two() { ⋯ 4 lines")
+	assert.NotContains(t, view, "c()")
+
+	m = press(m, keyFoldAll)
+	assert.NotContains(t, ansi.Strip(m.View()), "⋯")
+}
+
+func TestFoldSearchReveals(t *testing.T) {
+	m := New().SetSize(60, 20).Focus()
+	m, _ = m.Update(FileLoadedMsg{Path: "/demo.go", Content: foldSource})
+	m = press(m, keyFoldAll)
+	require.NotContains(t, ansi.Strip(m.View()), "c()")
+
+	m.performSearch(`c\(\)`)
+	m.viewport.SetContent(m.renderContent())
+	assert.Contains(t, ansi.Strip(m.View()), "c()")
+	line, _ := m.CursorPosition()
+	assert.Equal(t, 9, line)
+}
+
+func TestFoldClickLineNumber(t *testing.T) {
+	m := New().SetSize(60, 20).Focus()
+	m, _ = m.Update(FileLoadedMsg{Path: "/demo.go", Content: foldSource})
+
+	// Line 8 is on screen row 8, below the top border
+	m, _ = m.Update(tea.MouseClickMsg{X: 3, Y: 8, Button: tea.MouseLeft})
+	assert.Contains(t, ansi.Strip(m.View()), "▸ func two() {")
+	assert.False(t, m.HasSelection())
+
+	// Editing shows every line, and folds are gone after
+	require.True(t, m.StartEdit())
+	assert.Contains(t, ansi.Strip(m.renderEdit()), "c()")
+	m.stopEdit()
+	assert.NotContains(t, ansi.Strip(m.View()), "⋯")
+}
@@ -0,0 +1,146 @@
+package viewer
+
+import (
+	"strconv"
+	"strings"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/avitaltamir/vibecommander/internal/theme"
+)
+
+// gotoPlaceholder is shown in the empty go-to-line prompt.
+const gotoPlaceholder = "line[:column]"
+
+// newGotoInput creates the go-to-line prompt.
+func newGotoInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = gotoPlaceholder
+	ti.CharLimit = 24
+	ti.SetWidth(20)
+	return ti
+}
+
+// ParseLocation splits "line" or "line:column", both 1-indexed, into
+// 0-indexed numbers. The column is -1 when not given.
+func ParseLocation(s string) (line, col int, ok bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), ":")
+	lineText, colText, hasCol := strings.Cut(s, ":")
+	line, err := strconv.Atoi(lineText)
+	if err != nil || line < 1 {
+		return 0, 0, false
+	}
+	col = -1
+	if hasCol {
+		c, err := strconv.Atoi(colText)
+		if err != nil || c < 1 {
+			return 0, 0, false
+		}
+		col = c - 1
+	}
+	return line - 1, col, true
+}
+
+// startGoto opens the go-to-line prompt.
+func (m Model) startGoto() (Model, tea.Cmd) {
+	m.goingTo = true
+	m.gotoErr = false
+	m.gotoInput.SetValue("")
+	m.gotoInput.Focus()
+	return m, textinput.Blink
+}
+
+// stopGoto closes the go-to-line prompt.
+func (m *Model) stopGoto() {
+	m.goingTo = false
+	m.gotoInput.Blur()
+}
+
+// updateGoto handles keys while the go-to-line prompt is open.
+func (m Model) updateGoto(msg tea.KeyPressMsg) (Model, tea.Cmd) {
+	switch msg.Key().Code {
+	case tea.KeyEscape:
+		m.stopGoto()
+		return m, nil
+	case tea.KeyEnter:
+		line, col, ok := ParseLocation(m.gotoInput.Value())
+		if !ok {
+			m.gotoErr = true
+			return m, nil
+		}
+		m.stopGoto()
+		from := m.TopLine()
+		m.GotoLocation(line, col)
+		return m, m.jumpCmd(from)
+	}
+	var cmd tea.Cmd
+	m.gotoInput, cmd = m.gotoInput.Update(msg)
+	m.gotoErr = false
+	return m, cmd
+}
+
+// GotoLocation goes to line and, when it's 0 or more, scrolls sideways to
+// show col.
+func (m *Model) GotoLocation(line, col int) {
+	if last := m.lastLine(); last >= 0 {
+		line = min(line, last)
+	}
+	m.GotoLine(line)
+	if col >= 0 && m.foldable() {
+		m.showColumn(col)
+	}
+}
+
+// lastLine returns the last line (0-indexed), or -1 while a streamed file
+// is still being indexed.
+func (m Model) lastLine() int {
+	switch {
+	case m.editing:
+		return len(m.edit.lines) - 1
+	case m.streaming:
+		if !m.stream.done {
+			return -1 // Going past what's indexed waits for it
+		}
+		return m.stream.lineCount() - 1
+	}
+	return strings.Count(m.content, "\n")
+}
+
+// renderGotoBar renders the go-to-line prompt.
+func (m Model) renderGotoBar(width int) string {
+	prefix := lipgloss.NewStyle().
+		Foreground(theme.CyberCyan).
+		Bold(true).
+		Render(":")
+
+	var info string
+	if m.gotoErr {
+		info = lipgloss.NewStyle().
+			Foreground(theme.NeonRed).
+			Render(" [not a line number]")
+	} else if last := m.lastLine(); last >= 0 {
+		info = lipgloss.NewStyle().
+			Foreground(theme.MutedLavender).
+			Render(" [1-" + itoa(last+1) + "]")
+	}
+
+	return lipgloss.NewStyle().
+		Background(lipgloss.Color("236")).
+		Width(width).
+		Render(prefix + m.gotoInput.View() + info)
+}
+
+// prompting reports whether the search or go-to-line prompt is open.
+func (m Model) prompting() bool {
+	return m.searching || m.goingTo
+}
+
+// renderPromptBar renders whichever prompt is open.
+func (m Model) renderPromptBar(width int) string {
+	if m.goingTo {
+		return m.renderGotoBar(width)
+	}
+	return m.renderSearchBar(width)
+}
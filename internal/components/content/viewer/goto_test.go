@@ -0,0 +1,83 @@
+package viewer
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		in        string
+		line, col int
+		ok        bool
+	}{
+		{"42", 41, -1, true},
+		{":42", 41, -1, true},
+		{" 42:7 ", 41, 6, true},
+		{"0", 0, 0, false},
+		{"42:", 0, 0, false},
+		{"x", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		line, col, ok := ParseLocation(tt.in)
+		assert.Equal(t, tt.ok, ok, tt.in)
+		if tt.ok {
+			assert.Equal(t, tt.line, line, tt.in)
+			assert.Equal(t, tt.col, col, tt.in)
+		}
+	}
+}
+
+func TestGotoPrompt(t *testing.T) {
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = "line " + itoa(i+1)
+	}
+	lines[59] = strings.Repeat("x", 200) + "needle"
+	m := New().SetSize(60, 20).Focus()
+	m, _ = m.Update(FileLoadedMsg{Path: "/test.txt", Content: strings.Join(lines, "\n")})
+
+	m = press(m, tea.KeyPressMsg{Code: ':', Text: ":"})
+	require.True(t, m.prompting())
+	bar := ansi.Strip(m.View())
+	assert.Contains(t, bar, "line[:column]")
+	assert.Contains(t, bar, "[1-100]")
+
+	m = typeText(m, "abc")
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	assert.Contains(t, ansi.Strip(m.View()), "[not a line number]")
+
+	for range 3 {
+		m = press(m, tea.KeyPressMsg{Code: tea.KeyBackspace})
+	}
+	m = typeText(m, "60:201")
+	m, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	assert.False(t, m.prompting())
+	assert.Equal(t, 59, m.TopLine())
+	require.NotNil(t, cmd)
+	assert.Equal(t, JumpMsg{Path: "/test.txt", Line: 0}, cmd())
+
+	// Scrolled sideways so the column shows, the line number still in front
+	assert.Greater(t, m.ColumnOffset(), 140)
+	view := ansi.Strip(m.View())
+	assert.Contains(t, view, "  60 │ ")
+	assert.Contains(t, view, "needle")
+
+	t.Run("past the end goes to the last line", func(t *testing.T) {
+		m := m
+		m.GotoLocation(500, -1)
+		assert.Equal(t, 99, m.lineOfRow(m.viewport.YOffset()+m.viewport.Height()-1))
+	})
+
+	t.Run("escape closes the prompt", func(t *testing.T) {
+		m := press(m, tea.KeyPressMsg{Code: ':', Text: ":"}, tea.KeyPressMsg{Code: tea.KeyEscape})
+		assert.False(t, m.prompting())
+		assert.Equal(t, 59, m.TopLine())
+	})
+}
@@ -0,0 +1,436 @@
+package viewer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/avitaltamir/vibecommander/internal/theme"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	maxHexMatches     = 100000 // Search stops after this many matches
+	hexPlaceholder    = "text, or hex bytes (0xcafe, ca fe)..."
+	searchPlaceholder = "regex pattern..."
+)
+
+// HexSearchMsg carries the matches found in the next chunk of a binary file.
+type HexSearchMsg struct {
+	seq       int
+	searchSeq int
+	matches   []int64 // Offsets of matches starting in the chunk
+	next      int64   // Where the next chunk starts
+	done      bool
+	err       error
+}
+
+// hexView is the state of a binary file shown as hex. Rows are read from disk
+// as they scroll into view.
+type hexView struct {
+	size int64
+	kind string
+	seq  int // Load sequence, to drop messages about older files
+
+	top    int64  // First visible row
+	data   []byte // Bytes of the visible rows
+	dataAt int64  // Offset of data
+
+	pattern    []byte
+	matches    []int64
+	current    int // Index into matches (-1 if none)
+	searchSeq  int
+	searchDone bool
+	jumpFrom   int // Top row when the search started
+	message    string
+}
+
+// startHex shows a binary file as hex.
+func (m *Model) startHex(msg FileLoadedMsg) {
+	m.loadSeq++
+	m.binary = true
+	m.hex = hexView{
+		size:       msg.Size,
+		kind:       msg.Kind,
+		seq:        m.loadSeq,
+		current:    -1,
+		searchDone: true,
+	}
+	if m.hex.kind == "" {
+		m.hex.kind = "binary data"
+	}
+	m.searchInput.Placeholder = hexPlaceholder
+	m.GotoLine(msg.Line)
+}
+
+// hexSize returns the bytes per row and the number of rows shown, leaving a
+// row for the header and one for the status bar.
+func (m Model) hexSize() (int, int) {
+	w, h := m.Size()
+	perRow := 16
+	if w < hexRowWidth(16, m.hexDigits()) {
+		perRow = 8
+	}
+	h -= 2
+	if h < 1 {
+		h = 1
+	}
+	return perRow, h
+}
+
+// hexDigits returns the number of hex digits shown in offsets.
+func (m Model) hexDigits() int {
+	digits := 8
+	for n := m.hex.size >> 32; n > 0; n >>= 4 {
+		digits++
+	}
+	return digits
+}
+
+// hexRowWidth returns the width of a row with perRow bytes:
+// "00000010  00 11 22 33 44 55 66 77  88 99 aa bb cc dd ee ff  │0123456789abcdef│"
+func hexRowWidth(perRow, digits int) int {
+	return digits + 2 + perRow*3 + perRow/8 + 1 + perRow + 2
+}
+
+// hexRows returns the total number of rows.
+func (m Model) hexRows() int64 {
+	perRow, _ := m.hexSize()
+	return (m.hex.size + int64(perRow) - 1) / int64(perRow)
+}
+
+// scrollHex moves the view of a binary file to row top and reads its rows.
+func (m *Model) scrollHex(top int64) {
+	perRow, h := m.hexSize()
+	maxTop := m.hexRows() - int64(h)
+	if top > maxTop {
+		top = maxTop
+	}
+	if top < 0 {
+		top = 0
+	}
+	m.hex.top = top
+	m.hex.dataAt = top * int64(perRow)
+	m.hex.data = nil
+
+	f, err := os.Open(m.path)
+	if err != nil {
+		m.hex.message = "Read error: " + err.Error()
+		return
+	}
+	defer f.Close()
+
+	buf := make([]byte, perRow*h)
+	n, err := f.ReadAt(buf, m.hex.dataAt)
+	if err != nil && err != io.EOF {
+		m.hex.message = "Read error: " + err.Error()
+	}
+	m.hex.data = buf[:n]
+}
+
+// scrollBy scrolls a streamed or binary file by n rows.
+func (m *Model) scrollBy(n int) {
+	if m.binary {
+		m.scrollHex(m.hex.top + int64(n))
+	} else {
+		m.scrollStream(m.stream.top + n)
+	}
+}
+
+// updateHex handles keys for a binary file: scrolling, and search navigation.
+func (m Model) updateHex(msg tea.KeyPressMsg) (Model, tea.Cmd) {
+	_, h := m.hexSize()
+	keys := m.viewport.KeyMap
+	top := m.hex.top
+	from := m.TopLine()
+
+	switch {
+	case msg.String() == "esc" && m.searchQuery != "":
+		m.clearSearch()
+		return m, nil
+	case msg.String() == "n" && len(m.hex.matches) > 0:
+		m.hex.current = (m.hex.current + 1) % len(m.hex.matches)
+		m.scrollToHexMatch()
+		return m, m.jumpCmd(from)
+	case msg.String() == "p" && len(m.hex.matches) > 0:
+		m.hex.current--
+		if m.hex.current < 0 {
+			m.hex.current = len(m.hex.matches) - 1
+		}
+		m.scrollToHexMatch()
+		return m, m.jumpCmd(from)
+	case key.Matches(msg, keys.Down):
+		top++
+	case key.Matches(msg, keys.Up):
+		top--
+	case key.Matches(msg, keys.PageDown):
+		top += int64(h)
+	case key.Matches(msg, keys.PageUp):
+		top -= int64(h)
+	case key.Matches(msg, keys.HalfPageDown):
+		top += int64(h / 2)
+	case key.Matches(msg, keys.HalfPageUp):
+		top -= int64(h / 2)
+	case msg.String() == "home" || msg.String() == "g":
+		top = 0
+	case msg.String() == "end" || msg.String() == "G":
+		top = m.hexRows()
+	default:
+		return m, nil
+	}
+	m.scrollHex(top)
+	return m, nil
+}
+
+// scrollToHexMatch scrolls so the current match is roughly centered.
+func (m *Model) scrollToHexMatch() {
+	if m.hex.current < 0 || m.hex.current >= len(m.hex.matches) {
+		return
+	}
+	perRow, h := m.hexSize()
+	row := m.hex.matches[m.hex.current] / int64(perRow)
+	m.scrollHex(row - int64(h/2))
+}
+
+// parseHexQuery turns a search query into the bytes to find: hex when it
+// starts with 0x or is all space-separated byte pairs, text otherwise.
+func parseHexQuery(query string) []byte {
+	digits := ""
+	switch fields := strings.Fields(query); {
+	case strings.HasPrefix(query, "0x") || strings.HasPrefix(query, "0X"):
+		digits = strings.Join(fields, "")[2:]
+	case len(fields) > 1:
+		for _, f := range fields {
+			if len(f) != 2 {
+				return []byte(query)
+			}
+		}
+		digits = strings.Join(fields, "")
+	default:
+		return []byte(query)
+	}
+
+	if b, err := hex.DecodeString(digits); err == nil && len(b) > 0 {
+		return b
+	}
+	return []byte(query)
+}
+
+// startHexSearch searches a binary file in the background.
+func (m *Model) startHexSearch(query string) tea.Cmd {
+	m.searchQuery = query
+	m.hex.matches = nil
+	m.hex.current = -1
+	m.hex.pattern = nil
+	m.hex.searchSeq++
+	m.hex.searchDone = true
+	m.hex.jumpFrom = m.TopLine()
+	if query == "" {
+		return nil
+	}
+	m.hex.pattern = parseHexQuery(query)
+	m.hex.searchDone = false
+	return hexSearchCmd(m.path, m.hex.seq, m.hex.searchSeq, m.hex.pattern, 0)
+}
+
+// hexSearchCmd finds pattern in the chunk of path beginning at from.
+func hexSearchCmd(path string, seq, searchSeq int, pattern []byte, from int64) tea.Cmd {
+	return func() tea.Msg {
+		msg := HexSearchMsg{seq: seq, searchSeq: searchSeq}
+		f, err := os.Open(path)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		defer f.Close()
+
+		buf := make([]byte, chunkSize)
+		n, err := f.ReadAt(buf, from)
+		if err != nil && err != io.EOF {
+			msg.err = err
+			return msg
+		}
+		buf = buf[:n]
+		msg.done = n < chunkSize
+
+		for i := 0; ; {
+			j := bytes.Index(buf[i:], pattern)
+			if j < 0 {
+				break
+			}
+			msg.matches = append(msg.matches, from+int64(i+j))
+			i += j + 1
+		}
+
+		// Chunks overlap so a match across the boundary is found in the
+		// next one; it can't fit in this one
+		msg.next = from + int64(n-len(pattern)+1)
+		if msg.next <= from {
+			msg.next = from + int64(n)
+		}
+		return msg
+	}
+}
+
+// handleHexSearch records matches and asks for the next chunk. The first
+// match is jumped to as soon as it's found.
+func (m *Model) handleHexSearch(msg HexSearchMsg) tea.Cmd {
+	h := &m.hex
+	if !m.binary || msg.seq != h.seq || msg.searchSeq != h.searchSeq {
+		return nil
+	}
+	if msg.err != nil {
+		h.searchDone = true
+		h.message = "Search failed: " + msg.err.Error()
+		return nil
+	}
+
+	h.matches = append(h.matches, msg.matches...)
+	h.searchDone = msg.done
+	if len(h.matches) >= maxHexMatches {
+		h.matches = h.matches[:maxHexMatches]
+		h.searchDone = true
+		h.message = "stopped at " + itoa(maxHexMatches) + " matches"
+	}
+
+	var cmds []tea.Cmd
+	if h.current < 0 && len(h.matches) > 0 {
+		h.current = 0
+		m.scrollToHexMatch()
+		cmds = append(cmds, m.jumpCmd(h.jumpFrom))
+	}
+	if !h.searchDone {
+		cmds = append(cmds, hexSearchCmd(m.path, h.seq, h.searchSeq, h.pattern, msg.next))
+	}
+	return tea.Batch(cmds...)
+}
+
+// renderHex renders a header, the visible rows of a binary file as hex and
+// ASCII, and a status bar.
+func (m Model) renderHex() string {
+	w, _ := m.Size()
+	perRow, h := m.hexSize()
+	digits := m.hexDigits()
+
+	offsetStyle := theme.DiffLineNumberStyle.UnsetWidth()
+	sep := lipgloss.NewStyle().Foreground(theme.DimPurple).Render("│")
+	matchStyle := lipgloss.NewStyle().Background(theme.ElectricYellow).Foreground(lipgloss.Color("0"))
+	currentStyle := lipgloss.NewStyle().Background(theme.MatrixGreen).Foreground(lipgloss.Color("0"))
+	dimStyle := lipgloss.NewStyle().Foreground(theme.MutedLavender)
+
+	header := lipgloss.NewStyle().
+		Foreground(theme.CyberCyan).
+		Bold(true).
+		Render(ansi.Truncate(" "+m.hex.kind+" · "+formatSize(m.hex.size)+" ("+itoa(int(m.hex.size))+" bytes)", w, "…"))
+
+	rows := []string{header}
+	for r := 0; r < h; r++ {
+		start := r * perRow
+		if start >= len(m.hex.data) {
+			break
+		}
+		end := start + perRow
+		if end > len(m.hex.data) {
+			end = len(m.hex.data)
+		}
+		at := m.hex.dataAt + int64(start)
+
+		var hexCol, textCol strings.Builder
+		for i := start; i < start+perRow; i++ {
+			if i > start && (i-start)%8 == 0 {
+				hexCol.WriteString(" ")
+			}
+			if i >= end {
+				hexCol.WriteString("   ")
+				textCol.WriteString(" ")
+				continue
+			}
+			b := m.hex.data[i]
+			cell := hex.EncodeToString([]byte{b})
+			char := "."
+			if b >= 0x20 && b < 0x7f {
+				char = string(rune(b))
+			}
+
+			style, ok := m.hexMatchStyle(m.hex.dataAt+int64(i), matchStyle, currentStyle)
+			switch {
+			case ok:
+				cell, char = style.Render(cell), style.Render(char)
+			case b == 0:
+				cell, char = dimStyle.Render(cell), dimStyle.Render(char)
+			}
+			hexCol.WriteString(cell + " ")
+			textCol.WriteString(char)
+		}
+
+		row := offsetStyle.Render(hexOffset(at, digits)) + "  " + hexCol.String() + " " + sep + textCol.String() + sep
+		rows = append(rows, ansi.Truncate(row, w, ""))
+	}
+	for len(rows) < h+1 {
+		rows = append(rows, "")
+	}
+
+	if m.prompting() {
+		rows = append(rows, m.renderPromptBar(w))
+	} else {
+		rows = append(rows, m.renderHexBar(w))
+	}
+	return strings.Join(rows, "\n")
+}
+
+// hexMatchStyle returns the style for the byte at offset if it's part of a
+// search match.
+func (m Model) hexMatchStyle(offset int64, match, current lipgloss.Style) (lipgloss.Style, bool) {
+	matches, n := m.hex.matches, int64(len(m.hex.pattern))
+	if n == 0 {
+		return lipgloss.Style{}, false
+	}
+	// The last match starting at or before offset
+	i := sort.Search(len(matches), func(i int) bool { return matches[i] > offset }) - 1
+	style, ok := lipgloss.Style{}, false
+	for ; i >= 0 && matches[i]+n > offset; i-- {
+		if i == m.hex.current {
+			return current, true
+		}
+		style, ok = match, true
+	}
+	return style, ok
+}
+
+// renderHexBar renders the status bar of a binary file: the offset shown,
+// search progress and messages.
+func (m Model) renderHexBar(width int) string {
+	perRow, _ := m.hexSize()
+	info := " " + hexOffset(m.hex.top*int64(perRow), m.hexDigits()) + " · " + itoa(int(m.ScrollPercent())) + "%"
+	if m.searchQuery != "" {
+		info += " · " + itoa(len(m.hex.matches)) + " matches"
+		if !m.hex.searchDone {
+			info += ", searching…"
+		}
+	}
+	if m.hex.message != "" {
+		info += " · " + m.hex.message
+	}
+	return lipgloss.NewStyle().
+		Foreground(theme.MutedLavender).
+		Background(lipgloss.Color("236")).
+		Width(width).
+		Render(ansi.Truncate(info, width, "…"))
+}
+
+// hexOffset formats offset as a zero-padded hex number of digits digits.
+func hexOffset(offset int64, digits int) string {
+	const hexDigits = "0123456789abcdef"
+	b := make([]byte, digits)
+	for i := digits - 1; i >= 0; i-- {
+		b[i] = hexDigits[offset&0xf]
+		offset >>= 4
+	}
+	return string(b)
+}
@@ -0,0 +1,122 @@
+package viewer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// binaryFile writes an ELF-looking file of size bytes counting up from 0.
+func binaryFile(t *testing.T, size int) string {
+	t.Helper()
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	copy(data, "\x7fELF")
+
+	path := filepath.Join(t.TempDir(), "a.out")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	return path
+}
+
+func TestHexView(t *testing.T) {
+	path := binaryFile(t, 4096)
+
+	msg := loadFile(path, 0, false)
+	assert.True(t, msg.Binary)
+	assert.Equal(t, "ELF executable", msg.Kind)
+	assert.Empty(t, msg.Content, "binary files aren't read as text")
+
+	m := New().SetSize(80, 12).Focus()
+	m, _ = m.Update(msg)
+	view := ansi.Strip(m.View())
+	lines := strings.Split(view, "\n")
+	require.Len(t, lines, 12)
+
+	assert.Contains(t, lines[0], "ELF executable · 4.0 KB (4096 bytes)")
+	assert.Equal(t, "00000000  7f 45 4c 46 04 05 06 07  08 09 0a 0b 0c 0d 0e 0f  │.ELF............│", lines[1])
+	assert.Equal(t, "00000020  20 21 22 23 24 25 26 27  28 29 2a 2b 2c 2d 2e 2f  │ !\"#$%&'()*+,-./│", lines[3])
+	assert.Contains(t, lines[11], "00000000 · 0%")
+
+	m = press(m, keyDown, tea.KeyPressMsg{Code: tea.KeyPgDown})
+	assert.Equal(t, 11, m.TopLine())
+	m = press(m, tea.KeyPressMsg{Code: 'G', Text: "G"})
+	assert.Equal(t, 256-10, m.TopLine())
+	assert.Equal(t, 100.0, m.ScrollPercent())
+	assert.Contains(t, ansi.Strip(m.View()), "00000ff0  f0 f1")
+
+	// Narrow panes show 8 bytes per row, from the same offset
+	m = press(m, tea.KeyPressMsg{Code: 'g', Text: "g"}, keyDown)
+	m = m.SetSize(50, 12)
+	assert.Equal(t, 2, m.TopLine())
+	assert.Contains(t, ansi.Strip(m.View()), "00000010  10 11 12 13 14 15 16 17  │........│")
+}
+
+func TestParseHexQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"0xcafe", "\xca\xfe"},
+		{"0x ca fe", "\xca\xfe"},
+		{"ca fe ba be", "\xca\xfe\xba\xbe"},
+		{"cafe", "cafe"},
+		{"ELF", "ELF"},
+		{"hello world", "hello world"},
+		{"0xzz", "0xzz"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, []byte(tt.want), parseHexQuery(tt.query), tt.query)
+	}
+}
+
+func TestHexSearch(t *testing.T) {
+	smallChunks(t, 1000)
+	path := binaryFile(t, 5000)
+
+	m := New().SetSize(80, 12).Focus()
+	m, _ = m.Update(loadFile(path, 0, false))
+
+	// Byte pairs: found in every 256-byte cycle, including across chunks
+	m = press(m, tea.KeyPressMsg{Code: '/', Text: "/"})
+	m = typeText(m, "ff 00 01")
+	var cmd tea.Cmd
+	m, cmd = m.Update(keyEnter)
+	m = run(m, cmd)
+
+	assert.True(t, m.hex.searchDone)
+	assert.Equal(t, []int64{255, 511, 767, 1023, 1279, 1535, 1791, 2047, 2303, 2559, 2815, 3071, 3327, 3583, 3839, 4095, 4351, 4607, 4863}, m.hex.matches)
+	assert.Equal(t, 0, m.hex.current)
+	assert.Contains(t, ansi.Strip(m.View()), "19 matches")
+
+	m = press(m, tea.KeyPressMsg{Code: 'n', Text: "n"})
+	assert.Equal(t, 1, m.hex.current)
+	assert.Equal(t, 511/16-5, m.TopLine(), "the match is centered")
+
+	m = press(m, tea.KeyPressMsg{Code: 'p', Text: "p"}, tea.KeyPressMsg{Code: 'p', Text: "p"})
+	assert.Equal(t, 18, m.hex.current)
+
+	// Text search
+	m = press(m, keyEsc, tea.KeyPressMsg{Code: '/', Text: "/"})
+	m = typeText(m, "ELF")
+	m, cmd = m.Update(keyEnter)
+	m = run(m, cmd)
+	assert.Equal(t, []int64{1}, m.hex.matches)
+}
+
+func TestHexNotEditable(t *testing.T) {
+	m := New().SetSize(80, 12).Focus()
+	m, _ = m.Update(loadFile(binaryFile(t, 100), 0, true))
+	assert.False(t, m.IsEditing())
+
+	m = press(m, tea.KeyPressMsg{Code: 'e', Text: "e"})
+	assert.False(t, m.IsEditing())
+	assert.Contains(t, m.View(), "can't be edited")
+}
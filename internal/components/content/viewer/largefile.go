@@ -0,0 +1,534 @@
+package viewer
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/avitaltamir/vibecommander/internal/find"
+	"github.com/avitaltamir/vibecommander/internal/syntax"
+	"github.com/avitaltamir/vibecommander/internal/theme"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// LargeFileSize is the size above which files are streamed from disk: line
+// offsets are indexed in the background and only the visible window is read.
+const LargeFileSize = 1 << 20
+
+// HighlightSizeLimit is the size above which streamed files are shown as
+// plain text. Below it each window is highlighted on its own.
+const HighlightSizeLimit = 32 << 20
+
+// chunkSize is the number of bytes indexed or searched per step.
+var chunkSize = 8 << 20
+
+const (
+	maxLineBytes      = 16 << 10  // Longer lines are cut for display
+	maxHighlightBytes = 256 << 10 // Larger windows are shown plain
+	windowMargin      = 200       // Lines read beyond each edge of the view
+)
+
+// Messages
+type (
+	// IndexMsg carries the line offsets found in the next chunk of a
+	// streamed file.
+	IndexMsg struct {
+		seq     int
+		offsets []int64 // Start of each line beginning in the chunk
+		next    int64   // Where the next chunk starts
+		done    bool
+		err     error
+	}
+
+	// StreamSearchMsg carries the matches found in the next chunk of a
+	// streamed file.
+	StreamSearchMsg struct {
+		seq       int
+		searchSeq int
+		lines     []int
+		next      int64 // Where the next chunk starts
+		nextLine  int   // Line number at next
+		done      bool
+		err       error
+	}
+)
+
+// stream is the state of a file shown from disk rather than from memory.
+type stream struct {
+	size      int64
+	offsets   []int64 // Start of each line found so far
+	indexed   int64   // Bytes indexed so far
+	done      bool    // Whole file indexed
+	seq       int     // Load sequence, to drop messages about older files
+	lexer     chroma.Lexer
+	highlight bool
+
+	top  int // First visible line
+	goal int // Line asked for before it was indexed (-1 = none)
+
+	// Cached window of lines around the view
+	winStart int
+	raw      []string
+	lines    []string // raw, highlighted when possible
+
+	searchSeq  int
+	searchDone bool
+	jumpFrom   int // Top line when the search started (-1 = already jumped)
+	message    string
+}
+
+// startStream shows a large file by indexing it in the background.
+func (m *Model) startStream(msg FileLoadedMsg) tea.Cmd {
+	m.loadSeq++
+	m.streaming = true
+	m.stream = stream{
+		size:      msg.Size,
+		offsets:   []int64{0},
+		seq:       m.loadSeq,
+		highlight: msg.Size <= HighlightSizeLimit,
+		goal:      -1,
+		jumpFrom:  -1,
+	}
+	// Only the file name picks the lexer; analysing content would read it
+	if lexer := lexers.Match(filepath.Base(msg.Path)); lexer != nil {
+		m.stream.lexer = chroma.Coalesce(lexer)
+	} else {
+		m.stream.highlight = false
+	}
+	m.GotoLine(msg.Line)
+	return indexCmd(msg.Path, m.loadSeq, 0)
+}
+
+// indexCmd finds the line starts in the chunk of path beginning at from.
+func indexCmd(path string, seq int, from int64) tea.Cmd {
+	return func() tea.Msg {
+		f, err := os.Open(path)
+		if err != nil {
+			return IndexMsg{seq: seq, err: err}
+		}
+		defer f.Close()
+
+		buf := make([]byte, chunkSize)
+		n, err := f.ReadAt(buf, from)
+		if err != nil && err != io.EOF {
+			return IndexMsg{seq: seq, err: err}
+		}
+
+		var offsets []int64
+		for i := 0; i < n; {
+			j := bytes.IndexByte(buf[i:n], '\n')
+			if j < 0 {
+				break
+			}
+			i += j + 1
+			offsets = append(offsets, from+int64(i))
+		}
+		return IndexMsg{seq: seq, offsets: offsets, next: from + int64(n), done: n < chunkSize}
+	}
+}
+
+// handleIndex records indexed lines and asks for the next chunk.
+func (m *Model) handleIndex(msg IndexMsg) tea.Cmd {
+	s := &m.stream
+	if !m.streaming || msg.seq != s.seq {
+		return nil
+	}
+	if msg.err != nil {
+		s.done = true
+		s.message = "Read error: " + msg.err.Error()
+		return nil
+	}
+
+	// The last indexed line may have been read only in part
+	partial := s.winStart+len(s.raw) >= s.lineCount()
+	s.offsets = append(s.offsets, msg.offsets...)
+	s.indexed = msg.next
+	s.done = msg.done
+	if s.done && len(s.offsets) > 1 && s.offsets[len(s.offsets)-1] == s.size {
+		// A final line break doesn't start another line unless the file is
+		// otherwise empty, matching how small files are shown
+		s.offsets = s.offsets[:len(s.offsets)-1]
+	}
+
+	if s.goal >= 0 {
+		m.GotoLine(s.goal)
+	} else if partial {
+		m.loadWindow()
+	}
+
+	if s.done {
+		return nil
+	}
+	return indexCmd(m.path, s.seq, msg.next)
+}
+
+// lineCount returns the number of lines indexed so far.
+func (s *stream) lineCount() int {
+	return len(s.offsets)
+}
+
+// lineEnd returns the offset after line i, excluding its line break.
+func (s *stream) lineEnd(i int) int64 {
+	if i+1 < len(s.offsets) {
+		return s.offsets[i+1] - 1
+	}
+	if s.done {
+		return s.size
+	}
+	return s.indexed
+}
+
+// loadWindow reads the lines around the view from disk.
+func (m *Model) loadWindow() {
+	s := &m.stream
+	_, h := m.streamSize()
+	start := s.top - windowMargin
+	if start < 0 {
+		start = 0
+	}
+	end := s.top + h + windowMargin
+	if end > s.lineCount() {
+		end = s.lineCount()
+	}
+
+	s.winStart = start
+	s.raw = s.raw[:0:0]
+	s.lines = nil
+
+	f, err := os.Open(m.path)
+	if err != nil {
+		s.message = "Read error: " + err.Error()
+		return
+	}
+	defer f.Close()
+
+	var total int
+	for i := start; i < end; i++ {
+		from, to := s.offsets[i], s.lineEnd(i)
+		cut := to-from > maxLineBytes
+		if cut {
+			to = from + maxLineBytes
+		}
+		buf := make([]byte, to-from)
+		if _, err := f.ReadAt(buf, from); err != nil && err != io.EOF {
+			s.message = "Read error: " + err.Error()
+			return
+		}
+		line := strings.TrimSuffix(string(buf), "\r")
+		if cut {
+			line += "…"
+		}
+		s.raw = append(s.raw, line)
+		total += len(line)
+	}
+
+	s.lines = s.raw
+	if s.highlight && total <= maxHighlightBytes {
+		out := strings.Split(syntax.Highlight(s.lexer, strings.Join(s.raw, "\n")), "\n")
+		if len(out) >= len(s.raw) {
+			s.lines = out[:len(s.raw)]
+		}
+	}
+}
+
+// ensureWindow reloads the window when the view has moved outside it.
+func (m *Model) ensureWindow() {
+	s := &m.stream
+	_, h := m.streamSize()
+	end := s.top + h
+	if end > s.lineCount() {
+		end = s.lineCount()
+	}
+	if s.top < s.winStart || end > s.winStart+len(s.raw) || len(s.raw) == 0 {
+		m.loadWindow()
+	}
+}
+
+// scrollStream moves the view of a streamed file to line top.
+func (m *Model) scrollStream(top int) {
+	s := &m.stream
+	_, h := m.streamSize()
+	maxTop := s.lineCount() - h
+	if maxTop < 0 {
+		maxTop = 0
+	}
+
+	s.goal = -1
+	if top > maxTop {
+		if !s.done {
+			s.goal = top // Not indexed yet - go there when it is
+		}
+		top = maxTop
+	}
+	if top < 0 {
+		top = 0
+	}
+	s.top = top
+	m.ensureWindow()
+}
+
+// streamSize returns the width and the number of text rows of a streamed
+// view, leaving a row for the status bar.
+func (m Model) streamSize() (int, int) {
+	w, h := m.Size()
+	if h > 1 {
+		h--
+	}
+	return w, h
+}
+
+// updateStream handles scrolling keys for a streamed file.
+func (m Model) updateStream(msg tea.KeyPressMsg) Model {
+	_, h := m.streamSize()
+	keys := m.viewport.KeyMap
+	top := m.stream.top
+
+	switch {
+	case key.Matches(msg, keys.Down):
+		top++
+	case key.Matches(msg, keys.Up):
+		top--
+	case key.Matches(msg, keys.PageDown):
+		top += h
+	case key.Matches(msg, keys.PageUp):
+		top -= h
+	case key.Matches(msg, keys.HalfPageDown):
+		top += h / 2
+	case key.Matches(msg, keys.HalfPageUp):
+		top -= h / 2
+	case msg.String() == "home" || msg.String() == "g":
+		top = 0
+	case msg.String() == "end" || msg.String() == "G":
+		top = m.stream.lineCount()
+		if !m.stream.done {
+			m.stream.message = "Still indexing - showing what's read so far"
+		}
+	default:
+		return m
+	}
+	m.scrollStream(top)
+	return m
+}
+
+// startStreamSearch searches a streamed file in the background.
+func (m *Model) startStreamSearch(query string) tea.Cmd {
+	m.searchQuery = query
+	m.matchLines = nil
+	m.currentMatch = -1
+	m.searchRegex = find.Compile(query)
+	m.stream.searchSeq++
+	m.stream.searchDone = true
+	m.stream.jumpFrom = m.TopLine()
+	if m.searchRegex == nil {
+		return nil
+	}
+	m.stream.searchDone = false
+	return streamSearchCmd(m.path, m.stream.seq, m.stream.searchSeq, m.searchRegex, 0, 0)
+}
+
+// streamSearchCmd searches the chunk of path beginning at from, which is the
+// start of line.
+func streamSearchCmd(path string, seq, searchSeq int, re *regexp.Regexp, from int64, line int) tea.Cmd {
+	return func() tea.Msg {
+		msg := StreamSearchMsg{seq: seq, searchSeq: searchSeq}
+		f, err := os.Open(path)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		defer f.Close()
+
+		buf := make([]byte, chunkSize)
+		n, err := f.ReadAt(buf, from)
+		if err != nil && err != io.EOF {
+			msg.err = err
+			return msg
+		}
+		buf = buf[:n]
+		msg.done = n < chunkSize
+
+		// Stop after the last complete line; a line longer than a whole
+		// chunk is searched in pieces
+		if !msg.done {
+			if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+				buf = buf[:i+1]
+			}
+		}
+		msg.next = from + int64(len(buf))
+
+		for len(buf) > 0 {
+			text := buf
+			i := bytes.IndexByte(buf, '\n')
+			if i >= 0 {
+				text, buf = buf[:i], buf[i+1:]
+			} else {
+				buf = nil
+			}
+			if re.Match(text) {
+				msg.lines = append(msg.lines, line)
+			}
+			if i >= 0 {
+				line++
+			}
+		}
+		msg.nextLine = line
+		return msg
+	}
+}
+
+// handleStreamSearch records matches and asks for the next chunk. The first
+// match is jumped to as soon as it's found.
+func (m *Model) handleStreamSearch(msg StreamSearchMsg) tea.Cmd {
+	s := &m.stream
+	if !m.streaming || msg.seq != s.seq || msg.searchSeq != s.searchSeq {
+		return nil
+	}
+	if msg.err != nil {
+		s.searchDone = true
+		s.message = "Search failed: " + msg.err.Error()
+		return nil
+	}
+
+	lines := msg.lines
+	if n := len(m.matchLines); n > 0 && len(lines) > 0 && lines[0] == m.matchLines[n-1] {
+		lines = lines[1:] // Same line continued from a piece of the last chunk
+	}
+	m.matchLines = append(m.matchLines, lines...)
+	s.searchDone = msg.done
+
+	var cmds []tea.Cmd
+	if m.currentMatch < 0 && len(m.matchLines) > 0 {
+		m.currentMatch = 0
+		m.scrollToCurrentMatch()
+		cmds = append(cmds, m.jumpCmd(s.jumpFrom))
+		s.jumpFrom = -1
+	}
+	if !msg.done {
+		cmds = append(cmds, streamSearchCmd(m.path, s.seq, s.searchSeq, m.searchRegex, msg.next, msg.nextLine))
+	}
+	return tea.Batch(cmds...)
+}
+
+// renderStream renders the visible lines of a streamed file and a status bar.
+func (m Model) renderStream() string {
+	s := m.stream
+	w, h := m.streamSize()
+
+	digits := len(itoa(s.lineCount()))
+	if digits < 4 {
+		digits = 4
+	}
+	lineNumStyle := theme.DiffLineNumberStyle.UnsetWidth() // padLeft pads; a fixed width wraps long numbers
+	matchLineNumStyle := lipgloss.NewStyle().Foreground(theme.ElectricYellow).Bold(true)
+	currentMatchLineNumStyle := lipgloss.NewStyle().Foreground(theme.MatrixGreen).Bold(true)
+	sep := lipgloss.NewStyle().Foreground(theme.DimPurple).Render(" │ ")
+
+	currentMatchLine := -1
+	if m.currentMatch >= 0 && m.currentMatch < len(m.matchLines) {
+		currentMatchLine = m.matchLines[m.currentMatch]
+	}
+
+	rows := make([]string, 0, h+1)
+	for i := s.top; i < s.top+h && i < s.lineCount(); i++ {
+		j := i - s.winStart
+		if j < 0 || j >= len(s.lines) {
+			break
+		}
+		lineNum := lineNumStyle.Render(padLeft(i+1, digits))
+		line := s.lines[j]
+		switch {
+		case i == currentMatchLine:
+			lineNum = currentMatchLineNumStyle.Render(padLeft(i+1, digits))
+			line = m.highlightMatchesInLine(s.raw[j], true)
+		case m.isMatchLine(i):
+			lineNum = matchLineNumStyle.Render(padLeft(i+1, digits))
+			line = m.highlightMatchesInLine(s.raw[j], false)
+		}
+		rows = append(rows, ansi.Truncate(lineNum+sep+line, w, ""))
+	}
+	for len(rows) < h {
+		rows = append(rows, "")
+	}
+
+	if m.prompting() {
+		rows = append(rows, m.renderPromptBar(w))
+	} else {
+		rows = append(rows, m.renderStreamBar(w))
+	}
+	return strings.Join(rows, "\n")
+}
+
+// isMatchLine reports whether line has a search match.
+func (m Model) isMatchLine(line int) bool {
+	i := sort.SearchInts(m.matchLines, line)
+	return i < len(m.matchLines) && m.matchLines[i] == line
+}
+
+// renderStreamBar renders the status bar of a streamed file: its size, the
+// lines indexed so far, and whether highlighting is off.
+func (m Model) renderStreamBar(width int) string {
+	s := m.stream
+	info := " " + formatSize(s.size) + " · " + itoa(s.lineCount()) + " lines"
+	if !s.done && s.size > 0 {
+		info += " · indexing " + itoa(int(s.indexed*100/s.size)) + "%"
+	}
+	if !s.highlight {
+		info += " · highlighting off"
+	}
+	if m.searchQuery != "" {
+		info += " · " + itoa(len(m.matchLines)) + " matches"
+		if !s.searchDone {
+			info += ", searching…"
+		}
+	}
+	if s.message != "" {
+		info += " · " + s.message
+	}
+	return lipgloss.NewStyle().
+		Foreground(theme.MutedLavender).
+		Background(lipgloss.Color("236")).
+		Width(width).
+		Render(ansi.Truncate(info, width, "…"))
+}
+
+// formatSize formats a byte count for display.
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return itoa(int(n)) + " B"
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	tenths := int(n * 10 / div)
+	return itoa(tenths/10) + "." + itoa(tenths%10) + " " + string("KMGTPE"[exp]) + "B"
+}
+
+// streamPercent returns how far through a streamed file the view is. While
+// indexing, the position is measured in bytes as the line count isn't known.
+func (m Model) streamPercent() float64 {
+	s := m.stream
+	if !s.done {
+		if s.size == 0 || s.top >= len(s.offsets) {
+			return 0
+		}
+		return float64(s.offsets[s.top]) / float64(s.size) * 100
+	}
+	_, h := m.streamSize()
+	maxTop := s.lineCount() - h
+	if maxTop <= 0 {
+		return 100
+	}
+	return float64(s.top) / float64(maxTop) * 100
+}
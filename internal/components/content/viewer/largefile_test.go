@@ -0,0 +1,191 @@
+package viewer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// largeFile writes a file of n numbered lines, big enough to be streamed.
+func largeFile(t *testing.T, name string, n int) string {
+	t.Helper()
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteString("line ")
+		b.WriteString(itoa(i + 1))
+		b.WriteString(strings.Repeat(" padding", 8))
+		b.WriteString("\n")
+	}
+	require.Greater(t, b.Len(), LargeFileSize)
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(b.String()), 0644))
+	return path
+}
+
+// run executes cmd and feeds the resulting messages back into the model
+// until no work is left.
+func run(m Model, cmd tea.Cmd) Model {
+	queue := []tea.Cmd{cmd}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if c == nil {
+			continue
+		}
+		msg := c()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			queue = append(queue, batch...)
+			continue
+		}
+		var next tea.Cmd
+		m, next = m.Update(msg)
+		queue = append(queue, next)
+	}
+	return m
+}
+
+// smallChunks shrinks the index and search step so tests cross chunk
+// boundaries without huge files.
+func smallChunks(t *testing.T, size int) {
+	old := chunkSize
+	chunkSize = size
+	t.Cleanup(func() { chunkSize = old })
+}
+
+func TestLargeFileStreams(t *testing.T) {
+	smallChunks(t, 64<<10)
+	path := largeFile(t, "big.log", 20000)
+
+	msg := loadFile(path, 0, false)
+	assert.True(t, msg.Large)
+	assert.Empty(t, msg.Content, "large files shouldn't be read whole")
+
+	m := New().SetSize(80, 21).Focus()
+	m = run(m, func() tea.Msg { return msg })
+
+	assert.True(t, m.stream.done)
+	assert.Equal(t, 20000, m.stream.lineCount(), "a final line break doesn't add a line")
+	assert.Contains(t, m.View(), "line 1 ")
+	assert.Contains(t, m.View(), "20000 lines")
+
+	// Only the view and its margin are read
+	assert.LessOrEqual(t, len(m.stream.raw), 20+2*windowMargin)
+
+	m.GotoLine(12345)
+	assert.Equal(t, 12345, m.TopLine())
+	assert.Contains(t, ansi.Strip(m.View()), "12346 │ line 12346 ")
+
+	m = press(m, tea.KeyPressMsg{Code: 'G', Text: "G"})
+	assert.Equal(t, 20000-20, m.TopLine())
+	assert.Equal(t, 100.0, m.ScrollPercent())
+	assert.Contains(t, m.View(), "line 20000 ")
+
+	m = press(m, tea.KeyPressMsg{Code: 'g', Text: "g"}, keyDown, keyDown)
+	assert.Equal(t, 2, m.TopLine())
+}
+
+func TestLargeFileGotoBeforeIndexed(t *testing.T) {
+	smallChunks(t, 64<<10)
+	path := largeFile(t, "big.log", 20000)
+
+	// The line asked for is beyond the first chunk; the view gets there once
+	// it's indexed
+	m := New().SetSize(80, 21).Focus()
+	m = run(m, LoadFileAt(path, 15000))
+	assert.Equal(t, 15000, m.TopLine())
+	assert.Contains(t, m.View(), "line 15001 ")
+}
+
+func TestLargeFileSearch(t *testing.T) {
+	smallChunks(t, 64<<10)
+	path := largeFile(t, "big.log", 20000)
+
+	m := New().SetSize(80, 21).Focus()
+	m = run(m, LoadFile(path))
+
+	m = press(m, tea.KeyPressMsg{Code: '/', Text: "/"})
+	m = typeText(m, "line 1999[0-9] ")
+	var cmd tea.Cmd
+	m, cmd = m.Update(keyEnter)
+	require.NotNil(t, cmd, "the search runs in the background")
+	m = run(m, cmd)
+
+	assert.True(t, m.stream.searchDone)
+	assert.Equal(t, []int{19989, 19990, 19991, 19992, 19993, 19994, 19995, 19996, 19997, 19998}, m.matchLines)
+	assert.Equal(t, 0, m.currentMatch)
+	line, col := m.CursorPosition()
+	assert.Equal(t, 19989, line)
+	assert.Equal(t, 0, col)
+
+	m = press(m, tea.KeyPressMsg{Code: 'p', Text: "p"})
+	line, _ = m.CursorPosition()
+	assert.Equal(t, 19998, line)
+
+	// Clearing stops a search still running
+	m = press(m, tea.KeyPressMsg{Code: '/', Text: "/"})
+	m = typeText(m, "x")
+	m, cmd = m.Update(keyEnter)
+	m = press(m, keyEsc)
+	m = run(m, cmd)
+	assert.Empty(t, m.matchLines)
+}
+
+func TestLargeFileLongLines(t *testing.T) {
+	smallChunks(t, 64<<10)
+	// A single line longer than a chunk, with a match past the first chunk
+	long := strings.Repeat("a", 100<<10) + "needle" + strings.Repeat("b", 1<<20)
+	path := filepath.Join(t.TempDir(), "long.txt")
+	require.NoError(t, os.WriteFile(path, []byte("first\n"+long+"\nlast needle\n"), 0644))
+
+	m := New().SetSize(80, 21).Focus()
+	m = run(m, LoadFile(path))
+	assert.Equal(t, 3, m.stream.lineCount())
+	assert.LessOrEqual(t, len(m.stream.raw[1]), maxLineBytes+len("…"), "long lines are cut for display")
+
+	m.searchInput.SetValue("needle")
+	m = run(m, m.startStreamSearch("needle"))
+	assert.Equal(t, []int{1, 2}, m.matchLines)
+}
+
+func TestLargeFileHighlighting(t *testing.T) {
+	path := largeFile(t, "big.go", 20000)
+
+	m := New().SetSize(80, 21).Focus()
+	m = run(m, LoadFile(path))
+	assert.True(t, m.stream.highlight)
+	assert.NotContains(t, m.View(), "highlighting off")
+
+	// Above the limit, windows are shown plain
+	m = run(m, func() tea.Msg {
+		return FileLoadedMsg{Path: path, Size: HighlightSizeLimit + 1, Large: true}
+	})
+	assert.False(t, m.stream.highlight)
+	assert.Contains(t, m.View(), "highlighting off")
+	assert.Equal(t, m.stream.raw, m.stream.lines)
+}
+
+func TestLargeFileNotEditable(t *testing.T) {
+	path := largeFile(t, "big.txt", 20000)
+
+	m := New().SetSize(80, 21).Focus()
+	m = run(m, EditFileAt(path, 0))
+	assert.False(t, m.IsEditing(), "streamed files have no content to save")
+
+	m = press(m, tea.KeyPressMsg{Code: 'e', Text: "e"})
+	assert.False(t, m.IsEditing())
+	assert.Contains(t, m.View(), "too large to edit")
+
+	// Loading a small file afterwards works as usual
+	small := filepath.Join(t.TempDir(), "small.txt")
+	require.NoError(t, os.WriteFile(small, []byte("hi\n"), 0644))
+	m = run(m, LoadFile(small))
+	assert.False(t, m.streaming)
+	assert.True(t, m.StartEdit())
+}
@@ -0,0 +1,881 @@
+package viewer
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"charm.land/lipgloss/v2"
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/avitaltamir/vibecommander/internal/syntax"
+	"github.com/avitaltamir/vibecommander/internal/theme"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Block patterns
+var (
+	mdHeading   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	mdFence     = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^`\\s]*)")
+	mdRule      = regexp.MustCompile(`^ {0,3}([-*_])(?:[ \t]*[-*_]){2,}[ \t]*$`)
+	mdQuote     = regexp.MustCompile(`^ {0,3}> ?`)
+	mdListItem  = regexp.MustCompile(`^( *)([-*+]|\d{1,9}[.)])(?:( +)(.*))?$`)
+	mdTableSep  = regexp.MustCompile(`^ *\|? *:?-+:? *(?:\| *:?-+:? *)*\|? *$`)
+	mdSetextOne = regexp.MustCompile(`^ {0,3}=+[ \t]*$`)
+	mdSetextTwo = regexp.MustCompile(`^ {0,3}-+[ \t]*$`)
+)
+
+// isMarkdown reports whether path is a Markdown file.
+func isMarkdown(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown", ".mdown", ".mkd", ".mdx":
+		return true
+	}
+	return false
+}
+
+// span is a run of inline text in one style.
+type span struct {
+	text  string // "\n" for a hard line break
+	style lipgloss.Style
+}
+
+// renderMarkdown renders Markdown source as styled lines fitting width. It
+// also returns the source line (0-indexed) each rendered line comes from.
+func renderMarkdown(source string, width int) ([]string, []int) {
+	if width < 10 {
+		width = 10
+	}
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	source = strings.ReplaceAll(source, "\t", "    ")
+	return renderBlocks(strings.Split(source, "\n"), width, 0)
+}
+
+// renderBlocks renders block-level Markdown, separating blocks with a blank
+// line. depth is the list nesting level. It also returns the index in lines
+// of the block each rendered line belongs to.
+func renderBlocks(lines []string, width, depth int) ([]string, []int) {
+	var out []string
+	var src []int
+	start := 0
+	add := func(block []string) {
+		if len(out) > 0 {
+			out = append(out, "")
+			src = append(src, src[len(src)-1])
+		}
+		out = append(out, block...)
+		for range block {
+			src = append(src, start)
+		}
+	}
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		start = i
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+
+		case mdFence.MatchString(line):
+			m := mdFence.FindStringSubmatch(line)
+			indent, fence, lang := len(m[1]), m[2], m[3]
+			var code []string
+			for i++; i < len(lines); i++ {
+				l := strings.TrimLeft(lines[i], " ")
+				if strings.HasPrefix(l, fence[:3]) && strings.Trim(l, string(fence[0])+" ") == "" && len(strings.TrimRight(l, " ")) >= len(fence) {
+					i++
+					break
+				}
+				code = append(code, trimIndent(lines[i], indent))
+			}
+			add(renderCode(lang, code, width))
+
+		case mdHeading.MatchString(line):
+			m := mdHeading.FindStringSubmatch(line)
+			add(renderHeading(len(m[1]), m[2], width))
+			i++
+
+		case mdRule.MatchString(line):
+			add([]string{lipgloss.NewStyle().Foreground(theme.DimPurple).Render(strings.Repeat("─", width))})
+			i++
+
+		case mdQuote.MatchString(line):
+			var quoted []string
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				if !mdQuote.MatchString(lines[i]) && (len(quoted) == 0 || startsBlock(lines[i])) {
+					break
+				}
+				quoted = append(quoted, mdQuote.ReplaceAllString(lines[i], ""))
+			}
+			bar := lipgloss.NewStyle().Foreground(theme.LaserPurple).Render("┃ ")
+			inner, _ := renderBlocks(quoted, width-2, depth)
+			for j := range inner {
+				inner[j] = bar + inner[j]
+			}
+			add(inner)
+
+		case i+1 < len(lines) && strings.Contains(line, "|") && mdTableSep.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "-"):
+			rows := [][]string{splitRow(line)}
+			aligns := tableAligns(splitRow(lines[i+1]))
+			for i += 2; i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != ""; i++ {
+				rows = append(rows, splitRow(lines[i]))
+			}
+			add(renderTable(rows, aligns, width))
+
+		case mdListItem.MatchString(line):
+			var block []string
+			block, i = renderList(lines, i, width, depth)
+			add(block)
+
+		default:
+			// Paragraph, possibly a setext heading
+			var para []string
+			level := 0
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				if len(para) > 0 && mdSetextOne.MatchString(lines[i]) {
+					level = 1
+				} else if len(para) > 0 && mdSetextTwo.MatchString(lines[i]) {
+					level = 2
+				} else if len(para) > 0 && startsBlock(lines[i]) {
+					break
+				}
+				if level > 0 {
+					i++
+					break
+				}
+				para = append(para, lines[i])
+			}
+			if level > 0 {
+				add(renderHeading(level, strings.Join(trimLines(para), " "), width))
+			} else {
+				add(wrapSpans(paragraphSpans(para), width))
+			}
+		}
+	}
+	return out, src
+}
+
+// startsBlock reports whether line starts a block that interrupts a
+// paragraph.
+func startsBlock(line string) bool {
+	return mdFence.MatchString(line) || mdHeading.MatchString(line) || mdRule.MatchString(line) ||
+		mdQuote.MatchString(line) || mdListItem.MatchString(line)
+}
+
+// renderHeading renders a heading; the top two levels are underlined.
+func renderHeading(level int, text string, width int) []string {
+	style := lipgloss.NewStyle().Bold(true)
+	switch level {
+	case 1:
+		style = style.Foreground(theme.MagentaBlaze)
+	case 2:
+		style = style.Foreground(theme.CyberCyan)
+	case 3:
+		style = style.Foreground(theme.LaserPurple)
+	default:
+		style = style.Foreground(theme.PureWhite)
+	}
+
+	out := wrapSpans(parseInline(text, style), width)
+	rule := lipgloss.NewStyle().Foreground(theme.DimPurple)
+	switch level {
+	case 1:
+		out = append(out, rule.Render(strings.Repeat("━", width)))
+	case 2:
+		out = append(out, rule.Render(strings.Repeat("─", width)))
+	}
+	return out
+}
+
+// lexerForLang picks a lexer by a code block's language, falling back to
+// analysing the code.
+func lexerForLang(lang, code string) chroma.Lexer {
+	var lexer chroma.Lexer
+	if lang != "" {
+		lexer = lexers.Get(lang)
+	}
+	if lexer == nil {
+		lexer = lexers.Analyse(code)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	return chroma.Coalesce(lexer)
+}
+
+// renderCode renders a fenced code block, highlighted and cut to width.
+func renderCode(lang string, code []string, width int) []string {
+	text := strings.Join(code, "\n")
+	highlighted := strings.Split(syntax.Highlight(lexerForLang(lang, text), text), "\n")
+
+	bar := lipgloss.NewStyle().Foreground(theme.DimPurple).Render("▎ ")
+	out := make([]string, 0, len(code)+1)
+	if lang != "" {
+		out = append(out, bar+lipgloss.NewStyle().Foreground(theme.MutedLavender).Italic(true).Render(lang))
+	}
+	for i := range code {
+		line := code[i]
+		if i < len(highlighted) {
+			line = highlighted[i]
+		}
+		out = append(out, bar+ansi.Truncate(line, width-2, "…"))
+	}
+	return out
+}
+
+// renderList renders the list starting at lines[start], nested lists and
+// all, and returns the index of the line after it.
+func renderList(lines []string, start, width, depth int) ([]string, int) {
+	first := mdListItem.FindStringSubmatch(lines[start])
+	ordered := first[2][0] >= '0' && first[2][0] <= '9'
+	indent := len(first[1])
+
+	// Collect items: each is its marker and its content, dedented
+	type item struct {
+		marker  string
+		content []string
+	}
+	var items []item
+	loose, gap := false, false
+	i := start
+	for i < len(lines) {
+		m := mdListItem.FindStringSubmatch(lines[i])
+		if m == nil || len(m[1]) > indent+1 || len(m[1]) < indent || (m[2][0] >= '0' && m[2][0] <= '9') != ordered {
+			break
+		}
+		loose = loose || gap
+		pad := len(m[3])
+		if pad > 4 || m[4] == "" {
+			pad = 1
+		}
+		contentCol := len(m[1]) + len(m[2]) + pad
+		it := item{marker: m[2], content: []string{m[4]}}
+
+		blank := false
+		for i++; i < len(lines); i++ {
+			l := lines[i]
+			if strings.TrimSpace(l) == "" {
+				blank = true
+				it.content = append(it.content, "")
+				continue
+			}
+			lead := len(l) - len(strings.TrimLeft(l, " "))
+			if lead >= contentCol {
+				it.content = append(it.content, l[contentCol:])
+			} else if !blank && !startsBlock(l) {
+				it.content = append(it.content, strings.TrimLeft(l, " ")) // Lazy continuation
+			} else {
+				break
+			}
+			blank = false
+		}
+		// Blank lines between items make the list loose
+		gap = false
+		for len(it.content) > 1 && it.content[len(it.content)-1] == "" {
+			it.content = it.content[:len(it.content)-1]
+			gap = true
+		}
+		items = append(items, it)
+	}
+
+	// Markers line up: ordered markers are padded to the widest number
+	markerStyle := lipgloss.NewStyle().Foreground(theme.CyberCyan)
+	bullets := []string{"•", "◦", "▪"}
+	markerWidth := 2
+	number := 1
+	if ordered {
+		number = atoiPrefix(items[0].marker)
+		markerWidth = len(itoa(number+len(items)-1)) + 2
+	}
+
+	var out []string
+	for n, it := range items {
+		marker := bullets[depth%len(bullets)]
+		if ordered {
+			marker = itoa(number+n) + string(it.marker[len(it.marker)-1])
+		}
+
+		// Task list items
+		content := it.content
+		if len(content) > 0 && len(content[0]) >= 3 && content[0][0] == '[' && content[0][2] == ']' &&
+			(len(content[0]) == 3 || content[0][3] == ' ') {
+			switch content[0][1] {
+			case ' ':
+				marker = "☐"
+			case 'x', 'X':
+				marker = "☑"
+			}
+			if marker == "☐" || marker == "☑" {
+				content = append([]string{strings.TrimPrefix(content[0][3:], " ")}, content[1:]...)
+			}
+		}
+
+		body, _ := renderBlocks(content, width-markerWidth, depth+1)
+		if !loose {
+			// Tight lists keep an item's blocks together
+			tight := body[:0]
+			for _, l := range body {
+				if l != "" {
+					tight = append(tight, l)
+				}
+			}
+			body = tight
+		}
+		if len(body) == 0 {
+			body = []string{""}
+		}
+		if loose && n > 0 {
+			out = append(out, "")
+		}
+		for j, l := range body {
+			if j == 0 {
+				out = append(out, markerStyle.Render(padRight(marker, markerWidth))+l)
+			} else if l == "" {
+				out = append(out, "")
+			} else {
+				out = append(out, strings.Repeat(" ", markerWidth)+l)
+			}
+		}
+	}
+	return out, i
+}
+
+// splitRow splits a table row into cells on unescaped pipes.
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, "\\|") {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	inCode := false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case c == '`':
+			inCode = !inCode
+			cell.WriteByte(c)
+		case c == '|' && !inCode:
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(c)
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// tableAligns reads column alignments from a table's separator row.
+func tableAligns(sep []string) []lipgloss.Position {
+	aligns := make([]lipgloss.Position, len(sep))
+	for i, s := range sep {
+		left, right := strings.HasPrefix(s, ":"), strings.HasSuffix(s, ":")
+		switch {
+		case left && right:
+			aligns[i] = lipgloss.Center
+		case right:
+			aligns[i] = lipgloss.Right
+		default:
+			aligns[i] = lipgloss.Left
+		}
+	}
+	return aligns
+}
+
+// renderTable renders a table with box borders, shrinking the widest columns
+// and cutting their cells when it doesn't fit.
+func renderTable(rows [][]string, aligns []lipgloss.Position, width int) []string {
+	cols := len(aligns)
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(theme.CyberCyan)
+	border := lipgloss.NewStyle().Foreground(theme.DimPurple)
+
+	// Render cells and measure columns
+	cells := make([][]string, len(rows))
+	widths := make([]int, cols)
+	for r, row := range rows {
+		cells[r] = make([]string, cols)
+		for c := 0; c < cols; c++ {
+			text := ""
+			if c < len(row) {
+				text = row[c]
+			}
+			style := lipgloss.NewStyle()
+			if r == 0 {
+				style = headerStyle
+			}
+			cells[r][c] = strings.Join(wrapSpans(parseInline(text, style), 1<<30), "")
+			if w := ansi.StringWidth(cells[r][c]); w > widths[c] {
+				widths[c] = w
+			}
+		}
+	}
+
+	// "│ a │ b │": 3 per column plus 1
+	for total := sumInts(widths) + 3*cols + 1; total > width; total-- {
+		widest := 0
+		for c := range widths {
+			if widths[c] > widths[widest] {
+				widest = c
+			}
+		}
+		if widths[widest] <= 3 {
+			break
+		}
+		widths[widest]--
+	}
+
+	line := func(left, mid, right string) string {
+		var b strings.Builder
+		b.WriteString(left)
+		for c, w := range widths {
+			if c > 0 {
+				b.WriteString(mid)
+			}
+			b.WriteString(strings.Repeat("─", w+2))
+		}
+		b.WriteString(right)
+		return border.Render(b.String())
+	}
+
+	out := []string{line("┌", "┬", "┐")}
+	for r := range cells {
+		var b strings.Builder
+		b.WriteString(border.Render("│"))
+		for c, w := range widths {
+			cell := ansi.Truncate(cells[r][c], w, "…")
+			b.WriteString(" " + lipgloss.PlaceHorizontal(w, aligns[c], cell) + " ")
+			b.WriteString(border.Render("│"))
+		}
+		out = append(out, b.String())
+		if r == 0 {
+			out = append(out, line("├", "┼", "┤"))
+		}
+	}
+	return append(out, line("└", "┴", "┘"))
+}
+
+// paragraphSpans joins a paragraph's lines into spans. Lines ending in two
+// spaces or a backslash end with a hard break.
+func paragraphSpans(lines []string) []span {
+	var spans []span
+	for i, l := range lines {
+		hard := strings.HasSuffix(l, "  ") || strings.HasSuffix(l, "\\")
+		text := strings.TrimSpace(l)
+		if hard {
+			text = strings.TrimSuffix(text, "\\")
+		}
+		spans = append(spans, parseInline(text, lipgloss.NewStyle())...)
+		if i < len(lines)-1 {
+			if hard {
+				spans = append(spans, span{text: "\n"})
+			} else {
+				spans = append(spans, span{text: " "})
+			}
+		}
+	}
+	return spans
+}
+
+// parseInline splits inline Markdown into styled spans: code, emphasis,
+// strikethrough, links, images and autolinks.
+func parseInline(text string, base lipgloss.Style) []span {
+	var spans []span
+	var plain strings.Builder
+	flush := func() {
+		if plain.Len() > 0 {
+			spans = append(spans, span{text: plain.String(), style: base})
+			plain.Reset()
+		}
+	}
+	emit := func(s ...span) {
+		flush()
+		spans = append(spans, s...)
+	}
+
+	codeStyle := base.Foreground(theme.ElectricYellow).Background(lipgloss.Color("236"))
+	linkStyle := base.Foreground(theme.CyberCyan).Underline(true)
+	dimStyle := base.Foreground(theme.MutedLavender)
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		rest := text[i:]
+		switch {
+		case c == '\\' && i+1 < len(text) && unicode.IsPunct(rune(text[i+1])):
+			plain.WriteByte(text[i+1])
+			i += 2
+			continue
+
+		case c == '`':
+			n := len(rest) - len(strings.TrimLeft(rest, "`"))
+			fence := rest[:n]
+			if end := strings.Index(rest[n:], fence); end >= 0 {
+				code := rest[n : n+end]
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+					code = code[1 : len(code)-1]
+				}
+				emit(span{text: code, style: codeStyle})
+				i += 2*n + end
+				continue
+			}
+			plain.WriteString(fence)
+			i += n
+			continue
+
+		case c == '*' || c == '_' || c == '~':
+			if s, n, ok := parseEmphasis(text, i, base); ok {
+				emit(s...)
+				i += n
+				continue
+			}
+
+		case c == '!' && strings.HasPrefix(rest, "!["):
+			if label, url, n, ok := parseLink(rest[1:]); ok {
+				alt := label
+				if alt == "" {
+					alt = url
+				}
+				emit(span{text: "[image: " + alt + "]", style: dimStyle.Italic(true)})
+				i += 1 + n
+				continue
+			}
+
+		case c == '[':
+			if label, url, n, ok := parseLink(rest); ok {
+				emit(parseInline(label, linkStyle)...)
+				if url != "" && url != label && !strings.HasPrefix(url, "#") {
+					emit(span{text: " (" + url + ")", style: dimStyle})
+				}
+				i += n
+				continue
+			}
+
+		case c == '<':
+			if end := strings.IndexByte(rest, '>'); end > 1 {
+				target := rest[1:end]
+				if !strings.ContainsAny(target, " <") && (strings.Contains(target, "://") || strings.Contains(target, "@")) {
+					emit(span{text: target, style: linkStyle})
+					i += end + 1
+					continue
+				}
+			}
+		}
+
+		_, size := utf8.DecodeRuneInString(rest)
+		plain.WriteString(rest[:size])
+		i += size
+	}
+	flush()
+	return spans
+}
+
+// parseEmphasis parses emphasis or strikethrough opening at text[i], and
+// returns its spans and length.
+func parseEmphasis(text string, i int, base lipgloss.Style) ([]span, int, bool) {
+	c := text[i]
+	n := 0
+	for i+n < len(text) && text[i+n] == c && n < 3 {
+		n++
+	}
+	if c == '~' && n != 2 {
+		return nil, 0, false
+	}
+	// Underscores don't emphasize inside words
+	if c == '_' && i > 0 && isWordByte(text[i-1]) {
+		return nil, 0, false
+	}
+	delim := text[i : i+n]
+	after := text[i+n:]
+	if after == "" || after[0] == ' ' {
+		return nil, 0, false
+	}
+
+	end := strings.Index(after, delim)
+	for end >= 0 && (end == 0 || after[end-1] == ' ' || (c == '_' && end+n < len(after) && isWordByte(after[end+n]))) {
+		next := strings.Index(after[end+1:], delim)
+		if next < 0 {
+			end = -1
+			break
+		}
+		end += 1 + next
+	}
+	if end < 0 {
+		return nil, 0, false
+	}
+
+	style := base
+	switch {
+	case c == '~':
+		style = style.Strikethrough(true)
+	case n == 1:
+		style = style.Italic(true)
+	case n == 2:
+		style = style.Bold(true)
+	default:
+		style = style.Bold(true).Italic(true)
+	}
+	return parseInline(after[:end], style), 2*n + end, true
+}
+
+// parseLink parses "[label](url)" or "[label][ref]" at the start of text,
+// and returns the label, the URL (empty for references) and the length.
+func parseLink(text string) (label, url string, n int, ok bool) {
+	depth := 0
+	closing := -1
+	for i := 0; i < len(text) && closing < 0; i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closing = i
+			}
+		}
+	}
+	if closing < 0 {
+		return "", "", 0, false
+	}
+	label = text[1:closing]
+	rest := text[closing+1:]
+
+	switch {
+	case strings.HasPrefix(rest, "("):
+		end := strings.IndexByte(rest, ')')
+		if end < 0 {
+			return "", "", 0, false
+		}
+		url = strings.TrimSpace(rest[1:end])
+		if sp := strings.IndexAny(url, " \t"); sp >= 0 {
+			url = url[:sp] // Drop a title
+		}
+		url = strings.Trim(url, "<>")
+		return label, url, closing + 1 + end + 1, true
+	case strings.HasPrefix(rest, "["):
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			return "", "", 0, false
+		}
+		return label, "", closing + 1 + end + 1, true
+	}
+	return "", "", 0, false
+}
+
+// wrapSpans lays spans out in lines of at most width cells, breaking at
+// spaces; words longer than a line are split.
+func wrapSpans(spans []span, width int) []string {
+	var lines []string
+	var line strings.Builder
+	lineWidth := 0
+	pending := "" // Styled space waiting for the next word
+	pendingWidth := 0
+
+	newLine := func() {
+		lines = append(lines, line.String())
+		line.Reset()
+		lineWidth = 0
+		pending, pendingWidth = "", 0
+	}
+
+	for _, s := range spans {
+		if s.text == "\n" {
+			newLine()
+			continue
+		}
+		text := s.text
+		for text != "" {
+			// Next token: a run of spaces or a word
+			isSpace := text[0] == ' '
+			end := strings.IndexFunc(text, func(r rune) bool { return (r == ' ') != isSpace })
+			if end < 0 {
+				end = len(text)
+			}
+			token := text[:end]
+			text = text[end:]
+
+			if isSpace {
+				if lineWidth > 0 {
+					pending = s.style.Render(" ")
+					pendingWidth = 1
+				}
+				continue
+			}
+
+			w := ansi.StringWidth(token)
+			if lineWidth > 0 && lineWidth+pendingWidth+w > width {
+				newLine()
+			}
+			line.WriteString(pending)
+			lineWidth += pendingWidth
+			pending, pendingWidth = "", 0
+
+			// Split words longer than the space left
+			for lineWidth+w > width && width-lineWidth > 0 {
+				head := ansi.Truncate(token, width-lineWidth, "")
+				if head == "" {
+					break
+				}
+				line.WriteString(s.style.Render(head))
+				token = ansi.TruncateLeft(token, ansi.StringWidth(head), "")
+				w = ansi.StringWidth(token)
+				newLine()
+			}
+			if token != "" {
+				line.WriteString(s.style.Render(token))
+				lineWidth += w
+			}
+		}
+	}
+	if lineWidth > 0 || len(lines) == 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// trimIndent removes up to n leading spaces.
+func trimIndent(line string, n int) string {
+	for n > 0 && strings.HasPrefix(line, " ") {
+		line = line[1:]
+		n--
+	}
+	return line
+}
+
+// trimLines trims surrounding space from each line.
+func trimLines(lines []string) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = strings.TrimSpace(l)
+	}
+	return out
+}
+
+// padRight pads s with spaces to width cells.
+func padRight(s string, width int) string {
+	if w := ansi.StringWidth(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
+
+// atoiPrefix parses the leading digits of s.
+func atoiPrefix(s string) int {
+	n := 0
+	for i := 0; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+		n = n*10 + int(s[i]-'0')
+	}
+	return n
+}
+
+// sumInts adds up values.
+func sumInts(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// isWordByte reports whether b is an ASCII letter or digit.
+func isWordByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
+
+// previewing reports whether the file is shown as rendered Markdown.
+func (m Model) previewing() bool {
+	return m.preview && isMarkdown(m.path) && m.CanPreview() && !m.editing
+}
+
+// CanPreview reports whether the file has a rendered view: Markdown, or a
+// JSON, YAML, CSV or TSV file.
+func (m Model) CanPreview() bool {
+	return (isMarkdown(m.path) || dataFormatOf(m.path) != formatNone) && !m.streaming && !m.binary && m.err == nil
+}
+
+// IsPreview reports whether the viewer shows a rendered view.
+func (m Model) IsPreview() bool {
+	return m.previewing() || m.structured()
+}
+
+// TogglePreview switches between source and rendered view, keeping the same
+// source line at the top. The choice sticks for later files. A file that
+// fails to parse stays as source, scrolled to the error.
+func (m *Model) TogglePreview() {
+	line := m.TopLine()
+	m.preview = !m.preview
+	m.parseData()
+	if m.searchQuery != "" {
+		// Matches are rows of what's shown
+		m.performSearch(m.searchQuery)
+	}
+	m.selection.ClearSelection()
+	m.viewport.SetContent(m.renderContent())
+	if m.parseErr != nil && m.parseErr.line >= 0 {
+		line = max(m.parseErr.line-m.viewport.Height()/2, 0)
+	}
+	m.GotoLine(line)
+}
+
+// previewWidth returns the width Markdown is rendered at, leaving a margin.
+func (m Model) previewWidth() int {
+	w, _ := m.Size()
+	return w - 2
+}
+
+// renderPreview renders the content as Markdown, with search matches shown
+// like the source view's.
+func (m Model) renderPreview() string {
+	lines, _ := renderMarkdown(m.content, m.previewWidth())
+
+	currentMatchLine := -1
+	if m.currentMatch >= 0 && m.currentMatch < len(m.matchLines) {
+		currentMatchLine = m.matchLines[m.currentMatch]
+	}
+	for _, ln := range m.matchLines {
+		if ln < len(lines) {
+			lines[ln] = m.highlightMatchesInLine(ansi.Strip(lines[ln]), ln == currentMatchLine)
+		}
+	}
+	for i := range lines {
+		lines[i] = " " + lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// previewSourceLine returns the source line rendered at row.
+func (m Model) previewSourceLine(row int) int {
+	_, src := renderMarkdown(m.content, m.previewWidth())
+	if len(src) == 0 {
+		return 0
+	}
+	if row >= len(src) {
+		row = len(src) - 1
+	}
+	if row < 0 {
+		row = 0
+	}
+	return src[row]
+}
+
+// previewRow returns the first rendered row of source line, or of the block
+// containing it.
+func (m Model) previewRow(line int) int {
+	_, src := renderMarkdown(m.content, m.previewWidth())
+	row := 0
+	for i, s := range src {
+		if s > line {
+			break
+		}
+		if i == 0 || s != src[i-1] {
+			row = i
+		}
+	}
+	return row
+}
@@ -0,0 +1,165 @@
+package viewer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// plain renders Markdown and strips the styling.
+func plain(source string, width int) []string {
+	lines, _ := renderMarkdown(source, width)
+	for i := range lines {
+		lines[i] = ansi.Strip(lines[i])
+	}
+	return lines
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{
+			"headings",
+			"# One\n## Two\n### Three\nSetext\n---",
+			[]string{"One", strings.Repeat("━", 20), "", "Two", strings.Repeat("─", 20), "", "Three", "", "Setext", strings.Repeat("─", 20)},
+		},
+		{
+			"paragraph wraps and joins lines",
+			"Some *italic* and **bold**\ntext with `code` here",
+			[]string{"Some italic and bold", "text with code here"},
+		},
+		{
+			"hard break",
+			"one  \ntwo\\\nthree",
+			[]string{"one", "two", "three"},
+		},
+		{
+			"links and images",
+			"[site](https://x.io) [x.io](x.io) [top](#top) ![logo](a.png) <https://y.io>",
+			[]string{"site (https://x.io)", "x.io top [image:", "logo] https://y.io"},
+		},
+		{
+			"nested and task lists",
+			"- one\n- two\n  - inner\n- [x] done\n- [ ] todo",
+			[]string{"• one", "• two", "  ◦ inner", "☑ done", "☐ todo"},
+		},
+		{
+			"ordered list wraps with a hanging indent",
+			"9. first item is long\n10. second",
+			[]string{"9.  first item is", "    long", "10. second"},
+		},
+		{
+			"loose list",
+			"- one\n\n- two",
+			[]string{"• one", "", "• two"},
+		},
+		{
+			"quote",
+			"> quoted\nlazy line\n> # Heading",
+			[]string{"┃ quoted lazy line", "┃ ", "┃ Heading", "┃ " + strings.Repeat("━", 18)},
+		},
+		{
+			"code block",
+			"```go\nx := 1\n```",
+			[]string{"▎ go", "▎ x := 1"},
+		},
+		{
+			"table",
+			"| a | b |\n|:-|-:|\n| x | `y|z` |",
+			[]string{"┌───┬─────┐", "│ a │   b │", "├───┼─────┤", "│ x │ y|z │", "└───┴─────┘"},
+		},
+		{
+			"rule",
+			"a\n\n***\n\nb",
+			[]string{"a", "", strings.Repeat("─", 20), "", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, plain(tt.source, 20))
+		})
+	}
+}
+
+func TestRenderMarkdownTableShrinks(t *testing.T) {
+	lines := plain("| name | description |\n|---|---|\n| a | a rather long description |", 20)
+	for _, l := range lines {
+		assert.LessOrEqual(t, ansi.StringWidth(l), 20, l)
+	}
+	assert.Equal(t, "│ a    │ a rather… │", lines[3])
+}
+
+func TestRenderMarkdownSourceLines(t *testing.T) {
+	_, src := renderMarkdown("# Title\n\ntext\nmore\n\n- a\n- b", 40)
+	assert.Equal(t, []int{0, 0, 0, 2, 2, 5, 5}, src)
+}
+
+func TestWrapSpans(t *testing.T) {
+	style := lipgloss.NewStyle()
+	lines := wrapSpans([]span{{text: "ab abcdefghij", style: style}}, 4)
+	assert.Equal(t, []string{"ab", "abcd", "efgh", "ij"}, lines)
+
+	lines = wrapSpans([]span{{text: "a", style: style}, {text: "\n"}, {text: "b", style: style}}, 10)
+	assert.Equal(t, []string{"a", "b"}, lines)
+}
+
+func TestMarkdownPreview(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "README.md")
+	var doc strings.Builder
+	doc.WriteString("# Title\n\n")
+	for i := 0; i < 30; i++ {
+		doc.WriteString("Paragraph " + itoa(i) + " has some words in it.\n\n")
+	}
+	require.NoError(t, os.WriteFile(path, []byte(doc.String()), 0644))
+
+	m := New().SetSize(60, 10).Focus()
+	m, _ = m.Update(loadFile(path, 0, false))
+	assert.True(t, m.CanPreview())
+	assert.False(t, m.IsPreview())
+	assert.Contains(t, ansi.Strip(m.View()), "# Title")
+
+	m = press(m, tea.KeyPressMsg{Code: 'm', Text: "m"})
+	assert.True(t, m.IsPreview())
+	view := ansi.Strip(m.View())
+	assert.NotContains(t, view, "# Title")
+	assert.Contains(t, view, " Title")
+
+	// Positions are source lines: paragraph 10 is on line 22
+	m.GotoLine(22)
+	assert.Equal(t, 22, m.TopLine())
+	assert.Contains(t, strings.Split(ansi.Strip(m.View()), "\n")[0], "Paragraph 10")
+
+	// Narrow panes reflow
+	m = m.SetSize(20, 10)
+	assert.Equal(t, 22, m.TopLine())
+	assert.Equal(t, " Paragraph 10 has", strings.TrimRight(strings.Split(ansi.Strip(m.View()), "\n")[0], " "))
+
+	// Search finds rendered text and reports the source line
+	m = press(m, tea.KeyPressMsg{Code: '/', Text: "/"})
+	m = typeText(m, "paragraph 25")
+	m = press(m, keyEnter)
+	line, _ := m.CursorPosition()
+	assert.Equal(t, 52, line)
+
+	// Toggling back keeps the position, and the choice sticks
+	m = press(m, keyEsc, tea.KeyPressMsg{Code: 'm', Text: "m"})
+	assert.False(t, m.IsPreview())
+	m = press(m, tea.KeyPressMsg{Code: 'm', Text: "m"})
+	m, _ = m.Update(loadFile(path, 0, false))
+	assert.True(t, m.IsPreview())
+
+	// Editing shows the source
+	assert.True(t, m.StartEdit())
+	assert.False(t, m.IsPreview())
+}
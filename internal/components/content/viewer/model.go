@@ -1,22 +1,21 @@
 package viewer
 
 import (
-	"bytes"
 	"os"
-	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"charm.land/bubbles/v2/textinput"
 	"charm.land/bubbles/v2/viewport"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
-	"github.com/alecthomas/chroma/v2"
-	"github.com/alecthomas/chroma/v2/formatters"
-	"github.com/alecthomas/chroma/v2/lexers"
-	"github.com/alecthomas/chroma/v2/styles"
 	"github.com/avitaltamir/vibecommander/internal/components"
+	"github.com/avitaltamir/vibecommander/internal/filetype"
+	"github.com/avitaltamir/vibecommander/internal/find"
 	"github.com/avitaltamir/vibecommander/internal/selection"
+	"github.com/avitaltamir/vibecommander/internal/syntax"
 	"github.com/avitaltamir/vibecommander/internal/theme"
 	"github.com/charmbracelet/x/ansi"
 )
@@ -25,10 +24,34 @@ import (
 type (
 	// FileLoadedMsg is sent when a file has been loaded.
 	FileLoadedMsg struct {
+		Path     string
+		Content  string
+		Line     int               // 0-indexed line to scroll to
+		ModTime  time.Time         // Modification time, checked before saving edits
+		Edit     bool              // Start in edit mode
+		Size     int64             // File size in bytes
+		Large    bool              // Too large to read whole: Content is empty and the file is streamed
+		Binary   bool              // Binary content, shown as hex: Content is empty
+		Kind     string            // Detected file type, e.g. "PNG image"
+		Encoding filetype.Encoding // How the file stores Content
+		Err      error
+	}
+
+	// FileSavedMsg is sent when a save from edit mode finishes.
+	FileSavedMsg struct {
 		Path    string
 		Content string
+		ModTime time.Time
+		Undo    int // Undo depth the saved content corresponds to
 		Err     error
 	}
+
+	// JumpMsg is sent when a search moves the view, so the position left
+	// behind can be recorded in the jump list.
+	JumpMsg struct {
+		Path string
+		Line int // Top line (0-indexed) before the jump
+	}
 )
 
 // Model is the content viewer component.
@@ -38,9 +61,48 @@ type Model struct {
 	viewport viewport.Model
 	path     string
 	content  string
+	modTime  time.Time
+	encoding filetype.Encoding // How the file stores content, kept on save
 	ready    bool
 	err      error
 
+	// Edit mode
+	editing bool
+	edit    editor
+
+	// Large files, streamed from disk
+	streaming bool
+	stream    stream
+	loadSeq   int
+
+	// Binary files, shown as hex
+	binary bool
+	hex    hexView
+
+	// Markdown, JSON, YAML, CSV and TSV shown rendered rather than as
+	// source; sticks across files
+	preview bool
+
+	// JSON and YAML as a tree or CSV and TSV as a table while previewing, or
+	// why the file couldn't be parsed
+	data     dataView
+	parseErr *parseError
+
+	// Symbols and folded regions of the source view
+	fold folding
+
+	// Long lines in the source view wrap (sticks across files) or scroll
+	// sideways from column left. rows maps the view's rows to lines while
+	// wrapping or folded, nil otherwise.
+	wrap bool
+	left int
+	rows []viewRow
+
+	// Go-to-line prompt
+	goingTo   bool
+	gotoInput textinput.Model
+	gotoErr   bool
+
 	// Search
 	searching    bool
 	searchInput  textinput.Model
@@ -58,13 +120,14 @@ type Model struct {
 // New creates a new content viewer model.
 func New() Model {
 	ti := textinput.New()
-	ti.Placeholder = "regex pattern..."
+	ti.Placeholder = searchPlaceholder
 	ti.CharLimit = 256
 	ti.SetWidth(30)
 
 	return Model{
 		theme:        theme.DefaultTheme(),
 		searchInput:  ti,
+		gotoInput:    newGotoInput(),
 		currentMatch: -1,
 		selection:    selection.New(),
 	}
@@ -85,9 +148,42 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		// This is handled by SetSize from parent
 		return m, nil
 
+	case tea.MouseClickMsg, tea.MouseMotionMsg, tea.MouseReleaseMsg, tea.MouseWheelMsg:
+		if m.editing {
+			return m.editMouse(msg), nil
+		}
+		if m.streaming || m.binary {
+			// No selection in streamed or binary files, only scrolling
+			if wheel, ok := msg.(tea.MouseWheelMsg); ok {
+				switch wheel.Mouse().Button {
+				case tea.MouseWheelUp:
+					m.scrollBy(-3)
+				case tea.MouseWheelDown:
+					m.scrollBy(3)
+				}
+			}
+			return m, nil
+		}
+		if m.structured() {
+			return m.dataMouse(msg), nil
+		}
+	}
+
+	switch msg := msg.(type) {
+
 	case tea.MouseClickMsg:
+		if m.previewing() {
+			return m, nil // Rendered text doesn't map back to source for selection
+		}
 		// Handle text selection start - MouseClickMsg is only for left button
 		mouse := msg.Mouse()
+		// A click on the line number of a block folds or unfolds it
+		if line := m.lineOfRow(mouse.Y - 1 + m.viewport.YOffset()); mouse.X-1 < lineNumberWidth && m.foldable() {
+			if r, ok := m.regionAt(line); ok && r.Start == line {
+				m.toggleFold(line)
+				return m, nil
+			}
+		}
 		// Start selection - convert screen coordinates to text position
 		line, col := m.screenToTextPosition(mouse.X, mouse.Y)
 		m.selection.StartSelection(line, col)
@@ -117,24 +213,84 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		}
 
 	case tea.MouseWheelMsg:
+		// Sideways scrolling moves the text, keeping the line numbers
+		if mouse := msg.Mouse(); m.foldable() && !m.wrap {
+			switch {
+			case mouse.Button == tea.MouseWheelLeft, mouse.Button == tea.MouseWheelUp && mouse.Mod.Contains(tea.ModShift):
+				m.scrollColumn(m.left - scrollColumns)
+				return m, nil
+			case mouse.Button == tea.MouseWheelRight, mouse.Button == tea.MouseWheelDown && mouse.Mod.Contains(tea.ModShift):
+				m.scrollColumn(m.left + scrollColumns)
+				return m, nil
+			}
+		}
 		// Always handle mouse wheel for scrolling, even when not focused
 		m.viewport, cmd = m.viewport.Update(msg)
 		cmds = append(cmds, cmd)
 		return m, tea.Batch(cmds...)
 
 	case FileLoadedMsg:
+		m.editing = false
+		m.streaming = false
+		m.binary = false
+		m.left = 0
+		m.stopGoto()
+		m.searchInput.Placeholder = searchPlaceholder
+		m.selection.ClearSelection()
 		if msg.Err != nil {
 			m.err = msg.Err
 			m.content = ""
+			m.parseData()
+			m.loadOutline()
 			m.viewport.SetContent(m.renderError(msg.Err))
 		} else {
 			m.path = msg.Path
 			m.content = msg.Content
+			m.modTime = msg.ModTime
+			m.encoding = msg.Encoding
 			m.err = nil
 			// Clear search when loading new file
 			m.clearSearch()
+			m.parseData()
+			m.loadOutline()
+			if msg.Binary {
+				m.viewport.SetContent("")
+				m.startHex(msg)
+				return m, nil
+			}
+			if msg.Large {
+				m.viewport.SetContent("")
+				return m, m.startStream(msg)
+			}
 			m.viewport.SetContent(m.renderContent())
 			m.viewport.GotoTop()
+			m.GotoLine(msg.Line)
+			if msg.Edit {
+				m.StartEdit()
+				m.GotoLine(msg.Line)
+			}
+		}
+		return m, nil
+
+	case FileSavedMsg:
+		m.handleSaved(msg)
+		return m, nil
+
+	case IndexMsg:
+		return m, m.handleIndex(msg)
+
+	case StreamSearchMsg:
+		return m, m.handleStreamSearch(msg)
+
+	case HexSearchMsg:
+		return m, m.handleHexSearch(msg)
+
+	case tea.PasteMsg:
+		if m.editing && m.Focused() {
+			m.insert(msg.Content)
+			m.edit.merge = false
+			rows, cols := m.editSize()
+			m.edit.scrollToCursor(rows, cols)
 		}
 		return m, nil
 
@@ -143,6 +299,10 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			return m, nil
 		}
 
+		if m.editing {
+			return m.updateEdit(msg)
+		}
+
 		key := msg.Key()
 
 		// Handle copy (Ctrl+C) when text is selected
@@ -170,8 +330,18 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				return m, nil
 			case tea.KeyEnter:
 				// Perform search or go to next match
+				from := m.TopLine()
 				query := m.searchInput.Value()
-				if query != m.searchQuery {
+				if query != m.searchQuery && m.binary {
+					m.searching = false
+					m.searchInput.Blur()
+					return m, m.startHexSearch(query)
+				} else if query != m.searchQuery && m.streaming {
+					// Searched in the background, jumping to the first match
+					m.searching = false
+					m.searchInput.Blur()
+					return m, m.startStreamSearch(query)
+				} else if query != m.searchQuery {
 					// New search
 					m.performSearch(query)
 				} else if len(m.matchLines) > 0 {
@@ -183,7 +353,7 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				m.searching = false
 				m.searchInput.Blur()
 				m.viewport.SetContent(m.renderContent())
-				return m, nil
+				return m, m.jumpCmd(from)
 			default:
 				// Pass to text input
 				m.searchInput, cmd = m.searchInput.Update(msg)
@@ -192,6 +362,10 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			}
 		}
 
+		if m.goingTo {
+			return m.updateGoto(msg)
+		}
+
 		// Normal mode - check for Esc to clear search
 		if key.Code == tea.KeyEscape && len(m.matchLines) > 0 {
 			m.clearSearch()
@@ -206,23 +380,62 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			return m, textinput.Blink
 		}
 
+		// ':' asks for a line to go to
+		if key.Text == ":" && !m.binary {
+			return m.startGoto()
+		}
+
+		// 'e' switches to edit mode
+		if key.Text == "e" && m.StartEdit() {
+			return m, nil
+		}
+
+		if m.binary {
+			return m.updateHex(msg)
+		}
+
+		// 'm' toggles the rendered view
+		if key.Text == "m" && m.CanPreview() {
+			m.TogglePreview()
+			return m, nil
+		}
+
 		// Check for 'n' to go to next match (when not searching)
 		if key.Text == "n" && len(m.matchLines) > 0 {
+			from := m.TopLine()
 			m.currentMatch = (m.currentMatch + 1) % len(m.matchLines)
 			m.scrollToCurrentMatch()
 			m.viewport.SetContent(m.renderContent())
-			return m, nil
+			return m, m.jumpCmd(from)
 		}
 
 		// Check for 'p' to go to previous match
 		if key.Text == "p" && len(m.matchLines) > 0 {
+			from := m.TopLine()
 			m.currentMatch--
 			if m.currentMatch < 0 {
 				m.currentMatch = len(m.matchLines) - 1
 			}
 			m.scrollToCurrentMatch()
 			m.viewport.SetContent(m.renderContent())
-			return m, nil
+			return m, m.jumpCmd(from)
+		}
+
+		if m.streaming {
+			return m.updateStream(msg), nil
+		}
+
+		if m.structured() {
+			return m.updateData(msg)
+		}
+
+		if m.foldable() {
+			if folded, ok := m.updateFold(msg); ok {
+				return folded, nil
+			}
+			if scrolled, ok := m.updateColumns(msg); ok {
+				return scrolled, nil
+			}
 		}
 
 		// Pass other keys to viewport
@@ -232,7 +445,7 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	}
 
 	// Handle keyboard only when focused
-	if m.Focused() {
+	if m.Focused() && !m.streaming && !m.binary && !m.structured() {
 		m.viewport, cmd = m.viewport.Update(msg)
 		cmds = append(cmds, cmd)
 	}
@@ -246,8 +459,24 @@ func (m Model) View() string {
 		return m.renderPlaceholder()
 	}
 
+	if m.editing {
+		return m.renderEdit()
+	}
+
+	if m.streaming {
+		return m.renderStream()
+	}
+
+	if m.binary {
+		return m.renderHex()
+	}
+
+	if m.structured() {
+		return m.renderData()
+	}
+
 	// If searching, show search bar at bottom
-	if m.searching {
+	if m.prompting() {
 		w, h := m.Size()
 		viewportHeight := h - 1 // Reserve 1 line for search bar
 
@@ -258,11 +487,16 @@ func (m Model) View() string {
 		m.viewport.SetHeight(oldHeight)
 
 		// Render search bar
-		searchBar := m.renderSearchBar(w)
+		searchBar := m.renderPromptBar(w)
 
 		return lipgloss.JoinVertical(lipgloss.Left, content, searchBar)
 	}
 
+	if m.parseErr != nil {
+		w, _ := m.Size()
+		return lipgloss.JoinVertical(lipgloss.Left, m.viewport.View(), m.renderParseBar(w))
+	}
+
 	return m.viewport.View()
 }
 
@@ -293,6 +527,10 @@ func (m Model) renderContent() string {
 			Render("(empty file)")
 	}
 
+	if m.previewing() {
+		return m.renderPreview()
+	}
+
 	// Get syntax highlighted content
 	highlighted := m.highlightSyntax()
 
@@ -315,14 +553,38 @@ func (m Model) renderContent() string {
 
 	var result strings.Builder
 
-	lineNumStyle := theme.DiffLineNumberStyle
+	lineNumStyle := theme.DiffLineNumberStyle.UnsetWidth() // padLeft pads; a fixed width wraps long numbers
 	sepStyle := lipgloss.NewStyle().Foreground(theme.DimPurple)
 	matchLineNumStyle := lipgloss.NewStyle().Foreground(theme.ElectricYellow).Bold(true)
 	currentMatchLineNumStyle := lipgloss.NewStyle().Foreground(theme.MatrixGreen).Bold(true)
+	errorLine := -1
+	if m.parseErr != nil {
+		errorLine = m.parseErr.line
+	}
+
+	foldStyle := lipgloss.NewStyle().Foreground(theme.DimPurple).Italic(true)
+
+	width := m.textWidth()
+	var lineContent string
+
+	for row := 0; ; row++ {
+		r := m.rowAt(row) // Folded lines are skipped, wrapped ones take more rows
+		i := r.line
+		if i >= len(highlightedLines) {
+			break
+		}
+		if row > 0 {
+			result.WriteString("\n")
+		}
+		if r.col > 0 {
+			// The rest of a wrapped line, under the line number
+			result.WriteString(strings.Repeat(" ", len(padLeft(i+1, 4))))
+			result.WriteString(sepStyle.Render(" │ "))
+			result.WriteString(ansi.Cut(lineContent, r.col, r.col+width))
+			continue
+		}
 
-	for i := 0; i < len(highlightedLines); i++ {
 		var lineNum string
-		var lineContent string
 		sep := sepStyle.Render(" │ ")
 
 		if i == currentMatchLine {
@@ -343,6 +605,11 @@ func (m Model) renderContent() string {
 			} else {
 				lineContent = highlightedLines[i]
 			}
+		} else if i == errorLine {
+			// Where the rendered view failed to parse
+			lineNum = lipgloss.NewStyle().Foreground(theme.NeonRed).Bold(true).Render(padLeft(i+1, 4))
+			sep = lipgloss.NewStyle().Foreground(theme.NeonRed).Render(" │ ")
+			lineContent = highlightedLines[i]
 		} else if hasSelection && i < len(rawLines) {
 			// Render with selection highlighting on top of syntax highlighting
 			lineNum = lineNumStyle.Render(padLeft(i+1, 4))
@@ -352,12 +619,21 @@ func (m Model) renderContent() string {
 			lineContent = highlightedLines[i]
 		}
 
+		if hidden := m.foldedAt(i); hidden > 0 {
+			sep = strings.Replace(sep, "│", "▸", 1)
+			lineContent += foldStyle.Render(foldSuffix(hidden))
+		}
+
+		// Long lines are cut to the width here, so the line numbers stay
+		// put when scrolling sideways
+		lineContent = expandTabs(lineContent)
+		left := m.left
+		if m.wrap {
+			left = 0
+		}
 		result.WriteString(lineNum)
 		result.WriteString(sep)
-		result.WriteString(lineContent)
-		if i < len(highlightedLines)-1 {
-			result.WriteString("\n")
-		}
+		result.WriteString(ansi.Cut(lineContent, left, left+width))
 	}
 
 	return result.String()
@@ -407,36 +683,7 @@ func (m Model) renderLineWithSelection(line string, lineNum int) string {
 		return line
 	}
 
-	// Use ANSI-aware cutting to preserve syntax highlighting
-	// ansi.Cut(s, left, right) extracts characters from position left to right
-	selStyle := selection.SelectionStyle()
-	var result strings.Builder
-
-	// Part before selection (preserves syntax highlighting)
-	if selStart > 0 {
-		result.WriteString(ansi.Cut(line, 0, selStart))
-	}
-
-	// Selected part - apply selection background on top of syntax colors
-	// We need to handle ANSI reset sequences inside the selected part,
-	// as they would clear our background. Replace resets with reset+background.
-	selectedPart := ansi.Cut(line, selStart, selEnd)
-
-	// Selection background color from theme: #3D2D5E = rgb(61, 45, 94)
-	// ANSI 24-bit background: \x1b[48;2;R;G;Bm
-	selBg := "\x1b[48;2;61;45;94m"
-	// Replace SGR reset sequences with reset + re-apply background
-	selectedPart = strings.ReplaceAll(selectedPart, "\x1b[0m", "\x1b[0m"+selBg)
-	selectedPart = strings.ReplaceAll(selectedPart, "\x1b[m", "\x1b[m"+selBg)
-
-	result.WriteString(selStyle.Render(selectedPart))
-
-	// Part after selection (preserves syntax highlighting)
-	if selEnd < lineWidth {
-		result.WriteString(ansi.Cut(line, selEnd, lineWidth))
-	}
-
-	return result.String()
+	return overlayRange(line, selStart, selEnd)
 }
 
 // highlightMatchesInLine highlights all regex matches within a line.
@@ -486,71 +733,213 @@ func (m Model) highlightMatchesInLine(line string, isCurrent bool) string {
 
 // highlightSyntax returns syntax-highlighted content
 func (m Model) highlightSyntax() string {
-	// Try to get lexer by filename
-	var lexer chroma.Lexer
-	if m.path != "" {
-		lexer = lexers.Match(filepath.Base(m.path))
-	}
+	return syntax.File(m.path, m.content)
+}
+
+// LoadFile loads a file into the viewer.
+func LoadFile(path string) tea.Cmd {
+	return LoadFileAt(path, 0)
+}
 
-	// Fallback: try to analyze content
-	if lexer == nil {
-		lexer = lexers.Analyse(m.content)
+// LoadFileAt loads a file into the viewer and scrolls to line (0-indexed).
+func LoadFileAt(path string, line int) tea.Cmd {
+	return func() tea.Msg {
+		return loadFile(path, line, false)
 	}
+}
 
-	// Final fallback: plain text
-	if lexer == nil {
-		lexer = lexers.Fallback
+// EditFileAt loads a file into the viewer in edit mode at line (0-indexed).
+func EditFileAt(path string, line int) tea.Cmd {
+	return func() tea.Msg {
+		return loadFile(path, line, true)
 	}
+}
 
-	lexer = chroma.Coalesce(lexer)
+// Class is how the viewer shows a file.
+type Class int
 
-	// Use a dark theme that works well with terminal
-	style := styles.Get("monokai")
-	if style == nil {
-		style = styles.Fallback
-	}
+const (
+	ClassText   Class = iota // Read whole
+	ClassLarge               // Streamed from disk
+	ClassBinary              // Shown as hex, read as it scrolls into view
+)
 
-	// Use terminal256 formatter for colored output
-	formatter := formatters.Get("terminal256")
-	if formatter == nil {
-		formatter = formatters.Fallback
+// Classify sniffs the file at path, whose info is given, and says how the
+// viewer shows it.
+func Classify(path string, info os.FileInfo) (filetype.Info, Class, error) {
+	var kind filetype.Info
+	if !info.IsDir() {
+		var err error
+		kind, err = filetype.Sniff(path)
+		if err != nil {
+			return kind, ClassText, err
+		}
+		// Streaming reads UTF-8 only, so large UTF-16 files are shown as hex
+		if kind.Binary || kind.Encoding != filetype.UTF8 && info.Size() > LargeFileSize {
+			return kind, ClassBinary, nil
+		}
+	}
+	if info.Size() > LargeFileSize {
+		return kind, ClassLarge, nil
 	}
+	return kind, ClassText, nil
+}
 
-	// Tokenize and format
-	iterator, err := lexer.Tokenise(nil, m.content)
+// loadFile reads a file for the viewer.
+func loadFile(path string, line int, edit bool) FileLoadedMsg {
+	// Stat first: a write racing the read then shows up as a conflict on save
+	info, err := os.Stat(path)
 	if err != nil {
-		return m.content
+		return FileLoadedMsg{Path: path, Err: err}
 	}
-
-	var buf bytes.Buffer
-	err = formatter.Format(&buf, style, iterator)
+	kind, class, err := Classify(path, info)
+	switch {
+	case err != nil:
+		return FileLoadedMsg{Path: path, Err: err}
+	case class == ClassBinary:
+		return FileLoadedMsg{Path: path, Line: line, ModTime: info.ModTime(), Edit: edit, Size: info.Size(), Binary: true, Kind: kind.Kind}
+	case class == ClassLarge:
+		return FileLoadedMsg{Path: path, Line: line, ModTime: info.ModTime(), Edit: edit, Size: info.Size(), Large: true}
+	}
+	content, err := os.ReadFile(path)
 	if err != nil {
-		return m.content
+		return FileLoadedMsg{Path: path, Err: err}
 	}
-
-	return buf.String()
+	return FileLoadedMsg{Path: path, Content: kind.Encoding.Decode(content), Line: line, ModTime: info.ModTime(), Edit: edit, Size: info.Size(), Encoding: kind.Encoding}
 }
 
-// LoadFile loads a file into the viewer.
-func LoadFile(path string) tea.Cmd {
+// jumpCmd reports the position left behind when the view moved away from
+// line from; it returns nil when the view didn't move.
+func (m Model) jumpCmd(from int) tea.Cmd {
+	if m.path == "" || m.TopLine() == from {
+		return nil
+	}
+	path := m.path
 	return func() tea.Msg {
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return FileLoadedMsg{Path: path, Err: err}
+		return JumpMsg{Path: path, Line: from}
+	}
+}
+
+// TopLine returns the first visible line (0-indexed), or the cursor line in
+// edit mode.
+func (m Model) TopLine() int {
+	if m.editing {
+		return m.edit.cursor.Line
+	}
+	if m.streaming {
+		return m.stream.top
+	}
+	if m.binary {
+		return int(m.hex.top)
+	}
+	if m.structured() {
+		return m.dataLine(m.data.cursor)
+	}
+	if m.previewing() {
+		return m.previewSourceLine(m.viewport.YOffset())
+	}
+	return m.lineOfRow(m.viewport.YOffset())
+}
+
+// CursorPosition returns the line and column (0-indexed) of interest: the
+// edit cursor, the current search match, or the first visible line.
+func (m Model) CursorPosition() (line, col int) {
+	if m.editing {
+		return m.edit.cursor.Line, m.edit.cursor.Column
+	}
+	if m.structured() {
+		return m.dataLine(m.data.cursor), 0 // Search matches move the cursor
+	}
+	if m.currentMatch >= 0 && m.currentMatch < len(m.matchLines) && m.previewing() {
+		return m.previewSourceLine(m.matchLines[m.currentMatch]), 0
+	}
+	if m.currentMatch >= 0 && m.currentMatch < len(m.matchLines) {
+		line = m.matchLines[m.currentMatch]
+		text, ok := m.lineText(line)
+		if ok && m.searchRegex != nil {
+			if loc := m.searchRegex.FindStringIndex(text); loc != nil {
+				col = utf8.RuneCountInString(text[:loc[0]])
+			}
+		}
+		return line, col
+	}
+	return m.TopLine(), 0
+}
+
+// GotoLine scrolls so that line (0-indexed) is the first visible line. In
+// edit mode it moves the cursor there.
+func (m *Model) GotoLine(line int) {
+	if line < 0 {
+		line = 0
+	}
+	if m.editing {
+		line = clampInt(line, 0, len(m.edit.lines)-1)
+		m.edit.cursor = selection.Position{Line: line}
+		m.edit.top = line
+		m.edit.goal = -1
+		m.edit.merge = false
+		return
+	}
+	if m.streaming {
+		m.scrollStream(line)
+		return
+	}
+	if m.binary {
+		m.scrollHex(int64(line))
+		return
+	}
+	if m.structured() {
+		m.gotoDataLine(line)
+		return
+	}
+	if m.previewing() {
+		m.viewport.SetYOffset(m.previewRow(line))
+		return
+	}
+	m.reveal(line)
+	m.viewport.SetYOffset(m.rowOfLine(line))
+}
+
+// lineText returns line (0-indexed) of the file, if it's loaded.
+func (m Model) lineText(line int) (string, bool) {
+	if m.streaming {
+		i := line - m.stream.winStart
+		if i < 0 || i >= len(m.stream.raw) {
+			return "", false
 		}
-		return FileLoadedMsg{Path: path, Content: string(content)}
+		return m.stream.raw[i], true
+	}
+	lines := strings.Split(m.content, "\n")
+	if line < 0 || line >= len(lines) {
+		return "", false
 	}
+	return lines[line], true
 }
 
 // SetContent sets the content directly (for non-file content).
 func (m *Model) SetContent(content string) {
+	m.streaming = false
+	m.binary = false
 	m.content = content
 	m.path = ""
 	m.err = nil
+	m.left = 0
+	m.parseData()
+	m.loadOutline()
 	m.viewport.SetContent(m.renderContent())
 	m.viewport.GotoTop()
 }
 
+// Refresh re-renders the content, e.g. after the theme changed.
+func (m *Model) Refresh() {
+	switch {
+	case m.streaming:
+		m.loadWindow()
+	case m.content != "":
+		m.viewport.SetContent(m.renderContent())
+	}
+}
+
 // Path returns the current file path.
 func (m Model) Path() string {
 	return m.path
@@ -563,9 +952,16 @@ func (m Model) Content() string {
 
 // Clear clears the viewer.
 func (m *Model) Clear() {
+	m.streaming = false
+	m.binary = false
 	m.path = ""
 	m.content = ""
 	m.err = nil
+	m.data = dataView{}
+	m.parseErr = nil
+	m.fold = folding{}
+	m.rows = nil
+	m.left = 0
 	m.ready = false
 	m.viewport.SetContent("")
 }
@@ -584,6 +980,17 @@ func (m Model) Blur() Model {
 
 // SetSize updates the component's dimensions.
 func (m Model) SetSize(width, height int) Model {
+	// Rendered Markdown reflows: keep the same source line at the top
+	previewLine := -1
+	if m.ready && m.previewing() {
+		previewLine = m.TopLine()
+	}
+	// So do wrapped lines
+	wrapLine := -1
+	if m.ready && m.wrap && m.foldable() {
+		wrapLine = m.TopLine()
+	}
+
 	m.Base.SetSize(width, height)
 
 	// Initialize or resize viewport
@@ -596,17 +1003,69 @@ func (m Model) SetSize(width, height int) Model {
 		m.viewport.SetWidth(width)
 		m.viewport.SetHeight(height)
 	}
+	m.sizeViewport()
 
 	// Re-render content to fit new width
 	if m.content != "" {
+		m.layoutRows()
 		m.viewport.SetContent(m.renderContent())
 	}
+	if previewLine >= 0 {
+		m.GotoLine(previewLine)
+	}
+	if wrapLine >= 0 {
+		m.viewport.SetYOffset(m.rowOfLine(wrapLine))
+	}
+	if m.foldable() {
+		m.scrollColumn(m.left) // Less may need scrolling when wider
+	}
+	if m.editing {
+		rows, cols := m.editSize()
+		m.edit.scrollToCursor(rows, cols)
+	}
+	if m.streaming {
+		m.ensureWindow()
+	}
+	if m.binary {
+		// Keep the same offset at the top as the bytes per row change
+		perRow, _ := m.hexSize()
+		m.scrollHex(m.hex.dataAt / int64(perRow))
+	}
+	if m.structured() {
+		m.moveData(m.data.cursor)
+		if t := m.data.table; t != nil {
+			t.scrollToColumn(m.tableWidth(width))
+		}
+	}
 
 	return m
 }
 
 // ScrollPercent returns the current scroll position as a percentage (0-100).
 func (m Model) ScrollPercent() float64 {
+	if m.editing {
+		if len(m.edit.lines) <= 1 {
+			return 100
+		}
+		return float64(m.edit.cursor.Line) / float64(len(m.edit.lines)-1) * 100
+	}
+	if m.streaming {
+		return m.streamPercent()
+	}
+	if m.binary {
+		_, h := m.hexSize()
+		maxTop := m.hexRows() - int64(h)
+		if maxTop <= 0 {
+			return 100
+		}
+		return float64(m.hex.top) / float64(maxTop) * 100
+	}
+	if m.structured() {
+		if m.dataCount() <= 1 {
+			return 100
+		}
+		return float64(m.data.cursor) / float64(m.dataCount()-1) * 100
+	}
 	return m.viewport.ScrollPercent() * 100
 }
 
@@ -628,16 +1087,27 @@ func (m Model) renderSearchBar(width int) string {
 		Render("/")
 
 	// Match info
+	current, total := m.currentMatch, len(m.matchLines)
+	searching := m.streaming && !m.stream.searchDone
+	if m.binary {
+		current, total, searching = m.hex.current, len(m.hex.matches), !m.hex.searchDone
+	}
+
 	var matchInfo string
 	if m.searchQuery != "" {
-		if len(m.matchLines) == 0 {
+		if searching {
+			// Still searching in the background
+			matchInfo = lipgloss.NewStyle().
+				Foreground(theme.ElectricYellow).
+				Render(" [" + itoa(current+1) + "/" + itoa(total) + "+]")
+		} else if total == 0 {
 			matchInfo = lipgloss.NewStyle().
 				Foreground(theme.NeonRed).
 				Render(" [no matches]")
 		} else {
 			matchInfo = lipgloss.NewStyle().
 				Foreground(theme.MatrixGreen).
-				Render(" [" + itoa(m.currentMatch+1) + "/" + itoa(len(m.matchLines)) + "]")
+				Render(" [" + itoa(current+1) + "/" + itoa(total) + "]")
 		}
 	}
 
@@ -657,25 +1127,25 @@ func (m *Model) performSearch(query string) {
 	m.searchQuery = query
 	m.matchLines = nil
 	m.currentMatch = -1
-	m.searchRegex = nil
+	m.searchRegex = find.Compile(query)
 
-	if query == "" {
+	re := m.searchRegex
+	if re == nil {
 		return
 	}
 
-	// Compile regex (case-insensitive by default)
-	re, err := regexp.Compile("(?i)" + query)
-	if err != nil {
-		// Invalid regex, try as literal
-		re, err = regexp.Compile(regexp.QuoteMeta(query))
-		if err != nil {
-			return
-		}
-	}
-	m.searchRegex = re
-
 	// Find all matching lines
 	lines := strings.Split(m.content, "\n")
+	if m.structured() {
+		lines = m.dataText() // Matches are rows of the tree or table
+	}
+	if m.previewing() {
+		// Search what's shown; matches are rendered rows
+		lines, _ = renderMarkdown(m.content, m.previewWidth())
+		for i := range lines {
+			lines[i] = ansi.Strip(lines[i])
+		}
+	}
 	for i, line := range lines {
 		if re.MatchString(line) {
 			m.matchLines = append(m.matchLines, i)
@@ -696,12 +1166,32 @@ func (m *Model) scrollToCurrentMatch() {
 	}
 
 	line := m.matchLines[m.currentMatch]
+	if m.structured() {
+		m.moveData(line)
+		return
+	}
+	height := m.viewport.Height()
+	if m.streaming {
+		_, height = m.streamSize()
+	}
 	// Scroll so the match is roughly centered
-	targetLine := line - m.viewport.Height()/2
+	targetLine := line - height/2
 	if targetLine < 0 {
 		targetLine = 0
 	}
-	m.viewport.SetYOffset(targetLine)
+	if m.previewing() {
+		m.viewport.SetYOffset(targetLine) // Already a rendered row
+		return
+	}
+	if m.foldable() {
+		m.reveal(line)
+		m.viewport.SetYOffset(max(m.rowOfLine(line)-height/2, 0))
+		if _, col := m.CursorPosition(); !m.wrap {
+			m.showColumn(col)
+		}
+		return
+	}
+	m.GotoLine(targetLine)
 }
 
 // clearSearch clears the search state.
@@ -711,6 +1201,13 @@ func (m *Model) clearSearch() {
 	m.searchRegex = nil
 	m.matchLines = nil
 	m.currentMatch = -1
+	m.stream.searchSeq++ // Stops a background search
+	m.stream.searchDone = true
+	m.hex.searchSeq++
+	m.hex.searchDone = true
+	m.hex.matches = nil
+	m.hex.current = -1
+	m.hex.pattern = nil
 	m.searchInput.SetValue("")
 	m.searchInput.Blur()
 }
@@ -737,22 +1234,7 @@ func itoa(n int) string {
 
 // Helper to pad line numbers
 func padLeft(n, width int) string {
-	s := ""
-	for i := 0; i < width; i++ {
-		s += " "
-	}
-	ns := strings.TrimLeft(s+string(rune('0'+n%10)), " ")
-	if n >= 10 {
-		ns = string(rune('0'+n/10%10)) + string(rune('0'+n%10))
-	}
-	if n >= 100 {
-		ns = string(rune('0'+n/100%10)) + string(rune('0'+n/10%10)) + string(rune('0'+n%10))
-	}
-	if n >= 1000 {
-		ns = string(rune('0'+n/1000%10)) + string(rune('0'+n/100%10)) + string(rune('0'+n/10%10)) + string(rune('0'+n%10))
-	}
-
-	// Pad to width
+	ns := itoa(n)
 	for len(ns) < width {
 		ns = " " + ns
 	}
@@ -766,16 +1248,23 @@ const lineNumberWidth = 7 // 4 digits + " │ "
 // Takes into account the viewport scroll offset, line number prefix, and panel border.
 func (m Model) screenToTextPosition(x, y int) (line, col int) {
 	// Y coordinate: subtract 1 for top border, then add viewport scroll offset
-	line = y - 1 + m.viewport.YOffset()
+	row := m.rowAt(y - 1 + m.viewport.YOffset())
+	line = row.line
 	if line < 0 {
 		line = 0
 	}
 
-	// X coordinate: subtract 1 for left border, then subtract line number prefix width
+	// X coordinate: subtract 1 for left border, then subtract line number
+	// prefix width, then add where the row starts in the line
 	col = x - 1 - lineNumberWidth
 	if col < 0 {
 		col = 0
 	}
+	if m.wrap {
+		col += row.col
+	} else {
+		col += m.left
+	}
 
 	return line, col
 }
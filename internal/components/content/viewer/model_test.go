@@ -3,9 +3,11 @@ package viewer
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	tea "charm.land/bubbletea/v2"
+	"github.com/avitaltamir/vibecommander/internal/theme"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -244,3 +246,42 @@ func TestContent(t *testing.T) {
 
 	assert.Equal(t, "test content", m.Content())
 }
+
+func TestSearchJumps(t *testing.T) {
+	lines := make([]string, 200)
+	for i := range lines {
+		lines[i] = "line"
+	}
+	lines[150] = "needle"
+
+	m := New().SetSize(80, 20).Focus()
+	m, _ = m.Update(FileLoadedMsg{Path: "/test.txt", Content: strings.Join(lines, "\n"), Line: 10})
+	assert.Equal(t, 10, m.TopLine())
+
+	m.performSearch("needle")
+	m.GotoLine(5)
+
+	m, cmd := m.Update(tea.KeyPressMsg{Code: 'n', Text: "n"})
+	require.NotNil(t, cmd)
+	assert.Equal(t, JumpMsg{Path: "/test.txt", Line: 5}, cmd())
+	assert.Greater(t, m.TopLine(), 100)
+
+	t.Run("no jump when the view stays put", func(t *testing.T) {
+		_, cmd := m.Update(tea.KeyPressMsg{Code: 'n', Text: "n"})
+		assert.Nil(t, cmd)
+	})
+}
+
+func TestRefreshRehighlights(t *testing.T) {
+	before := theme.CurrentThemeIndex()
+	t.Cleanup(func() { theme.SetThemeIndex(before) })
+	theme.SetThemeIndex(0)
+
+	m := New().SetSize(80, 20)
+	m, _ = m.Update(FileLoadedMsg{Path: "/main.go", Content: "package main\n\nfunc main() {}\n"})
+	first := m.View()
+
+	theme.SetThemeIndex(1)
+	m.Refresh()
+	assert.NotEqual(t, first, m.View(), "code is colored for the new theme")
+}
@@ -0,0 +1,239 @@
+package viewer
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// maxCellWidth caps how wide a table column is shown.
+const maxCellWidth = 40
+
+// table is a CSV or TSV file. The first record is the header.
+type table struct {
+	header  []string
+	records [][]string // In file order
+	lines   []int      // Source line (0-indexed) of each record
+	order   []int      // Records in display order
+	widths  []int      // Column widths, including room for a sort arrow
+	numeric []bool     // Columns holding only numbers, aligned right
+
+	sortCol  int // -1 in file order
+	sortDesc bool
+	col      int // Current column
+	left     int // First column shown
+}
+
+// parseTable parses CSV, or TSV: fields separated by tabs, without quoting.
+func parseTable(src string, tsv bool) (*table, *parseError) {
+	t := &table{sortCol: -1}
+	add := func(record []string, line int) {
+		if t.header == nil {
+			t.header = record
+			return
+		}
+		t.records = append(t.records, record)
+		t.lines = append(t.lines, line)
+	}
+
+	if tsv {
+		for i, line := range strings.Split(strings.TrimSuffix(src, "\n"), "\n") {
+			if line = strings.TrimSuffix(line, "\r"); line != "" {
+				add(strings.Split(line, "\t"), i)
+			}
+		}
+	} else {
+		r := csv.NewReader(strings.NewReader(src))
+		r.FieldsPerRecord = -1
+		for {
+			record, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				pe := &parseError{format: "CSV", line: -1, col: -1, msg: err.Error()}
+				var csvErr *csv.ParseError
+				if errors.As(err, &csvErr) {
+					pe.line, pe.col, pe.msg = csvErr.Line-1, csvErr.Column-1, csvErr.Err.Error()
+					if csvErr.StartLine != csvErr.Line {
+						// A quoted field that never ends: point at its start
+						pe.line, pe.col = csvErr.StartLine-1, -1
+					}
+				}
+				return nil, pe
+			}
+			line, _ := r.FieldPos(0)
+			add(record, line-1)
+		}
+	}
+
+	columns := len(t.header)
+	for _, rec := range t.records {
+		columns = max(columns, len(rec))
+	}
+	for len(t.header) < columns {
+		t.header = append(t.header, "")
+	}
+	t.widths = make([]int, columns)
+	t.numeric = make([]bool, columns)
+	for c := range columns {
+		w := ansi.StringWidth(cellText(t.header[c])) + 2
+		numeric, filled := true, false
+		for _, rec := range t.records {
+			cell := cellAt(rec, c)
+			w = max(w, ansi.StringWidth(cellText(cell)))
+			if cell != "" {
+				_, ok := parseNumber(cell)
+				numeric, filled = numeric && ok, true
+			}
+		}
+		t.widths[c] = min(w, maxCellWidth)
+		t.numeric[c] = numeric && filled
+	}
+	t.sort()
+	return t, nil
+}
+
+// cellAt returns field c of record, or "" for short records.
+func cellAt(record []string, c int) string {
+	if c < len(record) {
+		return record[c]
+	}
+	return ""
+}
+
+// cellText returns a cell as shown on one line.
+func cellText(cell string) string {
+	return strings.NewReplacer("\r\n", "↵", "\n", "↵", "\t", " ").Replace(cell)
+}
+
+// parseNumber parses a numeric cell.
+func parseNumber(cell string) (float64, bool) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(cell), 64)
+	return f, err == nil
+}
+
+// sort orders the records by the sort column, keeping file order for ties
+// and when unsorted. Empty cells go last either way.
+func (t *table) sort() {
+	t.order = make([]int, len(t.records))
+	for i := range t.order {
+		t.order[i] = i
+	}
+	if t.sortCol < 0 {
+		return
+	}
+	c := t.sortCol
+	sort.SliceStable(t.order, func(i, j int) bool {
+		a, b := cellAt(t.records[t.order[i]], c), cellAt(t.records[t.order[j]], c)
+		if a == "" || b == "" {
+			return a != "" && b == ""
+		}
+		less, greater := compareCells(a, b)
+		if t.sortDesc {
+			return greater
+		}
+		return less
+	})
+}
+
+// compareCells compares two cells as numbers when both are, otherwise as
+// text ignoring case.
+func compareCells(a, b string) (less, greater bool) {
+	if x, ok := parseNumber(a); ok {
+		if y, ok := parseNumber(b); ok {
+			return x < y, x > y
+		}
+	}
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	return a < b, a > b
+}
+
+// cycleSort sorts by the current column: ascending, then descending, then
+// back to file order.
+func (t *table) cycleSort() {
+	switch {
+	case t.sortCol != t.col:
+		t.sortCol, t.sortDesc = t.col, false
+	case !t.sortDesc:
+		t.sortDesc = true
+	default:
+		t.sortCol, t.sortDesc = -1, false
+	}
+	t.sort()
+}
+
+// visibleColumns returns the columns that fit in width from the first column
+// shown, always at least one.
+func (t *table) visibleColumns(width int) []int {
+	var cols []int
+	used := 0
+	for c := t.left; c < len(t.widths); c++ {
+		w := t.widths[c]
+		if len(cols) > 0 {
+			w += 3 // " │ "
+		}
+		if len(cols) > 0 && used+w > width {
+			break
+		}
+		cols = append(cols, c)
+		used += w
+	}
+	return cols
+}
+
+// scrollToColumn moves the first column shown so the current column fits.
+func (t *table) scrollToColumn(width int) {
+	t.col = clampInt(t.col, 0, len(t.widths)-1)
+	if t.col < t.left {
+		t.left = t.col
+	}
+	for t.left < t.col {
+		cols := t.visibleColumns(width)
+		if cols[len(cols)-1] >= t.col {
+			break
+		}
+		t.left++
+	}
+}
+
+// formatCell pads or cuts a cell to column c's width.
+func (t *table) formatCell(cell string, c int) string {
+	w := t.widths[c]
+	cell = ansi.Truncate(cellText(cell), w, "…")
+	pad := strings.Repeat(" ", w-ansi.StringWidth(cell))
+	if t.numeric[c] {
+		return pad + cell
+	}
+	return cell + pad
+}
+
+// headerCell returns column c's name with an arrow when sorted by it.
+func (t *table) headerCell(c int) string {
+	name := cellText(t.header[c])
+	if c == t.sortCol {
+		arrow := " ▲"
+		if t.sortDesc {
+			arrow = " ▼"
+		}
+		name = ansi.Truncate(name, t.widths[c]-2, "…") + arrow
+	}
+	w := t.widths[c]
+	name = ansi.Truncate(name, w, "…")
+	return name + strings.Repeat(" ", w-ansi.StringWidth(name))
+}
+
+// rowText returns the cells of display row i in the given columns.
+func (t *table) rowText(i int, cols []int) string {
+	rec := t.records[t.order[i]]
+	cells := make([]string, len(cols))
+	for k, c := range cols {
+		cells[k] = t.formatCell(cellAt(rec, c), c)
+	}
+	return strings.Join(cells, " │ ")
+}
@@ -0,0 +1,73 @@
+package viewer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// column returns field c of each record in display order.
+func column(tb *table, c int) []string {
+	var out []string
+	for _, r := range tb.order {
+		out = append(out, cellAt(tb.records[r], c))
+	}
+	return out
+}
+
+func TestParseTable(t *testing.T) {
+	src := "name,size,note\n" +
+		"beta,10,\"two\nlines\"\n" +
+		"Alpha,9.5\n" +
+		"gamma,,x\n" +
+		"delta,100,y,extra\n"
+	tb, err := parseTable(src, false)
+	require.Nil(t, err)
+
+	assert.Equal(t, []string{"name", "size", "note", ""}, tb.header, "short headers are filled out")
+	assert.Equal(t, []int{1, 3, 4, 5}, tb.lines)
+	assert.Equal(t, []bool{false, true, false, false}, tb.numeric)
+	assert.Equal(t, "two↵lines", cellText(tb.records[0][2]))
+	assert.Equal(t, "    10", tb.formatCell("10", 1), "numbers align right")
+
+	// Ascending, descending, then file order; numbers compare as numbers and
+	// empty cells go last
+	tb.col = 1
+	tb.cycleSort()
+	assert.Equal(t, []string{"9.5", "10", "100", ""}, column(tb, 1))
+	tb.cycleSort()
+	assert.Equal(t, []string{"100", "10", "9.5", ""}, column(tb, 1))
+	tb.cycleSort()
+	assert.Equal(t, []string{"beta", "Alpha", "gamma", "delta"}, column(tb, 0))
+
+	tb.col = 0
+	tb.cycleSort()
+	assert.Equal(t, []string{"Alpha", "beta", "delta", "gamma"}, column(tb, 0), "text ignores case")
+	assert.Contains(t, tb.headerCell(0), "name ▲")
+}
+
+func TestParseTableTSV(t *testing.T) {
+	tb, err := parseTable("a\tb\n\n1\t\"quoted\" text\r\n2\n", true)
+	require.Nil(t, err)
+	assert.Equal(t, [][]string{{"1", `"quoted" text`}, {"2"}}, tb.records)
+	assert.Equal(t, []int{2, 3}, tb.lines)
+
+	_, perr := parseTable("a,b\n1,\"open\n2,3\n", false)
+	require.NotNil(t, perr)
+	assert.Equal(t, "CSV", perr.format)
+	assert.Equal(t, 1, perr.line)
+}
+
+func TestTableColumnsScroll(t *testing.T) {
+	tb, err := parseTable("aaaaaaaaaa,bbbbbbbbbb,cccccccccc,dddddddddd\n1,2,3,4\n", false)
+	require.Nil(t, err)
+
+	assert.Equal(t, []int{0, 1}, tb.visibleColumns(30))
+	tb.col = 3
+	tb.scrollToColumn(30)
+	assert.Equal(t, []int{2, 3}, tb.visibleColumns(30))
+	tb.col = 0
+	tb.scrollToColumn(30)
+	assert.Equal(t, 0, tb.left)
+}
@@ -0,0 +1,430 @@
+package viewer
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"charm.land/lipgloss/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/avitaltamir/vibecommander/internal/theme"
+)
+
+// nodeKind is the type of a value in a JSON or YAML document.
+type nodeKind int
+
+const (
+	nodeObject nodeKind = iota
+	nodeArray
+	nodeString
+	nodeNumber
+	nodeBool
+	nodeNull
+	nodeAlias // A YAML alias, shown rather than expanded
+)
+
+// treeNode is a value in a JSON or YAML document.
+type treeNode struct {
+	key       string // Member name; empty for array items and the root
+	index     int    // Position in the parent array, -1 otherwise
+	kind      nodeKind
+	value     string // Scalars as shown, strings quoted
+	children  []*treeNode
+	parent    *treeNode
+	line      int // 0-indexed source line
+	collapsed bool
+}
+
+// treeRow is a node shown in the tree.
+type treeRow struct {
+	node  *treeNode
+	depth int
+}
+
+// add appends child to n.
+func (n *treeNode) add(child *treeNode) {
+	child.parent = n
+	if n.kind == nodeArray {
+		child.index = len(n.children)
+	}
+	n.children = append(n.children, child)
+}
+
+// container reports whether n holds other values.
+func (n *treeNode) container() bool {
+	return n.kind == nodeObject || n.kind == nodeArray
+}
+
+// identKey matches member names that need no quoting in a path.
+var identKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// path returns n's location in jq syntax, e.g. .spec.containers[0].image.
+func (n *treeNode) path() string {
+	var parts []string
+	for ; n.parent != nil; n = n.parent {
+		switch {
+		case n.parent.kind == nodeArray:
+			parts = append(parts, "["+itoa(n.index)+"]")
+		case identKey.MatchString(n.key):
+			parts = append(parts, "."+n.key)
+		default:
+			parts = append(parts, "["+quoteJSON(n.key)+"]")
+		}
+	}
+	var b strings.Builder
+	for i := len(parts) - 1; i >= 0; i-- {
+		b.WriteString(parts[i])
+	}
+	path := b.String()
+	if !strings.HasPrefix(path, ".") {
+		path = "." + path
+	}
+	return path
+}
+
+// summary describes a container's size, e.g. "{3 keys}".
+func (n *treeNode) summary() string {
+	count := len(n.children)
+	if n.kind == nodeObject {
+		if count == 1 {
+			return "{1 key}"
+		}
+		return "{" + itoa(count) + " keys}"
+	}
+	if count == 1 {
+		return "[1 item]"
+	}
+	return "[" + itoa(count) + " items]"
+}
+
+// setCollapsed collapses or expands every container below n.
+func (n *treeNode) setCollapsed(collapsed bool) {
+	for _, c := range n.children {
+		if c.container() {
+			c.collapsed = collapsed
+			c.setCollapsed(collapsed)
+		}
+	}
+}
+
+// quoteJSON quotes s as a JSON string.
+func quoteJSON(s string) string {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return `"` + s + `"`
+	}
+	return string(b)
+}
+
+// documents returns the single document, or a list of them for multi-document
+// files.
+func documents(docs []*treeNode) *treeNode {
+	if len(docs) == 1 {
+		return docs[0]
+	}
+	root := &treeNode{kind: nodeArray, index: -1}
+	for _, d := range docs {
+		root.add(d)
+	}
+	if len(docs) > 0 {
+		root.line = docs[0].line
+	}
+	return root
+}
+
+// jsonParser builds a tree from a JSON token stream, tracking source lines.
+type jsonParser struct {
+	dec   *json.Decoder
+	src   string
+	lines []int // Offset at which each line starts
+}
+
+// parseJSON parses one JSON value, or several as in JSON Lines.
+func parseJSON(src string) (*treeNode, *parseError) {
+	p := &jsonParser{dec: json.NewDecoder(strings.NewReader(src)), src: src, lines: lineStarts(src)}
+	p.dec.UseNumber()
+
+	var docs []*treeNode
+	for {
+		start := p.dec.InputOffset()
+		tok, err := p.dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, p.error(err)
+		}
+		n, err := p.value(tok, start)
+		if err != nil {
+			return nil, p.error(err)
+		}
+		docs = append(docs, n)
+	}
+	return documents(docs), nil
+}
+
+// value builds the node for tok, reading the members of objects and arrays.
+func (p *jsonParser) value(tok json.Token, start int64) (*treeNode, error) {
+	n := &treeNode{index: -1, line: p.lineAt(start)}
+	switch t := tok.(type) {
+	case json.Delim:
+		n.kind = nodeObject
+		if t == '[' {
+			n.kind = nodeArray
+		}
+		for p.dec.More() {
+			keyStart := p.dec.InputOffset()
+			var key string
+			if n.kind == nodeObject {
+				keyTok, err := p.dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, _ = keyTok.(string)
+			}
+			valueStart := p.dec.InputOffset()
+			valueTok, err := p.dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			child, err := p.value(valueTok, valueStart)
+			if err != nil {
+				return nil, err
+			}
+			child.key = key
+			child.line = p.lineAt(keyStart)
+			n.add(child)
+		}
+		if _, err := p.dec.Token(); err != nil { // The closing delimiter
+			return nil, err
+		}
+	case string:
+		n.kind, n.value = nodeString, quoteJSON(t)
+	case json.Number:
+		n.kind, n.value = nodeNumber, string(t)
+	case bool:
+		n.kind, n.value = nodeBool, "false"
+		if t {
+			n.value = "true"
+		}
+	case nil:
+		n.kind, n.value = nodeNull, "null"
+	}
+	return n, nil
+}
+
+// lineAt returns the line of the token following offset.
+func (p *jsonParser) lineAt(offset int64) int {
+	i := int(offset)
+	for i < len(p.src) && strings.IndexByte(" \t\r\n,:", p.src[i]) >= 0 {
+		i++
+	}
+	return lineOf(p.lines, i)
+}
+
+// error locates a decoding error in the source.
+func (p *jsonParser) error(err error) *parseError {
+	offset := len(p.src)
+	var syntax *json.SyntaxError
+	if errors.As(err, &syntax) {
+		offset = int(syntax.Offset) - 1 // Just past the offending character
+	}
+	msg := err.Error()
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		msg = "unexpected end of JSON input"
+		offset = len(strings.TrimRight(p.src, " \t\r\n"))
+	}
+	offset = clampInt(offset, 0, len(p.src))
+	line := lineOf(p.lines, offset)
+	col := utf8.RuneCountInString(p.src[p.lines[line]:offset])
+	return &parseError{format: "JSON", line: line, col: col, msg: msg}
+}
+
+// lineStarts returns the offset at which each line of src starts.
+func lineStarts(src string) []int {
+	starts := []int{0}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// lineOf returns the line containing offset.
+func lineOf(starts []int, offset int) int {
+	return sort.Search(len(starts), func(i int) bool { return starts[i] > offset }) - 1
+}
+
+// yamlErrorLine finds the line number in a YAML error message.
+var yamlErrorLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): `)
+
+// parseYAML parses a YAML file; each document of a multi-document file
+// becomes an item of the root.
+func parseYAML(src string) (*treeNode, *parseError) {
+	dec := yaml.NewDecoder(strings.NewReader(src))
+	var docs []*treeNode
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			msg := err.Error()
+			line, col := -1, -1
+			if sub := yamlErrorLine.FindStringSubmatch(msg); sub != nil {
+				line = atoiPrefix(sub[1]) - 1
+				msg = msg[len(sub[0]):]
+			}
+			msg = strings.TrimPrefix(msg, "yaml: ")
+			return nil, &parseError{format: "YAML", line: line, col: col, msg: msg}
+		}
+		docs = append(docs, yamlTree(&doc))
+	}
+	return documents(docs), nil
+}
+
+// yamlTree converts a parsed YAML node.
+func yamlTree(y *yaml.Node) *treeNode {
+	n := &treeNode{index: -1, line: y.Line - 1}
+	switch y.Kind {
+	case yaml.DocumentNode:
+		if len(y.Content) == 0 {
+			n.kind, n.value = nodeNull, "null"
+			return n
+		}
+		return yamlTree(y.Content[0])
+	case yaml.MappingNode:
+		n.kind = nodeObject
+		for i := 0; i+1 < len(y.Content); i += 2 {
+			key := y.Content[i]
+			child := yamlTree(y.Content[i+1])
+			child.key = key.Value
+			child.line = key.Line - 1
+			n.add(child)
+		}
+	case yaml.SequenceNode:
+		n.kind = nodeArray
+		for _, item := range y.Content {
+			n.add(yamlTree(item))
+		}
+	case yaml.AliasNode:
+		n.kind, n.value = nodeAlias, "*"+y.Value
+	default:
+		switch y.ShortTag() {
+		case "!!int", "!!float":
+			n.kind, n.value = nodeNumber, y.Value
+		case "!!bool":
+			n.kind, n.value = nodeBool, y.Value
+		case "!!null":
+			n.kind, n.value = nodeNull, "null"
+		default:
+			n.kind, n.value = nodeString, quoteJSON(y.Value)
+		}
+	}
+	return n
+}
+
+// flattenTree lists the nodes shown: everything below expanded containers.
+// A root container isn't shown itself, only its members.
+func flattenTree(root *treeNode) []treeRow {
+	var rows []treeRow
+	var walk func(n *treeNode, depth int)
+	walk = func(n *treeNode, depth int) {
+		rows = append(rows, treeRow{node: n, depth: depth})
+		if !n.collapsed {
+			for _, c := range n.children {
+				walk(c, depth+1)
+			}
+		}
+	}
+	if root.container() && len(root.children) > 0 {
+		for _, c := range root.children {
+			walk(c, 0)
+		}
+	} else {
+		walk(root, 0)
+	}
+	return rows
+}
+
+// marker shows whether a row is expanded, collapsed or a leaf.
+func (r treeRow) marker() string {
+	n := r.node
+	switch {
+	case !n.container() || len(n.children) == 0:
+		return "  "
+	case n.collapsed:
+		return "▸ "
+	default:
+		return "▾ "
+	}
+}
+
+// label returns a row's text without styling.
+func (r treeRow) label() string {
+	name, value := r.parts()
+	return strings.Repeat("  ", r.depth) + r.marker() + name + value
+}
+
+// parts returns the name and value of a row as shown, e.g. `image` and
+// `: "nginx"`.
+func (r treeRow) parts() (name, value string) {
+	n := r.node
+	switch {
+	case n.parent == nil:
+	case n.parent.kind == nodeArray:
+		name = "[" + itoa(n.index) + "]"
+	default:
+		name = n.key
+	}
+
+	if n.container() {
+		value = n.summary()
+		if name != "" {
+			value = " " + value
+		}
+		return name, value
+	}
+	if name != "" {
+		value = ": "
+	}
+	return name, value + n.value
+}
+
+// render returns a row styled by the kind of value.
+func (r treeRow) render() string {
+	n := r.node
+	name, value := r.parts()
+
+	nameStyle := lipgloss.NewStyle().Foreground(theme.CyberCyan)
+	if n.parent != nil && n.parent.kind == nodeArray {
+		nameStyle = lipgloss.NewStyle().Foreground(theme.MutedLavender)
+	}
+	markerStyle := lipgloss.NewStyle().Foreground(theme.DimPurple)
+	var valueStyle lipgloss.Style
+	switch n.kind {
+	case nodeObject, nodeArray:
+		valueStyle = lipgloss.NewStyle().Foreground(theme.MutedLavender)
+	case nodeString:
+		valueStyle = lipgloss.NewStyle().Foreground(theme.MatrixGreen)
+	case nodeNumber:
+		valueStyle = lipgloss.NewStyle().Foreground(theme.ElectricYellow)
+	case nodeBool, nodeNull:
+		valueStyle = lipgloss.NewStyle().Foreground(theme.MagentaBlaze)
+	case nodeAlias:
+		valueStyle = lipgloss.NewStyle().Foreground(theme.LaserPurple).Italic(true)
+	}
+
+	sep := ""
+	if !n.container() && strings.HasPrefix(value, ": ") {
+		sep, value = ": ", value[2:]
+	}
+	return strings.Repeat("  ", r.depth) + markerStyle.Render(r.marker()) + nameStyle.Render(name) + markerStyle.Render(sep) + valueStyle.Render(value)
+}
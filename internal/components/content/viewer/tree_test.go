@@ -0,0 +1,133 @@
+package viewer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// labels returns the text of each row shown.
+func labels(root *treeNode) []string {
+	var out []string
+	for _, r := range flattenTree(root) {
+		out = append(out, r.label())
+	}
+	return out
+}
+
+func TestParseJSON(t *testing.T) {
+	src := `{
+  "spec": {
+    "containers": [
+      {"image": "nginx", "ports": [80, 443]}
+    ],
+    "odd key": true
+  },
+  "replicas": null
+}`
+	root, err := parseJSON(src)
+	require.Nil(t, err)
+	assert.Equal(t, []string{
+		"▾ spec {2 keys}",
+		"  ▾ containers [1 item]",
+		"    ▾ [0] {2 keys}",
+		`        image: "nginx"`,
+		"      ▾ ports [2 items]",
+		"          [0]: 80",
+		"          [1]: 443",
+		"    odd key: true",
+		"  replicas: null",
+	}, labels(root))
+
+	rows := flattenTree(root)
+	lines := make([]int, len(rows))
+	for i, r := range rows {
+		lines[i] = r.node.line
+	}
+	assert.Equal(t, []int{1, 2, 3, 3, 3, 3, 3, 5, 7}, lines)
+
+	assert.Equal(t, ".spec.containers[0].image", rows[3].node.path())
+	assert.Equal(t, ".spec.containers[0].ports[1]", rows[6].node.path())
+	assert.Equal(t, `.spec["odd key"]`, rows[7].node.path())
+}
+
+func TestParseJSONLines(t *testing.T) {
+	root, err := parseJSON("{\"a\": 1}\n{\"a\": 2}\n")
+	require.Nil(t, err)
+	assert.Equal(t, []string{"▾ [0] {1 key}", "    a: 1", "▾ [1] {1 key}", "    a: 2"}, labels(root))
+	assert.Equal(t, ".[1].a", flattenTree(root)[3].node.path())
+
+	root, err = parseJSON(`"just a string"`)
+	require.Nil(t, err)
+	assert.Equal(t, []string{`  "just a string"`}, labels(root))
+	assert.Equal(t, ".", root.path())
+}
+
+func TestParseJSONErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		line, col int
+		msg       string
+	}{
+		{"missing comma", "{\n  \"a\": 1\n  \"b\": 2\n}", 2, 2, "invalid character '\"' after object key:value pair"},
+		{"trailing comma", "[1,\n 2,\n]", 1, 2, "invalid character ',' looking for beginning of value"},
+		{"truncated", "{\"a\": [1, 2\n", 0, 11, "unexpected end of JSON input"},
+		{"trailing data", "{} x", 0, 3, "invalid character 'x' looking for beginning of value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseJSON(tt.src)
+			require.NotNil(t, err)
+			assert.Equal(t, tt.line, err.line)
+			assert.Equal(t, tt.col, err.col)
+			assert.Equal(t, tt.msg, err.msg)
+		})
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	src := `apiVersion: v1
+spec:
+  containers:
+    - name: web
+      image: nginx:1.25
+      replicas: 3
+  enabled: yes
+  base: &base {a: 1}
+  copy: *base
+---
+second: ~
+`
+	root, err := parseYAML(src)
+	require.Nil(t, err)
+	assert.Equal(t, []string{
+		"▾ [0] {2 keys}",
+		`    apiVersion: "v1"`,
+		"  ▾ spec {4 keys}",
+		"    ▾ containers [1 item]",
+		"      ▾ [0] {3 keys}",
+		`          name: "web"`,
+		`          image: "nginx:1.25"`,
+		"          replicas: 3",
+		`      enabled: "yes"`,
+		"    ▾ base {1 key}",
+		"        a: 1",
+		"      copy: *base",
+		"▾ [1] {1 key}",
+		"    second: null",
+	}, labels(root))
+
+	rows := flattenTree(root)
+	assert.Equal(t, ".[0].spec.containers[0].image", rows[6].node.path())
+	assert.Equal(t, 4, rows[6].node.line)
+	assert.Equal(t, 10, rows[13].node.line)
+
+	_, perr := parseYAML("a: 1\n b: 2\nc: 3\n")
+	require.NotNil(t, perr)
+	assert.Equal(t, "YAML", perr.format)
+	assert.Equal(t, 1, perr.line)
+	assert.Equal(t, "line 2", perr.location())
+	assert.Equal(t, "mapping values are not allowed in this context", perr.msg)
+}
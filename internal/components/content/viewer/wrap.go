@@ -0,0 +1,168 @@
+package viewer
+
+import (
+	"sort"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// scrollColumns is how far left and right scroll long lines.
+const scrollColumns = 8
+
+// viewRow is a row of the source view: a line, or a piece of a wrapped one.
+type viewRow struct {
+	line int
+	col  int // Column the row starts at; above 0 for the rest of a wrapped line
+}
+
+// textWidth is the width left for text beside the line numbers.
+func (m Model) textWidth() int {
+	w, _ := m.Size()
+	return max(w-lineNumberWidth, 1)
+}
+
+// layoutRows works out the rows of the source view from the folds and, when
+// wrapping, the line widths. Rows stay nil while there's one per line.
+func (m *Model) layoutRows() {
+	m.rows = nil
+	if !m.wrap && len(m.fold.folded) == 0 {
+		return
+	}
+	width := m.textWidth()
+	lines := strings.Split(m.content, "\n")
+	for line := 0; line < len(lines); line++ {
+		w := ansi.StringWidth(expandTabs(lines[line]))
+		if hidden := m.foldedAt(line); hidden > 0 {
+			w += ansi.StringWidth(foldSuffix(hidden))
+		}
+		for col := 0; ; col += width {
+			m.rows = append(m.rows, viewRow{line: line, col: col})
+			if !m.wrap || col+width >= w {
+				break
+			}
+		}
+		if end, ok := m.fold.folded[line]; ok {
+			line = end
+		}
+	}
+}
+
+// rowAt returns what's shown on a row of the source view. Rows past the end
+// continue the line numbers.
+func (m Model) rowAt(row int) viewRow {
+	rows := m.rows
+	if rows == nil || row < 0 {
+		return viewRow{line: row}
+	}
+	if row >= len(rows) {
+		return viewRow{line: rows[len(rows)-1].line + row - len(rows) + 1}
+	}
+	return rows[row]
+}
+
+// lineOfRow returns the line shown on a row of the source view.
+func (m Model) lineOfRow(row int) int {
+	return m.rowAt(row).line
+}
+
+// rowOfLine returns the first row showing line, or the fold hiding it.
+func (m Model) rowOfLine(line int) int {
+	rows := m.rows
+	if rows == nil {
+		return line
+	}
+	i := sort.Search(len(rows), func(i int) bool { return rows[i].line >= line })
+	if i < len(rows) && rows[i].line == line {
+		return i
+	}
+	i = max(i-1, 0)
+	for i > 0 && rows[i-1].line == rows[i].line {
+		i--
+	}
+	return i
+}
+
+// relayout re-renders after the rows changed, keeping line at the top.
+func (m *Model) relayout(line int) {
+	m.layoutRows()
+	m.viewport.SetContent(m.renderContent())
+	m.viewport.SetYOffset(m.rowOfLine(line))
+}
+
+// ToggleWrap switches between wrapping long lines and scrolling sideways.
+// It sticks across files.
+func (m *Model) ToggleWrap() {
+	top := m.TopLine()
+	m.wrap = !m.wrap
+	m.left = 0
+	m.relayout(top)
+}
+
+// IsWrapping reports whether long lines wrap.
+func (m Model) IsWrapping() bool {
+	return m.wrap
+}
+
+// ColumnOffset returns the first column shown (0-indexed) when long lines
+// are scrolled sideways.
+func (m Model) ColumnOffset() int {
+	if !m.foldable() {
+		return 0
+	}
+	return m.left
+}
+
+// scrollColumn scrolls sideways so that col is the first column shown, up to
+// where the longest line ends.
+func (m *Model) scrollColumn(col int) {
+	if m.wrap {
+		return
+	}
+	widest := 0
+	for _, line := range strings.Split(m.content, "\n") {
+		widest = max(widest, ansi.StringWidth(expandTabs(line)))
+	}
+	left := clampInt(col, 0, max(widest-m.textWidth(), 0))
+	if left != m.left {
+		m.left = left
+		m.viewport.SetContent(m.renderContent())
+	}
+}
+
+// showColumn scrolls sideways, if needed, so col is on screen.
+func (m *Model) showColumn(col int) {
+	width := m.textWidth()
+	if col < m.left || col >= m.left+width {
+		m.scrollColumn(col - width/3)
+	}
+}
+
+// updateColumns handles sideways scrolling: left/right (h/l) a few columns,
+// shift for half a screen, 0 back to the start. w toggles wrapping.
+func (m Model) updateColumns(msg tea.KeyPressMsg) (Model, bool) {
+	half := m.textWidth() / 2
+	switch msg.String() {
+	case "w":
+		m.ToggleWrap()
+	case "left", "h":
+		m.scrollColumn(m.left - scrollColumns)
+	case "right", "l":
+		m.scrollColumn(m.left + scrollColumns)
+	case "shift+left", "H":
+		m.scrollColumn(m.left - half)
+	case "shift+right", "L":
+		m.scrollColumn(m.left + half)
+	case "0", "home":
+		m.scrollColumn(0)
+	default:
+		return m, false
+	}
+	return m, true
+}
+
+// expandTabs replaces tabs with spaces, as the terminal shows them.
+func expandTabs(s string) string {
+	return strings.ReplaceAll(s, "\t", strings.Repeat(" ", tabWidth))
+}
@@ -0,0 +1,83 @@
+package viewer
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var keyW = tea.KeyPressMsg{Code: 'w', Text: "w"}
+
+// wrapModel shows a file whose second line is 100 columns wide in a view
+// 40 wide, leaving 33 for text.
+func wrapModel(t *testing.T) Model {
+	t.Helper()
+	long := strings.Repeat("abcdefghij", 10)
+	m := New().SetSize(40, 10).Focus()
+	m, _ = m.Update(FileLoadedMsg{Path: "/notes.txt", Content: "short\n" + long + "\nend"})
+	return m
+}
+
+func TestHorizontalScroll(t *testing.T) {
+	m := wrapModel(t)
+	assert.Equal(t, 0, m.ColumnOffset())
+	rows := strings.Split(ansi.Strip(m.View()), "\n")
+	assert.Equal(t, "   2 │ "+strings.Repeat("abcdefghij", 3)+"abc", strings.TrimRight(rows[1], " "))
+
+	m = press(m, tea.KeyPressMsg{Code: tea.KeyRight})
+	assert.Equal(t, scrollColumns, m.ColumnOffset())
+	rows = strings.Split(ansi.Strip(m.View()), "\n")
+	assert.True(t, strings.HasPrefix(rows[1], "   2 │ ijabcdefghij"), rows[1])
+	assert.True(t, strings.HasPrefix(rows[0], "   1 │ "), "line numbers stay")
+
+	// No further than the end of the longest line
+	m = press(m, tea.KeyPressMsg{Code: 'L', Text: "L"}, tea.KeyPressMsg{Code: 'L', Text: "L"}, tea.KeyPressMsg{Code: 'L', Text: "L"}, tea.KeyPressMsg{Code: 'L', Text: "L"})
+	assert.Equal(t, 100-33, m.ColumnOffset())
+
+	line, col := m.screenToTextPosition(1+lineNumberWidth+2, 2)
+	assert.Equal(t, 1, line)
+	assert.Equal(t, 69, col)
+
+	m = press(m, tea.KeyPressMsg{Code: '0', Text: "0"})
+	assert.Equal(t, 0, m.ColumnOffset())
+}
+
+func TestWrap(t *testing.T) {
+	m := wrapModel(t)
+	m = press(m, tea.KeyPressMsg{Code: tea.KeyRight}, keyW)
+	require.True(t, m.IsWrapping())
+	assert.Equal(t, 0, m.ColumnOffset())
+
+	rows := strings.Split(ansi.Strip(m.View()), "\n")
+	assert.Equal(t, "   1 │ short", strings.TrimRight(rows[0], " "))
+	assert.True(t, strings.HasPrefix(rows[1], "   2 │ abcdefghij"), rows[1])
+	assert.True(t, strings.HasPrefix(rows[2], "     │ defghij"), "continuations line up under the number")
+	assert.True(t, strings.HasPrefix(rows[3], "     │ ghij"), rows[3])
+	assert.Equal(t, "     │ j", strings.TrimRight(rows[4], " "))
+	assert.Equal(t, "   3 │ end", strings.TrimRight(rows[5], " "))
+
+	// Rows map back to lines and columns
+	assert.Equal(t, 2, m.lineOfRow(5))
+	assert.Equal(t, 5, m.rowOfLine(2))
+	line, col := m.screenToTextPosition(1+lineNumberWidth, 3)
+	assert.Equal(t, 1, line)
+	assert.Equal(t, 33, col)
+
+	// Sideways keys do nothing while wrapping
+	m = press(m, tea.KeyPressMsg{Code: tea.KeyRight})
+	assert.Equal(t, 0, m.ColumnOffset())
+
+	t.Run("sticks across files", func(t *testing.T) {
+		m, _ := m.Update(FileLoadedMsg{Path: "/other.txt", Content: "x"})
+		assert.True(t, m.IsWrapping())
+	})
+
+	t.Run("resizing rewraps", func(t *testing.T) {
+		m := m.SetSize(80, 10)
+		assert.Equal(t, 3, m.rowOfLine(2))
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 
 	"charm.land/bubbles/v2/key"
 	"charm.land/bubbles/v2/textinput"
@@ -11,6 +12,7 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/avitaltamir/vibecommander/internal/components"
 	"github.com/avitaltamir/vibecommander/internal/git"
+	"github.com/avitaltamir/vibecommander/internal/ignore"
 	"github.com/avitaltamir/vibecommander/internal/theme"
 )
 
@@ -21,6 +23,8 @@ type (
 		Path     string
 		Children []*Node
 		Err      error
+
+		tree uint64 // ID of the tree that requested the load (0 = any)
 	}
 
 	// SelectMsg is sent when a file is selected (to open it).
@@ -34,8 +38,58 @@ type (
 		Path     string
 		IsStaged bool // Current state (will be toggled)
 	}
+
+	// CompareMarkMsg is sent when a file or directory is marked to be
+	// compared with another, or the mark is cleared (empty Path).
+	CompareMarkMsg struct {
+		Path  string
+		IsDir bool
+	}
+
+	// CompareWithMsg is sent to compare a file or directory with the one
+	// marked.
+	CompareWithMsg struct {
+		Path  string
+		IsDir bool
+	}
 )
 
+// CompareMark flags a node that differs from the other side of a directory
+// comparison.
+type CompareMark int
+
+const (
+	MarkNone     CompareMark = iota
+	MarkDiffers              // Exists on both sides with different content
+	MarkOnlyHere             // Missing on the other side
+)
+
+// treeIDs hands out IDs so trees can tell their own LoadedMsgs apart.
+var treeIDs atomic.Uint64
+
+// IgnoredMode controls how entries matched by .gitignore rules are displayed.
+type IgnoredMode int
+
+const (
+	IgnoredDim  IgnoredMode = iota // Show ignored entries dimmed (default)
+	IgnoredHide                    // Hide ignored entries entirely
+	IgnoredShow                    // Show ignored entries like any other entry
+)
+
+// String returns a short label for the mode.
+func (i IgnoredMode) String() string {
+	switch i {
+	case IgnoredDim:
+		return "dim"
+	case IgnoredHide:
+		return "hide"
+	case IgnoredShow:
+		return "show"
+	default:
+		return "unknown"
+	}
+}
+
 // KeyMap defines the key bindings for the file tree.
 type KeyMap struct {
 	Up            key.Binding
@@ -49,6 +103,9 @@ type KeyMap struct {
 	End           key.Binding
 	Toggle        key.Binding
 	CompactIndent key.Binding
+	CycleIgnored  key.Binding
+	MarkCompare   key.Binding
+	CompareWith   key.Binding
 }
 
 // DefaultKeyMap returns the default key bindings.
@@ -87,6 +144,15 @@ func DefaultKeyMap() KeyMap {
 		CompactIndent: key.NewBinding(
 			key.WithKeys("alt+i", "ˆ"), // ˆ = Option+i on Mac
 		),
+		CycleIgnored: key.NewBinding(
+			key.WithKeys("alt+.", "≥"), // ≥ = Option+. on Mac
+		),
+		MarkCompare: key.NewBinding(
+			key.WithKeys("m"),
+		),
+		CompareWith: key.NewBinding(
+			key.WithKeys("="),
+		),
 	}
 }
 
@@ -94,6 +160,7 @@ func DefaultKeyMap() KeyMap {
 type Model struct {
 	components.Base
 
+	id         uint64 // Identifies this tree's LoadedMsgs
 	root       *Node
 	visible    []*Node // Flattened visible nodes
 	cursor     int     // Current cursor position
@@ -118,7 +185,24 @@ type Model struct {
 	matchCount  int             // Number of matching files
 
 	// Display options
-	compactIndent bool // Use 2-space indentation instead of 4-space
+	compactIndent bool        // Use 2-space indentation instead of 4-space
+	ignoredMode   IgnoredMode // How .gitignore'd entries are shown
+
+	// Ignore rules shared with the file watcher (nil = nothing ignored)
+	ignore *ignore.Matcher
+
+	// Directory comparison results, keyed by absolute path
+	compareMarks map[string]CompareMark
+
+	// File or directory marked to be compared with another
+	comparing string
+
+	// Path being revealed while its parent directories load
+	revealPath string
+
+	// Expanded directories, so the app can watch exactly what is shown
+	expandedDirs    map[string]bool
+	expandedVersion uint64 // Bumped whenever expandedDirs changes
 
 	keys  KeyMap
 	theme *theme.Theme
@@ -134,6 +218,7 @@ func New() Model {
 	ti.CharLimit = 100
 
 	m := Model{
+		id:                treeIDs.Add(1),
 		loading:           make(map[string]bool),
 		dirGitStatusCache: make(map[string]string),
 		keys:              DefaultKeyMap(),
@@ -260,6 +345,21 @@ func (m Model) handleKey(msg tea.KeyPressMsg) (Model, tea.Cmd) {
 		m.compactIndent = !m.compactIndent
 		m.MarkDirty()
 		return m, nil
+
+	case key.Matches(msg, m.keys.CycleIgnored):
+		m.ignoredMode = (m.ignoredMode + 1) % 3
+		m.rebuildVisible()
+		return m, nil
+
+	case key.Matches(msg, m.keys.MarkCompare):
+		return m.handleMarkCompare()
+
+	case key.Matches(msg, m.keys.CompareWith):
+		if node := m.SelectedNode(); node != nil {
+			return m, func() tea.Msg {
+				return CompareWithMsg{Path: node.Path, IsDir: node.IsDir}
+			}
+		}
 	}
 
 	return m, nil
@@ -325,6 +425,11 @@ func (m Model) handleMouseClick(msg tea.MouseClickMsg) (Model, tea.Cmd) {
 }
 
 func (m Model) handleLoaded(msg LoadedMsg) (Model, tea.Cmd) {
+	// Another tree's load - its nodes must not be shared with this tree
+	if msg.tree != 0 && msg.tree != m.id {
+		return m, nil
+	}
+
 	delete(m.loading, msg.Path)
 
 	if msg.Err != nil {
@@ -351,6 +456,11 @@ func (m Model) handleLoaded(msg LoadedMsg) (Model, tea.Cmd) {
 	}
 
 	m.rebuildVisible()
+
+	// Continue a pending Reveal now that another level is loaded
+	if m.revealPath != "" {
+		return m, m.continueReveal()
+	}
 	return m, nil
 }
 
@@ -385,6 +495,20 @@ func (m Model) handleSelect() (Model, tea.Cmd) {
 	}
 }
 
+// handleMarkCompare marks the selected file or directory to be compared
+// with another, or clears the mark when it's the one marked.
+func (m Model) handleMarkCompare() (Model, tea.Cmd) {
+	node := m.SelectedNode()
+	if node == nil {
+		return m, nil
+	}
+	msg := CompareMarkMsg{Path: node.Path, IsDir: node.IsDir}
+	if node.Path == m.comparing {
+		msg = CompareMarkMsg{}
+	}
+	return m, func() tea.Msg { return msg }
+}
+
 func (m Model) handleBack() (Model, tea.Cmd) {
 	if m.cursor < 0 || m.cursor >= len(m.visible) {
 		return m, nil
@@ -489,6 +613,18 @@ func (m *Model) rebuildVisible() {
 
 	allNodes := m.root.Flatten(m.showHidden)
 
+	// Drop ignored entries (their descendants are ignored too)
+	if m.ignoredMode == IgnoredHide {
+		kept := allNodes[:0]
+		for _, node := range allNodes {
+			if !node.Ignored || node == m.root {
+				kept = append(kept, node)
+			}
+		}
+		allNodes = kept
+	}
+	m.trackExpanded(allNodes)
+
 	// Apply search filter if active
 	if m.searchQuery != "" {
 		m.visible, m.matchCount = m.filterNodes(allNodes, m.searchQuery)
@@ -509,6 +645,34 @@ func (m *Model) rebuildVisible() {
 	m.MarkDirty()
 }
 
+// trackExpanded records which directories are expanded and bumps
+// expandedVersion when that set changes.
+func (m *Model) trackExpanded(nodes []*Node) {
+	count := 0
+	changed := false
+	for _, node := range nodes {
+		if node.IsDir && node.Expanded {
+			count++
+			if !m.expandedDirs[node.Path] {
+				changed = true
+			}
+		}
+	}
+	if !changed && count == len(m.expandedDirs) {
+		return
+	}
+
+	// Replace rather than mutate: copies of the model share the map
+	expanded := make(map[string]bool, count)
+	for _, node := range nodes {
+		if node.IsDir && node.Expanded {
+			expanded[node.Path] = true
+		}
+	}
+	m.expandedDirs = expanded
+	m.expandedVersion++
+}
+
 // filterNodes filters nodes based on search query.
 // Returns matching nodes (files that match + their parent directories) and match count.
 func (m *Model) filterNodes(nodes []*Node, query string) ([]*Node, int) {
@@ -556,7 +720,7 @@ func (m Model) loadChildren(path string) tea.Cmd {
 	return func() tea.Msg {
 		entries, err := os.ReadDir(path)
 		if err != nil {
-			return LoadedMsg{Path: path, Err: err}
+			return LoadedMsg{Path: path, Err: err, tree: m.id}
 		}
 
 		children := make([]*Node, 0, len(entries))
@@ -572,6 +736,7 @@ func (m Model) loadChildren(path string) tea.Cmd {
 				Name:    entry.Name(),
 				IsDir:   entry.IsDir(),
 				Depth:   0, // Will be set in handleLoaded
+				Ignored: m.ignore.Match(childPath, entry.IsDir()),
 				Size:    info.Size(),
 				ModTime: info.ModTime().Unix(),
 			}
@@ -581,7 +746,7 @@ func (m Model) loadChildren(path string) tea.Cmd {
 		// Sort: directories first, then alphabetically
 		sortNodes(children)
 
-		return LoadedMsg{Path: path, Children: children}
+		return LoadedMsg{Path: path, Children: children, tree: m.id}
 	}
 }
 
@@ -691,8 +856,15 @@ func (m Model) renderNode(node *Node, selected bool, maxWidth int) string {
 		name += "/"
 	}
 
-	// Get git status indicator
+	// Get git status indicator, preceded by any comparison mark
 	gitIndicator := m.getGitIndicator(node)
+	if mark := m.renderCompareMark(node); mark != "" {
+		if gitIndicator != "" {
+			gitIndicator = mark + " " + gitIndicator
+		} else {
+			gitIndicator = mark
+		}
+	}
 
 	line := indent + icon + " " + name
 
@@ -707,6 +879,8 @@ func (m Model) renderNode(node *Node, selected bool, maxWidth int) string {
 	var style lipgloss.Style
 	if selected {
 		style = theme.FileTreeSelected.Width(maxWidth - indicatorWidth - 1)
+	} else if node.Ignored && m.ignoredMode == IgnoredDim {
+		style = lipgloss.NewStyle().Foreground(theme.DimPurple)
 	} else if node.IsDir {
 		style = theme.FileTreeDir
 	} else {
@@ -728,6 +902,21 @@ func (m Model) renderNode(node *Node, selected bool, maxWidth int) string {
 	return result
 }
 
+// renderCompareMark returns the styled comparison mark for a node, if any.
+func (m Model) renderCompareMark(node *Node) string {
+	if node.Path == m.comparing {
+		return lipgloss.NewStyle().Foreground(theme.CyberCyan).Bold(true).Render("⇄")
+	}
+	switch m.compareMarks[node.Path] {
+	case MarkDiffers:
+		return lipgloss.NewStyle().Foreground(theme.ElectricYellow).Render("≠")
+	case MarkOnlyHere:
+		return lipgloss.NewStyle().Foreground(theme.NeonRed).Render("◆")
+	default:
+		return ""
+	}
+}
+
 // getGitIndicator returns a styled git status indicator for the node
 func (m Model) getGitIndicator(node *Node) string {
 	// Use cached indicator if it's up to date
@@ -835,6 +1024,149 @@ func (m Model) CompactIndent() bool {
 	return m.compactIndent
 }
 
+// SetIgnoreMatcher sets the .gitignore matcher used to flag ignored entries.
+func (m *Model) SetIgnoreMatcher(matcher *ignore.Matcher) {
+	m.ignore = matcher
+}
+
+// SetIgnoredMode sets how ignored entries are displayed.
+func (m *Model) SetIgnoredMode(mode IgnoredMode) {
+	if mode < IgnoredDim || mode > IgnoredShow {
+		mode = IgnoredDim
+	}
+	m.ignoredMode = mode
+	m.rebuildVisible()
+}
+
+// IgnoredMode returns how ignored entries are displayed.
+func (m Model) IgnoredMode() IgnoredMode {
+	return m.ignoredMode
+}
+
+// RefreshIgnored re-evaluates the ignore rules for every loaded node.
+// Call this after the matcher has been reloaded.
+func (m *Model) RefreshIgnored() {
+	if m.root == nil {
+		return
+	}
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		for _, child := range n.Children {
+			child.Ignored = m.ignore.Match(child.Path, child.IsDir)
+			walk(child)
+		}
+	}
+	walk(m.root)
+	m.rebuildVisible()
+}
+
+// SetCompareMarks sets the directory comparison marks, keyed by absolute
+// path. Pass nil to clear them.
+func (m *Model) SetCompareMarks(marks map[string]CompareMark) {
+	m.compareMarks = marks
+	m.MarkDirty()
+}
+
+// SetComparing marks the file or directory to be compared with another, ""
+// for none.
+func (m *Model) SetComparing(path string) {
+	m.comparing = path
+	m.MarkDirty()
+}
+
+// HasCompareMarks reports whether comparison marks are shown.
+func (m Model) HasCompareMarks() bool {
+	return len(m.compareMarks) > 0
+}
+
+// CurrentDir returns the directory the cursor is in: the selected directory
+// itself, or the parent of the selected file.
+func (m Model) CurrentDir() string {
+	node := m.SelectedNode()
+	if node == nil {
+		return m.Root()
+	}
+	if node.IsDir {
+		return node.Path
+	}
+	return filepath.Dir(node.Path)
+}
+
+// Reveal expands the directories leading to path and moves the cursor to it,
+// loading directories as needed. Paths outside the root are ignored.
+func (m *Model) Reveal(path string) tea.Cmd {
+	m.revealPath = filepath.Clean(path)
+	return m.continueReveal()
+}
+
+// continueReveal walks from the root towards revealPath, stopping to load
+// the first directory that isn't loaded yet.
+func (m *Model) continueReveal() tea.Cmd {
+	if m.root == nil || m.revealPath == "" {
+		return nil
+	}
+
+	rel, err := filepath.Rel(m.root.Path, m.revealPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		m.revealPath = ""
+		return nil
+	}
+
+	node := m.root
+	if rel != "." {
+		for _, part := range strings.Split(rel, string(filepath.Separator)) {
+			if !node.Loaded {
+				// Resumed from handleLoaded once the children arrive
+				node.Expanded = true
+				if m.loading[node.Path] {
+					return nil
+				}
+				m.loading[node.Path] = true
+				return m.loadChildren(node.Path)
+			}
+			node.Expanded = true
+
+			var next *Node
+			for _, child := range node.Children {
+				if child.Name == part {
+					next = child
+					break
+				}
+			}
+			if next == nil {
+				// Gone or never existed - show what we expanded so far
+				break
+			}
+			node = next
+		}
+	}
+
+	m.revealPath = ""
+	m.rebuildVisible()
+	for i, n := range m.visible {
+		if n == node {
+			m.cursor = i
+			m.ensureVisible()
+			break
+		}
+	}
+	return nil
+}
+
+// ExpandedDirs returns the paths of all expanded, visible directories.
+func (m Model) ExpandedDirs() []string {
+	dirs := make([]string, 0, len(m.expandedDirs))
+	for dir := range m.expandedDirs {
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// ExpandedVersion changes whenever the set returned by ExpandedDirs changes.
+func (m Model) ExpandedVersion() uint64 {
+	return m.expandedVersion
+}
+
 // RefreshDir triggers a reload of the specified directory.
 // If the path is a file, it refreshes the parent directory.
 func (m Model) RefreshDir(path string) tea.Cmd {
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	tea "charm.land/bubbletea/v2"
+	"github.com/avitaltamir/vibecommander/internal/ignore"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -356,3 +357,215 @@ func TestModelSetRoot(t *testing.T) {
 	assert.Equal(t, 0, m.cursor)
 	assert.Equal(t, 0, m.offset)
 }
+
+func TestModelIgnoredMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	m, _ := NewWithPath(tmpDir)
+	m = m.SetSize(30, 40)
+	m = m.Focus()
+
+	m.root.Loaded = true
+	m.root.Children = []*Node{
+		{Name: "dist", Path: filepath.Join(tmpDir, "dist"), IsDir: true, Depth: 1, Parent: m.root, Ignored: true},
+		{Name: "main.go", Path: filepath.Join(tmpDir, "main.go"), IsDir: false, Depth: 1, Parent: m.root},
+	}
+	m.rebuildVisible()
+
+	t.Run("dims ignored entries by default", func(t *testing.T) {
+		assert.Equal(t, IgnoredDim, m.IgnoredMode())
+		assert.Len(t, m.visible, 3)
+	})
+
+	t.Run("cycles through modes with key", func(t *testing.T) {
+		key := tea.KeyPressMsg{Code: '.', Mod: tea.ModAlt}
+
+		m, _ = m.Update(key)
+		assert.Equal(t, IgnoredHide, m.IgnoredMode())
+		require.Len(t, m.visible, 2)
+		assert.Equal(t, "main.go", m.visible[1].Name)
+
+		m, _ = m.Update(key)
+		assert.Equal(t, IgnoredShow, m.IgnoredMode())
+		assert.Len(t, m.visible, 3)
+
+		m, _ = m.Update(key)
+		assert.Equal(t, IgnoredDim, m.IgnoredMode())
+	})
+
+	t.Run("invalid mode falls back to dim", func(t *testing.T) {
+		m.SetIgnoredMode(IgnoredMode(42))
+		assert.Equal(t, IgnoredDim, m.IgnoredMode())
+	})
+}
+
+func TestModelLoadChildrenMarksIgnored(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte("*.log\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "debug.log"), []byte(""), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte(""), 0644))
+
+	m, _ := NewWithPath(tmpDir)
+	m.SetIgnoreMatcher(ignore.New(tmpDir))
+
+	msg := m.loadChildren(tmpDir)().(LoadedMsg)
+	require.NoError(t, msg.Err)
+
+	ignored := make(map[string]bool)
+	for _, child := range msg.Children {
+		ignored[child.Name] = child.Ignored
+	}
+	assert.True(t, ignored["debug.log"])
+	assert.False(t, ignored["main.go"])
+}
+
+func TestModelExpandedDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+	m, _ := NewWithPath(tmpDir)
+	m = m.SetSize(30, 40)
+
+	sub := &Node{Name: "sub", Path: filepath.Join(tmpDir, "sub"), IsDir: true, Depth: 1, Parent: m.root, Loaded: true}
+	m.root.Loaded = true
+	m.root.Children = []*Node{sub}
+	m.rebuildVisible()
+
+	assert.ElementsMatch(t, []string{tmpDir}, m.ExpandedDirs())
+	version := m.ExpandedVersion()
+
+	t.Run("expanding bumps the version", func(t *testing.T) {
+		sub.Expanded = true
+		m.rebuildVisible()
+		assert.ElementsMatch(t, []string{tmpDir, sub.Path}, m.ExpandedDirs())
+		assert.Greater(t, m.ExpandedVersion(), version)
+		version = m.ExpandedVersion()
+	})
+
+	t.Run("unrelated rebuilds keep the version", func(t *testing.T) {
+		m.rebuildVisible()
+		assert.Equal(t, version, m.ExpandedVersion())
+	})
+
+	t.Run("collapsing drops the directory", func(t *testing.T) {
+		sub.Collapse()
+		m.rebuildVisible()
+		assert.ElementsMatch(t, []string{tmpDir}, m.ExpandedDirs())
+	})
+}
+
+func TestModelIgnoresOtherTreesLoads(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "a.txt"), []byte(""), 0644))
+
+	m1, _ := NewWithPath(tmpDir)
+	m2, _ := NewWithPath(tmpDir)
+
+	msg := m2.loadChildren(tmpDir)().(LoadedMsg)
+
+	m1, _ = m1.Update(msg)
+	assert.False(t, m1.root.Loaded)
+
+	m2, _ = m2.Update(msg)
+	assert.True(t, m2.root.Loaded)
+	assert.Len(t, m2.root.Children, 1)
+}
+
+func TestModelCurrentDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	m, _ := NewWithPath(tmpDir)
+	m = m.SetSize(30, 40)
+
+	sub := &Node{Name: "sub", Path: filepath.Join(tmpDir, "sub"), IsDir: true, Depth: 1, Parent: m.root}
+	file := &Node{Name: "main.go", Path: filepath.Join(tmpDir, "main.go"), Depth: 1, Parent: m.root}
+	m.root.Loaded = true
+	m.root.Children = []*Node{sub, file}
+	m.rebuildVisible()
+
+	m.cursor = 0
+	assert.Equal(t, tmpDir, m.CurrentDir())
+
+	m.cursor = 1
+	assert.Equal(t, sub.Path, m.CurrentDir())
+
+	m.cursor = 2
+	assert.Equal(t, tmpDir, m.CurrentDir())
+}
+
+func TestModelCompareMarks(t *testing.T) {
+	tmpDir := t.TempDir()
+	m, _ := NewWithPath(tmpDir)
+	m = m.SetSize(30, 40)
+
+	changed := &Node{Name: "changed.go", Path: filepath.Join(tmpDir, "changed.go"), Depth: 1, Parent: m.root}
+	added := &Node{Name: "added.go", Path: filepath.Join(tmpDir, "added.go"), Depth: 1, Parent: m.root}
+	m.root.Loaded = true
+	m.root.Children = []*Node{added, changed}
+	m.rebuildVisible()
+
+	assert.False(t, m.HasCompareMarks())
+	assert.Empty(t, m.renderCompareMark(changed))
+
+	m.SetCompareMarks(map[string]CompareMark{
+		changed.Path: MarkDiffers,
+		added.Path:   MarkOnlyHere,
+	})
+	assert.True(t, m.HasCompareMarks())
+	assert.Contains(t, m.renderCompareMark(changed), "≠")
+	assert.Contains(t, m.renderCompareMark(added), "◆")
+	assert.Contains(t, m.View(), "≠")
+
+	m.SetCompareMarks(nil)
+	assert.False(t, m.HasCompareMarks())
+}
+
+func TestModelReveal(t *testing.T) {
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "a", "b", "c.txt")
+	require.NoError(t, os.MkdirAll(filepath.Dir(target), 0755))
+	require.NoError(t, os.WriteFile(target, []byte(""), 0644))
+
+	m, _ := NewWithPath(tmpDir)
+	m = m.SetSize(30, 40)
+
+	// Drive the loads the reveal asks for until it settles
+	cmd := m.Reveal(target)
+	for i := 0; cmd != nil && i < 10; i++ {
+		m, cmd = m.Update(cmd())
+	}
+
+	assert.Equal(t, target, m.SelectedPath())
+	assert.Empty(t, m.revealPath)
+
+	t.Run("paths outside the root are ignored", func(t *testing.T) {
+		assert.Nil(t, m.Reveal(filepath.Dir(tmpDir)))
+		assert.Equal(t, target, m.SelectedPath())
+	})
+}
+
+func TestModelCompareKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.txt")
+	require.NoError(t, os.WriteFile(testFile, []byte("content"), 0644))
+
+	m, _ := NewWithPath(tmpDir)
+	m = m.SetSize(30, 40)
+	m = m.Focus()
+	m.root.Loaded = true
+	m.root.Children = []*Node{
+		{Name: "test.txt", Path: testFile, IsDir: false, Depth: 1, Parent: m.root},
+	}
+	m.rebuildVisible()
+	m.cursor = 1
+
+	_, cmd := m.Update(tea.KeyPressMsg{Code: 'm', Text: "m"})
+	require.NotNil(t, cmd)
+	assert.Equal(t, CompareMarkMsg{Path: testFile}, cmd())
+
+	// Marking it again clears the mark
+	m.SetComparing(testFile)
+	_, cmd = m.Update(tea.KeyPressMsg{Code: 'm', Text: "m"})
+	require.NotNil(t, cmd)
+	assert.Equal(t, CompareMarkMsg{}, cmd())
+
+	_, cmd = m.Update(tea.KeyPressMsg{Code: '=', Text: "="})
+	require.NotNil(t, cmd)
+	assert.Equal(t, CompareWithMsg{Path: testFile}, cmd())
+}
@@ -17,6 +17,7 @@ type Node struct {
 	Depth    int
 	Loaded   bool  // Whether children have been loaded (for lazy loading)
 	Expanded bool  // Whether directory is expanded in view
+	Ignored  bool  // Whether the entry is excluded by .gitignore rules
 	Size     int64 // File size in bytes
 	ModTime  int64 // Modification time as unix timestamp
 
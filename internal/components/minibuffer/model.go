@@ -1,11 +1,9 @@
 package minibuffer
 
 import (
-	"fmt"
 	"io"
 	"os"
 	"os/exec"
-	"strings"
 	"sync"
 	"time"
 
@@ -13,9 +11,10 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/atotto/clipboard"
 	"github.com/avitaltamir/vibecommander/internal/components"
+	"github.com/avitaltamir/vibecommander/internal/links"
 	"github.com/avitaltamir/vibecommander/internal/theme"
+	"github.com/avitaltamir/vibecommander/internal/vt"
 	"github.com/creack/pty"
-	"github.com/hinshun/vt10x"
 )
 
 // Render throttling interval - render at most once per this duration
@@ -42,17 +41,18 @@ type (
 type Model struct {
 	components.Base
 
-	vt      vt10x.Terminal
+	vt      *vt.Terminal
 	cmd     *exec.Cmd
 	pty     *os.File
 	mu      sync.Mutex
 	running bool
 	exitErr error
 
-	// Scrollback buffer
-	scrollback    []string // Lines that scrolled off the top
-	scrollOffset  int      // 0 = live view, >0 = scrolled up N lines
-	maxScrollback int      // Max lines to keep
+	// Scrollback, kept by the virtual terminal
+	scrollOffset int // 0 = live view, >0 = scrolled up N lines
+
+	// File references and hyperlinks in the output
+	links links.Model
 
 	// Render throttling
 	cachedView      string    // Cached rendered view
@@ -67,26 +67,11 @@ type Model struct {
 // New creates a new mini buffer model.
 func New() Model {
 	return Model{
-		theme:         theme.DefaultTheme(),
-		maxScrollback: 10000,
+		theme: theme.DefaultTheme(),
+		links: links.New(),
 	}
 }
 
-// addToScrollback adds a line to scrollback with deduplication
-func (m *Model) addToScrollback(line string) {
-	// Check against recent entries to avoid duplicates
-	checkCount := 20 // Check last 20 entries
-	if checkCount > len(m.scrollback) {
-		checkCount = len(m.scrollback)
-	}
-	for i := len(m.scrollback) - checkCount; i < len(m.scrollback); i++ {
-		if m.scrollback[i] == line {
-			return // Already exists, skip
-		}
-	}
-	m.scrollback = append(m.scrollback, line)
-}
-
 // Init initializes the mini buffer.
 func (m Model) Init() tea.Cmd {
 	return nil
@@ -153,53 +138,14 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		m.scrollOffset = 0
 
 		if m.vt != nil {
-			cols, rows := m.vt.Size()
-
-			// Capture all screen lines before write
-			oldPlainLines := make([]string, rows)
-			oldRenderedLines := make([]string, rows)
-			for row := 0; row < rows; row++ {
-				oldPlainLines[row] = m.getScreenLinePlain(cols, row)
-				oldRenderedLines[row] = m.renderScreenLine(cols, row)
-			}
-
-			// Write data
 			m.vt.Write(msg.Data)
 
-			// Find where the new top line was in the old screen
-			newTopLine := m.getScreenLinePlain(cols, 0)
-			scrollAmount := 0
-
-			if len(strings.TrimSpace(newTopLine)) > 0 {
-				for i := 1; i < rows; i++ {
-					if len(strings.TrimSpace(oldPlainLines[i])) > 0 && oldPlainLines[i] == newTopLine {
-						scrollAmount = i
-						break
-					}
-				}
+			// Answer the shell's queries, and copy what it asked to
+			if replies := m.vt.TakeReplies(); len(replies) > 0 && m.pty != nil {
+				m.pty.Write(replies)
 			}
-
-			// Add scrolled lines to scrollback
-			if scrollAmount > 0 {
-				// Normal scroll - add lines that scrolled off
-				for i := 0; i < scrollAmount; i++ {
-					if len(strings.TrimSpace(oldPlainLines[i])) > 0 {
-						m.addToScrollback(oldRenderedLines[i])
-					}
-				}
-			} else if oldPlainLines[0] != newTopLine && len(strings.TrimSpace(oldPlainLines[0])) > 0 {
-				// Screen changed but couldn't detect scroll amount
-				// This happens with large data chunks - save all non-empty old lines
-				for i := 0; i < rows; i++ {
-					if len(strings.TrimSpace(oldPlainLines[i])) > 0 {
-						m.addToScrollback(oldRenderedLines[i])
-					}
-				}
-			}
-
-			// Trim scrollback if too large
-			if len(m.scrollback) > m.maxScrollback {
-				m.scrollback = m.scrollback[len(m.scrollback)-m.maxScrollback:]
+			for _, text := range m.vt.TakeClipboard() {
+				_ = clipboard.WriteAll(text)
 			}
 		}
 		m.dirty = true
@@ -228,12 +174,28 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		return m, m.StartShell()
 
 	case tea.MouseWheelMsg:
-		// Handle mouse scroll for scrollback buffer
+		if m.vt == nil || m.passMouse(msg) {
+			return m, nil
+		}
+		// Full-screen programs that don't take the mouse get arrow keys, as
+		// there's no scrollback on their screen
 		mouse := msg.Mouse()
+		if m.running && m.pty != nil && m.vt.AltScreen() && m.scrollOffset == 0 {
+			key := tea.Key{Code: tea.KeyUp}
+			if mouse.Button == tea.MouseWheelDown {
+				key.Code = tea.KeyDown
+			}
+			for range 3 {
+				m.pty.Write(m.vt.KeyInput(key))
+			}
+			return m, nil
+		}
+
+		// Handle mouse scroll for scrollback buffer
 		switch mouse.Button {
 		case tea.MouseWheelUp:
 			// Scroll up (into history)
-			maxScroll := len(m.scrollback)
+			maxScroll := m.vt.ScrollbackLen()
 			m.scrollOffset += 3
 			if m.scrollOffset > maxScroll {
 				m.scrollOffset = maxScroll
@@ -249,14 +211,40 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case tea.MouseClickMsg:
+		// Programs that asked for the mouse get it, otherwise open the link
+		// clicked, if any
+		if m.passMouse(msg) {
+			return m, nil
+		}
+		mouse := msg.Mouse()
+		if link, ok := m.links.At(links.Visible(m.vt, m.scrollOffset), mouse.Y-1, mouse.X-1); ok {
+			return m, link.Open()
+		}
+		return m, nil
+
+	case tea.MouseMotionMsg:
+		if m.passMouse(msg) {
+			return m, nil
+		}
+		// Underline the link under the mouse
+		mouse := msg.Mouse()
+		if m.links.Hover(links.Visible(m.vt, m.scrollOffset), mouse.Y-1, mouse.X-1) {
+			m.cachedView = m.renderVT()
+		}
+		return m, nil
+
+	case tea.MouseReleaseMsg:
+		m.passMouse(msg)
+		return m, nil
+
 	case tea.PasteMsg:
 		// Handle clipboard paste
 		if !m.Focused() {
 			return m, nil
 		}
 		if m.running && m.pty != nil && msg.Content != "" {
-			// Write pasted text directly to PTY
-			m.pty.Write([]byte(msg.Content))
+			m.pty.Write(m.vt.PasteInput(msg.Content))
 		}
 		return m, nil
 
@@ -265,87 +253,35 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			return m, nil
 		}
 
+		// Alt+K labels the links, and the next key opens the one labelled
+		if m.links.Hinting() {
+			link, ok := m.links.HintKey(msg.String())
+			m.cachedView = m.renderVT()
+			if ok {
+				return m, link.Open()
+			}
+			return m, nil
+		}
+		if s := msg.String(); s == "alt+k" || s == "˚" { // ˚ = Option+k on Mac
+			m.links.ClearHover()
+			if m.links.StartHints(links.Visible(m.vt, m.scrollOffset)) {
+				m.cachedView = m.renderVT()
+			}
+			return m, nil
+		}
+
 		// Send input to PTY if running
 		if m.running && m.pty != nil {
-			var input []byte
 			key := msg.Key()
-			hasAlt := key.Mod&tea.ModAlt != 0
-			hasCtrl := key.Mod&tea.ModCtrl != 0
-			hasShift := key.Mod&tea.ModShift != 0
 
-			// Handle Ctrl+V for paste (before generic Ctrl handling)
-			if hasCtrl && key.Code == 'v' {
+			// Handle Ctrl+V for paste
+			if key.Mod&tea.ModCtrl != 0 && key.Code == 'v' {
 				if text, err := clipboard.ReadAll(); err == nil && text != "" {
-					m.pty.Write([]byte(text))
+					m.pty.Write(m.vt.PasteInput(text))
 				}
 				return m, nil
 			}
-
-			// Handle Ctrl key combinations
-			if hasCtrl && key.Code >= 'a' && key.Code <= 'z' {
-				// Ctrl+A=1 through Ctrl+Z=26
-				input = []byte{byte(key.Code - 'a' + 1)}
-			} else if hasShift && key.Code == tea.KeyTab {
-				// Shift+Tab sends ESC [ Z (reverse tab / backtab)
-				input = []byte("\x1b[Z")
-			} else if hasAlt && key.Code >= 'a' && key.Code <= 'z' {
-				// Alt+letter sends ESC + letter (e.g., Alt+B for backward-word)
-				input = []byte{27, byte(key.Code)}
-			} else {
-				// Handle special keys and regular input
-				switch key.Code {
-				case tea.KeyEnter:
-					input = []byte("\r")
-				case tea.KeyBackspace:
-					if hasAlt {
-						input = []byte{27, 127} // ESC + DEL for Alt+Backspace (delete word)
-					} else {
-						input = []byte{127}
-					}
-				case tea.KeyTab:
-					input = []byte("\t")
-				case tea.KeySpace:
-					input = []byte(" ")
-				case tea.KeyUp:
-					input = []byte("\x1b[A")
-				case tea.KeyDown:
-					input = []byte("\x1b[B")
-				case tea.KeyRight:
-					input = []byte("\x1b[C")
-				case tea.KeyLeft:
-					input = []byte("\x1b[D")
-				case tea.KeyEscape:
-					input = []byte{27}
-				case tea.KeyHome:
-					input = []byte("\x1b[H")
-				case tea.KeyEnd:
-					input = []byte("\x1b[F")
-				case tea.KeyPgUp:
-					input = []byte("\x1b[5~")
-				case tea.KeyPgDown:
-					input = []byte("\x1b[6~")
-				case tea.KeyDelete:
-					input = []byte("\x1b[3~")
-				default:
-					// Regular character input
-					if key.Text != "" {
-						// Filter out mouse/escape sequence fragments
-						if looksLikeMouseSequence(key.Text) || looksLikeEscapeFragment(key.Text) {
-							return m, nil
-						}
-						if hasAlt {
-							for _, r := range key.Text {
-								input = append(input, 27)
-								input = append(input, byte(r))
-							}
-						} else {
-							input = []byte(key.Text)
-						}
-					}
-				}
-			}
-
-			if len(input) > 0 {
+			if input := m.vt.KeyInput(key); len(input) > 0 {
 				m.pty.Write(input)
 			}
 			return m, nil
@@ -364,8 +300,14 @@ func (m Model) startProcess() (Model, tea.Cmd) {
 		h = 24
 	}
 
-	// Create virtual terminal with current size
-	m.vt = vt10x.New(vt10x.WithSize(w, h))
+	// Create virtual terminal with current size, or clear the screen of the
+	// last shell's, keeping its scrollback
+	if m.vt == nil {
+		m.vt = vt.New(w, h)
+	} else {
+		m.vt.Resize(w, h)
+		m.vt.Write([]byte("\x1bc"))
+	}
 
 	// Get user's shell
 	shell := os.Getenv("SHELL")
@@ -441,286 +383,45 @@ func (m Model) View() string {
 		Render("Shell ready...")
 }
 
-// renderVT renders the virtual terminal screen buffer with colors
-// Optimized to use raw ANSI codes and batch consecutive characters with same style
-func (m Model) renderVT() string {
-	if m.vt == nil {
-		return ""
-	}
-
-	m.vt.Lock()
-	defer m.vt.Unlock()
-
-	cols, rows := m.vt.Size()
-	if cols <= 0 || rows <= 0 {
-		return ""
-	}
-
-	// If scrolled up, show scrollback + partial screen
-	if m.scrollOffset > 0 && len(m.scrollback) > 0 {
-		return m.renderWithScrollback(cols, rows)
-	}
-
-	// Live view - render current vt screen
-	return m.renderLiveScreen(cols, rows)
-}
-
-// renderLiveScreen renders the current vt10x screen (no scrollback)
-func (m Model) renderLiveScreen(cols, rows int) string {
-	cursor := m.vt.Cursor()
-	cursorVisible := m.vt.CursorVisible() && m.Focused()
-
-	var result strings.Builder
-	result.Grow(rows * cols * 2)
-
-	for row := 0; row < rows; row++ {
-		if row > 0 {
-			result.WriteByte('\n')
-		}
-
-		var currentFG, currentBG vt10x.Color
-		var currentMode int16
-		var currentIsCursor bool
-		var batch strings.Builder
-		firstCell := true
-
-		flushBatch := func() {
-			if batch.Len() == 0 {
-				return
-			}
-			result.WriteString(buildANSI(currentFG, currentBG, currentMode, currentIsCursor))
-			result.WriteString(batch.String())
-			result.WriteString("\x1b[0m")
-			batch.Reset()
-		}
-
-		for col := 0; col < cols; col++ {
-			glyph := m.vt.Cell(col, row)
-			ch := glyph.Char
-			if ch == 0 {
-				ch = ' '
-			}
-
-			isCursor := cursorVisible && col == cursor.X && row == cursor.Y
-
-			if !firstCell && (glyph.FG != currentFG || glyph.BG != currentBG || glyph.Mode != currentMode || isCursor != currentIsCursor) {
-				flushBatch()
-			}
-
-			currentFG = glyph.FG
-			currentBG = glyph.BG
-			currentMode = glyph.Mode
-			currentIsCursor = isCursor
-			firstCell = false
-
-			batch.WriteRune(ch)
-		}
-		flushBatch()
-	}
-
-	return result.String()
-}
-
-// renderWithScrollback renders view including scrollback history
-func (m Model) renderWithScrollback(cols, rows int) string {
-	var lines []string
-
-	// Calculate which lines to show
-	scrollbackLen := len(m.scrollback)
-	startIdx := scrollbackLen - m.scrollOffset
-	if startIdx < 0 {
-		startIdx = 0
-	}
-
-	// Add scrollback lines
-	for i := startIdx; i < scrollbackLen && len(lines) < rows; i++ {
-		lines = append(lines, m.scrollback[i])
-	}
-
-	// Add current screen lines if we have room
-	if len(lines) < rows {
-		screenRows := rows - len(lines)
-		for row := 0; row < screenRows; row++ {
-			lines = append(lines, m.renderScreenLine(cols, row))
-		}
-	}
-
-	// Pad with empty lines if needed
-	for len(lines) < rows {
-		lines = append(lines, strings.Repeat(" ", cols))
-	}
-
-	return strings.Join(lines, "\n")
-}
-
-// renderScreenLine renders a single line from the vt screen
-func (m Model) renderScreenLine(cols, row int) string {
-	var result strings.Builder
-	var currentFG, currentBG vt10x.Color
-	var currentMode int16
-	var batch strings.Builder
-	firstCell := true
-
-	flushBatch := func() {
-		if batch.Len() == 0 {
-			return
-		}
-		result.WriteString(buildANSI(currentFG, currentBG, currentMode, false))
-		result.WriteString(batch.String())
-		result.WriteString("\x1b[0m")
-		batch.Reset()
-	}
-
-	for col := 0; col < cols; col++ {
-		glyph := m.vt.Cell(col, row)
-		ch := glyph.Char
-		if ch == 0 {
-			ch = ' '
-		}
-
-		if !firstCell && (glyph.FG != currentFG || glyph.BG != currentBG || glyph.Mode != currentMode) {
-			flushBatch()
-		}
-
-		currentFG = glyph.FG
-		currentBG = glyph.BG
-		currentMode = glyph.Mode
-		firstCell = false
-
-		batch.WriteRune(ch)
-	}
-	flushBatch()
-
-	return result.String()
-}
-
-// getScreenLinePlain returns a screen line as plain text (no ANSI codes) for comparison
-func (m Model) getScreenLinePlain(cols, row int) string {
-	var result strings.Builder
-	for col := 0; col < cols; col++ {
-		ch := m.vt.Cell(col, row).Char
-		if ch == 0 {
-			ch = ' '
-		}
-		result.WriteRune(ch)
-	}
-	return strings.TrimRight(result.String(), " ")
-}
-
-// getTopLine returns the current top line of the terminal as plain text (no ANSI codes)
-func (m Model) getTopLine() string {
+// renderVT renders the lines shown, from the scrollback or the screen, with
+// their colors and links
+func (m *Model) renderVT() string {
 	if m.vt == nil {
 		return ""
 	}
-	cols, _ := m.vt.Size()
-	if cols <= 0 {
-		return ""
-	}
-	return m.getScreenLinePlain(cols, 0)
+	return m.vt.View(m.scrollOffset, vt.RenderOptions{
+		Cursor:   m.scrollOffset == 0 && m.Focused(),
+		Decorate: m.decorate,
+	})
 }
 
-// looksLikeEscapeFragment returns true if the string looks like a fragment of an escape sequence
-func looksLikeEscapeFragment(s string) bool {
-	// Single [ or < often comes from split escape sequences
-	if s == "[" || s == "<" || s == "[<" {
-		return true
-	}
-	// Sequences starting with [ followed by numbers/semicolons (partial CSI)
-	if len(s) > 0 && s[0] == '[' {
-		for i := 1; i < len(s); i++ {
-			c := s[i]
-			if c != ';' && c != '<' && (c < '0' || c > '9') {
-				return false
-			}
-		}
-		return len(s) > 1
+// decorate draws the links state over the rendered lines.
+func (m *Model) decorate(lines []string, _ int) {
+	if m.links.Active() {
+		m.links.Decorate(lines)
 	}
-	return false
 }
 
-// looksLikeMouseSequence returns true if the string looks like a partial SGR mouse sequence
-// These look like "65;83;57M" or "0;45;12m" - numbers, semicolons, ending with M or m
-func looksLikeMouseSequence(s string) bool {
-	if len(s) < 3 {
+// passMouse sends a mouse event to the shell's program, if it asked for that
+// kind of event and the live screen is shown. Holding Shift keeps the mouse
+// for opening links.
+func (m *Model) passMouse(msg tea.MouseMsg) bool {
+	if !m.running || m.pty == nil || m.scrollOffset > 0 {
 		return false
 	}
-	// Check if it ends with M or m (SGR mouse)
-	last := s[len(s)-1]
-	if last != 'M' && last != 'm' {
+	mouse := msg.Mouse()
+	col, row := mouse.X-1, mouse.Y-1 // Inside the border
+	if _, rows := m.vt.Size(); mouse.Mod&tea.ModShift != 0 || col < 0 || row < 0 || row >= rows {
 		return false
 	}
-	// Check if the rest is numbers and semicolons
-	for i := 0; i < len(s)-1; i++ {
-		c := s[i]
-		if c != ';' && (c < '0' || c > '9') && c != '<' {
-			return false
-		}
+	input := m.vt.MouseInput(msg, col, row)
+	if input == nil {
+		return false
 	}
+	m.pty.Write(input)
 	return true
 }
 
-// buildANSI builds ANSI escape sequence for the given style
-func buildANSI(fg, bg vt10x.Color, mode int16, isCursor bool) string {
-	var codes []string
-
-	if isCursor {
-		codes = append(codes, "7") // Reverse video
-	} else {
-		// Mode attributes
-		if mode&0x01 != 0 { // Reverse
-			codes = append(codes, "7")
-		}
-		if mode&0x02 != 0 { // Underline
-			codes = append(codes, "4")
-		}
-		if mode&0x04 != 0 { // Bold
-			codes = append(codes, "1")
-		}
-		if mode&0x10 != 0 { // Italic
-			codes = append(codes, "3")
-		}
-
-		// Foreground color
-		if fgCode := colorToANSI(fg, true); fgCode != "" {
-			codes = append(codes, fgCode)
-		}
-
-		// Background color
-		if bgCode := colorToANSI(bg, false); bgCode != "" {
-			codes = append(codes, bgCode)
-		}
-	}
-
-	if len(codes) == 0 {
-		return ""
-	}
-	return "\x1b[" + strings.Join(codes, ";") + "m"
-}
-
-// colorToANSI converts a vt10x color to ANSI escape code
-func colorToANSI(c vt10x.Color, isFG bool) string {
-	// Default color
-	if c >= 0x01000000 {
-		return ""
-	}
-
-	base := 38 // Foreground
-	if !isFG {
-		base = 48 // Background
-	}
-
-	// ANSI 256-color palette
-	if c < 256 {
-		return fmt.Sprintf("%d;5;%d", base, c)
-	}
-
-	// True color RGB
-	r := (c >> 16) & 0xFF
-	g := (c >> 8) & 0xFF
-	b := c & 0xFF
-	return fmt.Sprintf("%d;2;%d;%d;%d", base, r, g, b)
-}
-
 // Focus gives focus to this component.
 func (m Model) Focus() Model {
 	m.Base.Focus()
@@ -0,0 +1,326 @@
+package quickpick
+
+import (
+	"strconv"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/avitaltamir/vibecommander/internal/components"
+	"github.com/avitaltamir/vibecommander/internal/theme"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Item is one entry in the picker.
+type Item struct {
+	Title  string // Main text (e.g. file name)
+	Detail string // Dimmed secondary text (e.g. directory)
+	Value  string // Returned on selection (e.g. absolute path)
+	Index  int    // Position in the caller's list
+}
+
+// Messages
+type (
+	// SelectMsg is sent when an item is picked.
+	SelectMsg struct {
+		ID   string
+		Item Item
+	}
+
+	// RemoveMsg is sent when the user asks to remove an item from the list.
+	RemoveMsg struct {
+		ID   string
+		Item Item
+	}
+)
+
+// KeyMap defines the key bindings for the picker.
+type KeyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Select key.Binding
+	Remove key.Binding
+	Cancel key.Binding
+}
+
+// DefaultKeyMap returns the default key bindings.
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Up: key.NewBinding(
+			key.WithKeys("up", "ctrl+p", "ctrl+k"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "ctrl+n", "ctrl+j"),
+		),
+		Select: key.NewBinding(
+			key.WithKeys("enter"),
+		),
+		Remove: key.NewBinding(
+			key.WithKeys("ctrl+d"),
+		),
+		Cancel: key.NewBinding(
+			key.WithKeys("esc"),
+		),
+	}
+}
+
+// maxVisible is the number of items shown at once.
+const maxVisible = 12
+
+// Model is a filterable list overlay.
+type Model struct {
+	components.Base
+
+	open      bool
+	id        string // Identifies which list is being picked from
+	title     string
+	removable bool // Whether items can be removed with ctrl+d
+	items     []Item
+	filtered  []Item
+	cursor    int
+	offset    int
+	input     textinput.Model
+
+	keys KeyMap
+}
+
+// New creates a closed picker.
+func New() Model {
+	ti := textinput.New()
+	ti.Placeholder = "type to filter..."
+	ti.CharLimit = 100
+	ti.Prompt = "> "
+
+	return Model{
+		input: ti,
+		keys:  DefaultKeyMap(),
+	}
+}
+
+// Open shows the picker with the given items. id is echoed back in SelectMsg
+// and RemoveMsg; removable enables ctrl+d.
+func (m Model) Open(id, title string, items []Item, removable bool) (Model, tea.Cmd) {
+	m.open = true
+	m.id = id
+	m.title = title
+	m.items = items
+	m.removable = removable
+	m.cursor = 0
+	m.offset = 0
+	m.input.SetValue("")
+	m.filter()
+	return m, m.input.Focus()
+}
+
+// Close hides the picker.
+func (m Model) Close() Model {
+	m.open = false
+	m.input.Blur()
+	return m
+}
+
+// IsOpen reports whether the picker is visible.
+func (m Model) IsOpen() bool {
+	return m.open
+}
+
+// ID returns the id the picker was opened with.
+func (m Model) ID() string {
+	return m.id
+}
+
+// SetItems replaces the items, keeping the filter (e.g. after a removal).
+func (m Model) SetItems(items []Item) Model {
+	m.items = items
+	m.filter()
+	return m
+}
+
+// SetSize updates the component's dimensions.
+func (m Model) SetSize(width, height int) Model {
+	m.Base.SetSize(width, height)
+	return m
+}
+
+// Update handles messages.
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if !m.open {
+		return m, nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Cancel):
+		return m.Close(), nil
+
+	case key.Matches(keyMsg, m.keys.Up):
+		m.moveCursor(-1)
+		return m, nil
+
+	case key.Matches(keyMsg, m.keys.Down):
+		m.moveCursor(1)
+		return m, nil
+
+	case key.Matches(keyMsg, m.keys.Select):
+		item, ok := m.Selected()
+		if !ok {
+			return m, nil
+		}
+		id := m.id
+		m = m.Close()
+		return m, func() tea.Msg {
+			return SelectMsg{ID: id, Item: item}
+		}
+
+	case key.Matches(keyMsg, m.keys.Remove):
+		item, ok := m.Selected()
+		if !ok || !m.removable {
+			return m, nil
+		}
+		id := m.id
+		return m, func() tea.Msg {
+			return RemoveMsg{ID: id, Item: item}
+		}
+	}
+
+	// Everything else edits the filter
+	var cmd tea.Cmd
+	query := m.input.Value()
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != query {
+		m.cursor = 0
+		m.offset = 0
+		m.filter()
+	}
+	return m, cmd
+}
+
+// Selected returns the item under the cursor.
+func (m Model) Selected() (Item, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.filtered) {
+		return Item{}, false
+	}
+	return m.filtered[m.cursor], true
+}
+
+// SetCursor moves the cursor to the item at position i of the filtered list.
+func (m Model) SetCursor(i int) Model {
+	m.cursor = 0
+	m.moveCursor(i)
+	return m
+}
+
+// filter applies the query: every space-separated word must appear in the
+// title or detail, case-insensitively.
+func (m *Model) filter() {
+	words := strings.Fields(strings.ToLower(m.input.Value()))
+
+	var filtered []Item
+	for _, item := range m.items {
+		text := strings.ToLower(item.Title + " " + item.Detail)
+		matched := true
+		for _, w := range words {
+			if !strings.Contains(text, w) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			filtered = append(filtered, item)
+		}
+	}
+	m.filtered = filtered
+
+	if m.cursor >= len(m.filtered) {
+		m.cursor = len(m.filtered) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+func (m *Model) moveCursor(delta int) {
+	m.cursor += delta
+	if m.cursor >= len(m.filtered) {
+		m.cursor = len(m.filtered) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+maxVisible {
+		m.offset = m.cursor - maxVisible + 1
+	}
+}
+
+// View renders the picker box. The caller places it on screen.
+func (m Model) View() string {
+	w, _ := m.Size()
+	width := w
+	if width <= 0 || width > 70 {
+		width = 70
+	}
+	inner := width - 6 // Border and padding
+
+	titleStyle := lipgloss.NewStyle().Foreground(theme.MagentaBlaze).Bold(true)
+	detailStyle := lipgloss.NewStyle().Foreground(theme.MutedLavender)
+	selectedStyle := lipgloss.NewStyle().Foreground(theme.CyberCyan).Bold(true)
+	dimStyle := lipgloss.NewStyle().Foreground(theme.DimPurple)
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(m.title))
+	b.WriteString("\n")
+	b.WriteString(m.input.View())
+	b.WriteString("\n\n")
+
+	if len(m.filtered) == 0 {
+		b.WriteString(dimStyle.Render("(nothing here)"))
+	}
+
+	end := m.offset + maxVisible
+	if end > len(m.filtered) {
+		end = len(m.filtered)
+	}
+	for i := m.offset; i < end; i++ {
+		item := m.filtered[i]
+		marker := "  "
+		title := item.Title
+		if i == m.cursor {
+			marker = "> "
+			title = selectedStyle.Render(title)
+		}
+		line := marker + title
+		if item.Detail != "" {
+			line += "  " + detailStyle.Render(item.Detail)
+		}
+		b.WriteString(ansi.Truncate(line, inner, "…"))
+		if i < end-1 {
+			b.WriteString("\n")
+		}
+	}
+
+	hints := "enter:open  esc:close"
+	if m.removable {
+		hints = "enter:open  ctrl+d:remove  esc:close"
+	}
+	if len(m.filtered) > maxVisible {
+		hints = strconv.Itoa(m.cursor+1) + "/" + strconv.Itoa(len(m.filtered)) + "  " + hints
+	}
+	b.WriteString("\n\n")
+	b.WriteString(dimStyle.Render(hints))
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.CyberCyan).
+		Padding(0, 2).
+		Width(width).
+		Render(b.String())
+}
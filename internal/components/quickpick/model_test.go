@@ -0,0 +1,93 @@
+package quickpick
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testItems() []Item {
+	return []Item{
+		{Title: "main.go", Detail: "cmd/vc", Value: "/p/cmd/vc/main.go", Index: 0},
+		{Title: "app.go", Detail: "internal/app", Value: "/p/internal/app/app.go", Index: 1},
+		{Title: "model.go", Detail: "internal/components/filetree", Value: "/p/internal/components/filetree/model.go", Index: 2},
+	}
+}
+
+func typeText(m Model, text string) Model {
+	for _, r := range text {
+		m, _ = m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+	return m
+}
+
+func TestOpenClose(t *testing.T) {
+	m := New()
+	assert.False(t, m.IsOpen())
+
+	m, _ = m.Open("recent", "RECENT FILES", testItems(), false)
+	assert.True(t, m.IsOpen())
+	assert.Equal(t, "recent", m.ID())
+	assert.Contains(t, m.View(), "RECENT FILES")
+	assert.Contains(t, m.View(), "main.go")
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	assert.False(t, m.IsOpen())
+}
+
+func TestFilter(t *testing.T) {
+	m := New()
+	m, _ = m.Open("recent", "RECENT", testItems(), false)
+
+	t.Run("matches title and detail", func(t *testing.T) {
+		m := typeText(m, "internal go")
+		assert.Len(t, m.filtered, 2)
+
+		m = typeText(m, " filetree")
+		require.Len(t, m.filtered, 1)
+		assert.Equal(t, "model.go", m.filtered[0].Title)
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		m := typeText(m, "zzz")
+		assert.Empty(t, m.filtered)
+		_, ok := m.Selected()
+		assert.False(t, ok)
+		assert.Contains(t, m.View(), "nothing here")
+	})
+}
+
+func TestSelect(t *testing.T) {
+	m := New()
+	m, _ = m.Open("bookmarks", "BOOKMARKS", testItems(), true)
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyDown})
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyDown})
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyDown}) // Clamped at the end
+	item, ok := m.Selected()
+	require.True(t, ok)
+	assert.Equal(t, 2, item.Index)
+
+	t.Run("remove keeps the picker open", func(t *testing.T) {
+		m, cmd := m.Update(tea.KeyPressMsg{Code: 'd', Mod: tea.ModCtrl})
+		require.NotNil(t, cmd)
+		assert.Equal(t, RemoveMsg{ID: "bookmarks", Item: item}, cmd())
+		assert.True(t, m.IsOpen())
+	})
+
+	t.Run("enter picks and closes", func(t *testing.T) {
+		m, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+		require.NotNil(t, cmd)
+		assert.Equal(t, SelectMsg{ID: "bookmarks", Item: item}, cmd())
+		assert.False(t, m.IsOpen())
+	})
+}
+
+func TestRemoveDisabled(t *testing.T) {
+	m := New()
+	m, _ = m.Open("recent", "RECENT", testItems(), false)
+	_, cmd := m.Update(tea.KeyPressMsg{Code: 'd', Mod: tea.ModCtrl})
+	assert.Nil(t, cmd)
+}
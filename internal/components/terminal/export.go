@@ -0,0 +1,168 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/avitaltamir/vibecommander/internal/theme"
+)
+
+// ExportedMsg is sent when the transcript has been written, to Path or, when
+// View is set, to a temporary file to open in the viewer.
+type ExportedMsg struct {
+	Path string
+	View bool
+	Err  error
+}
+
+// export is the prompt for where to save the transcript.
+type export struct {
+	active bool // Prompt open
+	input  textinput.Model
+	ansi   bool // Keep colors and styles
+}
+
+// newExportInput creates the prompt for the file to save the transcript to.
+func newExportInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "file"
+	ti.CharLimit = 256
+	ti.SetWidth(40)
+	return ti
+}
+
+// Exporting reports whether the prompt for saving the transcript is open.
+func (m *Model) Exporting() bool {
+	return m.export.active
+}
+
+// startExport opens the prompt for saving the transcript, suggesting a file
+// named after the current time.
+func (m *Model) startExport() tea.Cmd {
+	m.export.active = true
+	m.export.input.SetValue("transcript-" + time.Now().Format("20060102-150405") + ".txt")
+	m.export.input.CursorEnd()
+	m.export.input.Focus()
+	return textinput.Blink
+}
+
+// updateExport handles keys while the export prompt is open: Enter saves to
+// the file, Tab switches between plain text and ANSI, Ctrl+O opens the plain
+// transcript in the viewer instead, and Esc cancels.
+func (m *Model) updateExport(msg tea.KeyPressMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		m.export.active = false
+		m.export.input.Blur()
+		return nil
+	case "tab":
+		m.export.ansi = !m.export.ansi
+		return nil
+	case "enter":
+		path := strings.TrimSpace(m.export.input.Value())
+		if path == "" {
+			return nil
+		}
+		m.export.active = false
+		m.export.input.Blur()
+		return saveTranscript(path, m.transcriptText(m.export.ansi))
+	case "ctrl+o":
+		m.export.active = false
+		m.export.input.Blur()
+		return viewTranscript(m.transcriptText(false))
+	}
+
+	var cmd tea.Cmd
+	m.export.input, cmd = m.export.input.Update(msg)
+	return cmd
+}
+
+// Transcript returns every line kept: the scrollback followed by the screen,
+// without trailing blank lines. Lines are plain text unless styled is set, in
+// which case they keep their ANSI colors and styles.
+func (m *Model) Transcript(styled bool) []string {
+	if m.vt == nil {
+		return nil
+	}
+	n := m.vt.ScrollbackLen() + m.rows()
+	lines := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if styled {
+			lines = append(lines, m.vt.Styled(i))
+		} else {
+			lines = append(lines, m.vt.Text(i))
+		}
+	}
+
+	for len(lines) > 0 && strings.TrimSpace(ansi.Strip(lines[len(lines)-1])) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// transcriptText returns the transcript as the contents of a file.
+func (m *Model) transcriptText(styled bool) string {
+	lines := m.Transcript(styled)
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// saveTranscript writes the transcript to path.
+func saveTranscript(path, text string) tea.Cmd {
+	return func() tea.Msg {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return ExportedMsg{Path: path, Err: err}
+		}
+		return ExportedMsg{Path: abs, Err: os.WriteFile(abs, []byte(text), 0o644)}
+	}
+}
+
+// viewTranscript writes the transcript to a temporary file for the viewer,
+// which the app removes on quit.
+func viewTranscript(text string) tea.Cmd {
+	return func() tea.Msg {
+		f, err := os.CreateTemp("", "transcript-*.txt")
+		if err != nil {
+			return ExportedMsg{View: true, Err: err}
+		}
+		_, err = f.WriteString(text)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(f.Name())
+		}
+		return ExportedMsg{Path: f.Name(), View: true, Err: err}
+	}
+}
+
+// renderExportBar renders the prompt for saving the transcript.
+func (m *Model) renderExportBar(width int) string {
+	prefix := lipgloss.NewStyle().
+		Foreground(theme.CyberCyan).
+		Bold(true).
+		Render("Save transcript: ")
+
+	format := "plain"
+	if m.export.ansi {
+		format = "ANSI"
+	}
+	hint := lipgloss.NewStyle().
+		Foreground(theme.MutedLavender).
+		Render(" " + format + " · tab format · ctrl+o view")
+
+	return lipgloss.NewStyle().
+		Background(lipgloss.Color("236")).
+		Width(width).
+		Render(prefix + m.export.input.View() + hint)
+}
@@ -0,0 +1,55 @@
+package terminal
+
+import (
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/avitaltamir/vibecommander/internal/links"
+	"github.com/avitaltamir/vibecommander/internal/theme"
+)
+
+// linkAt returns the link at screen coordinates, which include the border.
+func (m *Model) linkAt(x, y int) (links.Link, bool) {
+	return m.links.At(links.Visible(m.vt, m.scrollOffset), y-1, x-1)
+}
+
+// hoverLink underlines the link at screen coordinates, reporting whether
+// that changed what's underlined.
+func (m *Model) hoverLink(x, y int) bool {
+	return m.links.Hover(links.Visible(m.vt, m.scrollOffset), y-1, x-1)
+}
+
+// startHints labels the links shown, so one can be opened from the keyboard.
+func (m *Model) startHints() {
+	m.links.ClearHover()
+	if m.links.StartHints(links.Visible(m.vt, m.scrollOffset)) {
+		m.cachedView = m.renderVT()
+	}
+}
+
+// updateHints opens the link whose label was typed. Any other key just
+// drops the labels.
+func (m *Model) updateHints(msg tea.KeyPressMsg) tea.Cmd {
+	link, ok := m.links.HintKey(msg.String())
+	m.cachedView = m.renderVT()
+	if !ok {
+		return nil
+	}
+	return link.Open()
+}
+
+// renderHintBar renders the prompt shown while links are labelled.
+func (m *Model) renderHintBar(width int) string {
+	prefix := lipgloss.NewStyle().
+		Foreground(theme.CyberCyan).
+		Bold(true).
+		Render("Open link: ")
+	hint := lipgloss.NewStyle().
+		Foreground(theme.MutedLavender).
+		Render("type its label · esc cancel")
+
+	return lipgloss.NewStyle().
+		Background(lipgloss.Color("236")).
+		Width(width).
+		Render(prefix + hint)
+}
@@ -0,0 +1,47 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/avitaltamir/vibecommander/internal/links"
+	"github.com/avitaltamir/vibecommander/internal/vt"
+)
+
+func TestLinks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o644))
+
+	m := New().SetSize(120, 6)
+	m.vt = vt.New(120, 5)
+	m, _ = m.Focus()
+	m, _ = m.Update(OutputMsg{Data: []byte("build failed\r\n" + path + ":12:3: undefined: x\r\n")})
+
+	// Rows and columns are offset by the border
+	m, _ = m.Update(tea.MouseMotionMsg{X: 3, Y: 2})
+	assert.True(t, m.links.Active(), "underlined on hover")
+	assert.Contains(t, ansi.Strip(m.View()), path+":12:3")
+	m, _ = m.Update(tea.MouseMotionMsg{X: 3, Y: 1})
+	assert.False(t, m.links.Active())
+
+	// Clicking opens it, without starting a selection
+	m, cmd := m.Update(tea.MouseClickMsg{X: 3, Y: 2, Button: tea.MouseLeft})
+	require.NotNil(t, cmd)
+	assert.Equal(t, links.OpenMsg{Path: path, Line: 11}, cmd())
+	assert.False(t, m.selection.Selection.Active)
+
+	// Alt+K labels it, and typing the label opens it
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'k', Mod: tea.ModAlt})
+	require.True(t, m.links.Hinting())
+	assert.Contains(t, ansi.Strip(m.View()), "Open link")
+	m, cmd = m.Update(tea.KeyPressMsg{Code: 'a', Text: "a"})
+	require.NotNil(t, cmd)
+	assert.Equal(t, links.OpenMsg{Path: path, Line: 11}, cmd())
+	assert.False(t, m.links.Hinting())
+}
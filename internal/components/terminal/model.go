@@ -12,11 +12,13 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/atotto/clipboard"
+	"github.com/avitaltamir/vibecommander/internal/asciicast"
 	"github.com/avitaltamir/vibecommander/internal/components"
+	"github.com/avitaltamir/vibecommander/internal/links"
 	"github.com/avitaltamir/vibecommander/internal/selection"
 	"github.com/avitaltamir/vibecommander/internal/theme"
+	"github.com/avitaltamir/vibecommander/internal/vt"
 	"github.com/creack/pty"
-	"github.com/hinshun/vt10x"
 )
 
 // Render throttling interval - render at most once per this duration
@@ -40,8 +42,9 @@ type (
 
 	// StartMsg requests starting a command.
 	StartMsg struct {
-		Cmd  string
-		Args []string
+		Cmd    string
+		Args   []string
+		Record string // asciicast file to record the output to, if any
 	}
 )
 
@@ -49,22 +52,31 @@ type (
 type Model struct {
 	components.Base
 
-	vt      vt10x.Terminal
+	vt      *vt.Terminal
 	cmd     *exec.Cmd
 	pty     *os.File
 	mu      sync.Mutex
 	running bool
 	exitErr error
 
-	// Scrollback buffer
-	scrollback    []string // Lines that scrolled off the top
-	scrollOffset  int      // 0 = live view, >0 = scrolled up N lines
-	maxScrollback int      // Max lines to keep
-	scrollLocked  bool     // True when user has scrolled into history (prevents auto-scroll)
+	// Recording of the output, if any
+	recorder  *asciicast.Writer
+	recording string
+
+	// Scrollback, kept by the virtual terminal
+	scrollOffset int  // 0 = live view, >0 = scrolled up N lines
+	scrollLocked bool // True when user has scrolled into history (prevents auto-scroll)
+
+	// Scrollback search and transcript export
+	search search
+	export export
 
 	// Text selection
 	selection selection.Model
 
+	// File references and hyperlinks in the output
+	links links.Model
+
 	// Render throttling
 	cachedView      string    // Cached rendered view
 	lastRender      time.Time // Last time we rendered
@@ -78,27 +90,14 @@ type Model struct {
 // New creates a new terminal model.
 func New() Model {
 	return Model{
-		theme:         theme.DefaultTheme(),
-		maxScrollback: 10000,
-		selection:     selection.New(),
+		theme:     theme.DefaultTheme(),
+		selection: selection.New(),
+		links:     links.New(),
+		search:    search{input: newSearchInput(), current: -1},
+		export:    export{input: newExportInput()},
 	}
 }
 
-// addToScrollback adds a line to scrollback with deduplication
-func (m *Model) addToScrollback(line string) {
-	// Check against recent entries to avoid duplicates
-	checkCount := 20 // Check last 20 entries
-	if checkCount > len(m.scrollback) {
-		checkCount = len(m.scrollback)
-	}
-	for i := len(m.scrollback) - checkCount; i < len(m.scrollback); i++ {
-		if m.scrollback[i] == line {
-			return // Already exists, skip
-		}
-	}
-	m.scrollback = append(m.scrollback, line)
-}
-
 // Init initializes the terminal.
 func (m Model) Init() tea.Cmd {
 	return nil
@@ -156,72 +155,27 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		if m.running {
 			return m, nil
 		}
-		return m.startProcess(msg.Cmd, msg.Args)
+		return m.startProcess(msg.Cmd, msg.Args, msg.Record)
 
 	case OutputMsg:
 		m.mu.Lock()
 
 		if m.vt != nil {
-			cols, rows := m.vt.Size()
-			prevScrollbackLen := len(m.scrollback)
-
-			// Capture all screen lines before write (for scrollback detection)
-			oldPlainLines := make([]string, rows)
-			oldRenderedLines := make([]string, rows)
-			for row := 0; row < rows; row++ {
-				oldPlainLines[row] = m.getScreenLinePlain(cols, row)
-				oldRenderedLines[row] = m.renderScreenLine(cols, row)
-			}
-
-			// Write data to virtual terminal
+			seen := m.vt.Dropped() + m.vt.ScrollbackLen()
 			m.vt.Write(msg.Data)
 
-			// Find where the new top line was in the old screen to calculate scroll amount
-			newTopLine := m.getScreenLinePlain(cols, 0)
-			scrollAmount := 0
-
-			if len(strings.TrimSpace(newTopLine)) > 0 {
-				for i := 1; i < rows; i++ {
-					if len(strings.TrimSpace(oldPlainLines[i])) > 0 && oldPlainLines[i] == newTopLine {
-						scrollAmount = i
-						break
-					}
-				}
-			}
-
-			// Add scrolled lines to scrollback
-			if scrollAmount > 0 {
-				// Normal scroll - add lines that scrolled off
-				for i := 0; i < scrollAmount; i++ {
-					if len(strings.TrimSpace(oldPlainLines[i])) > 0 {
-						m.addToScrollback(oldRenderedLines[i])
-					}
-				}
-			} else if oldPlainLines[0] != newTopLine && len(strings.TrimSpace(oldPlainLines[0])) > 0 {
-				// Screen changed but couldn't detect scroll amount
-				// This happens with large data chunks - save all non-empty old lines
-				for i := 0; i < rows; i++ {
-					if len(strings.TrimSpace(oldPlainLines[i])) > 0 {
-						m.addToScrollback(oldRenderedLines[i])
-					}
-				}
+			// Answer the program's queries, and copy what it asked to
+			if replies := m.vt.TakeReplies(); len(replies) > 0 && m.pty != nil {
+				m.pty.Write(replies)
 			}
-
-			// Trim scrollback if too large
-			if len(m.scrollback) > m.maxScrollback {
-				m.scrollback = m.scrollback[len(m.scrollback)-m.maxScrollback:]
+			for _, text := range m.vt.TakeClipboard() {
+				_ = clipboard.WriteAll(text)
 			}
 
 			// If scroll-locked, adjust scroll offset to maintain position as new lines arrive
 			if m.scrollLocked && m.scrollOffset > 0 {
-				linesAdded := len(m.scrollback) - prevScrollbackLen
-				if linesAdded > 0 {
-					m.scrollOffset += linesAdded
-					// Cap at max scrollback
-					if m.scrollOffset > len(m.scrollback) {
-						m.scrollOffset = len(m.scrollback)
-					}
-				}
+				linesAdded := m.vt.Dropped() + m.vt.ScrollbackLen() - seen
+				m.scrollOffset = max(0, min(m.scrollOffset+linesAdded, m.vt.ScrollbackLen()))
 			}
 		}
 
@@ -246,11 +200,20 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		}
 		m.cmd = nil
 		m.mu.Unlock()
+		m.StopRecording()
 		return m, nil
 
 	case tea.MouseClickMsg:
-		// Handle text selection start - MouseClickMsg is only for left button
+		// Programs that asked for the mouse get it. Otherwise clicking a link
+		// opens it, anywhere else starts a text selection - MouseClickMsg is
+		// only for left button
+		if m.passMouse(msg) {
+			return m, nil
+		}
 		mouse := msg.Mouse()
+		if link, ok := m.linkAt(mouse.X, mouse.Y); ok {
+			return m, link.Open()
+		}
 		line, col := m.screenToTextPosition(mouse.X, mouse.Y)
 		m.selection.StartSelection(line, col)
 		m.updateSelectionContent()
@@ -266,6 +229,14 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			m.cachedView = m.renderVT() // Force re-render with selection
 			return m, nil
 		}
+		if m.passMouse(msg) {
+			return m, nil
+		}
+		// Underline the link under the mouse
+		if m.hoverLink(mouse.X, mouse.Y) {
+			m.cachedView = m.renderVT()
+		}
+		return m, nil
 
 	case tea.MouseReleaseMsg:
 		// End selection
@@ -277,14 +248,31 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			m.cachedView = m.renderVT() // Force re-render with selection
 			return m, nil
 		}
+		m.passMouse(msg)
 
 	case tea.MouseWheelMsg:
-		// Handle mouse scroll for scrollback buffer
+		if m.passMouse(msg) {
+			return m, nil
+		}
+		// Full-screen programs that don't take the mouse get arrow keys, as
+		// there's no scrollback on their screen
 		mouse := msg.Mouse()
+		if m.running && m.pty != nil && m.vt.AltScreen() && m.scrollOffset == 0 {
+			key := tea.Key{Code: tea.KeyUp}
+			if mouse.Button == tea.MouseWheelDown {
+				key.Code = tea.KeyDown
+			}
+			for range 3 {
+				m.pty.Write(m.vt.KeyInput(key))
+			}
+			return m, nil
+		}
+
+		// Handle mouse scroll for scrollback buffer
 		switch mouse.Button {
 		case tea.MouseWheelUp:
 			// Scroll up (into history)
-			maxScroll := len(m.scrollback)
+			maxScroll := m.scrollbackLen()
 			m.scrollOffset += 3
 			if m.scrollOffset > maxScroll {
 				m.scrollOffset = maxScroll
@@ -320,8 +308,7 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			return m, nil
 		}
 		if m.running && m.pty != nil && msg.Content != "" {
-			// Write pasted text directly to PTY
-			m.pty.Write([]byte(msg.Content))
+			m.pty.Write(m.vt.PasteInput(msg.Content))
 		}
 		return m, nil
 
@@ -332,6 +319,31 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 
 		key := msg.Key()
 
+		if m.search.active {
+			cmd := m.updateSearch(msg)
+			return m, cmd
+		}
+		if m.export.active {
+			cmd := m.updateExport(msg)
+			return m, cmd
+		}
+		if m.links.Hinting() {
+			cmd := m.updateHints(msg)
+			return m, cmd
+		}
+
+		// Alt+/ searches the scrollback, Alt+X saves the transcript, Alt+K
+		// labels the links to open one
+		switch msg.String() {
+		case "alt+/", "÷": // ÷ = Option+/ on Mac
+			return m, m.startSearch()
+		case "alt+x", "≈": // ≈ = Option+x on Mac
+			return m, m.startExport()
+		case "alt+k", "˚": // ˚ = Option+k on Mac
+			m.startHints()
+			return m, nil
+		}
+
 		// Handle copy (Ctrl+C) when text is selected - copy instead of SIGINT
 		if selection.IsCopyKey(msg.String()) && m.selection.HasSelection() {
 			_ = m.selection.CopyToClipboard()
@@ -357,8 +369,8 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		}
 
 		// Handle Home key to jump to top of scrollback
-		if key.Code == tea.KeyHome && len(m.scrollback) > 0 {
-			m.scrollOffset = len(m.scrollback)
+		if key.Code == tea.KeyHome && m.scrollbackLen() > 0 {
+			m.scrollOffset = m.scrollbackLen()
 			m.scrollLocked = true // Enable scroll lock
 			m.dirty = true
 			m.cachedView = ""
@@ -373,7 +385,7 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				pageSize = 10
 			}
 			if key.Code == tea.KeyPgUp {
-				maxScroll := len(m.scrollback)
+				maxScroll := m.scrollbackLen()
 				m.scrollOffset += pageSize
 				if m.scrollOffset > maxScroll {
 					m.scrollOffset = maxScroll
@@ -403,85 +415,14 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 
 		// Send input to PTY if running
 		if m.running && m.pty != nil {
-			var input []byte
-			hasAlt := key.Mod&tea.ModAlt != 0
-			hasCtrl := key.Mod&tea.ModCtrl != 0
-			hasShift := key.Mod&tea.ModShift != 0
-
-			// Handle Ctrl+V for paste (before generic Ctrl handling)
-			if hasCtrl && key.Code == 'v' {
+			// Handle Ctrl+V for paste
+			if key.Mod&tea.ModCtrl != 0 && key.Code == 'v' {
 				if text, err := clipboard.ReadAll(); err == nil && text != "" {
-					m.pty.Write([]byte(text))
+					m.pty.Write(m.vt.PasteInput(text))
 				}
 				return m, nil
 			}
-
-			// Handle Ctrl key combinations
-			if hasCtrl && key.Code >= 'a' && key.Code <= 'z' {
-				// Ctrl+A=1 through Ctrl+Z=26
-				input = []byte{byte(key.Code - 'a' + 1)}
-			} else if hasShift && key.Code == tea.KeyTab {
-				// Shift+Tab sends ESC [ Z (reverse tab / backtab)
-				input = []byte("\x1b[Z")
-			} else if hasAlt && key.Code >= 'a' && key.Code <= 'z' {
-				// Alt+letter sends ESC + letter (e.g., Alt+B for backward-word)
-				input = []byte{27, byte(key.Code)}
-			} else {
-				// Handle special keys and regular input
-				switch key.Code {
-				case tea.KeyEnter:
-					input = []byte("\r")
-				case tea.KeyBackspace:
-					if hasAlt {
-						input = []byte{27, 127} // ESC + DEL for Alt+Backspace (delete word)
-					} else {
-						input = []byte{127}
-					}
-				case tea.KeyTab:
-					input = []byte("\t")
-				case tea.KeySpace:
-					input = []byte(" ")
-				case tea.KeyUp:
-					input = []byte("\x1b[A")
-				case tea.KeyDown:
-					input = []byte("\x1b[B")
-				case tea.KeyRight:
-					input = []byte("\x1b[C")
-				case tea.KeyLeft:
-					input = []byte("\x1b[D")
-				case tea.KeyEscape:
-					input = []byte{27}
-				case tea.KeyHome:
-					input = []byte("\x1b[H")
-				case tea.KeyEnd:
-					input = []byte("\x1b[F")
-				case tea.KeyPgUp:
-					input = []byte("\x1b[5~")
-				case tea.KeyPgDown:
-					input = []byte("\x1b[6~")
-				case tea.KeyDelete:
-					input = []byte("\x1b[3~")
-				default:
-					// Regular character input
-					if key.Text != "" {
-						// Filter out mouse/escape sequence fragments
-						if looksLikeMouseSequence(key.Text) || looksLikeEscapeFragment(key.Text) {
-							return m, nil
-						}
-						// Check for Alt modifier (sends ESC + char)
-						if hasAlt {
-							for _, r := range key.Text {
-								input = append(input, 27) // ESC
-								input = append(input, byte(r))
-							}
-						} else {
-							input = []byte(key.Text)
-						}
-					}
-				}
-			}
-
-			if len(input) > 0 {
+			if input := m.vt.KeyInput(key); len(input) > 0 {
 				m.pty.Write(input)
 			}
 			return m, nil
@@ -491,7 +432,7 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
-func (m Model) startProcess(cmd string, args []string) (Model, tea.Cmd) {
+func (m Model) startProcess(cmd string, args []string, record string) (Model, tea.Cmd) {
 	w, h := m.Size()
 	if w <= 0 {
 		w = 80
@@ -501,7 +442,7 @@ func (m Model) startProcess(cmd string, args []string) (Model, tea.Cmd) {
 	}
 
 	// Create virtual terminal with current size
-	m.vt = vt10x.New(vt10x.WithSize(w, h-1)) // -1 for status line
+	m.vt = vt.New(w, h-1) // -1 for status line
 
 	m.cmd = exec.Command(cmd, args...)
 	m.cmd.Env = append(os.Environ(), "TERM=xterm-256color")
@@ -523,6 +464,10 @@ func (m Model) startProcess(cmd string, args []string) (Model, tea.Cmd) {
 		Cols: uint16(w),
 	})
 
+	if record != "" {
+		m.startRecording(record, cmd, w, h-1)
+	}
+
 	// Start reading output
 	return m, m.readOutput()
 }
@@ -536,6 +481,9 @@ func (m Model) readOutput() tea.Cmd {
 		// Large buffer to reduce number of redraws and flickering
 		buf := make([]byte, 65536)
 		n, err := m.pty.Read(buf)
+		if n > 0 && m.recorder != nil {
+			m.recorder.Output(buf[:n])
+		}
 		if err != nil {
 			if err == io.EOF {
 				// Wait for process to exit
@@ -586,6 +534,15 @@ func (m Model) View() string {
 				content = strings.Join(lines, "\n")
 			}
 		}
+
+		// The prompts go on the status line below the screen
+		if m.search.active {
+			content += "\n" + m.renderSearchBar(w)
+		} else if m.export.active {
+			content += "\n" + m.renderExportBar(w)
+		} else if m.links.Hinting() {
+			content += "\n" + m.renderHintBar(w)
+		}
 		return content
 	}
 
@@ -595,293 +552,50 @@ func (m Model) View() string {
 		Render("Terminal ready...")
 }
 
-// renderVT renders the virtual terminal screen buffer with colors
-// Optimized to use raw ANSI codes and batch consecutive characters with same style
-func (m Model) renderVT() string {
+// renderVT renders the lines shown, from the scrollback or the screen, with
+// their colors, search matches and links
+func (m *Model) renderVT() string {
 	if m.vt == nil {
 		return ""
 	}
 
-	m.vt.Lock()
-	defer m.vt.Unlock()
-
-	cols, rows := m.vt.Size()
-	if cols <= 0 || rows <= 0 {
-		return ""
+	opts := vt.RenderOptions{Cursor: m.scrollOffset == 0 && m.Focused()}
+	if m.selection.Selection.Active || m.selection.Selection.Complete {
+		opts.Highlight = m.selection.IsSelected
 	}
-
-	// If scrolled up, show scrollback + partial screen
-	if m.scrollOffset > 0 && len(m.scrollback) > 0 {
-		return m.renderWithScrollback(cols, rows)
-	}
-
-	// Live view - render current vt screen
-	return m.renderLiveScreen(cols, rows)
-}
-
-// renderLiveScreen renders the current vt10x screen (no scrollback)
-func (m Model) renderLiveScreen(cols, rows int) string {
-	cursor := m.vt.Cursor()
-	cursorVisible := m.vt.CursorVisible() && m.Focused()
-	hasSelection := m.selection.Selection.Active || m.selection.Selection.Complete
-
-	var result strings.Builder
-	result.Grow(rows * cols * 2)
-
-	for row := 0; row < rows; row++ {
-		if row > 0 {
-			result.WriteByte('\n')
-		}
-
-		var currentFG, currentBG vt10x.Color
-		var currentMode int16
-		var currentIsCursor bool
-		var currentIsSelected bool
-		var batch strings.Builder
-		firstCell := true
-
-		flushBatch := func() {
-			if batch.Len() == 0 {
-				return
-			}
-			result.WriteString(buildANSI(currentFG, currentBG, currentMode, currentIsCursor, currentIsSelected))
-			result.WriteString(batch.String())
-			result.WriteString("\x1b[0m")
-			batch.Reset()
-		}
-
-		for col := 0; col < cols; col++ {
-			glyph := m.vt.Cell(col, row)
-			ch := glyph.Char
-			if ch == 0 {
-				ch = ' '
-			}
-
-			isCursor := cursorVisible && col == cursor.X && row == cursor.Y
-			isSelected := hasSelection && m.selection.IsSelected(row, col)
-
-			if !firstCell && (glyph.FG != currentFG || glyph.BG != currentBG || glyph.Mode != currentMode || isCursor != currentIsCursor || isSelected != currentIsSelected) {
-				flushBatch()
-			}
-
-			currentFG = glyph.FG
-			currentBG = glyph.BG
-			currentMode = glyph.Mode
-			currentIsCursor = isCursor
-			currentIsSelected = isSelected
-			firstCell = false
-
-			batch.WriteRune(ch)
-		}
-		flushBatch()
+	if m.search.re != nil || m.links.Active() {
+		opts.Decorate = m.decorate
 	}
-
-	return result.String()
+	return m.vt.View(m.scrollOffset, opts)
 }
 
-// renderWithScrollback renders view including scrollback history
-func (m Model) renderWithScrollback(cols, rows int) string {
-	var lines []string
-
-	// Calculate which lines to show
-	scrollbackLen := len(m.scrollback)
-	startIdx := scrollbackLen - m.scrollOffset
-	if startIdx < 0 {
-		startIdx = 0
-	}
-
-	// Add scrollback lines
-	for i := startIdx; i < scrollbackLen && len(lines) < rows; i++ {
-		lines = append(lines, m.scrollback[i])
-	}
-
-	// Add current screen lines if we have room
-	if len(lines) < rows {
-		screenRows := rows - len(lines)
-		for row := 0; row < screenRows; row++ {
-			lines = append(lines, m.renderScreenLine(cols, row))
-		}
-	}
-
-	// Pad with empty lines if needed
-	for len(lines) < rows {
-		lines = append(lines, strings.Repeat(" ", cols))
-	}
-
-	return strings.Join(lines, "\n")
+// decorate draws the search matches and the links state over the rendered
+// lines, the first of which is transcript line top.
+func (m *Model) decorate(lines []string, top int) {
+	m.highlightMatches(lines, top)
+	m.links.Decorate(lines)
 }
 
-// renderScreenLine renders a single line from the vt screen
-func (m Model) renderScreenLine(cols, row int) string {
-	var result strings.Builder
-	var currentFG, currentBG vt10x.Color
-	var currentMode int16
-	var batch strings.Builder
-	firstCell := true
-
-	flushBatch := func() {
-		if batch.Len() == 0 {
-			return
-		}
-		result.WriteString(buildANSI(currentFG, currentBG, currentMode, false, false))
-		result.WriteString(batch.String())
-		result.WriteString("\x1b[0m")
-		batch.Reset()
-	}
-
-	for col := 0; col < cols; col++ {
-		glyph := m.vt.Cell(col, row)
-		ch := glyph.Char
-		if ch == 0 {
-			ch = ' '
-		}
-
-		if !firstCell && (glyph.FG != currentFG || glyph.BG != currentBG || glyph.Mode != currentMode) {
-			flushBatch()
-		}
-
-		currentFG = glyph.FG
-		currentBG = glyph.BG
-		currentMode = glyph.Mode
-		firstCell = false
-
-		batch.WriteRune(ch)
-	}
-	flushBatch()
-
-	return result.String()
-}
-
-// getScreenLinePlain returns a screen line as plain text (no ANSI codes) for comparison
-func (m Model) getScreenLinePlain(cols, row int) string {
-	var result strings.Builder
-	for col := 0; col < cols; col++ {
-		ch := m.vt.Cell(col, row).Char
-		if ch == 0 {
-			ch = ' '
-		}
-		result.WriteRune(ch)
-	}
-	return strings.TrimRight(result.String(), " ")
-}
-
-// getTopLine returns the current top line of the terminal as plain text (no ANSI codes)
-func (m Model) getTopLine() string {
-	if m.vt == nil {
-		return ""
-	}
-	cols, _ := m.vt.Size()
-	if cols <= 0 {
-		return ""
-	}
-	return m.getScreenLinePlain(cols, 0)
-}
-
-// looksLikeEscapeFragment returns true if the string looks like a fragment of an escape sequence
-func looksLikeEscapeFragment(s string) bool {
-	// Single [ or < often comes from split escape sequences
-	if s == "[" || s == "<" || s == "[<" {
-		return true
-	}
-	// Sequences starting with [ followed by numbers/semicolons (partial CSI)
-	if len(s) > 0 && s[0] == '[' {
-		for i := 1; i < len(s); i++ {
-			c := s[i]
-			if c != ';' && c != '<' && (c < '0' || c > '9') {
-				return false
-			}
-		}
-		return len(s) > 1
-	}
-	return false
-}
-
-// looksLikeMouseSequence returns true if the string looks like a partial SGR mouse sequence
-// These look like "65;83;57M" or "0;45;12m" - numbers, semicolons, ending with M or m
-func looksLikeMouseSequence(s string) bool {
-	if len(s) < 3 {
+// passMouse sends a mouse event to the program, if it asked for that kind of
+// event and the live screen is shown. Holding Shift keeps the mouse for
+// selecting text and opening links.
+func (m *Model) passMouse(msg tea.MouseMsg) bool {
+	if !m.running || m.pty == nil || m.scrollOffset > 0 {
 		return false
 	}
-	// Check if it ends with M or m (SGR mouse)
-	last := s[len(s)-1]
-	if last != 'M' && last != 'm' {
+	mouse := msg.Mouse()
+	col, row := mouse.X-1, mouse.Y-1 // Inside the border
+	if mouse.Mod&tea.ModShift != 0 || col < 0 || row < 0 || row >= m.rows() {
 		return false
 	}
-	// Check if the rest is numbers and semicolons
-	for i := 0; i < len(s)-1; i++ {
-		c := s[i]
-		if c != ';' && (c < '0' || c > '9') && c != '<' {
-			return false
-		}
+	input := m.vt.MouseInput(msg, col, row)
+	if input == nil {
+		return false
 	}
+	m.pty.Write(input)
 	return true
 }
 
-// buildANSI builds ANSI escape sequence for the given style
-func buildANSI(fg, bg vt10x.Color, mode int16, isCursor, isSelected bool) string {
-	var codes []string
-
-	if isCursor {
-		codes = append(codes, "7") // Reverse video
-	} else if isSelected {
-		// Selection styling - reverse video (swap fg/bg)
-		codes = append(codes, "7")
-	} else {
-		// Mode attributes
-		if mode&0x01 != 0 { // Reverse
-			codes = append(codes, "7")
-		}
-		if mode&0x02 != 0 { // Underline
-			codes = append(codes, "4")
-		}
-		if mode&0x04 != 0 { // Bold
-			codes = append(codes, "1")
-		}
-		if mode&0x10 != 0 { // Italic
-			codes = append(codes, "3")
-		}
-
-		// Foreground color
-		if fgCode := colorToANSI(fg, true); fgCode != "" {
-			codes = append(codes, fgCode)
-		}
-
-		// Background color
-		if bgCode := colorToANSI(bg, false); bgCode != "" {
-			codes = append(codes, bgCode)
-		}
-	}
-
-	if len(codes) == 0 {
-		return ""
-	}
-	return "\x1b[" + strings.Join(codes, ";") + "m"
-}
-
-// colorToANSI converts a vt10x color to ANSI escape code
-func colorToANSI(c vt10x.Color, isFG bool) string {
-	// Default color
-	if c >= 0x01000000 {
-		return ""
-	}
-
-	base := 38 // Foreground
-	if !isFG {
-		base = 48 // Background
-	}
-
-	// ANSI 256-color palette
-	if c < 256 {
-		return fmt.Sprintf("%d;5;%d", base, c)
-	}
-
-	// True color RGB
-	r := (c >> 16) & 0xFF
-	g := (c >> 8) & 0xFF
-	b := c & 0xFF
-	return fmt.Sprintf("%d;2;%d;%d;%d", base, r, g, b)
-}
-
 // Focus gives focus to this component.
 func (m Model) Focus() (Model, tea.Cmd) {
 	m.Base.Focus()
@@ -925,6 +639,9 @@ func (m Model) SetSize(width, height int) Model {
 
 	// Resize virtual terminal if it exists
 	if m.vt != nil && width > 0 && termHeight > 0 {
+		if cols, rows := m.vt.Size(); m.recorder != nil && (cols != width || rows != termHeight) {
+			m.recorder.Resize(width, termHeight)
+		}
 		m.vt.Resize(width, termHeight)
 	}
 
@@ -954,6 +671,7 @@ func (m *Model) Stop() {
 		m.pty = nil
 	}
 	m.running = false
+	m.StopRecording()
 }
 
 // ContinueReading returns a command to continue reading output.
@@ -964,102 +682,29 @@ func (m Model) ContinueReading() tea.Cmd {
 	return m.readOutput()
 }
 
-// screenToTextPosition converts screen coordinates to text line and column.
-// For terminal, coordinates map directly to the visible buffer position.
+// screenToTextPosition converts screen coordinates to a line and column of
+// what's shown.
 func (m Model) screenToTextPosition(x, y int) (line, col int) {
-	// Y coordinate: subtract 1 for top border, then adjust for scroll offset
-	adjustedY := y - 1
-	if adjustedY < 0 {
-		adjustedY = 0
-	}
-
-	line = adjustedY
-	if m.scrollOffset > 0 {
-		// When scrolled up, the visible lines are from scrollback
-		scrollbackLen := len(m.scrollback)
-		startIdx := scrollbackLen - m.scrollOffset
-		if startIdx < 0 {
-			startIdx = 0
-		}
-		line = startIdx + adjustedY
-	}
-
-	// X coordinate: subtract 1 for left border
-	col = x - 1
-	if col < 0 {
-		col = 0
-	}
-
-	return line, col
+	// Subtract 1 for the top and left border
+	return max(y-1, 0), max(x-1, 0)
 }
 
 // updateSelectionContent updates the selection model with all visible text content.
 func (m *Model) updateSelectionContent() {
-	if m.vt == nil {
-		m.selection.SetContent(nil)
-		return
-	}
-
-	m.vt.Lock()
-	defer m.vt.Unlock()
-
-	cols, rows := m.vt.Size()
-	if cols <= 0 || rows <= 0 {
-		m.selection.SetContent(nil)
-		return
-	}
-
-	var lines []string
-
-	// If scrolled up, include scrollback
-	if m.scrollOffset > 0 && len(m.scrollback) > 0 {
-		scrollbackLen := len(m.scrollback)
-		startIdx := scrollbackLen - m.scrollOffset
-		if startIdx < 0 {
-			startIdx = 0
-		}
-		// Add scrollback lines (these contain ANSI codes, so extract plain text)
-		for i := startIdx; i < scrollbackLen && len(lines) < rows; i++ {
-			lines = append(lines, stripANSI(m.scrollback[i]))
-		}
-		// Add current screen lines if we have room
-		if len(lines) < rows {
-			screenRows := rows - len(lines)
-			for row := 0; row < screenRows; row++ {
-				lines = append(lines, m.getScreenLinePlain(cols, row))
-			}
-		}
-	} else {
-		// Live view - get current screen content
-		for row := 0; row < rows; row++ {
-			lines = append(lines, m.getScreenLinePlain(cols, row))
-		}
-	}
-
-	m.selection.SetContent(lines)
+	m.selection.SetContent(m.visibleLines())
 }
 
-// stripANSI removes ANSI escape sequences from a string.
-func stripANSI(s string) string {
-	// Simple ANSI stripper - removes escape sequences
-	var result strings.Builder
-	i := 0
-	for i < len(s) {
-		if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '[' {
-			// Skip until we find a letter (end of sequence)
-			i += 2
-			for i < len(s) && !((s[i] >= 'A' && s[i] <= 'Z') || (s[i] >= 'a' && s[i] <= 'z')) {
-				i++
-			}
-			if i < len(s) {
-				i++ // Skip the final letter
-			}
-		} else {
-			result.WriteByte(s[i])
-			i++
-		}
+// visibleLines returns the lines shown as plain text.
+func (m *Model) visibleLines() []string {
+	if m.vt == nil {
+		return nil
+	}
+	top := m.vt.Top(m.scrollOffset)
+	lines := make([]string, m.rows())
+	for i := range lines {
+		lines[i] = m.vt.Text(top + i)
 	}
-	return result.String()
+	return lines
 }
 
 // HasSelection returns true if there is an active text selection.
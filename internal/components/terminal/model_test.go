@@ -0,0 +1,69 @@
+package terminal
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// attachPipe stands in for the PTY of a running program, returning what's
+// written to it.
+func attachPipe(t *testing.T, m Model) (Model, func() string) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	t.Cleanup(func() { r.Close() })
+	m.pty, m.running = w, true
+
+	return m, func() string {
+		w.Close()
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		return string(data)
+	}
+}
+
+func TestRepeatedLinesKept(t *testing.T) {
+	m := newTestTerminal(t, 0)
+	for range 8 {
+		m, _ = m.Update(OutputMsg{Data: []byte("PASS\r\n")})
+	}
+	assert.Equal(t, []string{"PASS", "PASS", "PASS", "PASS", "PASS", "PASS", "PASS", "PASS"}, m.Transcript(false))
+}
+
+func TestProgramInput(t *testing.T) {
+	m := newTestTerminal(t, 0)
+	m, written := attachPipe(t, m)
+
+	// Queries are answered, and the mouse goes to programs that ask for it
+	m, _ = m.Update(OutputMsg{Data: []byte("\x1b[?1000h\x1b[?1006h\x1b[?2004h\x1b[6n")})
+	m, _ = m.Update(tea.MouseClickMsg{X: 3, Y: 2, Button: tea.MouseLeft})
+	m, _ = m.Update(tea.MouseReleaseMsg{X: 3, Y: 2, Button: tea.MouseLeft})
+	assert.False(t, m.selection.Selection.Active)
+
+	// Except with Shift held, which selects instead
+	m, _ = m.Update(tea.MouseClickMsg{X: 3, Y: 2, Button: tea.MouseLeft, Mod: tea.ModShift})
+	assert.True(t, m.selection.Selection.Active)
+	m, _ = m.Update(tea.MouseReleaseMsg{X: 3, Y: 2, Button: tea.MouseLeft, Mod: tea.ModShift})
+
+	m, _ = m.Update(tea.PasteMsg{Content: "pasted"})
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyUp, Mod: tea.ModCtrl})
+	assert.Equal(t, "\x1b[1;1R\x1b[<0;3;2M\x1b[<0;3;2m\x1b[200~pasted\x1b[201~\x1b[1;5A", written())
+}
+
+func TestWheelOnAltScreen(t *testing.T) {
+	m := newTestTerminal(t, 10)
+	m, written := attachPipe(t, m)
+
+	// Full-screen programs get arrow keys; the scrollback isn't scrolled
+	m, _ = m.Update(OutputMsg{Data: []byte("\x1b[?1049h\x1b[Hless")})
+	m, _ = m.Update(tea.MouseWheelMsg{X: 3, Y: 2, Button: tea.MouseWheelDown})
+	assert.Zero(t, m.scrollOffset)
+	assert.Equal(t, "\x1b[B\x1b[B\x1b[B", written())
+	assert.Contains(t, ansi.Strip(m.View()), "less")
+}
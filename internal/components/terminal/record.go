@@ -0,0 +1,42 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/avitaltamir/vibecommander/internal/asciicast"
+)
+
+// startRecording records the output from now on to an asciicast file at
+// path. A recording that can't be started is reported on the screen.
+func (m *Model) startRecording(path, title string, cols, rows int) {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err == nil {
+		m.recorder, err = asciicast.Create(path, asciicast.Header{
+			Width:  cols,
+			Height: rows,
+			Title:  title,
+			Env:    map[string]string{"TERM": "xterm-256color", "SHELL": os.Getenv("SHELL")},
+		})
+	}
+	if err != nil {
+		m.vt.Write([]byte("\x1b[33mNot recording: " + err.Error() + "\x1b[0m\r\n"))
+		return
+	}
+	m.recording = path
+}
+
+// Recording returns the file the output is being recorded to, or "" when
+// it isn't.
+func (m *Model) Recording() string {
+	return m.recording
+}
+
+// StopRecording ends the recording, if there is one.
+func (m *Model) StopRecording() {
+	if m.recorder != nil {
+		m.recorder.Close()
+		m.recorder = nil
+	}
+	m.recording = ""
+}
@@ -0,0 +1,39 @@
+package terminal
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/avitaltamir/vibecommander/internal/asciicast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecording(t *testing.T) {
+	m := newTestTerminal(t, 0)
+	path := filepath.Join(t.TempDir(), "sessions", "run.cast")
+
+	m.startRecording(path, "claude", 40, 5)
+	require.Equal(t, path, m.Recording())
+	m.recorder.Output([]byte("hello\r\n"))
+	m = m.SetSize(50, 6)
+	m = m.SetSize(50, 6) // Same size - not recorded again
+	m.StopRecording()
+	assert.Empty(t, m.Recording())
+
+	rec, err := asciicast.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 40, rec.Header.Width)
+	assert.Equal(t, 5, rec.Header.Height)
+	assert.Equal(t, "claude", rec.Header.Title)
+	require.Len(t, rec.Events, 2)
+	assert.Equal(t, "hello\r\n", rec.Events[0].Data)
+	assert.Equal(t, asciicast.Event{Time: rec.Events[1].Time, Type: asciicast.Resize, Data: "50x5"}, rec.Events[1])
+}
+
+func TestRecordingFails(t *testing.T) {
+	m := newTestTerminal(t, 0)
+	m.startRecording(filepath.Join("/dev/null", "run.cast"), "claude", 40, 5)
+	assert.Empty(t, m.Recording())
+	assert.Contains(t, m.Transcript(false)[0], "Not recording")
+}
@@ -0,0 +1,250 @@
+package terminal
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/avitaltamir/vibecommander/internal/find"
+	"github.com/avitaltamir/vibecommander/internal/theme"
+)
+
+// search is an incremental regex search over the scrollback and the screen.
+// Matches are transcript lines counted from the first line ever kept, so
+// they stay put when old scrollback is dropped.
+type search struct {
+	active  bool // Prompt open
+	input   textinput.Model
+	query   string
+	re      *regexp.Regexp
+	matches []int // Transcript lines with a match
+	current int   // Index into matches (-1 if none)
+	anchor  int   // Bottom line shown when the search started
+}
+
+// newSearchInput creates the search prompt.
+func newSearchInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "regex pattern..."
+	ti.CharLimit = 256
+	ti.SetWidth(30)
+	return ti
+}
+
+// Searching reports whether the search prompt is open.
+func (m *Model) Searching() bool {
+	return m.search.active
+}
+
+// startSearch opens the search prompt, searching up from the bottom of what's
+// shown.
+func (m *Model) startSearch() tea.Cmd {
+	if m.vt == nil {
+		return nil
+	}
+	m.search.active = true
+	m.search.anchor = m.dropped() + m.vt.Top(m.scrollOffset) + m.rows() - 1
+	m.search.input.SetValue(m.search.query)
+	m.search.input.CursorEnd()
+	m.search.input.Focus()
+	return textinput.Blink
+}
+
+// updateSearch handles keys while the search prompt is open: typing searches
+// as you go, Enter and ↑ go to the match above, ↓ to the match below, and Esc
+// closes the prompt and clears the highlights.
+func (m *Model) updateSearch(msg tea.KeyPressMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		m.search = search{input: m.search.input, current: -1}
+		m.search.input.Blur()
+		m.cachedView = m.renderVT()
+		return nil
+	case "enter", "up", "ctrl+p":
+		m.stepMatch(-1)
+		return nil
+	case "down", "ctrl+n":
+		m.stepMatch(1)
+		return nil
+	}
+
+	var cmd tea.Cmd
+	m.search.input, cmd = m.search.input.Update(msg)
+	if query := m.search.input.Value(); query != m.search.query {
+		m.runSearch(query)
+	}
+	return cmd
+}
+
+// runSearch finds query in the transcript and goes to the match nearest above
+// the line the search started from.
+func (m *Model) runSearch(query string) {
+	m.search.query = query
+	m.search.re = find.Compile(query)
+	m.findMatches()
+
+	m.search.current = -1
+	for i, line := range m.search.matches {
+		if line <= m.search.anchor || m.search.current < 0 {
+			m.search.current = i
+		}
+	}
+	m.showMatch()
+}
+
+// findMatches finds the transcript lines matching the search.
+func (m *Model) findMatches() {
+	m.search.matches = nil
+	if m.search.re == nil {
+		return
+	}
+	for i, line := range m.Transcript(false) {
+		if m.search.re.MatchString(line) {
+			m.search.matches = append(m.search.matches, m.dropped()+i)
+		}
+	}
+}
+
+// stepMatch moves to the match above (dir -1) or below (dir 1), wrapping
+// around.
+func (m *Model) stepMatch(dir int) {
+	line := m.search.anchor
+	if m.search.current >= 0 {
+		line = m.search.matches[m.search.current]
+	}
+	m.findMatches() // More output may have arrived
+	n := len(m.search.matches)
+	if n == 0 {
+		m.search.current = -1
+		m.cachedView = m.renderVT()
+		return
+	}
+
+	// Find where we were again, then step from there
+	cur := -1
+	for i, l := range m.search.matches {
+		if l <= line {
+			cur = i
+		}
+	}
+	switch {
+	case cur >= 0 && m.search.matches[cur] == line:
+		cur = (cur + dir + n) % n
+	case dir < 0:
+		if cur < 0 {
+			cur = n - 1
+		}
+	default:
+		cur = (cur + 1) % n
+	}
+	m.search.current = cur
+	m.showMatch()
+}
+
+// showMatch scrolls so the current match is in the middle of the screen.
+func (m *Model) showMatch() {
+	if m.search.current >= 0 {
+		line := m.search.matches[m.search.current] - m.dropped()
+		offset := m.scrollbackLen() - (line - m.rows()/2)
+		m.scrollOffset = max(0, min(offset, m.scrollbackLen()))
+		m.scrollLocked = m.scrollOffset > 0
+	}
+	m.cachedView = m.renderVT()
+}
+
+// rows returns the number of rows on the screen.
+func (m *Model) rows() int {
+	if m.vt == nil {
+		return 0
+	}
+	_, rows := m.vt.Size()
+	return rows
+}
+
+// scrollbackLen returns how many lines have scrolled off the screen and are
+// still kept.
+func (m *Model) scrollbackLen() int {
+	if m.vt == nil {
+		return 0
+	}
+	return m.vt.ScrollbackLen()
+}
+
+// dropped returns how many lines have been trimmed off the front of the
+// scrollback. Matches are numbered counting them, so they stay put.
+func (m *Model) dropped() int {
+	if m.vt == nil {
+		return 0
+	}
+	return m.vt.Dropped()
+}
+
+// highlightMatches highlights the search matches in the rendered lines, the
+// first of which is transcript line top.
+func (m *Model) highlightMatches(lines []string, top int) {
+	if m.search.re == nil {
+		return
+	}
+	current := -1
+	if m.search.current >= 0 && m.search.current < len(m.search.matches) {
+		current = m.search.matches[m.search.current] - m.dropped()
+	}
+	for i, line := range lines {
+		plain := ansi.Strip(line)
+		locs := m.search.re.FindAllStringIndex(plain, -1)
+		if len(locs) == 0 {
+			continue
+		}
+		style := lipgloss.NewStyle().
+			Background(theme.ElectricYellow).
+			Foreground(lipgloss.Color("0"))
+		if top+i == current {
+			style = style.Background(theme.MatrixGreen)
+		}
+
+		var b strings.Builder
+		last := 0
+		for _, loc := range locs {
+			if loc[0] == loc[1] {
+				continue
+			}
+			start, end := ansi.StringWidth(plain[:loc[0]]), ansi.StringWidth(plain[:loc[1]])
+			b.WriteString(ansi.Cut(line, last, start))
+			b.WriteString(style.Render(plain[loc[0]:loc[1]]))
+			last = end
+		}
+		b.WriteString(ansi.Cut(line, last, ansi.StringWidth(plain)))
+		lines[i] = b.String()
+	}
+}
+
+// renderSearchBar renders the search prompt with the match count.
+func (m *Model) renderSearchBar(width int) string {
+	prefix := lipgloss.NewStyle().
+		Foreground(theme.CyberCyan).
+		Bold(true).
+		Render("/")
+
+	var info string
+	switch total := len(m.search.matches); {
+	case m.search.query == "":
+	case total == 0:
+		info = lipgloss.NewStyle().
+			Foreground(theme.NeonRed).
+			Render(" [no matches]")
+	default:
+		info = lipgloss.NewStyle().
+			Foreground(theme.MatrixGreen).
+			Render(fmt.Sprintf(" [%d/%d]", m.search.current+1, total))
+	}
+
+	return lipgloss.NewStyle().
+		Background(lipgloss.Color("236")).
+		Width(width).
+		Render(prefix + m.search.input.View() + info)
+}
@@ -0,0 +1,123 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/avitaltamir/vibecommander/internal/vt"
+)
+
+// newTestTerminal returns a focused terminal that was sent n numbered lines.
+func newTestTerminal(t *testing.T, n int) Model {
+	t.Helper()
+	m := New().SetSize(40, 6)
+	m.vt = vt.New(40, 5)
+	m, _ = m.Focus()
+	for i := 0; i < n; i++ {
+		m, _ = m.Update(OutputMsg{Data: []byte(fmt.Sprintf("line %d\r\n", i))})
+	}
+	return m
+}
+
+func typeText(m *Model, text string) {
+	for _, r := range text {
+		*m, _ = m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+}
+
+func TestTranscript(t *testing.T) {
+	m := newTestTerminal(t, 30)
+
+	lines := m.Transcript(false)
+	require.Len(t, lines, 30)
+	assert.Equal(t, "line 0", lines[0])
+	assert.Equal(t, "line 29", lines[29])
+
+	styled := m.Transcript(true)
+	require.Len(t, styled, 30)
+	assert.Equal(t, "line 29", strings.TrimRight(ansi.Strip(styled[29]), " "))
+}
+
+func TestSearchScrollback(t *testing.T) {
+	m := newTestTerminal(t, 30)
+
+	m, cmd := m.Update(tea.KeyPressMsg{Code: '/', Mod: tea.ModAlt})
+	assert.NotNil(t, cmd)
+	require.True(t, m.Searching())
+
+	// The match nearest above the bottom comes first
+	typeText(&m, "line 1")
+	require.Len(t, m.search.matches, 11) // 1 and 10-19
+	assert.Equal(t, 19, m.search.matches[m.search.current])
+	assert.Positive(t, m.scrollOffset, "scrolled back to the match")
+	assert.Contains(t, ansi.Strip(m.View()), "line 19")
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	assert.Equal(t, 18, m.search.matches[m.search.current])
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyDown})
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyDown})
+	assert.Equal(t, 1, m.search.matches[m.search.current], "wraps around")
+	assert.Contains(t, ansi.Strip(m.View()), "[1/11]")
+
+	// Regex
+	typeText(&m, "$")
+	assert.Len(t, m.search.matches, 1)
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	assert.False(t, m.Searching())
+	assert.Nil(t, m.search.re)
+}
+
+func TestSearchAfterDroppedScrollback(t *testing.T) {
+	m := newTestTerminal(t, 0)
+	m.vt.SetMaxScrollback(10)
+	for i := 0; i < 30; i++ {
+		m, _ = m.Update(OutputMsg{Data: []byte(fmt.Sprintf("line %d\r\n", i))})
+	}
+	require.Positive(t, m.dropped())
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: '/', Mod: tea.ModAlt})
+	typeText(&m, "line 2")
+	require.NotEmpty(t, m.search.matches)
+	line := m.search.matches[m.search.current] - m.dropped()
+	assert.Contains(t, m.Transcript(false)[line], "line 2")
+}
+
+func TestExportTranscript(t *testing.T) {
+	m := newTestTerminal(t, 3)
+	path := filepath.Join(t.TempDir(), "out.txt")
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'x', Mod: tea.ModAlt})
+	require.True(t, m.Exporting())
+	m.export.input.SetValue(path)
+	m, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	assert.False(t, m.Exporting())
+
+	msg := cmd().(ExportedMsg)
+	require.NoError(t, msg.Err)
+	assert.Equal(t, path, msg.Path)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "line 0\nline 1\nline 2\n", string(data))
+
+	// Opening it in the viewer writes a temporary file
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'x', Mod: tea.ModAlt})
+	_, cmd = m.Update(tea.KeyPressMsg{Code: 'o', Mod: tea.ModCtrl})
+	require.NotNil(t, cmd)
+	msg = cmd().(ExportedMsg)
+	require.NoError(t, msg.Err)
+	assert.True(t, msg.View)
+	defer os.Remove(msg.Path)
+	data, err = os.ReadFile(msg.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "line 0\nline 1\nline 2\n", string(data))
+}
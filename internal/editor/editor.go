@@ -0,0 +1,97 @@
+package editor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Placeholders in argument templates.
+const (
+	placeholderFile = "{file}"
+	placeholderLine = "{line}"
+	placeholderCol  = "{col}"
+)
+
+// templates holds the arguments that open a file at a position, keyed by the
+// editor's executable name.
+var templates = map[string][]string{
+	"vi":          {"+{line}", "{file}"},
+	"vim":         {"+{line}", "{file}"},
+	"nvim":        {"+{line}", "{file}"},
+	"emacs":       {"+{line}:{col}", "{file}"},
+	"emacsclient": {"+{line}:{col}", "{file}"},
+	"nano":        {"+{line},{col}", "{file}"},
+	"hx":          {"{file}:{line}:{col}"},
+	"helix":       {"{file}:{line}:{col}"},
+	"code":        {"--wait", "--goto", "{file}:{line}:{col}"},
+	"codium":      {"--wait", "--goto", "{file}:{line}:{col}"},
+}
+
+// fallback opens just the file, for editors without a known template.
+var fallback = []string{"{file}"}
+
+// FromEnv returns the user's editor command line: $VISUAL, then $EDITOR,
+// then vi.
+func FromEnv() string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if v := strings.TrimSpace(os.Getenv(name)); v != "" {
+			return v
+		}
+	}
+	return "vi"
+}
+
+// Args returns the program and arguments that open path at line and col
+// (1-indexed) in editor, a command line such as "code --wait". Arguments
+// already in the command line are kept and not repeated.
+func Args(editor, path string, line, col int) (string, []string) {
+	fields := strings.Fields(editor)
+	if len(fields) == 0 {
+		fields = []string{"vi"}
+	}
+	if line < 1 {
+		line = 1
+	}
+	if col < 1 {
+		col = 1
+	}
+
+	tmpl, ok := templates[filepath.Base(fields[0])]
+	if !ok {
+		tmpl = fallback
+	}
+
+	args := fields[1:]
+	replacer := strings.NewReplacer(
+		placeholderFile, path,
+		placeholderLine, strconv.Itoa(line),
+		placeholderCol, strconv.Itoa(col),
+	)
+	for _, a := range tmpl {
+		if !strings.Contains(a, "{") && contains(args, a) {
+			continue // e.g. --wait given in $EDITOR
+		}
+		args = append(args, replacer.Replace(a))
+	}
+	return fields[0], args
+}
+
+// Command returns the command that opens path at line and col (1-indexed) in
+// the user's editor.
+func Command(path string, line, col int) *exec.Cmd {
+	name, args := Args(FromEnv(), path, line, col)
+	return exec.Command(name, args...)
+}
+
+// contains reports whether list holds s.
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
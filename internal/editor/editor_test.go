@@ -0,0 +1,52 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArgs(t *testing.T) {
+	tests := []struct {
+		editor string
+		name   string
+		args   []string
+	}{
+		{"vim", "vim", []string{"+12", "/p/a.go"}},
+		{"nvim", "nvim", []string{"+12", "/p/a.go"}},
+		{"/usr/bin/vi", "/usr/bin/vi", []string{"+12", "/p/a.go"}},
+		{"emacs -nw", "emacs", []string{"-nw", "+12:3", "/p/a.go"}},
+		{"nano", "nano", []string{"+12,3", "/p/a.go"}},
+		{"hx", "hx", []string{"/p/a.go:12:3"}},
+		{"helix", "helix", []string{"/p/a.go:12:3"}},
+		{"code --wait", "code", []string{"--wait", "--goto", "/p/a.go:12:3"}},
+		{"code", "code", []string{"--wait", "--goto", "/p/a.go:12:3"}},
+		{"ed", "ed", []string{"/p/a.go"}},
+		{"", "vi", []string{"+12", "/p/a.go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.editor, func(t *testing.T) {
+			name, args := Args(tt.editor, "/p/a.go", 12, 3)
+			assert.Equal(t, tt.name, name)
+			assert.Equal(t, tt.args, args)
+		})
+	}
+
+	t.Run("positions start at 1", func(t *testing.T) {
+		_, args := Args("nano", "/p/a.go", 0, 0)
+		assert.Equal(t, []string{"+1,1", "/p/a.go"}, args)
+	})
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	assert.Equal(t, "vi", FromEnv())
+
+	t.Setenv("EDITOR", "nano")
+	assert.Equal(t, "nano", FromEnv())
+
+	t.Setenv("VISUAL", "code --wait")
+	assert.Equal(t, "code --wait", FromEnv())
+}
@@ -0,0 +1,127 @@
+package fileops
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// ErrExists is returned when the destination already exists.
+var ErrExists = errors.New("destination already exists")
+
+// ErrIntoItself is returned when a directory would be copied or moved into
+// itself.
+var ErrIntoItself = errors.New("cannot copy or move a directory into itself")
+
+// Copy copies a file or directory tree from src to dst. It never overwrites:
+// if dst exists, ErrExists is returned. File modes are preserved and symlinks
+// are copied as links.
+func Copy(src, dst string) error {
+	if err := checkDestination(src, dst); err != nil {
+		return err
+	}
+	return copyPath(src, dst)
+}
+
+// Move moves a file or directory tree from src to dst, copying and removing
+// the source when a rename isn't possible (e.g. across filesystems). It never
+// overwrites an existing dst.
+func Move(src, dst string) error {
+	if err := checkDestination(src, dst); err != nil {
+		return err
+	}
+
+	err := os.Rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	// Can't rename across filesystems - copy, then remove
+	if err := copyPath(src, dst); err != nil {
+		os.RemoveAll(dst) // Don't leave a partial copy behind
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// checkDestination validates a copy or move before anything is written.
+func checkDestination(src, dst string) error {
+	if _, err := os.Lstat(src); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		return ErrExists
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	absSrc, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	absDst, err := filepath.Abs(dst)
+	if err != nil {
+		return err
+	}
+	if absDst == absSrc || strings.HasPrefix(absDst, absSrc+string(filepath.Separator)) {
+		return ErrIntoItself
+	}
+	return nil
+}
+
+// copyPath copies src to dst, recursing into directories.
+func copyPath(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+
+	case info.IsDir():
+		if err := os.Mkdir(dst, info.Mode().Perm()); err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := copyPath(filepath.Join(src, e.Name()), filepath.Join(dst, e.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return copyFile(src, dst, info.Mode().Perm())
+	}
+}
+
+// copyFile copies a regular file's content, creating dst with perm.
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
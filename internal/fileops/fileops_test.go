@@ -0,0 +1,117 @@
+package fileops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestCopy(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("copies a file", func(t *testing.T) {
+		src := filepath.Join(dir, "a.txt")
+		writeFile(t, src, "hello")
+		require.NoError(t, os.Chmod(src, 0755))
+
+		dst := filepath.Join(dir, "out", "a.txt")
+		require.NoError(t, os.MkdirAll(filepath.Dir(dst), 0755))
+		require.NoError(t, Copy(src, dst))
+
+		assert.Equal(t, "hello", readFile(t, dst))
+		info, err := os.Stat(dst)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+		assert.FileExists(t, src)
+	})
+
+	t.Run("copies a directory tree", func(t *testing.T) {
+		src := filepath.Join(dir, "tree")
+		writeFile(t, filepath.Join(src, "x.txt"), "x")
+		writeFile(t, filepath.Join(src, "sub", "y.txt"), "y")
+
+		dst := filepath.Join(dir, "tree-copy")
+		require.NoError(t, Copy(src, dst))
+
+		assert.Equal(t, "x", readFile(t, filepath.Join(dst, "x.txt")))
+		assert.Equal(t, "y", readFile(t, filepath.Join(dst, "sub", "y.txt")))
+	})
+
+	t.Run("refuses to overwrite", func(t *testing.T) {
+		src := filepath.Join(dir, "b.txt")
+		dst := filepath.Join(dir, "c.txt")
+		writeFile(t, src, "new")
+		writeFile(t, dst, "old")
+
+		assert.ErrorIs(t, Copy(src, dst), ErrExists)
+		assert.Equal(t, "old", readFile(t, dst))
+	})
+
+	t.Run("refuses to copy a directory into itself", func(t *testing.T) {
+		src := filepath.Join(dir, "tree")
+		assert.ErrorIs(t, Copy(src, filepath.Join(src, "sub", "tree")), ErrIntoItself)
+	})
+}
+
+func TestMove(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("moves a file", func(t *testing.T) {
+		src := filepath.Join(dir, "a.txt")
+		dst := filepath.Join(dir, "b.txt")
+		writeFile(t, src, "hello")
+
+		require.NoError(t, Move(src, dst))
+		assert.NoFileExists(t, src)
+		assert.Equal(t, "hello", readFile(t, dst))
+	})
+
+	t.Run("moves a directory", func(t *testing.T) {
+		src := filepath.Join(dir, "tree")
+		writeFile(t, filepath.Join(src, "sub", "y.txt"), "y")
+
+		dst := filepath.Join(dir, "moved")
+		require.NoError(t, Move(src, dst))
+		assert.NoDirExists(t, src)
+		assert.Equal(t, "y", readFile(t, filepath.Join(dst, "sub", "y.txt")))
+	})
+
+	t.Run("refuses to overwrite", func(t *testing.T) {
+		src := filepath.Join(dir, "c.txt")
+		dst := filepath.Join(dir, "d.txt")
+		writeFile(t, src, "new")
+		writeFile(t, dst, "old")
+
+		assert.ErrorIs(t, Move(src, dst), ErrExists)
+		assert.FileExists(t, src)
+	})
+
+	t.Run("reports a missing source", func(t *testing.T) {
+		assert.Error(t, Move(filepath.Join(dir, "missing"), filepath.Join(dir, "x")))
+	})
+
+	t.Run("reports a failed rename without copying", func(t *testing.T) {
+		src := filepath.Join(dir, "e.txt")
+		writeFile(t, src, "e")
+
+		var linkErr *os.LinkError
+		assert.ErrorAs(t, Move(src, filepath.Join(dir, "no-such-dir", "e.txt")), &linkErr)
+		assert.Equal(t, "e", readFile(t, src))
+	})
+}
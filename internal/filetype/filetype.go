@@ -0,0 +1,202 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// SniffSize is the number of bytes at the start of a file that Detect looks
+// at.
+const SniffSize = 8 << 10
+
+// maxInvalidRatio is the share of bytes that may be invalid UTF-8 before
+// content counts as binary.
+const maxInvalidRatio = 0.1
+
+// Info describes a file's content.
+type Info struct {
+	Binary   bool
+	Kind     string   // e.g. "PNG image"; empty for unrecognized content
+	Encoding Encoding // How text is stored; only set for text
+}
+
+// Encoding is how a text file stores its characters.
+type Encoding int
+
+const (
+	UTF8    Encoding = iota // UTF-8, or plain ASCII
+	UTF16LE                 // UTF-16, little-endian, with a byte order mark
+	UTF16BE                 // UTF-16, big-endian, with a byte order mark
+)
+
+// byteOrder returns the byte order of a UTF-16 encoding, or nil for UTF-8.
+func (e Encoding) byteOrder() binary.ByteOrder {
+	switch e {
+	case UTF16LE:
+		return binary.LittleEndian
+	case UTF16BE:
+		return binary.BigEndian
+	}
+	return nil
+}
+
+// Decode returns the text data holds, as UTF-8, without the byte order mark.
+func (e Encoding) Decode(data []byte) string {
+	order := e.byteOrder()
+	if order == nil {
+		return string(data)
+	}
+	data = data[min(len(data), 2):]
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// Encode returns text stored in the encoding, with its byte order mark.
+func (e Encoding) Encode(text string) []byte {
+	order := e.byteOrder()
+	if order == nil {
+		return []byte(text)
+	}
+	units := utf16.Encode(append([]rune{'\ufeff'}, []rune(text)...))
+	data := make([]byte, 2*len(units))
+	for i, u := range units {
+		order.PutUint16(data[2*i:], u)
+	}
+	return data
+}
+
+// magic is a known file signature.
+type magic struct {
+	offset int
+	sig    []byte
+	kind   string
+	binary bool
+}
+
+// magics holds known signatures, checked in order. Signatures that are
+// plain text only count for content that isn't, so a text file that happens
+// to start with "MZ" or "ID3" is still shown as text.
+var magics = []magic{
+	{0, []byte("\x89PNG\r\n\x1a\n"), "PNG image", true},
+	{0, []byte("\xff\xd8\xff"), "JPEG image", true},
+	{0, []byte("GIF87a"), "GIF image", true},
+	{0, []byte("GIF89a"), "GIF image", true},
+	{0, []byte("\x00\x00\x01\x00"), "ICO image", true},
+	{8, []byte("WEBP"), "WebP image", true},
+	{8, []byte("WAVE"), "WAV audio", true},
+	{8, []byte("AVI "), "AVI video", true},
+	{4, []byte("ftyp"), "MP4 media", true},
+	{0, []byte("ID3"), "MP3 audio", true},
+	{0, []byte("OggS"), "Ogg media", true},
+	{0, []byte("fLaC"), "FLAC audio", true},
+	{0, []byte("%PDF-"), "PDF document", true},
+	{0, []byte("PK\x03\x04"), "ZIP archive", true},
+	{0, []byte("PK\x05\x06"), "ZIP archive", true},
+	{0, []byte("\x1f\x8b"), "gzip archive", true},
+	{0, []byte("BZh"), "bzip2 archive", true},
+	{0, []byte("\xfd7zXZ\x00"), "xz archive", true},
+	{0, []byte("\x28\xb5\x2f\xfd"), "zstd archive", true},
+	{0, []byte("7z\xbc\xaf\x27\x1c"), "7-Zip archive", true},
+	{0, []byte("Rar!\x1a\x07"), "RAR archive", true},
+	{257, []byte("ustar"), "tar archive", true},
+	{0, []byte("\x7fELF"), "ELF executable", true},
+	{0, []byte("\xcf\xfa\xed\xfe"), "Mach-O executable", true},
+	{0, []byte("\xce\xfa\xed\xfe"), "Mach-O executable", true},
+	{0, []byte("\xca\xfe\xba\xbe"), "Mach-O universal binary or Java class", true},
+	{0, []byte("MZ"), "Windows executable", true},
+	{0, []byte("\x00asm"), "WebAssembly module", true},
+	{0, []byte("SQLite format 3\x00"), "SQLite database", true},
+	{0, []byte("wOFF"), "WOFF font", true},
+	{0, []byte("wOF2"), "WOFF2 font", true},
+	{0, []byte("\x00\x01\x00\x00\x00"), "TrueType font", true},
+	{0, []byte("OTTO"), "OpenType font", true},
+	{0, []byte("\xef\xbb\xbf"), "UTF-8 text (BOM)", false},
+}
+
+// Detect classifies content from its first bytes (up to SniffSize): UTF-16
+// byte order marks and known signatures first, then NUL bytes and the share
+// of invalid UTF-8.
+func Detect(head []byte) Info {
+	if len(head) > SniffSize {
+		head = head[:SniffSize]
+	}
+
+	switch {
+	case bytes.HasPrefix(head, []byte("\xff\xfe")):
+		return Info{Kind: "UTF-16 text", Encoding: UTF16LE}
+	case bytes.HasPrefix(head, []byte("\xfe\xff")):
+		return Info{Kind: "UTF-16 text", Encoding: UTF16BE}
+	}
+
+	nul := bytes.IndexByte(head, 0) >= 0
+	invalid := invalidUTF8(head)
+	text := !nul && invalid == 0
+	for _, mg := range magics {
+		end := mg.offset + len(mg.sig)
+		if end > len(head) || !bytes.Equal(head[mg.offset:end], mg.sig) {
+			continue
+		}
+		if mg.binary && text && printable(mg.sig) {
+			continue // Text that starts like a signature
+		}
+		return Info{Binary: mg.binary, Kind: mg.kind}
+	}
+
+	if nul {
+		return Info{Binary: true, Kind: "binary data"}
+	}
+	if len(head) > 0 && float64(invalid)/float64(len(head)) > maxInvalidRatio {
+		return Info{Binary: true, Kind: "binary data"}
+	}
+	return Info{}
+}
+
+// invalidUTF8 counts the bytes of head that aren't valid UTF-8, leaving out
+// a character cut off at the end.
+func invalidUTF8(head []byte) int {
+	invalid := 0
+	for i := 0; i < len(head); {
+		r, size := utf8.DecodeRune(head[i:])
+		if r == utf8.RuneError && size <= 1 {
+			if !utf8.FullRune(head[i:]) {
+				break // Cut off by the sniff limit
+			}
+			invalid++
+		}
+		i += size
+	}
+	return invalid
+}
+
+// printable reports whether sig is all printable ASCII.
+func printable(sig []byte) bool {
+	for _, b := range sig {
+		if b < ' ' || b > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// Sniff reads the start of the file at path and classifies it.
+func Sniff(path string) (Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Info{}, err
+	}
+	defer f.Close()
+
+	head := make([]byte, SniffSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return Info{}, err
+	}
+	return Detect(head[:n]), nil
+}
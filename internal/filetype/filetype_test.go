@@ -0,0 +1,76 @@
+package filetype
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		head string
+		want Info
+	}{
+		{"empty", "", Info{}},
+		{"text", "package main\n\nfunc main() {}\n", Info{}},
+		{"unicode text", "héllo 世界 👋\n", Info{}},
+		{"utf-8 bom", "\xef\xbb\xbfhello", Info{Kind: "UTF-8 text (BOM)"}},
+		{"png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", Info{Binary: true, Kind: "PNG image"}},
+		{"elf", "\x7fELF\x02\x01\x01", Info{Binary: true, Kind: "ELF executable"}},
+		{"webp", "RIFF\x10\x00\x00\x00WEBPVP8 ", Info{Binary: true, Kind: "WebP image"}},
+		{"pdf", "%PDF-1.7\n%\xe2\xe3\xcf\xd3\n", Info{Binary: true, Kind: "PDF document"}},
+		{"windows executable", "MZ\x90\x00\x03\x00", Info{Binary: true, Kind: "Windows executable"}},
+		{"text starting like a signature", "MZ is short for Mark Zbikowski\n", Info{}},
+		{"text starting with ID3", "ID3 tags hold the title\n", Info{}},
+		{"utf-16le", "\xff\xfeh\x00i\x00", Info{Kind: "UTF-16 text", Encoding: UTF16LE}},
+		{"utf-16be", "\xfe\xff\x00h\x00i", Info{Kind: "UTF-16 text", Encoding: UTF16BE}},
+		{"nul byte", "abc\x00def", Info{Binary: true, Kind: "binary data"}},
+		{"mostly invalid utf-8", "\xc3\x28\xa0\xa1\xe2\x28\xa1abc", Info{Binary: true, Kind: "binary data"}},
+		{"latin-1 word in text", strings.Repeat("plain ascii text ", 10) + "caf\xe9", Info{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Detect([]byte(tt.head)))
+		})
+	}
+
+	t.Run("rune cut off by the sniff limit", func(t *testing.T) {
+		head := []byte(strings.Repeat("a", SniffSize-1) + "世")
+		assert.Equal(t, Info{}, Detect(head))
+	})
+}
+
+func TestEncoding(t *testing.T) {
+	for _, enc := range []Encoding{UTF8, UTF16LE, UTF16BE} {
+		text := "héllo 世界 👋\n"
+		data := enc.Encode(text)
+		assert.Equal(t, text, enc.Decode(data))
+		assert.Equal(t, enc, Detect(data).Encoding)
+	}
+	assert.Equal(t, []byte("\xff\xfeh\x00i\x00"), UTF16LE.Encode("hi"))
+}
+
+func TestSniff(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "a.bin")
+	require.NoError(t, os.WriteFile(bin, []byte("\x1f\x8b\x08\x00"), 0644))
+	text := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(text, []byte("hello\n"), 0644))
+
+	info, err := Sniff(bin)
+	require.NoError(t, err)
+	assert.Equal(t, Info{Binary: true, Kind: "gzip archive"}, info)
+
+	info, err = Sniff(text)
+	require.NoError(t, err)
+	assert.False(t, info.Binary)
+
+	_, err = Sniff(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
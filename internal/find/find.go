@@ -0,0 +1,23 @@
+// Package find compiles the queries typed into the viewer's and the
+// terminal's search bars.
+package find
+
+import "regexp"
+
+// Compile compiles a query as a case-insensitive regex, or as a
+// case-insensitive literal when it isn't valid regex. It returns nil for an
+// empty query.
+func Compile(query string) *regexp.Regexp {
+	if query == "" {
+		return nil
+	}
+	re, err := regexp.Compile("(?i)" + query)
+	if err != nil {
+		// Invalid regex, try as literal
+		re, err = regexp.Compile("(?i)" + regexp.QuoteMeta(query))
+		if err != nil {
+			return nil
+		}
+	}
+	return re
+}
@@ -0,0 +1,31 @@
+package find
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompile(t *testing.T) {
+	assert.Nil(t, Compile(""))
+
+	tests := []struct {
+		query, text string
+		match       bool
+	}{
+		{"err.r", "ERROR", true},     // Regex, any case
+		{"fo+", "FOOD", true},        // Regex, any case
+		{"f(", "call F(x)", true},    // Invalid regex: literal, any case
+		{"f(", "call fx", false},     // Literal, not regex
+		{"[a", "list [A b]", true},   // Invalid regex: literal, any case
+		{"main", "package x", false}, // No match
+	}
+	for _, tt := range tests {
+		t.Run(tt.query+" in "+tt.text, func(t *testing.T) {
+			re := Compile(tt.query)
+			if assert.NotNil(t, re) {
+				assert.Equal(t, tt.match, re.MatchString(tt.text))
+			}
+		})
+	}
+}
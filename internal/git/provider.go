@@ -1,6 +1,15 @@
 package git
 
-import "context"
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownCommit is returned when a ref to diff against doesn't name a
+// commit.
+var ErrUnknownCommit = errors.New("not a branch, tag or commit")
 
 // Provider defines the interface for git operations.
 type Provider interface {
@@ -11,7 +20,12 @@ type Provider interface {
 	GetStatus(ctx context.Context) (*Status, error)
 
 	// GetDiff returns the diff for a file or the entire working tree
-	GetDiff(ctx context.Context, path string) (string, error)
+	GetDiff(ctx context.Context, path string, opts DiffOptions) (string, error)
+
+	// GetChanges returns the diff of every changed file in scope, with paths
+	// relative to the working directory. since is the commit for ChangesSince;
+	// the scope decides what's diffed against, so opts.Base is ignored.
+	GetChanges(ctx context.Context, scope ChangeScope, since string, opts DiffOptions) (string, error)
 
 	// IsRepo checks if the current directory is a git repository
 	IsRepo() bool
@@ -26,6 +40,98 @@ type Provider interface {
 	Commit(ctx context.Context, message string) error
 }
 
+// ChangeScope picks which changes GetChanges covers.
+type ChangeScope int
+
+const (
+	ChangesAll      ChangeScope = iota // Staged and unstaged, against HEAD
+	ChangesUnstaged                    // Not staged yet
+	ChangesStaged                      // Staged for the next commit
+	ChangesSince                       // Everything since a commit, committed or not
+)
+
+// String returns how the scope is shown.
+func (s ChangeScope) String() string {
+	switch s {
+	case ChangesUnstaged:
+		return "unstaged"
+	case ChangesStaged:
+		return "staged"
+	case ChangesSince:
+		return "since"
+	default:
+		return "all changes"
+	}
+}
+
+// Whitespace is which changes to whitespace a diff ignores.
+type Whitespace int
+
+const (
+	WhitespaceShown    Whitespace = iota // Whitespace changes are changes
+	WhitespaceIgnored                    // Ignore all whitespace
+	WhitespaceTrailing                   // Ignore whitespace at the end of lines
+)
+
+// DefaultContext is how many lines of context git shows around a change.
+const DefaultContext = 3
+
+// DiffOptions change how a diff is made. The zero value is git's default
+// diff of the working tree against the index.
+type DiffOptions struct {
+	Whitespace       Whitespace
+	IgnoreBlankLines bool
+	Context          int    // Lines of context around each change, 0 for DefaultContext
+	FunctionContext  bool   // Show the whole function around each change
+	Base             string // Ref or commit to diff the working tree against
+}
+
+// args returns the git diff arguments for the options, but the base.
+func (o DiffOptions) args() []string {
+	var args []string
+	switch o.Whitespace {
+	case WhitespaceIgnored:
+		args = append(args, "--ignore-all-space")
+	case WhitespaceTrailing:
+		args = append(args, "--ignore-space-at-eol")
+	}
+	if o.IgnoreBlankLines {
+		args = append(args, "--ignore-blank-lines")
+	}
+	if o.Context > 0 {
+		args = append(args, "--unified="+strconv.Itoa(o.Context))
+	}
+	if o.FunctionContext {
+		args = append(args, "--function-context")
+	}
+	return args
+}
+
+// String describes the options that differ from the default, or returns ""
+// when none do.
+func (o DiffOptions) String() string {
+	var parts []string
+	switch o.Whitespace {
+	case WhitespaceIgnored:
+		parts = append(parts, "no whitespace")
+	case WhitespaceTrailing:
+		parts = append(parts, "no trailing space")
+	}
+	if o.IgnoreBlankLines {
+		parts = append(parts, "no blank lines")
+	}
+	if o.Context > 0 && o.Context != DefaultContext {
+		parts = append(parts, strconv.Itoa(o.Context)+" lines context")
+	}
+	if o.FunctionContext {
+		parts = append(parts, "whole functions")
+	}
+	if o.Base != "" {
+		parts = append(parts, "vs "+o.Base)
+	}
+	return strings.Join(parts, " · ")
+}
+
 // Status represents the overall repository status.
 type Status struct {
 	Branch    string
@@ -116,3 +116,27 @@ func TestStatus(t *testing.T) {
 		assert.Equal(t, StatusModified, s.Files["test.go"].Staging)
 	})
 }
+
+func TestChangeScope(t *testing.T) {
+	assert.Equal(t, "all changes", ChangesAll.String())
+	assert.Equal(t, "unstaged", ChangesUnstaged.String())
+	assert.Equal(t, "staged", ChangesStaged.String())
+	assert.Equal(t, "since", ChangesSince.String())
+}
+
+func TestDiffOptions(t *testing.T) {
+	assert.Empty(t, DiffOptions{}.args())
+	assert.Empty(t, DiffOptions{}.String())
+	assert.Empty(t, DiffOptions{Context: DefaultContext}.String())
+
+	opts := DiffOptions{
+		Whitespace:       WhitespaceTrailing,
+		IgnoreBlankLines: true,
+		Context:          10,
+		FunctionContext:  true,
+		Base:             "main",
+	}
+	assert.Equal(t, []string{"--ignore-space-at-eol", "--ignore-blank-lines", "--unified=10", "--function-context"}, opts.args())
+	assert.Equal(t, "no trailing space · no blank lines · 10 lines context · whole functions · vs main", opts.String())
+	assert.Equal(t, []string{"--ignore-all-space"}, DiffOptions{Whitespace: WhitespaceIgnored}.args())
+}
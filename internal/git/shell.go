@@ -3,6 +3,10 @@ package git
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
@@ -28,6 +32,49 @@ func (p *ShellProvider) IsRepo() bool {
 	return err == nil
 }
 
+// GitDirs returns the absolute paths of the repository's git directory and
+// its common directory. They differ only for linked worktrees, where HEAD and
+// index live in the former and refs in the latter.
+func (p *ShellProvider) GitDirs() (gitDir, commonDir string, err error) {
+	cmd := exec.Command("git", "rev-parse", "--absolute-git-dir", "--git-common-dir")
+	cmd.Dir = p.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", "", err
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	gitDir = lines[0]
+	commonDir = gitDir
+	if len(lines) > 1 && lines[1] != "" {
+		commonDir = lines[1]
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(p.workDir, commonDir)
+		}
+	}
+	return gitDir, filepath.Clean(commonDir), nil
+}
+
+// TrackedDirs returns the working directory and the directories below it
+// that hold files tracked in HEAD, relative to the working directory.
+func (p *ShellProvider) TrackedDirs(ctx context.Context) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", "--no-optional-locks", "ls-tree", "-r", "-d", "-z", "--name-only", "HEAD")
+	cmd.Dir = p.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	// ls-tree lists subdirectories only
+	dirs := []string{"."}
+	for _, dir := range strings.Split(string(out), "\x00") {
+		if dir != "" {
+			dirs = append(dirs, filepath.FromSlash(dir))
+		}
+	}
+	return dirs, nil
+}
+
 // GetBranch returns the current branch name.
 func (p *ShellProvider) GetBranch(ctx context.Context) (string, error) {
 	p.mu.Lock()
@@ -140,27 +187,175 @@ func (p *ShellProvider) GetStatus(ctx context.Context) (*Status, error) {
 }
 
 // GetDiff returns the diff for a file or the entire working tree.
-func (p *ShellProvider) GetDiff(ctx context.Context, path string) (string, error) {
+func (p *ShellProvider) GetDiff(ctx context.Context, path string, opts DiffOptions) (string, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	var cmd *exec.Cmd
+	args := append([]string{"--no-optional-locks", "diff"}, opts.args()...)
+	if opts.Base != "" {
+		base, err := p.resolveCommit(ctx, opts.Base)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, base)
+	}
 	if path == "" {
-		cmd = exec.CommandContext(ctx, "git", "--no-optional-locks", "diff")
-	} else {
-		cmd = exec.CommandContext(ctx, "git", "--no-optional-locks", "diff", "--", path)
+		return p.git(ctx, args...)
+	}
+	out, err := p.git(ctx, append(args, "--", path)...)
+	if err != nil || out != "" {
+		return out, err
+	}
+
+	// Nothing unstaged: a deletion may be staged already, showing the file
+	// as it was at HEAD, all removed
+	if _, statErr := os.Lstat(p.abs(path)); errors.Is(statErr, fs.ErrNotExist) {
+		if opts.Base != "" {
+			return "", nil // Diffed against the base already
+		}
+		args = append([]string{"--no-optional-locks", "diff"}, opts.args()...)
+		return p.git(ctx, append(args, "HEAD", "--", path)...)
+	}
+
+	// Or git doesn't know the file yet: all of it is added
+	untracked, err := p.untracked(ctx, path)
+	if err != nil || len(untracked) == 0 {
+		return "", err
+	}
+	return p.addedDiff(ctx, untracked[0], opts)
+}
+
+// GetChanges returns the diff of every changed file in scope, with paths
+// relative to the working directory. Untracked files are in the unstaged
+// changes, all added.
+func (p *ShellProvider) GetChanges(ctx context.Context, scope ChangeScope, since string, opts DiffOptions) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	args := append([]string{"--no-optional-locks", "diff", "--relative"}, opts.args()...)
+	switch scope {
+	case ChangesAll:
+		args = append(args, "HEAD")
+	case ChangesStaged:
+		args = append(args, "--cached")
+	case ChangesSince:
+		commit, err := p.resolveCommit(ctx, since)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, commit)
+	}
+	out, err := p.git(ctx, append(args, "--")...)
+	if err != nil || scope == ChangesStaged {
+		return out, err
 	}
-	cmd.Dir = p.workDir
+
+	untracked, err := p.untracked(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(out)
+	for _, path := range untracked {
+		added, err := p.addedDiff(ctx, path, opts)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(added)
+	}
+	return b.String(), nil
+}
+
+// resolveCommit returns the commit a typed ref names. Only the resolved hash
+// is passed on, so a ref can't be taken for an option or a path.
+func (p *ShellProvider) resolveCommit(ctx context.Context, rev string) (string, error) {
+	if rev == "" || strings.HasPrefix(rev, "-") {
+		return "", fmt.Errorf("%q: %w", rev, ErrUnknownCommit)
+	}
+	out, err := p.git(ctx, "rev-parse", "--verify", "--quiet", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("%q: %w", rev, ErrUnknownCommit)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// untracked lists the untracked files that aren't ignored under path, or
+// the whole working directory for "", relative to it.
+func (p *ShellProvider) untracked(ctx context.Context, path string) ([]string, error) {
+	args := []string{"--no-optional-locks", "ls-files", "--others", "--exclude-standard", "-z"}
+	if path != "" {
+		args = append(args, "--", path)
+	}
+	out, err := p.git(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, name := range strings.Split(out, "\x00") {
+		if name != "" {
+			paths = append(paths, name)
+		}
+	}
+	return paths, nil
+}
+
+// addedDiff returns the diff of a file git doesn't know: all of it added,
+// as git diff --no-index /dev/null shows it.
+func (p *ShellProvider) addedDiff(ctx context.Context, path string, opts DiffOptions) (string, error) {
+	return DiffNoIndex(ctx, p.workDir, "/dev/null", path, opts)
+}
+
+// DiffNoIndex returns the diff between two files or directories, which
+// needn't be in a repository, as git diff --no-index shows it. The paths
+// are relative to dir, where git runs, and "/dev/null" stands for no file.
+// opts.Base is ignored.
+func DiffNoIndex(ctx context.Context, dir, left, right string, opts DiffOptions) (string, error) {
+	args := append([]string{"--no-optional-locks", "diff", "--no-index"}, opts.args()...)
+	cmd := exec.CommandContext(ctx, "git", append(args, "--", left, right)...)
+	cmd.Dir = dir
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	err := cmd.Run()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == 1 && stdout.Len() > 0 {
+		return stdout.String(), nil // It exits 1 when the two differ
+	}
 	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
 		return "", err
 	}
+	return stdout.String(), nil
+}
+
+// abs returns path made absolute against the working directory.
+func (p *ShellProvider) abs(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(p.workDir, path)
+}
+
+// git runs git in the working directory and returns what it printed. The
+// error carries what git said went wrong, when it said anything.
+func (p *ShellProvider) git(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = p.workDir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
 
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
 	return stdout.String(), nil
 }
 
@@ -0,0 +1,149 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRepo creates a repository with a committed tracked.txt.
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "Test")
+	run("config", "commit.gpgsign", "false")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tracked.txt"), []byte("one\ntwo\n"), 0o644))
+	run("add", "tracked.txt")
+	run("commit", "-q", "-m", "init")
+	return dir
+}
+
+func TestTrackedDirs(t *testing.T) {
+	dir := newRepo(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "a", "b"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a", "b", "c.txt"), []byte("c\n"), 0o644))
+	for _, args := range [][]string{{"add", "a"}, {"commit", "-q", "-m", "nested"}} {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	dirs, err := NewShellProvider(dir).TrackedDirs(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{".", "a", filepath.Join("a", "b")}, dirs)
+}
+
+func TestGetDiffUntracked(t *testing.T) {
+	dir := newRepo(t)
+	p := NewShellProvider(dir)
+	path := filepath.Join(dir, "new.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hello\nworld\n"), 0o644))
+
+	diff, err := p.GetDiff(context.Background(), path, DiffOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, diff, "--- /dev/null")
+	assert.Contains(t, diff, "+hello\n+world\n")
+}
+
+func TestGetDiffDeleted(t *testing.T) {
+	dir := newRepo(t)
+	p := NewShellProvider(dir)
+	path := filepath.Join(dir, "tracked.txt")
+	require.NoError(t, os.Remove(path))
+
+	diff, err := p.GetDiff(context.Background(), path, DiffOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, diff, "-one\n-two\n")
+
+	// Still shown once the deletion is staged
+	require.NoError(t, p.Stage(context.Background(), path))
+	diff, err = p.GetDiff(context.Background(), path, DiffOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+++ /dev/null")
+	assert.Contains(t, diff, "-one\n-two\n")
+}
+
+func TestGetChangesUntracked(t *testing.T) {
+	dir := newRepo(t)
+	p := NewShellProvider(dir)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tracked.txt"), []byte("one\n2\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.txt"), []byte("hello\n"), 0o644))
+
+	diff, err := p.GetChanges(context.Background(), ChangesAll, "", DiffOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+++ b/tracked.txt")
+	assert.Contains(t, diff, "+++ b/new.txt")
+
+	diff, err = p.GetChanges(context.Background(), ChangesStaged, "", DiffOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+}
+
+func TestGetDiffOptions(t *testing.T) {
+	dir := newRepo(t)
+	p := NewShellProvider(dir)
+	path := filepath.Join(dir, "tracked.txt")
+	require.NoError(t, os.WriteFile(path, []byte("one  \ntwo\n"), 0o644))
+
+	diff, err := p.GetDiff(context.Background(), path, DiffOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+one  \n")
+
+	diff, err = p.GetDiff(context.Background(), path, DiffOptions{Whitespace: WhitespaceTrailing})
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	// Staged, it's no change from the index, but still one from HEAD
+	require.NoError(t, p.Stage(context.Background(), path))
+	diff, err = p.GetDiff(context.Background(), path, DiffOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+	diff, err = p.GetDiff(context.Background(), path, DiffOptions{Base: "HEAD"})
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+one  \n")
+
+	// Refs that aren't commits, options included, never reach git diff
+	for _, base := range []string{"no-such-branch", "--output=" + filepath.Join(dir, "out")} {
+		_, err = p.GetDiff(context.Background(), path, DiffOptions{Base: base})
+		assert.ErrorIs(t, err, ErrUnknownCommit)
+		_, err = p.GetChanges(context.Background(), ChangesSince, base, DiffOptions{})
+		assert.ErrorIs(t, err, ErrUnknownCommit)
+	}
+	assert.NoFileExists(t, filepath.Join(dir, "out"))
+}
+
+func TestDiffNoIndex(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("two\n"), 0o644))
+
+	diff, err := DiffNoIndex(context.Background(), dir, "a.txt", "b.txt", DiffOptions{})
+	require.NoError(t, err, "differing files aren't an error")
+	assert.Contains(t, diff, "-one")
+	assert.Contains(t, diff, "+two")
+
+	diff, err = DiffNoIndex(context.Background(), dir, "a.txt", "a.txt", DiffOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	_, err = DiffNoIndex(context.Background(), dir, "a.txt", "missing.txt", DiffOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,187 @@
+package history
+
+// Location is a position in a file.
+type Location struct {
+	Path string
+	Line int // 0-indexed
+}
+
+// MaxJumps is how many locations the jump list remembers.
+const MaxJumps = 100
+
+// JumpList is an editor-style back/forward list of visited locations.
+// Like Vim's Ctrl+O/Ctrl+I, jumping somewhere new drops the forward history.
+type JumpList struct {
+	entries []Location
+	index   int // Position in entries; len(entries) when at the newest end
+}
+
+// Push records the location being left before a jump.
+func (j *JumpList) Push(loc Location) {
+	if loc.Path == "" {
+		return
+	}
+
+	// A new jump discards everything ahead of the current position
+	j.entries = j.entries[:j.index]
+
+	// Don't stack the same spot twice in a row
+	if n := len(j.entries); n > 0 && j.entries[n-1] == loc {
+		j.index = n
+		return
+	}
+
+	j.entries = append(j.entries, loc)
+	if len(j.entries) > MaxJumps {
+		j.entries = j.entries[len(j.entries)-MaxJumps:]
+	}
+	j.index = len(j.entries)
+}
+
+// Back moves to the previous location. current is where the user is now; it
+// is remembered when leaving the newest end so Forward can return to it.
+func (j *JumpList) Back(current Location) (Location, bool) {
+	if j.index == 0 {
+		return Location{}, false
+	}
+	if j.index == len(j.entries) && current.Path != "" {
+		if j.entries[j.index-1] == current {
+			// Already at the newest entry - step past it
+			if j.index == 1 {
+				return Location{}, false
+			}
+			j.index--
+		} else {
+			j.entries = append(j.entries, current)
+		}
+	}
+	j.index--
+	return j.entries[j.index], true
+}
+
+// Forward moves to the next location after a Back.
+func (j *JumpList) Forward() (Location, bool) {
+	if j.index+1 >= len(j.entries) {
+		return Location{}, false
+	}
+	j.index++
+	return j.entries[j.index], true
+}
+
+// Entries returns the locations, oldest first.
+func (j JumpList) Entries() []Location {
+	return j.entries
+}
+
+// Index returns the position of the current location in Entries, or
+// len(Entries) when at the newest end.
+func (j JumpList) Index() int {
+	return j.index
+}
+
+// Jump moves directly to entry i (as picked from Entries).
+func (j *JumpList) Jump(i int, current Location) (Location, bool) {
+	if i < 0 || i >= len(j.entries) {
+		return Location{}, false
+	}
+	if j.index == len(j.entries) && current.Path != "" && j.entries[len(j.entries)-1] != current {
+		j.entries = append(j.entries, current)
+	}
+	j.index = i
+	return j.entries[i], true
+}
+
+// MaxRecent is how many files the recent list remembers.
+const MaxRecent = 50
+
+// Recent is a most-recently-used list of paths.
+type Recent struct {
+	paths []string
+}
+
+// NewRecent creates a recent list from persisted paths, newest first.
+func NewRecent(paths []string) Recent {
+	r := Recent{}
+	for i := len(paths) - 1; i >= 0; i-- {
+		r.Touch(paths[i])
+	}
+	return r
+}
+
+// Touch moves path to the front of the list.
+func (r *Recent) Touch(path string) {
+	if path == "" {
+		return
+	}
+	r.Remove(path)
+	r.paths = append([]string{path}, r.paths...)
+	if len(r.paths) > MaxRecent {
+		r.paths = r.paths[:MaxRecent]
+	}
+}
+
+// Remove drops path from the list.
+func (r *Recent) Remove(path string) {
+	for i, p := range r.paths {
+		if p == path {
+			r.paths = append(r.paths[:i:i], r.paths[i+1:]...)
+			return
+		}
+	}
+}
+
+// Paths returns the paths, newest first.
+func (r Recent) Paths() []string {
+	return r.paths
+}
+
+// Bookmarks is an ordered set of bookmarked paths.
+type Bookmarks struct {
+	paths []string
+}
+
+// NewBookmarks creates a bookmark set from persisted paths.
+func NewBookmarks(paths []string) Bookmarks {
+	b := Bookmarks{}
+	for _, p := range paths {
+		if p != "" && !b.Has(p) {
+			b.paths = append(b.paths, p)
+		}
+	}
+	return b
+}
+
+// Toggle adds or removes path and reports whether it is now bookmarked.
+func (b *Bookmarks) Toggle(path string) bool {
+	if b.Has(path) {
+		b.Remove(path)
+		return false
+	}
+	b.paths = append(b.paths, path)
+	return true
+}
+
+// Has reports whether path is bookmarked.
+func (b Bookmarks) Has(path string) bool {
+	for _, p := range b.paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove drops a bookmark.
+func (b *Bookmarks) Remove(path string) {
+	for i, p := range b.paths {
+		if p == path {
+			b.paths = append(b.paths[:i:i], b.paths[i+1:]...)
+			return
+		}
+	}
+}
+
+// Paths returns the bookmarked paths in the order they were added.
+func (b Bookmarks) Paths() []string {
+	return b.paths
+}
@@ -0,0 +1,125 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJumpList(t *testing.T) {
+	a := Location{Path: "/a.go", Line: 10}
+	b := Location{Path: "/b.go"}
+	c := Location{Path: "/c.go", Line: 5}
+
+	t.Run("back and forward", func(t *testing.T) {
+		var j JumpList
+		j.Push(a)
+		j.Push(b)
+
+		loc, ok := j.Back(c)
+		require.True(t, ok)
+		assert.Equal(t, b, loc)
+
+		loc, ok = j.Back(b)
+		require.True(t, ok)
+		assert.Equal(t, a, loc)
+
+		_, ok = j.Back(a)
+		assert.False(t, ok)
+
+		loc, ok = j.Forward()
+		require.True(t, ok)
+		assert.Equal(t, b, loc)
+
+		loc, ok = j.Forward()
+		require.True(t, ok)
+		assert.Equal(t, c, loc)
+
+		_, ok = j.Forward()
+		assert.False(t, ok)
+	})
+
+	t.Run("jumping from the middle drops forward history", func(t *testing.T) {
+		var j JumpList
+		j.Push(a)
+		j.Push(b)
+		_, _ = j.Back(c)
+		_, _ = j.Back(b)
+
+		j.Push(a)
+		assert.Equal(t, []Location{a}, j.Entries())
+		_, ok := j.Forward()
+		assert.False(t, ok)
+	})
+
+	t.Run("back from the newest entry steps past it", func(t *testing.T) {
+		var j JumpList
+		j.Push(a)
+		j.Push(b)
+
+		loc, ok := j.Back(b)
+		require.True(t, ok)
+		assert.Equal(t, a, loc)
+	})
+
+	t.Run("duplicates and empty paths are skipped", func(t *testing.T) {
+		var j JumpList
+		j.Push(a)
+		j.Push(a)
+		j.Push(Location{})
+		assert.Len(t, j.Entries(), 1)
+	})
+
+	t.Run("is capped", func(t *testing.T) {
+		var j JumpList
+		for i := 0; i < MaxJumps+10; i++ {
+			j.Push(Location{Path: "/x.go", Line: i})
+		}
+		assert.Len(t, j.Entries(), MaxJumps)
+		assert.Equal(t, MaxJumps, j.Index())
+	})
+
+	t.Run("jump to an entry", func(t *testing.T) {
+		var j JumpList
+		j.Push(a)
+		j.Push(b)
+
+		loc, ok := j.Jump(0, c)
+		require.True(t, ok)
+		assert.Equal(t, a, loc)
+		assert.Equal(t, []Location{a, b, c}, j.Entries())
+
+		_, ok = j.Jump(5, c)
+		assert.False(t, ok)
+	})
+}
+
+func TestRecent(t *testing.T) {
+	r := NewRecent([]string{"/b", "/a"})
+	assert.Equal(t, []string{"/b", "/a"}, r.Paths())
+
+	r.Touch("/c")
+	r.Touch("/a")
+	assert.Equal(t, []string{"/a", "/c", "/b"}, r.Paths())
+
+	r.Remove("/c")
+	assert.Equal(t, []string{"/a", "/b"}, r.Paths())
+
+	for i := 0; i < MaxRecent+5; i++ {
+		r.Touch("/f" + string(rune('a'+i%26)) + string(rune('a'+i/26)))
+	}
+	assert.Len(t, r.Paths(), MaxRecent)
+}
+
+func TestBookmarks(t *testing.T) {
+	b := NewBookmarks([]string{"/a", "", "/a", "/b"})
+	assert.Equal(t, []string{"/a", "/b"}, b.Paths())
+
+	assert.True(t, b.Toggle("/c"))
+	assert.True(t, b.Has("/c"))
+
+	assert.False(t, b.Toggle("/a"))
+	assert.False(t, b.Has("/a"))
+	assert.Equal(t, []string{"/b", "/c"}, b.Paths())
+}
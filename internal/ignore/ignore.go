@@ -0,0 +1,348 @@
+package ignore
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// rule is a single compiled gitignore pattern.
+type rule struct {
+	base    string         // Directory the rule is relative to ("" = repository root)
+	re      *regexp.Regexp // Compiled pattern
+	negate  bool           // Pattern started with "!" (re-include)
+	dirOnly bool           // Pattern ended with "/" (directories only)
+}
+
+// Matcher decides whether paths are ignored according to .gitignore files,
+// .git/info/exclude and the user's global excludes file.
+// It is safe for concurrent use.
+type Matcher struct {
+	root string // Repository root (or working directory outside a repo)
+
+	mu         sync.Mutex
+	global     []rule            // Rules from core.excludesFile and info/exclude
+	dirRules   map[string][]rule // .gitignore rules per directory (relative to root)
+	dirIgnored map[string]bool   // Cached results for directories
+}
+
+// New creates a matcher for the repository containing dir.
+// If dir is not inside a git repository, dir itself is used as the root.
+func New(dir string) *Matcher {
+	root := dir
+	if top := gitOutput(dir, "rev-parse", "--show-toplevel"); top != "" {
+		root = top
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+
+	m := &Matcher{root: root}
+	m.Reload()
+	return m
+}
+
+// Root returns the directory the matcher's rules are relative to.
+func (m *Matcher) Root() string {
+	if m == nil {
+		return ""
+	}
+	return m.root
+}
+
+// Reload discards all cached rules and results so that edited ignore files
+// take effect.
+func (m *Matcher) Reload() {
+	if m == nil {
+		return
+	}
+
+	var global []rule
+	if path := globalExcludesFile(m.root); path != "" {
+		global = append(global, loadRules(path, "")...)
+	}
+	if path := infoExcludeFile(m.root); path != "" {
+		global = append(global, loadRules(path, "")...)
+	}
+
+	m.mu.Lock()
+	m.global = global
+	m.dirRules = make(map[string][]rule)
+	m.dirIgnored = make(map[string]bool)
+	m.mu.Unlock()
+}
+
+// Match reports whether path is ignored. Files inside an ignored directory
+// are ignored too, as with git.
+func (m *Matcher) Match(path string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+
+	rel, ok := m.relative(path)
+	if !ok || rel == "" {
+		return false
+	}
+
+	parts := strings.Split(rel, "/")
+	if parts[0] == ".git" {
+		return true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Check ancestors first - git never re-includes files under an ignored dir
+	for i := 1; i < len(parts); i++ {
+		if m.dirMatch(strings.Join(parts[:i], "/")) {
+			return true
+		}
+	}
+	if isDir {
+		return m.dirMatch(rel)
+	}
+	return m.matchLocked(rel, false)
+}
+
+// dirMatch returns the cached result for a directory. Caller holds m.mu.
+func (m *Matcher) dirMatch(rel string) bool {
+	if ignored, ok := m.dirIgnored[rel]; ok {
+		return ignored
+	}
+	ignored := m.matchLocked(rel, true)
+	m.dirIgnored[rel] = ignored
+	return ignored
+}
+
+// matchLocked evaluates all applicable rules for rel, ignoring ancestors.
+// The last matching rule wins. Caller holds m.mu.
+func (m *Matcher) matchLocked(rel string, isDir bool) bool {
+	ignored := false
+	apply := func(rules []rule) {
+		for _, r := range rules {
+			if r.dirOnly && !isDir {
+				continue
+			}
+			target := rel
+			if r.base != "" {
+				if !strings.HasPrefix(rel, r.base+"/") {
+					continue
+				}
+				target = rel[len(r.base)+1:]
+			}
+			if r.re.MatchString(target) {
+				ignored = !r.negate
+			}
+		}
+	}
+
+	apply(m.global)
+
+	// .gitignore files from the root down to the path's parent directory
+	apply(m.rulesFor(""))
+	dir := ""
+	parts := strings.Split(rel, "/")
+	for _, part := range parts[:len(parts)-1] {
+		if dir == "" {
+			dir = part
+		} else {
+			dir += "/" + part
+		}
+		apply(m.rulesFor(dir))
+	}
+
+	return ignored
+}
+
+// rulesFor lazily loads the .gitignore in the given directory. Caller holds m.mu.
+func (m *Matcher) rulesFor(dir string) []rule {
+	if rules, ok := m.dirRules[dir]; ok {
+		return rules
+	}
+	rules := loadRules(filepath.Join(m.root, filepath.FromSlash(dir), ".gitignore"), dir)
+	m.dirRules[dir] = rules
+	return rules
+}
+
+// relative converts path to a slash-separated path relative to the root.
+func (m *Matcher) relative(path string) (string, bool) {
+	if !filepath.IsAbs(path) {
+		return filepath.ToSlash(filepath.Clean(path)), true
+	}
+	rel, err := filepath.Rel(m.root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		// Retry with symlinks resolved (e.g. /tmp -> /private/tmp on macOS)
+		resolved, rerr := filepath.EvalSymlinks(path)
+		if rerr != nil {
+			return "", false
+		}
+		rel, err = filepath.Rel(m.root, resolved)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return "", false
+		}
+	}
+	if rel == "." {
+		return "", true
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// loadRules parses an ignore file. Missing files yield no rules.
+func loadRules(path, base string) []rule {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var rules []rule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if r, ok := parseRule(scanner.Text(), base); ok {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// parseRule compiles one line of a gitignore file.
+func parseRule(line, base string) (rule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+
+	// Trailing spaces are ignored unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	r := rule{base: base}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+
+	// A slash anywhere but the end anchors the pattern to the base directory
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return rule{}, false
+	}
+	r.re = re
+	return r, true
+}
+
+// globToRegexp translates gitignore glob syntax to a regular expression.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				atStart := i == 0 || glob[i-1] == '/'
+				atEnd := i+2 == len(glob)
+				if atStart && atEnd {
+					b.WriteString(".*")
+					i++
+					continue
+				}
+				if atStart && glob[i+2] == '/' {
+					b.WriteString("(?:.*/)?")
+					i += 2
+					continue
+				}
+			}
+			// Any other run of asterisks matches within one path segment
+			for i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// globalExcludesFile returns the user's core.excludesFile, falling back to
+// git's default location.
+func globalExcludesFile(dir string) string {
+	if path := gitOutput(dir, "config", "--path", "--get", "core.excludesFile"); path != "" {
+		return path
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "git", "ignore")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".config", "git", "ignore")
+	}
+	return ""
+}
+
+// infoExcludeFile returns the path of the repository's info/exclude file.
+func infoExcludeFile(dir string) string {
+	path := gitOutput(dir, "rev-parse", "--git-path", "info/exclude")
+	if path == "" {
+		return ""
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return path
+}
+
+// gitOutput runs a git command in dir and returns its trimmed output,
+// or "" on failure.
+func gitOutput(dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
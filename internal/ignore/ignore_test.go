@@ -0,0 +1,116 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		matches bool
+	}{
+		{"*.log", "debug.log", false, true},
+		{"*.log", "logs/debug.log", false, true},
+		{"*.log", "debug.txt", false, false},
+		{"/build", "build", true, true},
+		{"/build", "pkg/build", true, false},
+		{"build/", "build", true, true},
+		{"build/", "build", false, false},
+		{"doc/*.txt", "doc/notes.txt", false, true},
+		{"doc/*.txt", "doc/server/arch.txt", false, false},
+		{"**/foo", "a/b/foo", false, true},
+		{"**/foo", "foo", false, true},
+		{"a/**/b", "a/b", false, true},
+		{"a/**/b", "a/x/y/b", false, true},
+		{"abc/**", "abc/x/y", false, true},
+		{"file?.go", "file1.go", false, true},
+		{"file[0-9].go", "file7.go", false, true},
+		{"file[!0-9].go", "file7.go", false, false},
+		{"\\#notcomment", "#notcomment", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"_"+tt.path, func(t *testing.T) {
+			r, ok := parseRule(tt.pattern, "")
+			require.True(t, ok)
+			matched := r.re.MatchString(tt.path) && (!r.dirOnly || tt.isDir)
+			assert.Equal(t, tt.matches, matched)
+		})
+	}
+}
+
+func TestParseRuleSkipsCommentsAndBlanks(t *testing.T) {
+	for _, line := range []string{"", "   ", "# comment", "/"} {
+		_, ok := parseRule(line, "")
+		assert.False(t, ok, "line %q should be skipped", line)
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitignore"), "*.log\n/dist/\nnode_modules\n!keep.log\n")
+	writeFile(t, filepath.Join(root, "pkg", ".gitignore"), "generated.go\n")
+
+	m := &Matcher{root: root}
+	m.Reload()
+	m.global = nil // Don't depend on the user's global excludes
+
+	t.Run("matches root patterns", func(t *testing.T) {
+		assert.True(t, m.Match(filepath.Join(root, "debug.log"), false))
+		assert.True(t, m.Match(filepath.Join(root, "dist"), true))
+		assert.True(t, m.Match(filepath.Join(root, "web", "node_modules"), true))
+	})
+
+	t.Run("negation re-includes", func(t *testing.T) {
+		assert.False(t, m.Match(filepath.Join(root, "keep.log"), false))
+	})
+
+	t.Run("children of ignored directories are ignored", func(t *testing.T) {
+		assert.True(t, m.Match(filepath.Join(root, "dist", "app.js"), false))
+	})
+
+	t.Run("nested gitignore applies to its directory only", func(t *testing.T) {
+		assert.True(t, m.Match(filepath.Join(root, "pkg", "generated.go"), false))
+		assert.False(t, m.Match(filepath.Join(root, "generated.go"), false))
+	})
+
+	t.Run("build directory is not ignored without a rule", func(t *testing.T) {
+		assert.False(t, m.Match(filepath.Join(root, "build"), true))
+	})
+
+	t.Run(".git is always ignored", func(t *testing.T) {
+		assert.True(t, m.Match(filepath.Join(root, ".git"), true))
+	})
+
+	t.Run("paths outside root are not ignored", func(t *testing.T) {
+		assert.False(t, m.Match("/definitely/elsewhere.log", false))
+	})
+
+	t.Run("reload picks up edited rules", func(t *testing.T) {
+		writeFile(t, filepath.Join(root, ".gitignore"), "*.tmp\n")
+		m.Reload()
+		m.global = nil
+		assert.False(t, m.Match(filepath.Join(root, "debug.log"), false))
+		assert.True(t, m.Match(filepath.Join(root, "x.tmp"), false))
+	})
+}
+
+func TestNilMatcher(t *testing.T) {
+	var m *Matcher
+	assert.False(t, m.Match("/any/path", false))
+	assert.Empty(t, m.Root())
+	m.Reload() // Should not panic
+}
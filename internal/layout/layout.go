@@ -12,6 +12,14 @@ const (
 	MinPanelHeight          = 5
 )
 
+// Mode selects how the main area is split.
+type Mode int
+
+const (
+	ModeNormal   Mode = iota // File tree on the left, content on the right
+	ModeDualPane             // Two file trees side by side (Norton Commander style)
+)
+
 // Layout holds calculated dimensions for all panels.
 type Layout struct {
 	// Total terminal dimensions
@@ -36,18 +44,24 @@ type Layout struct {
 	// Visibility flags
 	MiniVisible     bool
 	GitPanelVisible bool
+
+	// Split mode
+	Mode Mode
 }
 
 // Calculate computes the layout dimensions based on terminal size.
 // leftPercent controls the width of the left panel (file tree).
 // gitPanelVisible controls whether the git panel splits the left panel.
-func Calculate(width, height int, miniVisible bool, leftPercent int, gitPanelVisible bool) Layout {
+// In ModeDualPane the right panel holds a second file tree and both panels
+// get half the width, whatever leftPercent says.
+func Calculate(width, height int, miniVisible bool, leftPercent int, gitPanelVisible bool, mode Mode) Layout {
 	l := Layout{
 		TotalWidth:      width,
 		TotalHeight:     height,
 		StatusHeight:    StatusBarHeight,
 		MiniVisible:     miniVisible,
 		GitPanelVisible: gitPanelVisible,
+		Mode:            mode,
 	}
 
 	// Clamp left panel percentage to valid range
@@ -57,6 +71,9 @@ func Calculate(width, height int, miniVisible bool, leftPercent int, gitPanelVis
 	if leftPercent > MaxLeftPanelPercent {
 		leftPercent = MaxLeftPanelPercent
 	}
+	if mode == ModeDualPane {
+		leftPercent = 50
+	}
 
 	// Calculate horizontal split
 	l.LeftWidth = max(width*leftPercent/100, MinPanelWidth)
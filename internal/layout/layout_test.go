@@ -61,7 +61,7 @@ func TestCalculate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			l := Calculate(tt.width, tt.height, tt.miniVisible, DefaultLeftPanelPercent, false)
+			l := Calculate(tt.width, tt.height, tt.miniVisible, DefaultLeftPanelPercent, false, ModeNormal)
 
 			assert.Equal(t, tt.width, l.TotalWidth, "TotalWidth")
 			assert.Equal(t, tt.height, l.TotalHeight, "TotalHeight")
@@ -77,7 +77,7 @@ func TestCalculate(t *testing.T) {
 }
 
 func TestLayoutBounds(t *testing.T) {
-	l := Calculate(100, 40, true, DefaultLeftPanelPercent, false)
+	l := Calculate(100, 40, true, DefaultLeftPanelPercent, false, ModeNormal)
 
 	t.Run("LeftPanelBounds", func(t *testing.T) {
 		x, y, width, height := l.LeftPanelBounds()
@@ -104,7 +104,7 @@ func TestLayoutBounds(t *testing.T) {
 	})
 
 	t.Run("MiniBufferBounds when hidden", func(t *testing.T) {
-		l2 := Calculate(100, 40, false, DefaultLeftPanelPercent, false)
+		l2 := Calculate(100, 40, false, DefaultLeftPanelPercent, false, ModeNormal)
 		x, y, width, height := l2.MiniBufferBounds()
 		assert.Equal(t, 0, x)
 		assert.Equal(t, 0, y)
@@ -114,7 +114,7 @@ func TestLayoutBounds(t *testing.T) {
 }
 
 func TestContentDimensions(t *testing.T) {
-	l := Calculate(100, 40, false, DefaultLeftPanelPercent, false)
+	l := Calculate(100, 40, false, DefaultLeftPanelPercent, false, ModeNormal)
 
 	t.Run("ContentWidth", func(t *testing.T) {
 		width := l.ContentWidth(50, 1)
@@ -134,3 +134,25 @@ func TestContentDimensions(t *testing.T) {
 		assert.Equal(t, 0, width) // max(2-4, 0) = 0
 	})
 }
+
+func TestCalculateDualPane(t *testing.T) {
+	t.Run("splits the main area in half", func(t *testing.T) {
+		l := Calculate(101, 40, false, DefaultLeftPanelPercent, false, ModeDualPane)
+		assert.Equal(t, ModeDualPane, l.Mode)
+		assert.Equal(t, 50, l.LeftWidth)
+		assert.Equal(t, 51, l.RightWidth)
+		assert.Equal(t, 39, l.MainHeight)
+	})
+
+	t.Run("ignores the saved tree width", func(t *testing.T) {
+		l := Calculate(100, 40, false, MaxLeftPanelPercent, false, ModeDualPane)
+		assert.Equal(t, 50, l.LeftWidth)
+	})
+
+	t.Run("keeps the git panel in the left column", func(t *testing.T) {
+		l := Calculate(100, 40, false, DefaultLeftPanelPercent, true, ModeDualPane)
+		assert.Greater(t, l.GitPanelHeight, 0)
+		_, _, _, rightHeight := l.RightPanelBounds()
+		assert.Equal(t, l.MainHeight, rightHeight)
+	})
+}
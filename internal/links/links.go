@@ -0,0 +1,268 @@
+// Package links finds file:line references and OSC 8 hyperlinks in terminal
+// output, so they can be underlined under the mouse, clicked, or picked by
+// label from the keyboard.
+package links
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/avitaltamir/vibecommander/internal/theme"
+	"github.com/avitaltamir/vibecommander/internal/vt"
+)
+
+// hintKeys are the labels of the links in hint mode, easiest first.
+const hintKeys = "asdfghjklqwertyuiopzxcvbnm"
+
+// fileRef matches path:line and path:line:col, as printed by compilers,
+// linters, test runners and stack traces.
+var fileRef = regexp.MustCompile(`[\w./+@~-]*[\w+@~-]:(\d+)(?::\d+)?`)
+
+// OpenMsg asks for a link to be opened: a file at a line, or a URL that isn't
+// a file.
+type OpenMsg struct {
+	Path string
+	Line int // 0-indexed line to scroll to
+	URL  string
+}
+
+// Link is a reference found in a line of output.
+type Link struct {
+	Row        int // Line it's on
+	Start, End int // Cells it covers, end exclusive
+	Text       string
+	Path       string // Absolute path of the file, if it's one
+	Line       int    // 0-indexed line in the file
+	URL        string // Target of a hyperlink that isn't a file
+}
+
+// Open returns a command asking for the link to be opened.
+func (l Link) Open() tea.Cmd {
+	msg := OpenMsg{Path: l.Path, Line: l.Line, URL: l.URL}
+	return func() tea.Msg {
+		return msg
+	}
+}
+
+// Line is a line of output as plain text, with the OSC 8 hyperlinks the
+// terminal saw in it.
+type Line struct {
+	Text       string
+	Hyperlinks []vt.Hyperlink
+}
+
+// Visible returns the lines of t shown when scrolled back offset lines.
+func Visible(t *vt.Terminal, offset int) []Line {
+	if t == nil {
+		return nil
+	}
+	top := t.Top(offset)
+	_, rows := t.Size()
+	lines := make([]Line, rows)
+	for i := range lines {
+		lines[i] = Line{Text: t.Text(top + i), Hyperlinks: t.Hyperlinks(top + i)}
+	}
+	return lines
+}
+
+// Model holds the links state for a terminal: the link under the mouse and
+// the labels shown in hint mode.
+type Model struct {
+	Dir string // Directory relative paths are resolved against; the working directory if empty
+
+	hover    Link
+	hovering bool
+	hints    []Link // Labelled links while in hint mode
+}
+
+// New creates a new links model.
+func New() Model {
+	return Model{}
+}
+
+// Find returns the links in lines: hyperlinks first, then references to
+// files that exist.
+func (m Model) Find(lines []Line) []Link {
+	var links []Link
+	for row, line := range lines {
+		links = append(links, m.findInLine(row, line)...)
+	}
+	return links
+}
+
+// findInLine returns the links in one line.
+func (m Model) findInLine(row int, line Line) []Link {
+	var links []Link
+	overlaps := func(start, end int) bool {
+		for _, l := range links {
+			if start < l.End && l.Start < end {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, h := range line.Hyperlinks {
+		text := ansi.Cut(line.Text, h.Start, h.End)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if link, ok := m.hyperlinkTarget(h.URI, text); ok {
+			link.Row, link.Start, link.End, link.Text = row, h.Start, h.End, text
+			links = append(links, link)
+		}
+	}
+
+	for _, loc := range fileRef.FindAllStringSubmatchIndex(line.Text, -1) {
+		start, end := ansi.StringWidth(line.Text[:loc[0]]), ansi.StringWidth(line.Text[:loc[1]])
+		if overlaps(start, end) {
+			continue
+		}
+		text := line.Text[loc[0]:loc[1]]
+		path := m.resolve(text[:strings.IndexByte(text, ':')])
+		if path == "" {
+			continue
+		}
+		n, _ := strconv.Atoi(line.Text[loc[2]:loc[3]])
+		links = append(links, Link{Row: row, Start: start, End: end, Text: text, Path: path, Line: max(n-1, 0)})
+	}
+	return links
+}
+
+// hyperlinkTarget works out what a hyperlink opens: a file:// URI opens the
+// file, at the line in its fragment or its text; anything else is a URL.
+func (m Model) hyperlinkTarget(uri, text string) (Link, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return Link{URL: uri}, true
+	}
+	path := m.resolve(u.Path)
+	if path == "" {
+		return Link{}, false
+	}
+
+	n, _ := strconv.Atoi(strings.TrimPrefix(u.Fragment, "L"))
+	if loc := fileRef.FindStringSubmatch(text); n == 0 && loc != nil {
+		n, _ = strconv.Atoi(loc[1])
+	}
+	return Link{Path: path, Line: max(n-1, 0)}, true
+}
+
+// resolve returns the absolute path of a file, or "" if there's no such file.
+func (m Model) resolve(path string) string {
+	if path == "" {
+		return ""
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.Dir, path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	if info, err := os.Stat(abs); err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	return abs
+}
+
+// At returns the link at a row and column of lines.
+func (m Model) At(lines []Line, row, col int) (Link, bool) {
+	if row < 0 || row >= len(lines) {
+		return Link{}, false
+	}
+	for _, l := range m.findInLine(row, lines[row]) {
+		if col >= l.Start && col < l.End {
+			return l, true
+		}
+	}
+	return Link{}, false
+}
+
+// Hover underlines the link at a row and column of lines, if any. It reports
+// whether that changed what's underlined.
+func (m *Model) Hover(lines []Line, row, col int) bool {
+	link, ok := m.At(lines, row, col)
+	if ok == m.hovering && link == m.hover {
+		return false
+	}
+	m.hover, m.hovering = link, ok
+	return true
+}
+
+// ClearHover stops underlining, reporting whether anything was.
+func (m *Model) ClearHover() bool {
+	if !m.hovering {
+		return false
+	}
+	m.hover, m.hovering = Link{}, false
+	return true
+}
+
+// StartHints labels the links in lines, nearest the bottom first. It reports
+// whether there were any.
+func (m *Model) StartHints(lines []Line) bool {
+	found := m.Find(lines)
+	m.hints = nil
+	for i := len(found) - 1; i >= 0 && len(m.hints) < len(hintKeys); i-- {
+		m.hints = append(m.hints, found[i])
+	}
+	return len(m.hints) > 0
+}
+
+// Hinting reports whether links are labelled.
+func (m Model) Hinting() bool {
+	return len(m.hints) > 0
+}
+
+// HintKey ends hint mode, returning the link labelled key if there is one.
+func (m *Model) HintKey(key string) (Link, bool) {
+	hints := m.hints
+	m.hints = nil
+	if i := strings.Index(hintKeys, key); len(key) == 1 && i >= 0 && i < len(hints) {
+		return hints[i], true
+	}
+	return Link{}, false
+}
+
+// Active reports whether Decorate has anything to draw.
+func (m Model) Active() bool {
+	return m.hovering || len(m.hints) > 0
+}
+
+// Decorate draws the links state over rendered lines: the link under the
+// mouse is underlined, and in hint mode each link gets its label. Links whose
+// text has since changed are left alone.
+func (m Model) Decorate(lines []string) {
+	if m.hovering {
+		overlay(lines, m.hover, lipgloss.NewStyle().Underline(true).Foreground(theme.CyberCyan).Render(m.hover.Text))
+	}
+	label := lipgloss.NewStyle().Background(theme.ElectricYellow).Foreground(lipgloss.Color("0")).Bold(true)
+	rest := lipgloss.NewStyle().Underline(true)
+	for i, l := range m.hints {
+		_, size := utf8.DecodeRuneInString(l.Text)
+		overlay(lines, l, label.Render(hintKeys[i:i+1])+rest.Render(l.Text[size:]))
+	}
+}
+
+// overlay replaces the cells of a link with rendered, provided they still
+// hold the link's text.
+func overlay(lines []string, l Link, rendered string) {
+	if l.Row < 0 || l.Row >= len(lines) {
+		return
+	}
+	line := lines[l.Row]
+	if ansi.Strip(ansi.Cut(line, l.Start, l.End)) != l.Text {
+		return
+	}
+	lines[l.Row] = ansi.Cut(line, 0, l.Start) + rendered + ansi.Cut(line, l.End, ansi.StringWidth(line))
+}
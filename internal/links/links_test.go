@@ -0,0 +1,110 @@
+package links
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/avitaltamir/vibecommander/internal/vt"
+)
+
+// plain returns lines without hyperlinks.
+func plain(texts ...string) []Line {
+	lines := make([]Line, len(texts))
+	for i, text := range texts {
+		lines[i] = Line{Text: text}
+	}
+	return lines
+}
+
+// newTestModel returns a model resolving paths in a directory holding
+// internal/app/app.go.
+func newTestModel(t *testing.T) (Model, string) {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "internal", "app"), 0o755))
+	path := filepath.Join(dir, "internal", "app", "app.go")
+	require.NoError(t, os.WriteFile(path, []byte("package app\n"), 0o644))
+	m := New()
+	m.Dir = dir
+	return m, path
+}
+
+func TestFindFileReferences(t *testing.T) {
+	m, path := newTestModel(t)
+
+	found := m.Find(plain(
+		"ok",
+		"internal/app/app.go:317:5: undefined: foo",
+		"missing.go:12 and 12:30:45 and localhost:8080",
+		"\t"+path+":3 +0x1d",
+		"internal/app:4", // A directory
+	))
+	require.Len(t, found, 2)
+
+	assert.Equal(t, Link{Row: 1, Start: 0, End: 25, Text: "internal/app/app.go:317:5", Path: path, Line: 316}, found[0])
+	assert.Equal(t, 3, found[1].Row)
+	assert.Equal(t, path, found[1].Path)
+	assert.Equal(t, 2, found[1].Line)
+}
+
+func TestHyperlinks(t *testing.T) {
+	m, path := newTestModel(t)
+
+	term := vt.New(40, 1)
+	term.Write([]byte("see \x1b]8;;file://" + path + "#L7\x1b\\the \x1b[1mfile\x1b[0m\x1b]8;;\x1b\\ or \x1b]8;id=1;https://example.com\adocs\x1b]8;;\a"))
+
+	found := m.Find([]Line{{Text: term.Text(0), Hyperlinks: term.Hyperlinks(0)}})
+	require.Len(t, found, 2)
+	assert.Equal(t, Link{Start: 4, End: 12, Text: "the file", Path: path, Line: 6}, found[0])
+	assert.Equal(t, Link{Start: 16, End: 20, Text: "docs", URL: "https://example.com"}, found[1])
+
+	// A file:// link to a file that isn't there is left alone
+	found = m.Find([]Line{{Text: "gone", Hyperlinks: []vt.Hyperlink{{Start: 0, End: 4, URI: "file:///nowhere/gone.go"}}}})
+	assert.Empty(t, found)
+}
+
+func TestHoverAndHints(t *testing.T) {
+	m, path := newTestModel(t)
+	texts := []string{"internal/app/app.go:1", "nothing", "x internal/app/app.go:2"}
+	lines := plain(texts...)
+
+	assert.False(t, m.Hover(lines, 1, 0))
+	assert.True(t, m.Hover(lines, 0, 5))
+	assert.False(t, m.Hover(lines, 0, 6), "still the same link")
+	rendered := append([]string(nil), texts...)
+	m.Decorate(rendered)
+	assert.NotEqual(t, texts[0], rendered[0])
+	assert.Equal(t, texts[0], ansi.Strip(rendered[0]))
+	assert.True(t, m.ClearHover())
+
+	// Labelled from the bottom up; the label covers the first cell
+	require.True(t, m.StartHints(lines))
+	rendered = append([]string(nil), texts...)
+	m.Decorate(rendered)
+	assert.True(t, strings.HasPrefix(ansi.Strip(rendered[2]), "x anternal"))
+	assert.True(t, strings.HasPrefix(ansi.Strip(rendered[0]), "snternal"))
+
+	link, ok := m.HintKey("s")
+	require.True(t, ok)
+	assert.Equal(t, path, link.Path)
+	assert.Equal(t, 0, link.Line)
+	assert.False(t, m.Hinting())
+
+	require.True(t, m.StartHints(lines))
+	_, ok = m.HintKey("esc")
+	assert.False(t, ok)
+	assert.False(t, m.Hinting())
+
+	assert.False(t, m.StartHints(plain("nothing")))
+}
+
+func TestOpen(t *testing.T) {
+	msg := Link{Path: "/a.go", Line: 4}.Open()()
+	assert.Equal(t, OpenMsg{Path: "/a.go", Line: 4}, msg)
+}
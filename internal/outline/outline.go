@@ -0,0 +1,478 @@
+// Package outline lists the symbols of a source file (functions, types,
+// headings) and the regions of it that can be folded.
+package outline
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// Kind is what a symbol declares.
+type Kind int
+
+const (
+	Func Kind = iota
+	Method
+	Type
+	Class
+	Heading
+)
+
+// String returns the kind as shown in lists.
+func (k Kind) String() string {
+	switch k {
+	case Func:
+		return "func"
+	case Method:
+		return "method"
+	case Type:
+		return "type"
+	case Class:
+		return "class"
+	case Heading:
+		return "heading"
+	default:
+		return "symbol"
+	}
+}
+
+// Symbol is a declaration or heading in a file.
+type Symbol struct {
+	Name  string
+	Kind  Kind
+	Line  int // 0-indexed
+	End   int // Last line of its body, inclusive
+	Depth int // Nesting: methods in a class, or a heading's level - 1
+}
+
+// Region is a range of lines that can be folded. Start stays visible.
+type Region struct {
+	Start, End int // 0-indexed, inclusive
+}
+
+// Symbols lists the symbols of a file in source order. Go files are parsed,
+// Markdown headings are read, and other languages are matched line by line,
+// falling back to the names chroma's lexer marks as declared.
+func Symbols(path, content string) []Symbol {
+	lines := strings.Split(content, "\n")
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case ext == ".go":
+		if syms, ok := goSymbols(content); ok {
+			return syms
+		}
+	case isMarkdown(ext):
+		return headings(lines)
+	}
+
+	var syms []Symbol
+	if patterns, ok := languages[ext]; ok {
+		syms = matchSymbols(lines, patterns)
+	} else {
+		syms = lexerSymbols(path, content)
+	}
+
+	ends := blockEnds(lines)
+	for i := range syms {
+		syms[i].End = symbolEnd(lines, ends, syms[i].Line)
+	}
+	nest(syms)
+	return syms
+}
+
+// Regions lists the foldable regions of a file: heading sections in
+// Markdown, indented blocks elsewhere.
+func Regions(path, content string) []Region {
+	lines := strings.Split(content, "\n")
+	if isMarkdown(strings.ToLower(filepath.Ext(path))) {
+		var regions []Region
+		for _, h := range headings(lines) {
+			if h.End > h.Line {
+				regions = append(regions, Region{Start: h.Line, End: h.End})
+			}
+		}
+		return regions
+	}
+
+	ends := blockEnds(lines)
+	regions := make([]Region, 0, len(ends))
+	for start := range ends {
+		regions = append(regions, Region{Start: start, End: chainEnd(ends, start)})
+	}
+	sort.Slice(regions, func(i, j int) bool { return regions[i].Start < regions[j].Start })
+	return regions
+}
+
+// isMarkdown reports whether ext is a Markdown extension.
+func isMarkdown(ext string) bool {
+	switch ext {
+	case ".md", ".markdown", ".mdown", ".mkd", ".mdx":
+		return true
+	}
+	return false
+}
+
+// goSymbols lists the functions, methods and types of a Go file. A file with
+// syntax errors still yields what parsed before them.
+func goSymbols(content string) ([]Symbol, bool) {
+	fset := token.NewFileSet()
+	f, _ := parser.ParseFile(fset, "", content, parser.SkipObjectResolution)
+	if f == nil {
+		return nil, false
+	}
+	line := func(p token.Pos) int { return fset.Position(p).Line - 1 }
+
+	var syms []Symbol
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			sym := Symbol{Name: d.Name.Name, Kind: Func, Line: line(d.Pos()), End: line(d.End())}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				sym.Kind = Method
+				sym.Name = receiverName(d.Recv.List[0].Type) + "." + d.Name.Name
+			}
+			syms = append(syms, sym)
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				syms = append(syms, Symbol{Name: ts.Name.Name, Kind: Type, Line: line(ts.Pos()), End: line(ts.End())})
+			}
+		}
+	}
+	return syms, true
+}
+
+// receiverName returns the type name of a method receiver, e.g. Model for
+// (m *Model) or List for (l List[T]).
+func receiverName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(e.X)
+	case *ast.IndexExpr:
+		return receiverName(e.X)
+	case *ast.IndexListExpr:
+		return receiverName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return "?"
+}
+
+var (
+	atxHeading = regexp.MustCompile(`^ {0,3}(#{1,6})\s+(.*?)\s*#*\s*$`)
+	fence      = regexp.MustCompile("^ {0,3}(```|~~~)")
+	setextOne  = regexp.MustCompile(`^ {0,3}=+\s*$`)
+	setextTwo  = regexp.MustCompile(`^ {0,3}-+\s*$`)
+)
+
+// headings lists Markdown headings outside code blocks. Each one's section
+// runs until the next heading of the same or a higher level.
+func headings(lines []string) []Symbol {
+	var syms []Symbol
+	var levels []int
+	inFence := ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if m := fence.FindStringSubmatch(line); m != nil {
+			switch {
+			case inFence == "":
+				inFence = m[1]
+			case inFence == m[1]:
+				inFence = ""
+			}
+			continue
+		}
+		if inFence != "" {
+			continue
+		}
+
+		level, name := 0, ""
+		if m := atxHeading.FindStringSubmatch(line); m != nil {
+			level, name = len(m[1]), m[2]
+		} else if i+1 < len(lines) && strings.TrimSpace(line) != "" && !strings.HasPrefix(strings.TrimSpace(line), "-") {
+			if setextOne.MatchString(lines[i+1]) {
+				level, name = 1, strings.TrimSpace(line)
+			} else if setextTwo.MatchString(lines[i+1]) {
+				level, name = 2, strings.TrimSpace(line)
+			}
+		}
+		if level == 0 {
+			continue
+		}
+		syms = append(syms, Symbol{Name: name, Kind: Heading, Line: i, Depth: level - 1})
+		levels = append(levels, level)
+	}
+
+	for i := range syms {
+		end := len(lines) - 1
+		for j := i + 1; j < len(syms); j++ {
+			if levels[j] <= levels[i] {
+				end = syms[j].Line - 1
+				break
+			}
+		}
+		for end > syms[i].Line && strings.TrimSpace(lines[end]) == "" {
+			end--
+		}
+		syms[i].End = end
+	}
+	return syms
+}
+
+// pattern matches a declaration line. The last group is the name.
+type pattern struct {
+	re   *regexp.Regexp
+	kind Kind
+}
+
+func p(kind Kind, re string) pattern {
+	return pattern{re: regexp.MustCompile(re), kind: kind}
+}
+
+var (
+	pythonPatterns = []pattern{
+		p(Class, `^\s*class\s+(\w+)`),
+		p(Func, `^\s*(?:async\s+)?def\s+(\w+)`),
+	}
+	jsPatterns = []pattern{
+		p(Class, `^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(\w+)`),
+		p(Type, `^\s*(?:export\s+)?(?:declare\s+)?(?:interface|type|enum)\s+(\w+)`),
+		p(Func, `^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(\w+)`),
+		p(Func, `^\s*(?:export\s+)?(?:const|let|var)\s+(\w+)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*(?::[^=]+)?=>|\w+\s*=>)`),
+		p(Method, `^\s+(?:(?:public|private|protected|static|async|get|set|readonly|override)\s+)*(\w+)\s*\([^)]*\)\s*(?::\s*[^{]+)?\{\s*$`),
+	}
+	rustPatterns = []pattern{
+		p(Type, `^\s*(?:pub(?:\([^)]*\))?\s+)?(?:struct|enum|trait|union|type)\s+(\w+)`),
+		p(Class, `^\s*impl(?:<[^>]*>)?\s+(?:[\w:<>, ]+\s+for\s+)?([\w:]+)`),
+		p(Func, `^\s*(?:pub(?:\([^)]*\))?\s+)?(?:(?:const|async|unsafe|extern(?:\s+"\w+")?)\s+)*fn\s+(\w+)`),
+	}
+	rubyPatterns = []pattern{
+		p(Class, `^\s*(?:class|module)\s+([\w:]+)`),
+		p(Func, `^\s*def\s+(?:self\.)?(\w+[?!=]?)`),
+	}
+	shellPatterns = []pattern{
+		p(Func, `^\s*function\s+([\w-]+)`),
+		p(Func, `^\s*([\w-]+)\s*\(\)\s*\{?`),
+	}
+	luaPatterns = []pattern{
+		p(Func, `^\s*(?:local\s+)?function\s+([\w.:]+)`),
+	}
+	cPatterns = []pattern{
+		p(Class, `^\s*(?:[\w@]+\s+)*(?:class|interface|enum|struct|record|object|protocol|trait|namespace)\s+(\w+)`),
+		p(Func, `^\s*(?:[\w@]+\s+)*(?:fun|func|def|function)\s+(?:<[^>]*>\s*)?(\w+)`),
+		p(Func, `^\s*(?:[\w<>\[\],.*&:~]+\s+)+[*&]*(~?\w+)\s*\([^;=]*$`),
+	}
+)
+
+// languages maps file extensions to their declaration patterns.
+var languages = map[string][]pattern{
+	".py": pythonPatterns, ".pyi": pythonPatterns,
+	".js": jsPatterns, ".jsx": jsPatterns, ".mjs": jsPatterns, ".cjs": jsPatterns,
+	".ts": jsPatterns, ".tsx": jsPatterns,
+	".rs": rustPatterns,
+	".rb": rubyPatterns,
+	".sh": shellPatterns, ".bash": shellPatterns, ".zsh": shellPatterns,
+	".lua": luaPatterns,
+	".c":   cPatterns, ".h": cPatterns, ".cc": cPatterns, ".cpp": cPatterns, ".cxx": cPatterns, ".hpp": cPatterns,
+	".java": cPatterns, ".kt": cPatterns, ".kts": cPatterns, ".cs": cPatterns, ".scala": cPatterns,
+	".swift": cPatterns, ".m": cPatterns, ".php": cPatterns,
+}
+
+// statementWords start lines that look like declarations but aren't.
+var statementWords = map[string]bool{
+	"if": true, "else": true, "for": true, "while": true, "switch": true, "case": true,
+	"return": true, "new": true, "throw": true, "catch": true, "delete": true, "await": true,
+	"yield": true, "goto": true, "sizeof": true, "do": true, "try": true, "with": true, "typeof": true,
+}
+
+// matchSymbols finds declaration lines by pattern.
+func matchSymbols(lines []string, patterns []pattern) []Symbol {
+	var syms []Symbol
+	for i, line := range lines {
+		first := strings.Fields(line)
+		if len(first) == 0 || statementWords[strings.TrimRight(first[0], "({")] {
+			continue
+		}
+		for _, pat := range patterns {
+			m := pat.re.FindStringSubmatch(line)
+			if m == nil || statementWords[m[len(m)-1]] {
+				continue
+			}
+			syms = append(syms, Symbol{Name: m[len(m)-1], Kind: pat.kind, Line: i})
+			break
+		}
+	}
+	return syms
+}
+
+// lexerSymbols lists the function and class names chroma's lexer for path
+// marks right after a keyword, as in "def name" or "class Name".
+func lexerSymbols(path, content string) []Symbol {
+	lexer := lexers.Match(filepath.Base(path))
+	if lexer == nil {
+		return nil
+	}
+	it, err := chroma.Coalesce(lexer).Tokenise(nil, content)
+	if err != nil {
+		return nil
+	}
+
+	var syms []Symbol
+	line := 0
+	afterKeyword := false
+	for tok := it(); tok != chroma.EOF; tok = it() {
+		switch {
+		case tok.Type == chroma.NameFunction && afterKeyword:
+			syms = append(syms, Symbol{Name: tok.Value, Kind: Func, Line: line})
+		case tok.Type == chroma.NameClass && afterKeyword:
+			syms = append(syms, Symbol{Name: tok.Value, Kind: Class, Line: line})
+		}
+		if strings.TrimSpace(tok.Value) != "" {
+			afterKeyword = tok.Type.InCategory(chroma.Keyword)
+		}
+		line += strings.Count(tok.Value, "\n")
+	}
+	return syms
+}
+
+// blockEnds finds indented blocks: a line followed by more indented ones.
+// It maps the first line of each block to its last, which includes a
+// closing line (}, end, ...) at the first line's indentation.
+func blockEnds(lines []string) map[int]int {
+	type open struct{ line, indent int }
+	var stack []open
+	ends := make(map[int]int)
+	last := -1 // Last non-blank line
+
+	closeTo := func(indent int, closer bool, at int) {
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			o := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			end := last
+			if closer && o.indent == indent {
+				end = at
+			}
+			if end > o.line {
+				ends[o.line] = end
+			}
+		}
+	}
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := indentWidth(line)
+		closeTo(indent, isCloser(line), i)
+		stack = append(stack, open{line: i, indent: indent})
+		last = i
+	}
+	closeTo(0, false, len(lines))
+	return ends
+}
+
+// chainEnd follows a block whose last line opens another, as in "} else {"
+// or a signature split over lines, to the end of the whole construct.
+func chainEnd(ends map[int]int, start int) int {
+	end := ends[start]
+	for {
+		next, ok := ends[end]
+		if !ok || next <= end {
+			return end
+		}
+		end = next
+	}
+}
+
+// symbolEnd returns the last line of the symbol declared on line: the end of
+// its block, or of a block opened by a brace on the next line.
+func symbolEnd(lines []string, ends map[int]int, line int) int {
+	if _, ok := ends[line]; ok {
+		return chainEnd(ends, line)
+	}
+	for next := line + 1; next < len(lines); next++ {
+		text := strings.TrimSpace(lines[next])
+		if text == "" {
+			continue
+		}
+		if text == "{" {
+			if _, ok := ends[next]; ok {
+				return chainEnd(ends, next)
+			}
+		}
+		break
+	}
+	return line
+}
+
+// nest sets each symbol's depth from the symbols whose bodies contain it.
+// Functions inside a class or impl become methods.
+func nest(syms []Symbol) {
+	var stack []Symbol
+	for i := range syms {
+		for len(stack) > 0 && stack[len(stack)-1].End < syms[i].Line {
+			stack = stack[:len(stack)-1]
+		}
+		syms[i].Depth = len(stack)
+		if len(stack) > 0 && syms[i].Kind == Func {
+			if k := stack[len(stack)-1].Kind; k == Class || k == Type {
+				syms[i].Kind = Method
+			}
+		}
+		stack = append(stack, syms[i])
+	}
+}
+
+// indentWidth returns the width of line's leading whitespace, a tab counting
+// as four.
+func indentWidth(line string) int {
+	w := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			w++
+		case '\t':
+			w += 4
+		default:
+			return w
+		}
+	}
+	return w
+}
+
+// isCloser reports whether line closes a block, as with } or end.
+func isCloser(line string) bool {
+	text := strings.TrimSpace(line)
+	if text == "" {
+		return false
+	}
+	switch text[0] {
+	case '}', ']', ')':
+		return true
+	}
+	word := strings.FieldsFunc(text, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z')
+	})
+	if len(word) == 0 || !strings.HasPrefix(text, word[0]) {
+		return false
+	}
+	switch word[0] {
+	case "end", "fi", "done", "esac", "endif", "endfor", "endwhile", "endfunction":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package outline
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// names returns each symbol as "kind name line-end", indented by depth.
+func names(syms []Symbol) []string {
+	var out []string
+	for _, s := range syms {
+		out = append(out, strings.Repeat("  ", s.Depth)+s.Kind.String()+" "+s.Name+" "+strconv.Itoa(s.Line)+"-"+strconv.Itoa(s.End))
+	}
+	return out
+}
+
+func TestSymbolsGo(t *testing.T) {
+	src := `package demo
+
+// Model holds state.
+type Model struct {
+	n int
+}
+
+type (
+	ID   int
+	List[T any] []T
+)
+
+func New() Model {
+	return Model{}
+}
+
+func (m *Model) Inc() { m.n++ }
+
+func (l List[T]) Len() int {
+	return len(l)
+}
+`
+	assert.Equal(t, []string{
+		"type Model 3-5",
+		"type ID 8-8",
+		"type List 9-9",
+		"func New 12-14",
+		"method Model.Inc 16-16",
+		"method List.Len 18-20",
+	}, names(Symbols("demo.go", src)))
+
+	// What parsed before a syntax error is still listed
+	broken := "package demo\n\nfunc A() {}\n\nfunc B( {\n"
+	assert.Equal(t, []string{"func A 2-2"}, names(Symbols("demo.go", broken))[:1])
+}
+
+func TestSymbolsMarkdown(t *testing.T) {
+	src := "# Title\n\nintro\n\n## Install\n\n```sh\n# not a heading\n```\n\nSetext\n------\n\ntext\n\n# Second\n"
+	assert.Equal(t, []string{
+		"heading Title 0-13",
+		"  heading Install 4-8",
+		"  heading Setext 10-13",
+		"heading Second 15-15",
+	}, names(Symbols("README.md", src)))
+}
+
+func TestSymbolsPython(t *testing.T) {
+	src := `import os
+
+class Greeter:
+    def __init__(self, name):
+        self.name = name
+
+    async def greet(self):
+        if self.name:
+            print(self.name)
+
+def main():
+    Greeter("x")
+`
+	assert.Equal(t, []string{
+		"class Greeter 2-8",
+		"  method __init__ 3-4",
+		"  method greet 6-8",
+		"func main 10-11",
+	}, names(Symbols("app.py", src)))
+}
+
+func TestSymbolsBraces(t *testing.T) {
+	src := `export class Store {
+  get(key: string): string {
+    if (key) {
+      return key;
+    }
+    return "";
+  }
+}
+
+export const handler = async (req) => {
+  return req;
+};
+
+function split(
+  a,
+  b,
+) {
+  return a + b;
+}
+`
+	assert.Equal(t, []string{
+		"class Store 0-7",
+		"  method get 1-6",
+		"func handler 9-11",
+		"func split 13-18",
+	}, names(Symbols("store.ts", src)))
+
+	c := "int\nmain(void)\n{\n    return 0;\n}\n\nstatic void helper(int x) {\n    if (x) {\n        return;\n    }\n}\n"
+	assert.Equal(t, []string{"func helper 6-10"}, names(Symbols("main.c", c)))
+}
+
+func TestSymbolsLexerFallback(t *testing.T) {
+	src := "const std = @import(\"std\");\n\npub fn main() void {\n    std.debug.print(\"hi\", .{});\n}\n"
+	assert.Equal(t, []string{"func main 2-4"}, names(Symbols("main.zig", src)))
+}
+
+func TestRegions(t *testing.T) {
+	src := `func a() {
+	if x {
+		y()
+	} else {
+		z()
+	}
+}
+
+func b() {}
+`
+	assert.Equal(t, []Region{{0, 6}, {1, 5}, {3, 5}}, Regions("a.go", src))
+
+	md := "# A\n\ntext\n\n## B\n\nmore\n"
+	assert.Equal(t, []Region{{0, 6}, {4, 6}}, Regions("a.md", md))
+}
@@ -65,6 +65,21 @@ func (m *Model) EndSelection() {
 	m.Selection.Active = false
 }
 
+// SetRange sets a completed selection from anchor to end, as when extending
+// a selection from the keyboard.
+func (m *Model) SetRange(anchor, end Position) {
+	m.Selection = Selection{
+		Start:    anchor,
+		End:      end,
+		Complete: true,
+	}
+}
+
+// Range returns the selected range with start before end.
+func (m Model) Range() (Position, Position) {
+	return m.normalizeRange()
+}
+
 // ClearSelection clears any active selection.
 func (m *Model) ClearSelection() {
 	m.Selection = Selection{}
@@ -209,3 +209,19 @@ func TestClamp(t *testing.T) {
 	assert.Equal(t, 0, clamp(0, 0, 10))
 	assert.Equal(t, 10, clamp(10, 0, 10))
 }
+
+func TestSetRange(t *testing.T) {
+	m := New()
+	m.SetRange(Position{Line: 3, Column: 4}, Position{Line: 1, Column: 2})
+
+	assert.True(t, m.HasSelection())
+	start, end := m.Range()
+	assert.Equal(t, Position{Line: 1, Column: 2}, start)
+	assert.Equal(t, Position{Line: 3, Column: 4}, end)
+
+	// Anchor is kept as the start so the selection can be extended
+	assert.Equal(t, Position{Line: 3, Column: 4}, m.Selection.Start)
+
+	m.SetRange(Position{Line: 1, Column: 2}, Position{Line: 1, Column: 2})
+	assert.False(t, m.HasSelection())
+}
@@ -2,14 +2,17 @@ package state
 
 import (
 	"encoding/json"
+	"fmt"
+	"hash/fnv"
 	"os"
 	"path/filepath"
 )
 
 const (
-	configDirName = ".config"
-	appDirName    = "vibecommander"
-	stateFileName = "state.json"
+	configDirName     = ".config"
+	appDirName        = "vibecommander"
+	stateFileName     = "state.json"
+	recordingsDirName = "recordings"
 )
 
 // State represents the persisted application state.
@@ -22,10 +25,30 @@ type State struct {
 	LeftPanelPercent int `json:"left_panel_percent,omitempty"`
 	// CompactIndent indicates if the file tree uses compact (2-space) indentation
 	CompactIndent bool `json:"compact_indent,omitempty"`
+	// IgnoredMode is how git-ignored files are shown (0 = dimmed, 1 = hidden, 2 = shown)
+	IgnoredMode int `json:"ignored_mode,omitempty"`
+	// SyntaxStyle is the chroma style code is highlighted with, or empty to
+	// derive it from the theme
+	SyntaxStyle string `json:"syntax_style,omitempty"`
 	// AICommand is the CLI command for the AI assistant (e.g., "claude", "gemini")
 	AICommand string `json:"ai_command,omitempty"`
 	// AIArgs are additional arguments for the AI command
 	AIArgs []string `json:"ai_args,omitempty"`
+	// RecordAI indicates if AI sessions are recorded as asciicast files
+	RecordAI bool `json:"record_ai,omitempty"`
+	// Projects holds per-project state, keyed by the project's absolute path
+	Projects map[string]Project `json:"projects,omitempty"`
+}
+
+// Project is the state remembered for a single project directory.
+type Project struct {
+	// Bookmarks are bookmarked files and directories (absolute paths)
+	Bookmarks []string `json:"bookmarks,omitempty"`
+	// Recent are recently opened files, newest first (absolute paths)
+	Recent []string `json:"recent,omitempty"`
+	// Reviewed are the files marked reviewed, relative to the project, with
+	// a fingerprint of each so a change unmarks it
+	Reviewed map[string]string `json:"reviewed,omitempty"`
 }
 
 // DefaultState returns the default state for first run.
@@ -54,6 +77,21 @@ func statePath() (string, error) {
 	return filepath.Join(dir, stateFileName), nil
 }
 
+// RecordingsDir returns the directory a project's AI sessions are recorded
+// in: ~/.config/vibecommander/recordings/<name>-<hash>, named after the
+// project directory and told apart from others of the same name by a hash
+// of its path.
+func RecordingsDir(project string) (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	h := fnv.New32a()
+	h.Write([]byte(project))
+	name := fmt.Sprintf("%s-%08x", filepath.Base(project), h.Sum32())
+	return filepath.Join(dir, recordingsDirName, name), nil
+}
+
 // Load reads the global application state.
 // Returns default state if file doesn't exist or can't be read.
 func Load() State {
@@ -34,6 +34,19 @@ func TestStatePath(t *testing.T) {
 	assert.Equal(t, expected, path)
 }
 
+func TestRecordingsDir(t *testing.T) {
+	dir, err := RecordingsDir("/home/me/src/app")
+	assert.NoError(t, err)
+
+	home, _ := os.UserHomeDir()
+	assert.Equal(t, filepath.Join(home, ".config", "vibecommander", "recordings"), filepath.Dir(dir))
+	assert.Regexp(t, `^app-[0-9a-f]{8}$`, filepath.Base(dir))
+
+	other, err := RecordingsDir("/home/me/work/app")
+	assert.NoError(t, err)
+	assert.NotEqual(t, dir, other, "projects with the same name are kept apart")
+}
+
 func TestStateAIFields(t *testing.T) {
 	t.Run("DefaultState has empty AI fields", func(t *testing.T) {
 		s := DefaultState()
@@ -71,6 +84,7 @@ func TestSaveAndLoadState(t *testing.T) {
 			ThemeIndex:       2,
 			LeftPanelPercent: 30,
 			CompactIndent:    true,
+			IgnoredMode:      1,
 			AICommand:        "gemini",
 			AIArgs:           []string{"--model", "pro"},
 		}
@@ -87,6 +101,7 @@ func TestSaveAndLoadState(t *testing.T) {
 		assert.Equal(t, original.ThemeIndex, loaded.ThemeIndex)
 		assert.Equal(t, original.LeftPanelPercent, loaded.LeftPanelPercent)
 		assert.Equal(t, original.CompactIndent, loaded.CompactIndent)
+		assert.Equal(t, original.IgnoredMode, loaded.IgnoredMode)
 		assert.Equal(t, original.AICommand, loaded.AICommand)
 		assert.Equal(t, original.AIArgs, loaded.AIArgs)
 	})
@@ -132,3 +147,27 @@ func TestSaveAndLoadState(t *testing.T) {
 		assert.Equal(t, original.AIArgs, loaded.AIArgs)
 	})
 }
+
+func TestStateProjects(t *testing.T) {
+	original := State{
+		Projects: map[string]Project{
+			"/work/app": {
+				Bookmarks: []string{"/work/app/main.go", "/work/app/internal"},
+				Recent:    []string{"/work/app/go.mod"},
+			},
+		},
+	}
+
+	data, err := json.Marshal(original)
+	assert.NoError(t, err)
+
+	var loaded State
+	assert.NoError(t, json.Unmarshal(data, &loaded))
+	assert.Equal(t, original.Projects, loaded.Projects)
+
+	t.Run("omitted when empty", func(t *testing.T) {
+		data, err := json.Marshal(State{})
+		assert.NoError(t, err)
+		assert.NotContains(t, string(data), "projects")
+	})
+}
@@ -0,0 +1,289 @@
+// Package syntax highlights source code in colors that match the current
+// theme.
+package syntax
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"image/color"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"charm.land/lipgloss/v2"
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+
+	"github.com/avitaltamir/vibecommander/internal/theme"
+)
+
+// cacheSize is how many highlighted files are kept.
+const cacheSize = 16
+
+var (
+	mu       sync.Mutex
+	override string                       // Chroma style picked by the user, or "" to follow the theme
+	derived  = map[string]*chroma.Style{} // Styles derived from themes, by theme name
+	cache    []cached                     // Most recently used last
+)
+
+// cached is the highlighted output of a file in a style.
+type cached struct {
+	key string
+	out string
+}
+
+// SetOverride makes every theme highlight with the named chroma style, or
+// follow the theme again when name is empty. It returns false, leaving the
+// override as it was, for a style chroma doesn't know.
+func SetOverride(name string) bool {
+	if name != "" && !hasStyle(name) {
+		return false
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	override = name
+	return true
+}
+
+// Override returns the chroma style picked by the user, or "" when the theme
+// decides.
+func Override() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return override
+}
+
+// StyleNames returns the chroma styles that can be picked as an override.
+func StyleNames() []string {
+	return styles.Names()
+}
+
+// hasStyle reports whether chroma has a style called name.
+func hasStyle(name string) bool {
+	for _, s := range styles.Names() {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Style returns the chroma style for the current theme: the user's override,
+// the style the theme declares, or one derived from its palette.
+func Style() *chroma.Style {
+	mu.Lock()
+	defer mu.Unlock()
+	return styleFor(theme.CurrentTheme())
+}
+
+// styleFor returns the style for t. mu must be held.
+func styleFor(t *theme.Theme) *chroma.Style {
+	name := override
+	if name == "" {
+		name = t.SyntaxStyle
+	}
+	if name != "" {
+		if s := styles.Get(name); s != nil {
+			return s
+		}
+	}
+	if s, ok := derived[t.Name]; ok {
+		return s
+	}
+	s, err := Derive(t.Name, t.Colors)
+	if err != nil {
+		s = styles.Fallback
+	}
+	derived[t.Name] = s
+	return s
+}
+
+// Derive builds a chroma style from a theme's palette: keywords in the
+// primary accent, names in the secondary one, strings and numbers in the
+// success and warning colors, comments muted. The background is left to the
+// panel.
+func Derive(name string, p theme.ColorPalette) (*chroma.Style, error) {
+	return chroma.NewStyle(name, chroma.StyleEntries{
+		chroma.Text:                hex(p.TextPrimary),
+		chroma.Error:               hex(p.Error),
+		chroma.Comment:             "italic " + hex(p.TextMuted),
+		chroma.CommentPreproc:      hex(p.AI),
+		chroma.Keyword:             "bold " + hex(p.Primary),
+		chroma.KeywordType:         "nobold " + hex(p.Secondary),
+		chroma.KeywordConstant:     hex(p.Warning),
+		chroma.Operator:            hex(p.TextSecondary),
+		chroma.Punctuation:         hex(p.TextSecondary),
+		chroma.Name:                hex(p.TextPrimary),
+		chroma.NameBuiltin:         hex(p.Secondary),
+		chroma.NameFunction:        hex(p.Secondary),
+		chroma.NameClass:           "bold " + hex(p.Secondary),
+		chroma.NameDecorator:       hex(p.AI),
+		chroma.NameTag:             hex(p.Primary),
+		chroma.NameAttribute:       hex(p.Secondary),
+		chroma.NameConstant:        hex(p.Warning),
+		chroma.LiteralString:       hex(p.Success),
+		chroma.LiteralStringEscape: hex(p.Warning),
+		chroma.LiteralNumber:       hex(p.Warning),
+		chroma.GenericHeading:      "bold " + hex(p.Primary),
+		chroma.GenericSubheading:   "bold " + hex(p.Secondary),
+		chroma.GenericInserted:     hex(p.Success),
+		chroma.GenericDeleted:      hex(p.Error),
+		chroma.GenericEmph:         "italic",
+		chroma.GenericStrong:       "bold",
+	})
+}
+
+// hex formats c as a chroma color.
+func hex(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
+
+// LexerFor picks a lexer by file name, falling back to analysing content.
+func LexerFor(path, content string) chroma.Lexer {
+	// Try to get lexer by filename
+	var lexer chroma.Lexer
+	if path != "" {
+		lexer = lexers.Match(filepath.Base(path))
+	}
+
+	// Fallback: try to analyze content
+	if lexer == nil {
+		lexer = lexers.Analyse(content)
+	}
+
+	// Final fallback: plain text
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+
+	return chroma.Coalesce(lexer)
+}
+
+// Highlight returns text colored by lexer in the current style, or text
+// itself if that fails.
+func Highlight(lexer chroma.Lexer, text string) string {
+	return format(Style(), lexer, text)
+}
+
+// format colors text for a 256-color terminal.
+func format(style *chroma.Style, lexer chroma.Lexer, text string) string {
+	formatter := formatters.Get("terminal256")
+	if formatter == nil {
+		formatter = formatters.Fallback
+	}
+
+	iterator, err := lexer.Tokenise(nil, text)
+	if err != nil {
+		return text
+	}
+
+	var buf bytes.Buffer
+	if err := formatter.Format(&buf, style, iterator); err != nil {
+		return text
+	}
+	return buf.String()
+}
+
+// Span is a piece of a line in one style.
+type Span struct {
+	Text  string
+	Style lipgloss.Style
+}
+
+// Lines splits text colored by lexer in the current style into lines of
+// spans, for callers that add backgrounds of their own. There's a line for
+// every line of text.
+func Lines(lexer chroma.Lexer, text string) [][]Span {
+	want := strings.Count(text, "\n") + 1
+	out := make([][]Span, 0, want)
+
+	iterator, err := lexer.Tokenise(nil, text)
+	if err != nil {
+		for _, line := range strings.Split(text, "\n") {
+			out = append(out, []Span{{Text: line}})
+		}
+		return out
+	}
+
+	style := Style()
+	styled := map[chroma.TokenType]lipgloss.Style{}
+	for _, tokens := range chroma.SplitTokensIntoLines(iterator.Tokens()) {
+		var line []Span
+		for _, tok := range tokens {
+			text := strings.TrimSuffix(tok.Value, "\n")
+			if text == "" {
+				continue
+			}
+			s, ok := styled[tok.Type]
+			if !ok {
+				s = spanStyle(style.Get(tok.Type))
+				styled[tok.Type] = s
+			}
+			line = append(line, Span{Text: text, Style: s})
+		}
+		out = append(out, line)
+	}
+	for len(out) < want {
+		out = append(out, nil)
+	}
+	return out[:want]
+}
+
+// spanStyle turns a chroma style entry into a lipgloss style, leaving out the
+// background.
+func spanStyle(e chroma.StyleEntry) lipgloss.Style {
+	s := lipgloss.NewStyle()
+	if e.Colour.IsSet() {
+		s = s.Foreground(lipgloss.Color(e.Colour.String()))
+	}
+	if e.Bold == chroma.Yes {
+		s = s.Bold(true)
+	}
+	if e.Italic == chroma.Yes {
+		s = s.Italic(true)
+	}
+	if e.Underline == chroma.Yes {
+		s = s.Underline(true)
+	}
+	return s
+}
+
+// File highlights the content of the file at path in the current style. The
+// output is kept for the last few files and styles, so re-rendering a file,
+// or going back to a theme, doesn't highlight it again.
+func File(path, content string) string {
+	style := Style()
+	key := cacheKey(path, content, style.Name)
+
+	mu.Lock()
+	for i, c := range cache {
+		if c.key == key {
+			cache = append(append(cache[:i:i], cache[i+1:]...), c)
+			mu.Unlock()
+			return c.out
+		}
+	}
+	mu.Unlock()
+
+	out := format(style, LexerFor(path, content), content)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(cache) >= cacheSize {
+		cache = cache[1:]
+	}
+	cache = append(cache, cached{key: key, out: out})
+	return out
+}
+
+// cacheKey identifies a file's content highlighted in a style.
+func cacheKey(path, content, style string) string {
+	h := fnv.New64a()
+	h.Write([]byte(content))
+	return fmt.Sprintf("%s\x00%s\x00%d\x00%x", path, style, len(content), h.Sum64())
+}
@@ -0,0 +1,89 @@
+package syntax
+
+import (
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/avitaltamir/vibecommander/internal/theme"
+)
+
+// useTheme switches to the theme at index for the rest of the test.
+func useTheme(t *testing.T, index int) {
+	before := theme.CurrentThemeIndex()
+	require.True(t, theme.SetThemeIndex(index))
+	t.Cleanup(func() { theme.SetThemeIndex(before) })
+}
+
+func TestDerive(t *testing.T) {
+	p := theme.PinaColadaTheme().Colors
+	s, err := Derive("Piña Colada", p)
+	require.NoError(t, err)
+
+	assert.Equal(t, hex(p.Primary), s.Get(chroma.Keyword).Colour.String())
+	assert.Equal(t, hex(p.Success), s.Get(chroma.LiteralString).Colour.String())
+	assert.Equal(t, hex(p.TextMuted), s.Get(chroma.CommentSingle).Colour.String(), "inherited from Comment")
+	assert.False(t, s.Get(chroma.Background).Background.IsSet(), "the panel shows through")
+}
+
+func TestStyleFollowsTheme(t *testing.T) {
+	useTheme(t, 0)
+	first := Style()
+	assert.Equal(t, theme.CurrentTheme().Name, first.Name)
+
+	useTheme(t, 1)
+	assert.Equal(t, theme.CurrentTheme().Name, Style().Name)
+	assert.NotEqual(t, first.Get(chroma.Keyword).Colour, Style().Get(chroma.Keyword).Colour)
+}
+
+func TestOverride(t *testing.T) {
+	t.Cleanup(func() { SetOverride("") })
+	useTheme(t, 0)
+
+	assert.False(t, SetOverride("no-such-style"))
+	assert.Equal(t, "", Override())
+
+	require.True(t, SetOverride("dracula"))
+	assert.Equal(t, "dracula", Style().Name)
+	useTheme(t, 2)
+	assert.Equal(t, "dracula", Style().Name, "applies to every theme")
+
+	require.True(t, SetOverride(""))
+	assert.Equal(t, theme.CurrentTheme().Name, Style().Name)
+}
+
+func TestFile(t *testing.T) {
+	useTheme(t, 0)
+	src := "package main\n\nfunc main() {}\n"
+
+	first := File("/main.go", src)
+	assert.Contains(t, first, "\x1b[")
+	assert.Equal(t, first, File("/main.go", src))
+
+	// Another theme highlights it again in its own colors
+	useTheme(t, 1)
+	assert.NotEqual(t, first, File("/main.go", src))
+
+	// Going back reuses what was highlighted before
+	useTheme(t, 0)
+	assert.Equal(t, first, File("/main.go", src))
+	assert.Equal(t, first, cache[len(cache)-1].out, "most recently used last")
+}
+
+func TestLines(t *testing.T) {
+	src := "/* a\ncomment */\nx := 1"
+	lines := Lines(LexerFor("main.go", src), src)
+	require.Len(t, lines, 3)
+
+	// Every line keeps its text, including the end of a comment started above
+	var second string
+	for _, s := range lines[1] {
+		second += s.Text
+	}
+	assert.Equal(t, "comment */", second)
+	assert.Equal(t, Lines(LexerFor("main.go", "/* c */"), "/* c */")[0][0].Style, lines[1][0].Style)
+
+	assert.Len(t, Lines(LexerFor("a.txt", "one\n\n"), "one\n\n"), 3, "blank lines at the end count")
+}
@@ -38,6 +38,9 @@ var (
 	BgDiffAdded   color.Color = lipgloss.Color("#0D2818") // Dark green tint
 	BgDiffRemoved color.Color = lipgloss.Color("#2D0A0A") // Dark red tint
 	BgDiffHunk    color.Color = lipgloss.Color("#1A1A3E") // Hunk header background
+
+	BgDiffAddedWord   color.Color = lipgloss.Color("#1C5A32") // Changed words in added lines
+	BgDiffRemovedWord color.Color = lipgloss.Color("#6B1A1A") // Changed words in removed lines
 )
 
 // Selection Colors - Text selection highlighting
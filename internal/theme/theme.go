@@ -10,6 +10,9 @@ type Theme struct {
 	// Color palette
 	Colors ColorPalette
 
+	// Chroma style for syntax highlighting; derived from Colors when empty
+	SyntaxStyle string
+
 	// Whether to use Nerd Font icons
 	UseNerdFonts bool
 }
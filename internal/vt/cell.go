@@ -0,0 +1,212 @@
+package vt
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Color is a cell's foreground or background color: the default, one of the
+// 256 palette colors, or a 24-bit RGB color.
+type Color uint32
+
+// DefaultColor is the terminal's own foreground or background.
+const DefaultColor Color = 0
+
+const (
+	indexedColor = 1 << 24
+	rgbColor     = 2 << 24
+)
+
+// IndexedColor returns palette color i: 0-7 are the standard colors, 8-15
+// their bright versions, then a 6x6x6 cube and a gray ramp.
+func IndexedColor(i uint8) Color {
+	return Color(indexedColor | uint32(i))
+}
+
+// RGBColor returns a 24-bit color.
+func RGBColor(r, g, b uint8) Color {
+	return Color(rgbColor | uint32(r)<<16 | uint32(g)<<8 | uint32(b))
+}
+
+// sgr returns the SGR parameters selecting the color, or "" for the default.
+func (c Color) sgr(fg bool) string {
+	base := "38"
+	if !fg {
+		base = "48"
+	}
+	switch c &^ 0xffffff {
+	case indexedColor:
+		return base + ";5;" + strconv.Itoa(int(c&0xff))
+	case rgbColor:
+		return base + ";2;" + strconv.Itoa(int(c>>16&0xff)) + ";" + strconv.Itoa(int(c>>8&0xff)) + ";" + strconv.Itoa(int(c&0xff))
+	}
+	return ""
+}
+
+// Attr is a set of text attributes.
+type Attr uint16
+
+// Text attributes
+const (
+	Bold Attr = 1 << iota
+	Faint
+	Italic
+	Underline
+	Blink
+	Reverse
+	Hidden
+	Strikethrough
+)
+
+// attrCodes are the SGR parameters turning on each attribute, in bit order.
+var attrCodes = []string{"1", "2", "3", "4", "5", "7", "8", "9"}
+
+// Style is how a cell is drawn.
+type Style struct {
+	FG, BG Color
+	Attrs  Attr
+}
+
+// sgr returns the escape sequence drawing text in the style, after a reset.
+func (s Style) sgr() string {
+	var codes []string
+	for i, code := range attrCodes {
+		if s.Attrs&(1<<i) != 0 {
+			codes = append(codes, code)
+		}
+	}
+	if fg := s.FG.sgr(true); fg != "" {
+		codes = append(codes, fg)
+	}
+	if bg := s.BG.sgr(false); bg != "" {
+		codes = append(codes, bg)
+	}
+	if len(codes) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m"
+}
+
+// Cell is one column of a line.
+type Cell struct {
+	Content string // Character shown, with any combining marks; "" when blank
+	Width   int    // Columns taken: 2 for wide characters, 0 for the column a wide one covers
+	Style   Style
+	Link    string // Target of the OSC 8 hyperlink it's part of
+}
+
+// blank returns an empty cell with the background of style, as erasing
+// leaves behind.
+func blank(style Style) Cell {
+	return Cell{Width: 1, Style: Style{BG: style.BG}}
+}
+
+// isBlank reports whether a cell shows nothing, not even a background.
+func (c Cell) isBlank() bool {
+	return c.Content == "" && c.Width == 1 && c.Style == Style{} && c.Link == ""
+}
+
+// Line is a row of cells.
+type Line []Cell
+
+// newLine returns a line of blank cells.
+func newLine(cols int, style Style) Line {
+	line := make(Line, cols)
+	for i := range line {
+		line[i] = blank(style)
+	}
+	return line
+}
+
+// trimmed returns the line without the blank cells at its end.
+func (l Line) trimmed() Line {
+	n := len(l)
+	for n > 0 && l[n-1].isBlank() {
+		n--
+	}
+	return l[:n:n]
+}
+
+// text returns the characters of the line without trailing spaces.
+func (l Line) text() string {
+	var b strings.Builder
+	for _, c := range l {
+		switch {
+		case c.Width == 0:
+		case c.Content == "":
+			b.WriteByte(' ')
+		default:
+			b.WriteString(c.Content)
+		}
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// Hyperlink is a run of cells of a line that's an OSC 8 hyperlink.
+type Hyperlink struct {
+	Start, End int // Columns, end exclusive
+	URI        string
+}
+
+// hyperlinks returns the hyperlinks in the line.
+func (l Line) hyperlinks() []Hyperlink {
+	var links []Hyperlink
+	for i := 0; i < len(l); {
+		if l[i].Link == "" {
+			i++
+			continue
+		}
+		start := i
+		for i < len(l) && l[i].Link == l[start].Link {
+			i++
+		}
+		links = append(links, Hyperlink{Start: start, End: i, URI: l[start].Link})
+	}
+	return links
+}
+
+// render writes the line with its styles as ANSI escape sequences, padded
+// with blanks to width if it's shorter. highlight, if set, reports columns
+// drawn in reverse video instead, such as the cursor or a selection.
+func (l Line) render(b *strings.Builder, width int, highlight func(col int) bool) {
+	var run strings.Builder
+	var current string
+	flush := func() {
+		if run.Len() == 0 {
+			return
+		}
+		b.WriteString(current)
+		b.WriteString(run.String())
+		if current != "" {
+			b.WriteString("\x1b[0m")
+		}
+		run.Reset()
+	}
+
+	for col := 0; col < width; col++ {
+		c := Cell{Width: 1}
+		if col < len(l) {
+			c = l[col]
+		}
+		if c.Width == 0 {
+			continue // Drawn by the wide character before it
+		}
+		sgr := c.Style.sgr()
+		if highlight != nil && highlight(col) {
+			sgr = "\x1b[7m"
+		}
+		if sgr != current {
+			flush()
+			current = sgr
+		}
+		switch {
+		case c.Content == "" || c.Style.Attrs&Hidden != 0:
+			run.WriteString(strings.Repeat(" ", max(c.Width, 1)))
+		case c.Width == 2 && col == width-1:
+			run.WriteByte(' ') // Cut in half at the edge
+		default:
+			run.WriteString(c.Content)
+		}
+	}
+	flush()
+}
@@ -0,0 +1,693 @@
+package vt
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// decGraphics maps the DEC special graphics charset, used for drawing lines
+// and boxes, to Unicode.
+var decGraphics = map[rune]string{
+	'`': "◆", 'a': "▒", 'b': "␉", 'c': "␌", 'd': "␍", 'e': "␊", 'f': "°", 'g': "±",
+	'h': "␤", 'i': "␋", 'j': "┘", 'k': "┐", 'l': "┌", 'm': "└", 'n': "┼", 'o': "⎺",
+	'p': "⎻", 'q': "─", 'r': "⎼", 's': "⎽", 't': "├", 'u': "┤", 'v': "┴", 'w': "┬",
+	'x': "│", 'y': "≤", 'z': "≥", '{': "π", '|': "≠", '}': "£", '~': "·",
+}
+
+// print writes a character at the cursor and moves past it.
+func (t *Terminal) print(r rune) {
+	s := t.scr
+	ch := string(r)
+	if s.cur.charsets[s.cur.gl] == '0' {
+		if g, ok := decGraphics[r]; ok {
+			ch = g
+		}
+	}
+
+	width := 1
+	if r >= 0x7f {
+		width = ansi.StringWidth(ch)
+	}
+	if width == 0 || t.joinsPrevious() {
+		// Combining marks, variation selectors and the parts of emoji joined
+		// by ZWJ belong to the character before
+		if x, y, ok := t.previousCell(); ok {
+			s.lines[y][x].Content += ch
+		}
+		return
+	}
+
+	if s.cur.wrapNext && t.autowrap {
+		t.newline(true)
+	}
+	if width == 2 && s.cur.x == t.cols-1 {
+		// No room for a wide character at the end of the line
+		if !t.autowrap {
+			return
+		}
+		t.setCell(s.cur.x, blank(s.cur.style))
+		t.newline(true)
+	}
+	if t.insert {
+		t.insertCells(width)
+	}
+
+	t.setCell(s.cur.x, Cell{Content: ch, Width: width, Style: s.cur.style, Link: s.cur.link})
+	if width == 2 {
+		t.setCell(s.cur.x+1, Cell{Width: 0, Style: s.cur.style, Link: s.cur.link})
+	}
+	t.last = ch
+
+	if s.cur.x+width >= t.cols {
+		s.cur.x = t.cols - 1
+		s.cur.wrapNext = t.autowrap
+	} else {
+		s.cur.x += width
+	}
+}
+
+// joinsPrevious reports whether the previous character ends with a zero width
+// joiner, so the next one is part of the same emoji.
+func (t *Terminal) joinsPrevious() bool {
+	x, y, ok := t.previousCell()
+	return ok && strings.HasSuffix(t.scr.lines[y][x].Content, "‍")
+}
+
+// previousCell returns the cell of the character last written before the
+// cursor on its line.
+func (t *Terminal) previousCell() (x, y int, ok bool) {
+	s := t.scr
+	x, y = s.cur.x, s.cur.y
+	if !s.cur.wrapNext {
+		x--
+	}
+	if x > 0 && s.lines[y][x].Width == 0 {
+		x--
+	}
+	if x < 0 || s.lines[y][x].Content == "" {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
+// setCell replaces the cell at column x of the cursor's line, blanking what's
+// left of a wide character it overwrites half of.
+func (t *Terminal) setCell(x int, c Cell) {
+	if x < 0 || x >= t.cols {
+		return
+	}
+	line := t.scr.lines[t.scr.cur.y]
+	if line[x].Width == 0 && x > 0 && line[x-1].Width == 2 {
+		line[x-1] = blank(line[x-1].Style)
+	}
+	if line[x].Width == 2 && x+1 < t.cols && c.Width != 2 {
+		line[x+1] = blank(line[x+1].Style)
+	}
+	line[x] = c
+}
+
+// execute handles a control character.
+func (t *Terminal) execute(b byte) {
+	s := t.scr
+	switch b {
+	case ansi.BS:
+		if s.cur.x > 0 {
+			s.cur.x--
+		}
+		s.cur.wrapNext = false
+	case ansi.HT:
+		t.tab(1)
+	case ansi.LF, ansi.VT, ansi.FF:
+		t.newline(false)
+	case ansi.CR:
+		s.cur.x = 0
+		s.cur.wrapNext = false
+	case ansi.SO:
+		s.cur.gl = 1
+	case ansi.SI:
+		s.cur.gl = 0
+	case ansi.IND:
+		t.newline(false)
+	case ansi.NEL:
+		t.newline(true)
+	case ansi.HTS:
+		t.tabs[s.cur.x] = true
+	case ansi.RI:
+		t.reverseIndex()
+	}
+}
+
+// newline moves the cursor down a line, scrolling at the bottom of the
+// scroll region, and back to the first column if cr is set.
+func (t *Terminal) newline(cr bool) {
+	s := t.scr
+	if s.cur.y == s.bottom {
+		t.scrollUp(1)
+	} else if s.cur.y < t.rows-1 {
+		s.cur.y++
+	}
+	if cr {
+		s.cur.x = 0
+	}
+	s.cur.wrapNext = false
+}
+
+// reverseIndex moves the cursor up a line, scrolling down at the top of the
+// scroll region.
+func (t *Terminal) reverseIndex() {
+	s := t.scr
+	if s.cur.y == s.top {
+		t.scrollDown(1)
+	} else if s.cur.y > 0 {
+		s.cur.y--
+	}
+	s.cur.wrapNext = false
+}
+
+// tab moves the cursor to the nth next tab stop, or back to the nth previous
+// one when n is negative.
+func (t *Terminal) tab(n int) {
+	s := t.scr
+	for ; n > 0 && s.cur.x < t.cols-1; n-- {
+		for s.cur.x++; s.cur.x < t.cols-1 && !t.tabs[s.cur.x]; s.cur.x++ {
+		}
+	}
+	for ; n < 0 && s.cur.x > 0; n++ {
+		for s.cur.x--; s.cur.x > 0 && !t.tabs[s.cur.x]; s.cur.x-- {
+		}
+	}
+	s.cur.wrapNext = false
+}
+
+// scrollUp moves the lines of the scroll region up by n, adding blank lines
+// at its bottom. Lines scrolling off the top of the main screen go to the
+// scrollback.
+func (t *Terminal) scrollUp(n int) {
+	s := t.scr
+	n = min(n, s.bottom-s.top+1)
+	if s == t.main && s.top == 0 {
+		t.pushScrollback(s.lines[:n])
+	}
+	region := s.lines[s.top : s.bottom+1]
+	copy(region, region[n:])
+	for i := len(region) - n; i < len(region); i++ {
+		region[i] = newLine(t.cols, s.cur.style)
+	}
+}
+
+// scrollDown moves the lines of the scroll region down by n, adding blank
+// lines at its top.
+func (t *Terminal) scrollDown(n int) {
+	s := t.scr
+	n = min(n, s.bottom-s.top+1)
+	region := s.lines[s.top : s.bottom+1]
+	copy(region[n:], region)
+	for i := 0; i < n; i++ {
+		region[i] = newLine(t.cols, s.cur.style)
+	}
+}
+
+// insertCells moves the rest of the line right by n blanks.
+func (t *Terminal) insertCells(n int) {
+	s := t.scr
+	line := s.lines[s.cur.y]
+	n = min(n, t.cols-s.cur.x)
+	copy(line[s.cur.x+n:], line[s.cur.x:])
+	for i := s.cur.x; i < s.cur.x+n; i++ {
+		line[i] = blank(s.cur.style)
+	}
+	t.fixWideAtEdge(line)
+}
+
+// deleteCells removes n cells at the cursor, moving the rest of the line left.
+func (t *Terminal) deleteCells(n int) {
+	s := t.scr
+	line := s.lines[s.cur.y]
+	n = min(n, t.cols-s.cur.x)
+	copy(line[s.cur.x:], line[s.cur.x+n:])
+	for i := t.cols - n; i < t.cols; i++ {
+		line[i] = blank(s.cur.style)
+	}
+	if line[s.cur.x].Width == 0 {
+		line[s.cur.x] = blank(s.cur.style) // The right half of a wide character
+	}
+}
+
+// fixWideAtEdge blanks a wide character pushed half off the end of a line.
+func (t *Terminal) fixWideAtEdge(line Line) {
+	if last := len(line) - 1; line[last].Width == 2 {
+		line[last] = blank(line[last].Style)
+	}
+}
+
+// erase blanks the cells from column x0 of line y0 up to column x1 of line
+// y1, exclusive.
+func (t *Terminal) erase(x0, y0, x1, y1 int) {
+	s := t.scr
+	for y := y0; y <= y1 && y < t.rows; y++ {
+		from, to := 0, t.cols
+		if y == y0 {
+			from = x0
+		}
+		if y == y1 {
+			to = x1
+		}
+		line := s.lines[y]
+		for x := max(from, 0); x < min(to, t.cols); x++ {
+			line[x] = blank(s.cur.style)
+		}
+		// Don't leave half of a wide character behind
+		if from > 0 && from < t.cols && line[from-1].Width == 2 {
+			line[from-1] = blank(line[from-1].Style)
+		}
+		if to < t.cols && line[to].Width == 0 {
+			line[to] = blank(line[to].Style)
+		}
+	}
+}
+
+// moveTo puts the cursor at a position, relative to the scroll region in
+// origin mode.
+func (t *Terminal) moveTo(x, y int) {
+	s := t.scr
+	top, bottom := 0, t.rows-1
+	if s.cur.origin {
+		top, bottom = s.top, s.bottom
+	}
+	s.cur.x = max(0, min(x, t.cols-1))
+	s.cur.y = max(top, min(y+top, bottom))
+	s.cur.wrapNext = false
+}
+
+// moveBy moves the cursor, stopping at the scroll region's margins when it
+// starts inside them and the screen's edges otherwise.
+func (t *Terminal) moveBy(dx, dy int) {
+	s := t.scr
+	top, bottom := 0, t.rows-1
+	if s.cur.y >= s.top && s.cur.y <= s.bottom {
+		top, bottom = s.top, s.bottom
+	}
+	s.cur.x = max(0, min(s.cur.x+dx, t.cols-1))
+	s.cur.y = max(top, min(s.cur.y+dy, bottom))
+	s.cur.wrapNext = false
+}
+
+// count returns parameter i as a count, where missing and 0 both mean 1.
+func count(params ansi.Params, i int) int {
+	n, _, _ := params.Param(i, 1)
+	return max(n, 1)
+}
+
+// csi handles a control sequence.
+func (t *Terminal) csi(cmd ansi.Cmd, params ansi.Params) {
+	s := t.scr
+	n := count(params, 0)
+	switch cmd.Prefix() {
+	case '?':
+		switch cmd.Final() {
+		case 'h', 'l':
+			params.ForEach(0, func(_, mode int, _ bool) {
+				t.setPrivateMode(mode, cmd.Final() == 'h')
+			})
+		case 'J':
+			t.csi(ansi.Cmd('J'), params) // Selective erase, treated as plain
+		case 'K':
+			t.csi(ansi.Cmd('K'), params)
+		}
+		return
+	case '>':
+		if cmd.Final() == 'c' {
+			t.replies = append(t.replies, "\x1b[>1;10;0c"...) // Secondary device attributes
+		}
+		return
+	case 0:
+	default:
+		return
+	}
+	if cmd.Intermediate() != 0 {
+		return // Such as the cursor shape (SP q)
+	}
+
+	switch cmd.Final() {
+	case '@': // ICH
+		t.insertCells(n)
+	case 'A': // CUU
+		t.moveBy(0, -n)
+	case 'B', 'e': // CUD, VPR
+		t.moveBy(0, n)
+	case 'C', 'a': // CUF, HPR
+		t.moveBy(n, 0)
+	case 'D': // CUB
+		t.moveBy(-n, 0)
+	case 'E': // CNL
+		t.moveBy(0, n)
+		s.cur.x = 0
+	case 'F': // CPL
+		t.moveBy(0, -n)
+		s.cur.x = 0
+	case 'G', '`': // CHA, HPA
+		s.cur.x = max(0, min(n-1, t.cols-1))
+		s.cur.wrapNext = false
+	case 'H', 'f': // CUP, HVP
+		t.moveTo(count(params, 1)-1, n-1)
+	case 'I': // CHT
+		t.tab(n)
+	case 'Z': // CBT
+		t.tab(-n)
+	case 'J': // ED
+		switch mode, _, _ := params.Param(0, 0); mode {
+		case 0:
+			t.erase(s.cur.x, s.cur.y, t.cols, t.rows-1)
+		case 1:
+			t.erase(0, 0, s.cur.x+1, s.cur.y)
+		case 2:
+			t.erase(0, 0, t.cols, t.rows-1)
+		case 3:
+			t.dropped += len(t.scrollback)
+			t.scrollback = nil
+		}
+	case 'K': // EL
+		switch mode, _, _ := params.Param(0, 0); mode {
+		case 0:
+			t.erase(s.cur.x, s.cur.y, t.cols, s.cur.y)
+		case 1:
+			t.erase(0, s.cur.y, s.cur.x+1, s.cur.y)
+		case 2:
+			t.erase(0, s.cur.y, t.cols, s.cur.y)
+		}
+	case 'L', 'M': // IL, DL
+		if s.cur.y < s.top || s.cur.y > s.bottom {
+			return
+		}
+		top := s.top
+		s.top = s.cur.y
+		if cmd.Final() == 'L' {
+			t.scrollDown(n)
+		} else {
+			t.scrollUp(n) // Never into the scrollback, since the top moved
+		}
+		s.top = top
+		s.cur.x = 0
+		s.cur.wrapNext = false
+	case 'P': // DCH
+		t.deleteCells(n)
+	case 'S': // SU
+		t.scrollUp(n)
+	case 'T': // SD
+		t.scrollDown(n)
+	case 'X': // ECH
+		t.erase(s.cur.x, s.cur.y, s.cur.x+n, s.cur.y)
+	case 'b': // REP
+		if t.last != "" {
+			for range min(n, t.cols*t.rows) {
+				for _, r := range t.last {
+					t.print(r)
+				}
+			}
+		}
+	case 'c': // DA
+		t.replies = append(t.replies, "\x1b[?62;22c"...)
+	case 'd': // VPA
+		t.moveTo(s.cur.x, n-1)
+	case 'g': // TBC
+		switch mode, _, _ := params.Param(0, 0); mode {
+		case 0:
+			t.tabs[s.cur.x] = false
+		case 3:
+			clear(t.tabs)
+		}
+	case 'h', 'l': // SM, RM
+		params.ForEach(0, func(_, mode int, _ bool) {
+			if mode == 4 {
+				t.insert = cmd.Final() == 'h'
+			}
+		})
+	case 'm': // SGR
+		t.sgr(params)
+	case 'n': // DSR
+		switch mode, _, _ := params.Param(0, 0); mode {
+		case 5:
+			t.replies = append(t.replies, "\x1b[0n"...)
+		case 6:
+			y := s.cur.y
+			if s.cur.origin {
+				y -= s.top
+			}
+			t.replies = append(t.replies, fmt.Sprintf("\x1b[%d;%dR", y+1, s.cur.x+1)...)
+		}
+	case 'r': // DECSTBM
+		top := count(params, 0) - 1
+		bottom, _, _ := params.Param(1, t.rows)
+		if bottom == 0 || bottom > t.rows {
+			bottom = t.rows
+		}
+		if top < bottom-1 {
+			s.top, s.bottom = top, bottom-1
+			t.moveTo(0, 0)
+		}
+	case 's': // SCOSC
+		s.saved = s.cur
+	case 'u': // SCORC
+		t.restoreCursor()
+	}
+}
+
+// setPrivateMode sets or resets a DEC private mode.
+func (t *Terminal) setPrivateMode(mode int, on bool) {
+	switch mode {
+	case 1: // DECCKM
+		t.appCursor = on
+	case 6: // DECOM
+		t.scr.cur.origin = on
+		t.moveTo(0, 0)
+	case 7: // DECAWM
+		t.autowrap = on
+		if !on {
+			t.scr.cur.wrapNext = false
+		}
+	case 25: // DECTCEM
+		t.cursorVisible = on
+	case 47, 1047:
+		if !on && mode == 1047 {
+			t.alt = t.newScreen() // Cleared on leaving
+		}
+		t.switchScreen(on)
+	case 1048:
+		if on {
+			t.scr.saved = t.scr.cur
+		} else {
+			t.restoreCursor()
+		}
+	case 1049:
+		if on {
+			t.main.saved = t.main.cur
+			t.alt = t.newScreen()
+			t.alt.cur = t.main.cur
+			t.alt.cur.wrapNext = false
+			t.switchScreen(true)
+		} else {
+			t.switchScreen(false)
+			t.restoreCursor()
+		}
+	case 1000:
+		t.setMouse(MouseClicks, on)
+	case 1002:
+		t.setMouse(MouseDrags, on)
+	case 1003:
+		t.setMouse(MouseMotion, on)
+	case 1006:
+		t.mouseSGR = on
+	case 2004:
+		t.bracketedPaste = on
+	}
+}
+
+// setMouse turns a mouse mode on, or off if it's the one on.
+func (t *Terminal) setMouse(mode MouseMode, on bool) {
+	if on {
+		t.mouse = mode
+	} else if t.mouse == mode {
+		t.mouse = MouseOff
+	}
+}
+
+// switchScreen shows the alternate screen, or the main one.
+func (t *Terminal) switchScreen(alt bool) {
+	if alt {
+		t.scr = t.alt
+	} else {
+		t.scr = t.main
+	}
+}
+
+// restoreCursor goes back to the cursor saved with DECSC.
+func (t *Terminal) restoreCursor() {
+	s := t.scr
+	s.cur = s.saved
+	s.cur.x = min(s.cur.x, t.cols-1)
+	s.cur.y = min(s.cur.y, t.rows-1)
+}
+
+// esc handles an escape sequence.
+func (t *Terminal) esc(cmd ansi.Cmd) {
+	s := t.scr
+	switch cmd.Intermediate() {
+	case '(', ')': // Designate G0 or G1
+		g := 0
+		if cmd.Intermediate() == ')' {
+			g = 1
+		}
+		s.cur.charsets[g] = cmd.Final()
+		return
+	case '#':
+		if cmd.Final() == '8' { // DECALN
+			for y := range s.lines {
+				for x := range s.lines[y] {
+					s.lines[y][x] = Cell{Content: "E", Width: 1}
+				}
+			}
+		}
+		return
+	case 0:
+	default:
+		return
+	}
+
+	switch cmd.Final() {
+	case '7': // DECSC
+		s.saved = s.cur
+	case '8': // DECRC
+		t.restoreCursor()
+	case 'D': // IND
+		t.newline(false)
+	case 'E': // NEL
+		t.newline(true)
+	case 'H': // HTS
+		t.tabs[s.cur.x] = true
+	case 'M': // RI
+		t.reverseIndex()
+	case 'c': // RIS
+		t.reset()
+	}
+}
+
+// osc handles an operating system command: the title, hyperlinks and the
+// clipboard.
+func (t *Terminal) osc(cmd int, data []byte) {
+	_, arg, _ := strings.Cut(string(data), ";")
+	switch cmd {
+	case 0, 2:
+		t.title = arg
+	case 8:
+		// 8;params;URI starts a hyperlink, and an empty URI ends it
+		_, uri, _ := strings.Cut(arg, ";")
+		t.scr.cur.link = uri
+	case 52:
+		// 52;selection;base64 sets the clipboard; reading it isn't allowed
+		_, encoded, _ := strings.Cut(arg, ";")
+		if text, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+			t.clipboard = append(t.clipboard, string(text))
+		}
+	}
+}
+
+// sgr sets the style of the text that follows.
+func (t *Terminal) sgr(params ansi.Params) {
+	style := &t.scr.cur.style
+	if len(params) == 0 {
+		*style = Style{}
+		return
+	}
+	for i := 0; i < len(params); i++ {
+		p := params[i].Param(0)
+		switch {
+		case p == 0:
+			*style = Style{}
+		case p == 1:
+			style.Attrs |= Bold
+		case p == 2:
+			style.Attrs |= Faint
+		case p == 3:
+			style.Attrs |= Italic
+		case p == 4:
+			// 4:0 turns underline off, other styles are drawn as a plain one
+			if params[i].HasMore() && i+1 < len(params) {
+				i++
+				if params[i].Param(1) == 0 {
+					style.Attrs &^= Underline
+					continue
+				}
+			}
+			style.Attrs |= Underline
+		case p == 5 || p == 6:
+			style.Attrs |= Blink
+		case p == 7:
+			style.Attrs |= Reverse
+		case p == 8:
+			style.Attrs |= Hidden
+		case p == 9:
+			style.Attrs |= Strikethrough
+		case p == 21:
+			style.Attrs |= Underline
+		case p == 22:
+			style.Attrs &^= Bold | Faint
+		case p == 23:
+			style.Attrs &^= Italic
+		case p == 24:
+			style.Attrs &^= Underline
+		case p == 25:
+			style.Attrs &^= Blink
+		case p == 27:
+			style.Attrs &^= Reverse
+		case p == 28:
+			style.Attrs &^= Hidden
+		case p == 29:
+			style.Attrs &^= Strikethrough
+		case p >= 30 && p <= 37:
+			style.FG = IndexedColor(uint8(p - 30))
+		case p == 38:
+			style.FG, i = extendedColor(params, i)
+		case p == 39:
+			style.FG = DefaultColor
+		case p >= 40 && p <= 47:
+			style.BG = IndexedColor(uint8(p - 40))
+		case p == 48:
+			style.BG, i = extendedColor(params, i)
+		case p == 49:
+			style.BG = DefaultColor
+		case p >= 90 && p <= 97:
+			style.FG = IndexedColor(uint8(p - 90 + 8))
+		case p >= 100 && p <= 107:
+			style.BG = IndexedColor(uint8(p - 100 + 8))
+		}
+	}
+}
+
+// extendedColor reads the 256-color or RGB color starting at parameter i,
+// which is 38 or 48, in either the 38;5;n or the 38:5:n form. It returns
+// the color and the index of its last parameter.
+func extendedColor(params ansi.Params, i int) (Color, int) {
+	arg := func(j int) int { return params[j].Param(0) }
+	if i+1 >= len(params) {
+		return DefaultColor, i
+	}
+	switch arg(i + 1) {
+	case 5:
+		if i+2 < len(params) {
+			return IndexedColor(uint8(arg(i + 2))), i + 2
+		}
+	case 2:
+		// The colon form may have a color space id before the components
+		j := i + 2
+		if params[i+1].HasMore() && i+5 < len(params) && params[i+4].HasMore() {
+			j++
+		}
+		if j+2 < len(params) {
+			return RGBColor(uint8(arg(j)), uint8(arg(j+1)), uint8(arg(j+2))), j + 2
+		}
+	}
+	return DefaultColor, len(params) - 1
+}
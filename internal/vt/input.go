@@ -0,0 +1,178 @@
+package vt
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// cursorKeys are the final bytes of the cursor keys' sequences.
+var cursorKeys = map[rune]byte{
+	tea.KeyUp: 'A', tea.KeyDown: 'B', tea.KeyRight: 'C', tea.KeyLeft: 'D',
+	tea.KeyHome: 'H', tea.KeyEnd: 'F',
+	tea.KeyF1: 'P', tea.KeyF2: 'Q', tea.KeyF3: 'R', tea.KeyF4: 'S',
+}
+
+// tildeKeys are the numbers of the keys sent as CSI n ~.
+var tildeKeys = map[rune]int{
+	tea.KeyInsert: 2, tea.KeyDelete: 3, tea.KeyPgUp: 5, tea.KeyPgDown: 6,
+	tea.KeyF5: 15, tea.KeyF6: 17, tea.KeyF7: 18, tea.KeyF8: 19,
+	tea.KeyF9: 20, tea.KeyF10: 21, tea.KeyF11: 23, tea.KeyF12: 24,
+}
+
+// KeyInput returns what a key press sends to the program, as xterm would, or
+// nil for keys that send nothing.
+func (t *Terminal) KeyInput(key tea.Key) []byte {
+	t.mu.Lock()
+	appCursor := t.appCursor
+	t.mu.Unlock()
+
+	alt := key.Mod&tea.ModAlt != 0
+	ctrl := key.Mod&tea.ModCtrl != 0
+	shift := key.Mod&tea.ModShift != 0
+
+	// Modified special keys carry the modifiers as a parameter
+	mod := 1
+	if shift {
+		mod++
+	}
+	if alt {
+		mod += 2
+	}
+	if ctrl {
+		mod += 4
+	}
+
+	if final, ok := cursorKeys[key.Code]; ok {
+		switch {
+		case mod > 1:
+			return fmt.Appendf(nil, "\x1b[1;%d%c", mod, final)
+		case final >= 'P' && final <= 'S', appCursor:
+			return []byte{0x1b, 'O', final}
+		}
+		return []byte{0x1b, '[', final}
+	}
+	if n, ok := tildeKeys[key.Code]; ok {
+		if mod > 1 {
+			return fmt.Appendf(nil, "\x1b[%d;%d~", n, mod)
+		}
+		return fmt.Appendf(nil, "\x1b[%d~", n)
+	}
+
+	var input []byte
+	switch {
+	case key.Code == tea.KeyEnter:
+		input = []byte{'\r'}
+	case key.Code == tea.KeyBackspace:
+		input = []byte{0x7f}
+		if ctrl {
+			input = []byte{0x08}
+		}
+	case key.Code == tea.KeyTab && shift:
+		return []byte("\x1b[Z")
+	case key.Code == tea.KeyTab:
+		input = []byte{'\t'}
+	case key.Code == tea.KeyEscape:
+		input = []byte{0x1b}
+	case key.Code == tea.KeySpace && ctrl:
+		input = []byte{0}
+	case key.Code == tea.KeySpace:
+		input = []byte{' '}
+	case ctrl && key.Code >= 'a' && key.Code <= 'z':
+		input = []byte{byte(key.Code - 'a' + 1)} // Ctrl+A is 1 through Ctrl+Z 26
+	case ctrl && key.Code >= '[' && key.Code <= '_':
+		input = []byte{byte(key.Code - '@')} // Ctrl+[ is ESC, Ctrl+\ is FS and so on
+	case key.Text != "":
+		input = []byte(key.Text)
+	case alt && key.Code < utf8.RuneSelf && key.Code > ' ':
+		input = []byte{byte(key.Code)} // Alt+letter comes without text
+	default:
+		return nil
+	}
+
+	if alt {
+		input = append([]byte{0x1b}, input...)
+	}
+	return input
+}
+
+// PasteInput returns what pasting text sends to the program, marked as a
+// paste if it asked for bracketed paste.
+func (t *Terminal) PasteInput(text string) []byte {
+	if !t.BracketedPaste() {
+		return []byte(text)
+	}
+	return []byte("\x1b[200~" + text + "\x1b[201~")
+}
+
+// MouseInput returns what a mouse event at a column and row of the screen
+// sends to the program, or nil if it didn't ask for that kind of event.
+func (t *Terminal) MouseInput(msg tea.MouseMsg, col, row int) []byte {
+	t.mu.Lock()
+	mode, sgr := t.mouse, t.mouseSGR
+	t.mu.Unlock()
+	if mode == MouseOff {
+		return nil
+	}
+
+	mouse := msg.Mouse()
+	var code int
+	switch mouse.Button {
+	case tea.MouseLeft:
+		code = 0
+	case tea.MouseMiddle:
+		code = 1
+	case tea.MouseRight:
+		code = 2
+	case tea.MouseNone:
+		code = 3
+	case tea.MouseWheelUp:
+		code = 64
+	case tea.MouseWheelDown:
+		code = 65
+	case tea.MouseWheelLeft:
+		code = 66
+	case tea.MouseWheelRight:
+		code = 67
+	default:
+		return nil
+	}
+
+	release := false
+	switch msg.(type) {
+	case tea.MouseMotionMsg:
+		if mode == MouseClicks || (mode == MouseDrags && mouse.Button == tea.MouseNone) {
+			return nil
+		}
+		code += 32
+	case tea.MouseReleaseMsg:
+		release = true
+	}
+	if mouse.Mod&tea.ModShift != 0 {
+		code += 4
+	}
+	if mouse.Mod&tea.ModAlt != 0 {
+		code += 8
+	}
+	if mouse.Mod&tea.ModCtrl != 0 {
+		code += 16
+	}
+
+	if sgr {
+		final := 'M'
+		if release {
+			final = 'm'
+		}
+		return fmt.Appendf(nil, "\x1b[<%d;%d;%d%c", code, col+1, row+1, final)
+	}
+	// The old encoding can't say which button was released, and only has
+	// room for 223 columns and rows
+	if release {
+		code = code&^3 | 3
+	}
+	if col+1 > 223 || row+1 > 223 {
+		return nil
+	}
+	return []byte{0x1b, '[', 'M', byte(32 + code), byte(33 + col), byte(33 + row)}
+}
@@ -0,0 +1,416 @@
+// Package vt is the terminal emulator behind the AI terminal, the mini
+// buffer and the recording player. It keeps the screen a program draws as
+// styled cells, including wide characters, along with the lines that scroll
+// off its top, the alternate screen full-screen programs switch to, and
+// what the program asked of the terminal: its modes, title, clipboard
+// contents and hyperlinks.
+//
+// A Terminal is safe to use from several goroutines.
+package vt
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// DefaultMaxScrollback is how many lines that scrolled off the screen are
+// kept unless SetMaxScrollback says otherwise.
+const DefaultMaxScrollback = 10000
+
+// MouseMode is which mouse events a program asked to be sent.
+type MouseMode int
+
+// Mouse modes
+const (
+	MouseOff    MouseMode = iota
+	MouseClicks           // Presses, releases and the wheel (mode 1000)
+	MouseDrags            // Also motion with a button down (mode 1002)
+	MouseMotion           // Also motion with no button down (mode 1003)
+)
+
+// cursor is the cursor position and the state DECSC saves with it.
+type cursor struct {
+	x, y     int
+	style    Style
+	link     string
+	wrapNext bool // At the last column with a character written there
+	origin   bool // Positions are relative to the scroll region
+	charsets [2]byte
+	gl       int // Charset in use: G0 or G1
+}
+
+// screen is the main or the alternate screen.
+type screen struct {
+	lines       []Line
+	cur         cursor
+	saved       cursor
+	top, bottom int // Scroll region, inclusive
+}
+
+// Terminal is a virtual terminal: write a program's output to it and read
+// back what's on the screen.
+type Terminal struct {
+	mu     sync.Mutex
+	parser *ansi.Parser
+
+	cols, rows int
+	main, alt  *screen
+	scr        *screen // The one shown
+	tabs       []bool
+
+	// Modes
+	autowrap       bool
+	insert         bool
+	cursorVisible  bool
+	appCursor      bool
+	bracketedPaste bool
+	mouse          MouseMode
+	mouseSGR       bool
+
+	scrollback    []Line
+	maxScrollback int
+	dropped       int // Lines trimmed off the front of the scrollback
+
+	title     string
+	clipboard []string // OSC 52 copies not yet taken
+	replies   []byte   // Answers to queries not yet taken
+	last      string   // Last character printed, for REP
+}
+
+// New creates a terminal of the given size.
+func New(cols, rows int) *Terminal {
+	t := &Terminal{maxScrollback: DefaultMaxScrollback}
+	t.cols, t.rows = max(cols, 1), max(rows, 1)
+	t.reset()
+
+	t.parser = ansi.NewParser()
+	t.parser.SetHandler(ansi.Handler{
+		Print:     t.print,
+		Execute:   t.execute,
+		HandleCsi: t.csi,
+		HandleEsc: t.esc,
+		HandleOsc: t.osc,
+	})
+	return t
+}
+
+// reset puts the terminal back in its initial state, keeping the scrollback.
+func (t *Terminal) reset() {
+	t.main = t.newScreen()
+	t.alt = t.newScreen()
+	t.scr = t.main
+	t.tabs = make([]bool, t.cols)
+	for i := 8; i < t.cols; i += 8 {
+		t.tabs[i] = true
+	}
+	t.autowrap = true
+	t.insert = false
+	t.cursorVisible = true
+	t.appCursor = false
+	t.bracketedPaste = false
+	t.mouse = MouseOff
+	t.mouseSGR = false
+	t.title = ""
+	t.last = ""
+}
+
+// newScreen returns a blank screen the size of the terminal.
+func (t *Terminal) newScreen() *screen {
+	s := &screen{lines: make([]Line, t.rows), top: 0, bottom: t.rows - 1}
+	for i := range s.lines {
+		s.lines[i] = newLine(t.cols, Style{})
+	}
+	s.saved = s.cur
+	return s
+}
+
+// Write processes output from the program. Escape sequences may be split
+// across writes.
+func (t *Terminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.parser.Parse(p)
+	return len(p), nil
+}
+
+// Size returns the columns and rows of the screen.
+func (t *Terminal) Size() (cols, rows int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cols, t.rows
+}
+
+// Resize changes the size of the screen. Lines don't reflow: they're cut or
+// padded. When rows are taken away, lines above the cursor scroll off into
+// the scrollback so it stays on the screen.
+func (t *Terminal) Resize(cols, rows int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cols, rows = max(cols, 1), max(rows, 1)
+	if cols == t.cols && rows == t.rows {
+		return
+	}
+
+	for _, s := range []*screen{t.main, t.alt} {
+		if extra := s.cur.y - (rows - 1); extra > 0 {
+			if s == t.main {
+				t.pushScrollback(s.lines[:extra])
+			}
+			s.lines = s.lines[extra:]
+			s.cur.y -= extra
+		}
+		if len(s.lines) > rows {
+			s.lines = s.lines[:rows]
+		}
+		for len(s.lines) < rows {
+			s.lines = append(s.lines, newLine(cols, Style{}))
+		}
+		for i, line := range s.lines {
+			if len(line) > cols {
+				line = line[:cols]
+				if line[cols-1].Width == 2 {
+					line[cols-1] = blank(line[cols-1].Style)
+				}
+			}
+			for len(line) < cols {
+				line = append(line, blank(Style{}))
+			}
+			s.lines[i] = line
+		}
+		s.top, s.bottom = 0, rows-1
+		s.cur.x = min(s.cur.x, cols-1)
+		s.cur.wrapNext = false
+		s.saved.x, s.saved.y = min(s.saved.x, cols-1), min(s.saved.y, rows-1)
+	}
+
+	tabs := make([]bool, cols)
+	for i := range tabs {
+		if i < len(t.tabs) {
+			tabs[i] = t.tabs[i]
+		} else {
+			tabs[i] = i%8 == 0
+		}
+	}
+	t.tabs = tabs
+	t.cols, t.rows = cols, rows
+}
+
+// Cursor returns the cursor position on the screen.
+func (t *Terminal) Cursor() (x, y int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.scr.cur.x, t.scr.cur.y
+}
+
+// CursorVisible reports whether the program wants the cursor shown.
+func (t *Terminal) CursorVisible() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cursorVisible
+}
+
+// AltScreen reports whether the alternate screen is shown.
+func (t *Terminal) AltScreen() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.scr == t.alt
+}
+
+// BracketedPaste reports whether the program wants pastes marked.
+func (t *Terminal) BracketedPaste() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.bracketedPaste
+}
+
+// MouseMode returns which mouse events the program wants.
+func (t *Terminal) MouseMode() MouseMode {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.mouse
+}
+
+// Title returns the window title the program set.
+func (t *Terminal) Title() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.title
+}
+
+// TakeClipboard returns the text the program copied with OSC 52 since the
+// last call.
+func (t *Terminal) TakeClipboard() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	copies := t.clipboard
+	t.clipboard = nil
+	return copies
+}
+
+// TakeReplies returns the answers to the program's queries since the last
+// call, such as the cursor position, to be written to its input.
+func (t *Terminal) TakeReplies() []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	replies := t.replies
+	t.replies = nil
+	return replies
+}
+
+// SetMaxScrollback sets how many lines of scrollback are kept.
+func (t *Terminal) SetMaxScrollback(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.maxScrollback = max(n, 0)
+	t.trimScrollback()
+}
+
+// ScrollbackLen returns how many lines of scrollback are kept.
+func (t *Terminal) ScrollbackLen() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.scrollback)
+}
+
+// Dropped returns how many lines have been trimmed off the front of the
+// scrollback, so positions counted from the first line ever kept can be
+// told apart from those counted from the first line still kept.
+func (t *Terminal) Dropped() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.dropped
+}
+
+// pushScrollback adds lines scrolled off the main screen to the scrollback.
+func (t *Terminal) pushScrollback(lines []Line) {
+	for _, line := range lines {
+		t.scrollback = append(t.scrollback, line.trimmed())
+	}
+	t.trimScrollback()
+}
+
+// trimScrollback drops the oldest lines beyond the limit. The slice is cut
+// from the front rather than copied, so the kept lines only move when append
+// outgrows the backing array, once in many lines.
+func (t *Terminal) trimScrollback() {
+	if extra := len(t.scrollback) - t.maxScrollback; extra > 0 {
+		clear(t.scrollback[:extra]) // Let the dropped lines be collected
+		t.scrollback = t.scrollback[extra:]
+		t.dropped += extra
+	}
+}
+
+// line returns line i of the scrollback followed by the screen, or nil past
+// the end.
+func (t *Terminal) line(i int) Line {
+	switch {
+	case i < 0:
+		return nil
+	case i < len(t.scrollback):
+		return t.scrollback[i]
+	case i < len(t.scrollback)+t.rows:
+		return t.scr.lines[i-len(t.scrollback)]
+	}
+	return nil
+}
+
+// Text returns line i as plain text without trailing spaces, counting the
+// scrollback first and then the screen.
+func (t *Terminal) Text(i int) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.line(i).text()
+}
+
+// Styled returns line i with its colors and styles, without trailing blanks.
+func (t *Terminal) Styled(i int) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	line := t.line(i).trimmed()
+	var b strings.Builder
+	line.render(&b, len(line), nil)
+	return b.String()
+}
+
+// Hyperlinks returns the OSC 8 hyperlinks in line i.
+func (t *Terminal) Hyperlinks(i int) []Hyperlink {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.line(i).hyperlinks()
+}
+
+// RenderOptions are what's drawn over the lines by Render.
+type RenderOptions struct {
+	Cursor    bool                          // Draw the cursor, if the program shows it
+	Highlight func(row, col int) bool       // Cells drawn in reverse video, by row of the view
+	Decorate  func(lines []string, top int) // Draws over the lines of a View, the first of which is line top
+}
+
+// Render draws n lines from line top, counting the scrollback first and then
+// the screen, with their colors and styles. Lines are padded to the width of
+// the screen, and past the end with blank lines.
+func (t *Terminal) Render(top, n int, opts RenderOptions) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.render(top, n, opts)
+}
+
+// Top returns the line at the top of a view scrolled back offset lines,
+// counting the scrollback first and then the screen.
+func (t *Terminal) Top(offset int) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return max(len(t.scrollback)-offset, 0)
+}
+
+// View draws a screen's height of lines scrolled back offset lines, like
+// Render, then passes them to opts.Decorate, if set, outside the lock.
+func (t *Terminal) View(offset int, opts RenderOptions) string {
+	t.mu.Lock()
+	top := max(len(t.scrollback)-offset, 0)
+	view := t.render(top, t.rows, opts)
+	t.mu.Unlock()
+
+	if opts.Decorate == nil {
+		return view
+	}
+	lines := strings.Split(view, "\n")
+	opts.Decorate(lines, top)
+	return strings.Join(lines, "\n")
+}
+
+// Screen draws the screen, without the scrollback.
+func (t *Terminal) Screen(opts RenderOptions) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.render(len(t.scrollback), t.rows, opts)
+}
+
+// render draws n lines from line top.
+func (t *Terminal) render(top, n int, opts RenderOptions) string {
+	cursorLine := -1
+	if opts.Cursor && t.cursorVisible {
+		cursorLine = len(t.scrollback) + t.scr.cur.y
+	}
+
+	var b strings.Builder
+	b.Grow(n * t.cols * 2)
+	for row := 0; row < n; row++ {
+		if row > 0 {
+			b.WriteByte('\n')
+		}
+		var highlight func(col int) bool
+		switch {
+		case top+row == cursorLine && opts.Highlight != nil:
+			highlight = func(col int) bool { return col == t.scr.cur.x || opts.Highlight(row, col) }
+		case top+row == cursorLine:
+			highlight = func(col int) bool { return col == t.scr.cur.x }
+		case opts.Highlight != nil:
+			highlight = func(col int) bool { return opts.Highlight(row, col) }
+		}
+		t.line(top+row).render(&b, t.cols, highlight)
+	}
+	return b.String()
+}
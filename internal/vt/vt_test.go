@@ -0,0 +1,352 @@
+package vt
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// screenText returns the text of each line on the screen.
+func screenText(t *Terminal) []string {
+	_, rows := t.Size()
+	lines := make([]string, rows)
+	for i := range lines {
+		lines[i] = t.Text(t.ScrollbackLen() + i)
+	}
+	return lines
+}
+
+// scrollbackText returns the text of each line of the scrollback.
+func scrollbackText(t *Terminal) []string {
+	var lines []string
+	for i := range t.ScrollbackLen() {
+		lines = append(lines, t.Text(i))
+	}
+	return lines
+}
+
+func TestConformance(t *testing.T) {
+	tests := []struct {
+		name   string
+		cols   int
+		input  string
+		screen []string
+		x, y   int
+	}{
+		{"text", 10, "hello", []string{"hello", "", ""}, 5, 0},
+		{"newline keeps the column", 10, "ab\ncd", []string{"ab", "  cd", ""}, 4, 1},
+		{"carriage return", 10, "abc\rX", []string{"Xbc", "", ""}, 1, 0},
+		{"backspace", 10, "abc\b\bX", []string{"aXc", "", ""}, 2, 0},
+		{"tab stops", 20, "a\tb\tc", []string{"a       b       c", "", ""}, 17, 0},
+		{"back tab", 20, "\t\tx\x1b[2Zy", []string{"        y       x", "", ""}, 9, 0},
+		{"cursor position", 10, "\x1b[2;4Hx", []string{"", "   x", ""}, 4, 1},
+		{"cursor moves clamp", 10, "\x1b[99;99Hx\x1b[9Ay\x1b[99Dz", []string{"z        y", "", "         x"}, 1, 0},
+		{"column and row absolute", 10, "\x1b[3Gx\x1b[3dy", []string{"  x", "", "   y"}, 4, 2},
+		{"next and previous line", 10, "ab\x1b[Ecd\x1b[Fe", []string{"eb", "cd", ""}, 1, 0},
+		{"pending wrap", 5, "abcde", []string{"abcde", "", ""}, 4, 0},
+		{"wraps on the next character", 5, "abcdef", []string{"abcde", "f", ""}, 1, 1},
+		{"carriage return cancels the wrap", 5, "abcde\rX", []string{"Xbcde", "", ""}, 1, 0},
+		{"autowrap off", 5, "\x1b[?7labcdefg", []string{"abcdg", "", ""}, 4, 0},
+		{"erase to end of line", 10, "abcdef\x1b[3D\x1b[K", []string{"abc", "", ""}, 3, 0},
+		{"erase to start of line", 10, "abcdef\x1b[3D\x1b[1K", []string{"    ef", "", ""}, 3, 0},
+		{"erase line", 10, "abcdef\x1b[2K", []string{"", "", ""}, 6, 0},
+		{"erase below", 10, "aaa\r\nbbb\r\nccc\x1b[2;2H\x1b[J", []string{"aaa", "b", ""}, 1, 1},
+		{"erase above", 10, "aaa\r\nbbb\r\nccc\x1b[2;2H\x1b[1J", []string{"", "  b", "ccc"}, 1, 1},
+		{"erase screen", 10, "aaa\r\nbbb\x1b[2J", []string{"", "", ""}, 3, 1},
+		{"erase characters", 10, "abcdef\x1b[1G\x1b[2X", []string{"  cdef", "", ""}, 0, 0},
+		{"insert characters", 10, "abcdef\x1b[1G\x1b[2@", []string{"  abcdef", "", ""}, 0, 0},
+		{"delete characters", 10, "abcdef\x1b[1G\x1b[2P", []string{"cdef", "", ""}, 0, 0},
+		{"insert mode", 10, "abc\x1b[1G\x1b[4hXY\x1b[4lZ", []string{"XYZbc", "", ""}, 3, 0},
+		{"insert lines", 10, "a\r\nb\r\nc\x1b[2H\x1b[L", []string{"a", "", "b"}, 0, 1},
+		{"delete lines", 10, "a\r\nb\r\nc\x1b[1H\x1b[M", []string{"b", "c", ""}, 0, 0},
+		{"repeat", 10, "ab\x1b[3b", []string{"abbbb", "", ""}, 5, 0},
+		{"reverse index scrolls down", 10, "a\r\nb\x1b[H\x1bMc", []string{"c", "a", "b"}, 1, 0},
+		{"save and restore cursor", 10, "ab\x1b7\x1b[3;5Hx\x1b8c", []string{"abc", "", "    x"}, 3, 0},
+		{"scroll region", 10, "a\r\nb\r\nc\x1b[1;2r\x1b[2Hx\r\ny\r\n", []string{"y", "", "c"}, 0, 1},
+		{"scroll up and down", 10, "a\r\nb\r\nc\x1b[S", []string{"b", "c", ""}, 1, 2},
+		{"origin mode", 10, "\x1b[2;3r\x1b[?6h\x1b[1;1Hx", []string{"", "x", ""}, 1, 1},
+		{"DEC line drawing", 10, "\x1b(0lqk\x1b(Bq", []string{"┌─┐q", "", ""}, 4, 0},
+		{"shift out to G1", 10, "\x1b)0a\x0eq\x0fq", []string{"a─q", "", ""}, 3, 0},
+		{"wide characters", 10, "世界!", []string{"世界!", "", ""}, 5, 0},
+		{"wide character wraps at the edge", 5, "abcd世", []string{"abcd", "世", ""}, 2, 1},
+		{"overwriting half a wide character", 10, "世界\x1b[1Gx", []string{"x 界", "", ""}, 1, 0},
+		{"emoji", 10, "🙂x", []string{"🙂x", "", ""}, 3, 0},
+		{"emoji joined by ZWJ", 10, "👩‍💻x", []string{"👩‍💻x", "", ""}, 3, 0},
+		{"combining marks", 10, "éx", []string{"éx", "", ""}, 2, 0},
+		{"sequences split across writes", 10, "\x1b[2;\x1b", []string{"", "", ""}, 0, 0},
+		{"unknown sequences are ignored", 10, "a\x1b[?1049$pb\x1bP+q544e\x1b\\c\x1b[5 qd", []string{"abcd", "", ""}, 4, 0},
+		{"full reset", 10, "abc\x1b[31m\x1bcd", []string{"d", "", ""}, 1, 0},
+		{"alignment test", 3, "\x1b#8", []string{"EEE", "EEE", "EEE"}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := New(tt.cols, 3)
+			for _, b := range []byte(tt.input) {
+				term.Write([]byte{b}) // One byte at a time, so nothing relies on whole writes
+			}
+			assert.Equal(t, tt.screen, screenText(term))
+			x, y := term.Cursor()
+			assert.Equal(t, tt.x, x, "cursor column")
+			assert.Equal(t, tt.y, y, "cursor row")
+		})
+	}
+}
+
+func TestScrollback(t *testing.T) {
+	term := New(10, 3)
+	term.Write([]byte("same\r\nsame\r\nsame\r\nsame\r\n\x1b[1mbold\x1b[0m\r\nlast"))
+
+	// Repeated lines are all kept, in order, with their styles
+	assert.Equal(t, []string{"same", "same", "same"}, scrollbackText(term))
+	assert.Equal(t, []string{"same", "bold", "last"}, screenText(term))
+	term.Write([]byte("\r\n\r\n"))
+	assert.Equal(t, "\x1b[1mbold\x1b[0m", term.Styled(4))
+
+	// Scrolling inside a region that doesn't start at the top, and on the
+	// alternate screen, isn't kept
+	n := term.ScrollbackLen()
+	term.Write([]byte("\x1b[2;3r\x1b[3H\r\n\r\n\x1b[r"))
+	assert.Equal(t, n, term.ScrollbackLen())
+	term.Write([]byte("\x1b[?1049h\r\n\r\n\r\n\r\n\x1b[?1049l"))
+	assert.Equal(t, n, term.ScrollbackLen())
+
+	// Trimmed from the front, counting what's dropped
+	term.SetMaxScrollback(2)
+	assert.Equal(t, 2, term.ScrollbackLen())
+	assert.Equal(t, n-2, term.Dropped())
+	term.Write([]byte("\x1b[3H"))
+	for i := 1; i <= 100; i++ {
+		term.Write([]byte("\r\n" + strconv.Itoa(i)))
+	}
+	assert.Equal(t, []string{"96", "97"}, scrollbackText(term))
+	assert.Equal(t, n+98, term.Dropped())
+
+	// ED 3 clears it
+	term.Write([]byte("\x1b[3J"))
+	assert.Zero(t, term.ScrollbackLen())
+	assert.Equal(t, n+100, term.Dropped())
+}
+
+func TestAltScreen(t *testing.T) {
+	term := New(10, 3)
+	term.Write([]byte("shell$ \x1b[?1049h\x1b[Hvim"))
+	assert.True(t, term.AltScreen())
+	assert.Equal(t, []string{"vim", "", ""}, screenText(term))
+
+	// Leaving restores the main screen and the cursor
+	term.Write([]byte("\x1b[?1049lls"))
+	assert.False(t, term.AltScreen())
+	assert.Equal(t, []string{"shell$ ls", "", ""}, screenText(term))
+
+	// 47 switches without clearing, 1047 clears on leaving
+	term.Write([]byte("\x1b[?47hx\x1b[?47l\x1b[?47h"))
+	assert.Contains(t, screenText(term)[0], "x")
+	term.Write([]byte("\x1b[?47l\x1b[?1047h\x1b[?1047l\x1b[?47h"))
+	assert.Equal(t, []string{"", "", ""}, screenText(term))
+}
+
+func TestColors(t *testing.T) {
+	term := New(20, 1)
+	term.Write([]byte("\x1b[31ma\x1b[38;5;208mb\x1b[38;2;1;2;3mc\x1b[48:2::4:5:6md\x1b[0;1;4;7me\x1b[22;24;27;97;104mf\x1b[39;49mg"))
+
+	styled := term.Styled(0)
+	for _, want := range []string{
+		"\x1b[38;5;1ma",
+		"\x1b[38;5;208mb",
+		"\x1b[38;2;1;2;3mc",
+		"\x1b[38;2;1;2;3;48;2;4;5;6md",
+		"\x1b[1;4;7me",
+		"\x1b[38;5;15;48;5;12mf",
+	} {
+		assert.Contains(t, styled, want)
+	}
+	assert.True(t, strings.HasSuffix(styled, "\x1b[0mg"), "back to the defaults")
+	assert.Equal(t, "abcdefg", term.Text(0))
+
+	// Erasing keeps the background
+	term.Write([]byte("\r\x1b[41m\x1b[2K"))
+	assert.Contains(t, term.Render(0, 1, RenderOptions{}), "\x1b[48;5;1m")
+}
+
+func TestRender(t *testing.T) {
+	term := New(6, 2)
+	term.Write([]byte("ab世\r\ncd"))
+
+	assert.Equal(t, "ab世  \ncd    ", term.Screen(RenderOptions{}))
+	assert.Equal(t, "ab世  \ncd\x1b[7m \x1b[0m   ", term.Screen(RenderOptions{Cursor: true}))
+	term.Write([]byte("\x1b[?25l"))
+	assert.Equal(t, "ab世  \ncd    ", term.Screen(RenderOptions{Cursor: true}), "hidden cursor")
+
+	highlight := func(row, col int) bool { return row == 0 && col < 2 }
+	assert.Equal(t, "\x1b[7mab\x1b[0m世  \ncd    ", term.Screen(RenderOptions{Highlight: highlight}))
+
+	// Past the end is blank
+	assert.Equal(t, "cd    \n      ", term.Render(1, 2, RenderOptions{}))
+}
+
+func TestView(t *testing.T) {
+	term := New(4, 2)
+	term.Write([]byte("1\r\n2\r\n3\r\n4"))
+	assert.Equal(t, 2, term.Top(0))
+	assert.Equal(t, "3   \n4   ", term.View(0, RenderOptions{}))
+
+	// Scrolled back, stopping at the first line kept
+	assert.Equal(t, 1, term.Top(1))
+	assert.Equal(t, 0, term.Top(5))
+	var tops []int
+	decorate := func(lines []string, top int) {
+		tops = append(tops, top)
+		lines[0] = strings.ToUpper(strings.TrimSpace(term.Text(top))) + "!"
+	}
+	assert.Equal(t, "2!\n3   ", term.View(1, RenderOptions{Decorate: decorate}))
+	assert.Equal(t, []int{1}, tops)
+}
+
+func TestResize(t *testing.T) {
+	term := New(10, 4)
+	term.Write([]byte("one\r\ntwo\r\nthree\r\nfour"))
+
+	// Lines above the cursor go to the scrollback so it stays on the screen
+	term.Resize(3, 2)
+	assert.Equal(t, []string{"one", "two"}, scrollbackText(term))
+	assert.Equal(t, []string{"thr", "fou"}, screenText(term))
+	x, y := term.Cursor()
+	assert.Equal(t, []int{2, 1}, []int{x, y})
+
+	// Growing back brings the tab stops back too
+	term.Resize(10, 3)
+	term.Write([]byte("\x1b[Hx\tab"))
+	assert.Equal(t, []string{"xhr     ab", "fou", ""}, screenText(term))
+}
+
+func TestHyperlinks(t *testing.T) {
+	term := New(30, 2)
+	term.Write([]byte("see \x1b]8;;file:///a.go#L7\x1b\\the \x1b[1mfile\x1b[0m\x1b]8;;\x1b\\ or \x1b]8;id=1;https://example.com\adocs\x1b]8;;\a"))
+
+	assert.Equal(t, []Hyperlink{
+		{Start: 4, End: 12, URI: "file:///a.go#L7"},
+		{Start: 16, End: 20, URI: "https://example.com"},
+	}, term.Hyperlinks(0))
+	assert.Empty(t, term.Hyperlinks(1))
+}
+
+func TestTitleAndClipboard(t *testing.T) {
+	term := New(10, 1)
+	term.Write([]byte("\x1b]0;vim\a\x1b]52;c;aGVsbG8=\x1b\\\x1b]52;c;?\a\x1b]52;c;!!\a"))
+
+	assert.Equal(t, "vim", term.Title())
+	assert.Equal(t, []string{"hello"}, term.TakeClipboard(), "queries and bad data are ignored")
+	assert.Empty(t, term.TakeClipboard())
+}
+
+func TestReplies(t *testing.T) {
+	term := New(10, 5)
+	term.Write([]byte("\x1b[3;4H\x1b[6n\x1b[5n\x1b[c\x1b[>c"))
+	assert.Equal(t, "\x1b[3;4R\x1b[0n\x1b[?62;22c\x1b[>1;10;0c", string(term.TakeReplies()))
+	assert.Empty(t, term.TakeReplies())
+}
+
+func TestModes(t *testing.T) {
+	term := New(10, 1)
+	assert.Equal(t, MouseOff, term.MouseMode())
+	term.Write([]byte("\x1b[?1002h\x1b[?1006h\x1b[?2004h\x1b[?25l"))
+	assert.Equal(t, MouseDrags, term.MouseMode())
+	assert.True(t, term.BracketedPaste())
+	assert.False(t, term.CursorVisible())
+
+	term.Write([]byte("\x1b[?1000l")) // Not the mode that's on
+	assert.Equal(t, MouseDrags, term.MouseMode())
+	term.Write([]byte("\x1b[?1002l\x1b[?2004l"))
+	assert.Equal(t, MouseOff, term.MouseMode())
+	assert.False(t, term.BracketedPaste())
+}
+
+func TestKeyInput(t *testing.T) {
+	term := New(10, 1)
+	tests := []struct {
+		key  tea.Key
+		want string
+	}{
+		{tea.Key{Code: 'a', Text: "a"}, "a"},
+		{tea.Key{Code: 'é', Text: "é"}, "é"},
+		{tea.Key{Code: tea.KeyEnter}, "\r"},
+		{tea.Key{Code: tea.KeyBackspace}, "\x7f"},
+		{tea.Key{Code: tea.KeyBackspace, Mod: tea.ModAlt}, "\x1b\x7f"},
+		{tea.Key{Code: tea.KeyTab}, "\t"},
+		{tea.Key{Code: tea.KeyTab, Mod: tea.ModShift}, "\x1b[Z"},
+		{tea.Key{Code: tea.KeyEscape}, "\x1b"},
+		{tea.Key{Code: 'c', Mod: tea.ModCtrl}, "\x03"},
+		{tea.Key{Code: ']', Mod: tea.ModCtrl}, "\x1d"},
+		{tea.Key{Code: tea.KeySpace, Mod: tea.ModCtrl}, "\x00"},
+		{tea.Key{Code: 'b', Mod: tea.ModAlt}, "\x1bb"},
+		{tea.Key{Code: tea.KeyUp}, "\x1b[A"},
+		{tea.Key{Code: tea.KeyLeft, Mod: tea.ModCtrl}, "\x1b[1;5D"},
+		{tea.Key{Code: tea.KeyRight, Mod: tea.ModShift | tea.ModAlt}, "\x1b[1;4C"},
+		{tea.Key{Code: tea.KeyHome}, "\x1b[H"},
+		{tea.Key{Code: tea.KeyPgUp}, "\x1b[5~"},
+		{tea.Key{Code: tea.KeyDelete, Mod: tea.ModCtrl}, "\x1b[3;5~"},
+		{tea.Key{Code: tea.KeyF1}, "\x1bOP"},
+		{tea.Key{Code: tea.KeyF12}, "\x1b[24~"},
+		{tea.Key{Code: tea.KeyCapsLock}, ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, string(term.KeyInput(tt.key)), tt.key.String())
+	}
+
+	// Application cursor keys
+	term.Write([]byte("\x1b[?1h"))
+	assert.Equal(t, "\x1bOA", string(term.KeyInput(tea.Key{Code: tea.KeyUp})))
+	assert.Equal(t, "\x1b[1;5A", string(term.KeyInput(tea.Key{Code: tea.KeyUp, Mod: tea.ModCtrl})))
+}
+
+func TestPasteInput(t *testing.T) {
+	term := New(10, 1)
+	assert.Equal(t, "a\nb", string(term.PasteInput("a\nb")))
+	term.Write([]byte("\x1b[?2004h"))
+	assert.Equal(t, "\x1b[200~a\nb\x1b[201~", string(term.PasteInput("a\nb")))
+}
+
+func TestMouseInput(t *testing.T) {
+	term := New(10, 5)
+	click := tea.MouseClickMsg{Button: tea.MouseLeft}
+	assert.Nil(t, term.MouseInput(click, 2, 3), "not asked for")
+
+	term.Write([]byte("\x1b[?1000h"))
+	assert.Equal(t, "\x1b[M #$", string(term.MouseInput(click, 2, 3)))
+	assert.Equal(t, "\x1b[M##$", string(term.MouseInput(tea.MouseReleaseMsg{Button: tea.MouseLeft}, 2, 3)))
+	assert.Nil(t, term.MouseInput(tea.MouseMotionMsg{Button: tea.MouseLeft}, 2, 3), "drags not asked for")
+
+	term.Write([]byte("\x1b[?1006h"))
+	assert.Equal(t, "\x1b[<0;3;4M", string(term.MouseInput(click, 2, 3)))
+	assert.Equal(t, "\x1b[<0;3;4m", string(term.MouseInput(tea.MouseReleaseMsg{Button: tea.MouseLeft}, 2, 3)))
+	assert.Equal(t, "\x1b[<80;1;1M", string(term.MouseInput(tea.MouseWheelMsg{Button: tea.MouseWheelUp, Mod: tea.ModCtrl}, 0, 0)))
+
+	term.Write([]byte("\x1b[?1002h"))
+	assert.Equal(t, "\x1b[<32;3;4M", string(term.MouseInput(tea.MouseMotionMsg{Button: tea.MouseLeft}, 2, 3)))
+	assert.Nil(t, term.MouseInput(tea.MouseMotionMsg{}, 2, 3), "motion without a button not asked for")
+
+	term.Write([]byte("\x1b[?1003h"))
+	assert.Equal(t, "\x1b[<35;3;4M", string(term.MouseInput(tea.MouseMotionMsg{}, 2, 3)))
+}
+
+func TestConcurrentUse(t *testing.T) {
+	term := New(20, 5)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 200 {
+			term.Write([]byte("line of output\r\n\x1b[31mred\x1b[0m\r\n"))
+		}
+	}()
+	for range 200 {
+		_ = ansi.Strip(term.Screen(RenderOptions{Cursor: true}))
+		term.Resize(20+term.ScrollbackLen()%3, 5)
+	}
+	<-done
+	require.Positive(t, term.ScrollbackLen())
+}
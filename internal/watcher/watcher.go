@@ -0,0 +1,420 @@
+package watcher
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Reason records why a directory is watched. A directory stays watched while
+// at least one reason still applies.
+type Reason uint8
+
+const (
+	ReasonTree    Reason = 1 << iota // Expanded in the file tree
+	ReasonTracked                    // Contains git-tracked files
+	ReasonGit                        // Git metadata (HEAD, index, refs)
+)
+
+// Mode describes how changes are currently being detected.
+type Mode int
+
+const (
+	ModeOff     Mode = iota // Nothing is watched
+	ModeNotify              // Kernel notifications (inotify, kqueue, ...)
+	ModePolling             // Watch limit reached; some directories are scanned periodically
+)
+
+// String returns a short label for the mode.
+func (m Mode) String() string {
+	switch m {
+	case ModeOff:
+		return "off"
+	case ModeNotify:
+		return "notify"
+	case ModePolling:
+		return "polling"
+	default:
+		return "unknown"
+	}
+}
+
+// PollInterval is how often directories that could not be watched are rescanned.
+const PollInterval = 2 * time.Second
+
+// Stats summarizes the watcher's state for display.
+type Stats struct {
+	Mode    Mode
+	Watched int // Directories watched via kernel notifications
+	Polled  int // Directories scanned by mtime
+}
+
+// entry is the part of a directory entry compared between scans.
+type entry struct {
+	modTime time.Time
+	size    int64
+	isDir   bool
+}
+
+// dir is a watched directory.
+type dir struct {
+	reasons  Reason
+	polled   bool             // Scanned by mtime instead of kernel notifications
+	snapshot map[string]entry // Last scan result (polled directories only)
+}
+
+// Watcher watches a changing set of directories. It uses kernel
+// notifications until the system watch limit is exhausted and falls back to
+// periodic mtime scanning for any directory added after that, until removed
+// watches make room again.
+// It is safe for concurrent use.
+type Watcher struct {
+	mu       sync.Mutex
+	fs       *fsnotify.Watcher
+	dirs     map[string]*dir
+	limitHit bool // Watch limit reached; new directories are polled
+
+	events    chan fsnotify.Event
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New creates a watcher. If no notification backend is available, every
+// directory is polled.
+func New() *Watcher {
+	w := &Watcher{
+		dirs:   make(map[string]*dir),
+		events: make(chan fsnotify.Event, 256),
+		done:   make(chan struct{}),
+	}
+
+	if fs, err := fsnotify.NewWatcher(); err == nil {
+		w.fs = fs
+		go w.forward()
+	} else {
+		w.limitHit = true // e.g. out of inotify instances
+	}
+	go w.poll()
+
+	return w
+}
+
+// Events returns the channel on which file changes are delivered.
+func (w *Watcher) Events() <-chan fsnotify.Event {
+	return w.events
+}
+
+// Add watches dir for the given reason. Adding an already watched
+// directory only records the extra reason.
+func (w *Watcher) Add(path string, reason Reason) error {
+	if w == nil {
+		return nil
+	}
+	path = filepath.Clean(path)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if d, ok := w.dirs[path]; ok {
+		d.reasons |= reason
+		return nil
+	}
+
+	d := &dir{reasons: reason}
+	if w.fs != nil && !w.limitHit {
+		err := w.fs.Add(path)
+		if err == nil {
+			w.dirs[path] = d
+			return nil
+		}
+		if !IsLimitError(err) {
+			return err
+		}
+		w.limitHit = true
+	}
+
+	snapshot, err := scan(path)
+	if err != nil {
+		return err
+	}
+	d.polled = true
+	d.snapshot = snapshot
+	w.dirs[path] = d
+	return nil
+}
+
+// Remove drops reason from dir and stops watching it once no reason is left.
+func (w *Watcher) Remove(path string, reason Reason) {
+	if w == nil {
+		return
+	}
+	path = filepath.Clean(path)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	d, ok := w.dirs[path]
+	if !ok {
+		return
+	}
+	d.reasons &^= reason
+	if d.reasons == 0 {
+		w.dropLocked(path, d)
+		w.promoteLocked()
+	}
+}
+
+// RemoveTree stops watching dir and every directory below it, whatever the
+// reason. Use it when a directory disappears.
+func (w *Watcher) RemoveTree(path string) {
+	if w == nil {
+		return
+	}
+	path = filepath.Clean(path)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.removeTreeLocked(path)
+	w.promoteLocked()
+}
+
+// Has reports whether dir is watched for the given reason.
+func (w *Watcher) Has(path string, reason Reason) bool {
+	if w == nil {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	d, ok := w.dirs[filepath.Clean(path)]
+	return ok && d.reasons&reason != 0
+}
+
+// Stats returns the current watch counts and mode.
+func (w *Watcher) Stats() Stats {
+	if w == nil {
+		return Stats{Mode: ModeOff}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var stats Stats
+	for _, d := range w.dirs {
+		if d.polled {
+			stats.Polled++
+		} else {
+			stats.Watched++
+		}
+	}
+
+	switch {
+	case stats.Polled > 0:
+		stats.Mode = ModePolling
+	case stats.Watched > 0:
+		stats.Mode = ModeNotify
+	default:
+		stats.Mode = ModeOff
+	}
+	return stats
+}
+
+// Close stops all watches. Events is not closed.
+func (w *Watcher) Close() error {
+	if w == nil {
+		return nil
+	}
+
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		if w.fs != nil {
+			err = w.fs.Close()
+		}
+	})
+	return err
+}
+
+// IsLimitError reports whether err means the system watch limit is exhausted
+// (inotify's max_user_watches, or the open file limit for kqueue).
+func IsLimitError(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EMFILE)
+}
+
+// dropLocked forgets a single directory. Caller holds w.mu.
+func (w *Watcher) dropLocked(path string, d *dir) {
+	if !d.polled && w.fs != nil {
+		w.fs.Remove(path)  // Fails harmlessly if the kernel already dropped it
+		w.limitHit = false // A watch was freed; try again
+	}
+	delete(w.dirs, path)
+}
+
+// promoteLocked moves polled directories back to kernel notifications for as
+// long as the watch limit allows. Caller holds w.mu.
+func (w *Watcher) promoteLocked() {
+	if w.fs == nil || w.limitHit {
+		return
+	}
+	for path, d := range w.dirs {
+		if !d.polled {
+			continue
+		}
+		if err := w.fs.Add(path); err != nil {
+			if IsLimitError(err) {
+				w.limitHit = true
+				return
+			}
+			continue // Polling reports it if it's gone
+		}
+		d.polled = false
+		d.snapshot = nil
+	}
+}
+
+// removeTreeLocked forgets path and its descendants. Caller holds w.mu.
+func (w *Watcher) removeTreeLocked(path string) {
+	prefix := path + string(filepath.Separator)
+	for p, d := range w.dirs {
+		if p == path || strings.HasPrefix(p, prefix) {
+			w.dropLocked(p, d)
+		}
+	}
+}
+
+// forward relays kernel events and forgets directories that disappear.
+func (w *Watcher) forward() {
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+				w.mu.Lock()
+				if _, watched := w.dirs[event.Name]; watched {
+					w.removeTreeLocked(event.Name)
+					w.promoteLocked()
+				}
+				w.mu.Unlock()
+			}
+			w.send(event)
+		case _, ok := <-w.fs.Errors:
+			// Overflow and similar errors only mean missed events
+			if !ok {
+				return
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// poll periodically rescans polled directories and reports differences.
+func (w *Watcher) poll() {
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, event := range w.pollOnce() {
+				w.send(event)
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// pollOnce rescans every polled directory and returns the changes found.
+func (w *Watcher) pollOnce() []fsnotify.Event {
+	w.mu.Lock()
+	polled := make(map[string]map[string]entry)
+	for path, d := range w.dirs {
+		if d.polled {
+			polled[path] = d.snapshot
+		}
+	}
+	w.mu.Unlock()
+
+	var events []fsnotify.Event
+	for path, old := range polled {
+		current, err := scan(path)
+
+		w.mu.Lock()
+		d, ok := w.dirs[path]
+		if !ok {
+			w.mu.Unlock()
+			continue // Removed while scanning
+		}
+		if err != nil {
+			w.removeTreeLocked(path)
+			w.mu.Unlock()
+			events = append(events, fsnotify.Event{Name: path, Op: fsnotify.Remove})
+			continue
+		}
+		d.snapshot = current
+		w.mu.Unlock()
+
+		events = append(events, diff(path, old, current)...)
+	}
+	return events
+}
+
+// send delivers an event unless the watcher is closed.
+func (w *Watcher) send(event fsnotify.Event) {
+	select {
+	case w.events <- event:
+	case <-w.done:
+	}
+}
+
+// scan lists a directory for mtime comparison.
+func scan(path string) (map[string]entry, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(map[string]entry, len(entries))
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			continue // Removed between ReadDir and Info
+		}
+		snapshot[e.Name()] = entry{
+			modTime: info.ModTime(),
+			size:    info.Size(),
+			isDir:   e.IsDir(),
+		}
+	}
+	return snapshot, nil
+}
+
+// diff compares two scans of dir and returns the equivalent events.
+func diff(dir string, old, current map[string]entry) []fsnotify.Event {
+	var events []fsnotify.Event
+	for name, e := range current {
+		prev, ok := old[name]
+		switch {
+		case !ok:
+			events = append(events, fsnotify.Event{Name: filepath.Join(dir, name), Op: fsnotify.Create})
+		case !prev.modTime.Equal(e.modTime) || prev.size != e.size || prev.isDir != e.isDir:
+			events = append(events, fsnotify.Event{Name: filepath.Join(dir, name), Op: fsnotify.Write})
+		}
+	}
+	for name := range old {
+		if _, ok := current[name]; !ok {
+			events = append(events, fsnotify.Event{Name: filepath.Join(dir, name), Op: fsnotify.Remove})
+		}
+	}
+	return events
+}
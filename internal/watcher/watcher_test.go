@@ -0,0 +1,152 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcherReasons(t *testing.T) {
+	dir := t.TempDir()
+	w := New()
+	defer w.Close()
+
+	require.NoError(t, w.Add(dir, ReasonTree))
+	require.NoError(t, w.Add(dir, ReasonTracked))
+	assert.True(t, w.Has(dir, ReasonTree))
+	assert.True(t, w.Has(dir, ReasonTracked))
+
+	t.Run("stays watched while a reason remains", func(t *testing.T) {
+		w.Remove(dir, ReasonTree)
+		assert.False(t, w.Has(dir, ReasonTree))
+		assert.True(t, w.Has(dir, ReasonTracked))
+		assert.Equal(t, 1, w.Stats().Watched+w.Stats().Polled)
+	})
+
+	t.Run("dropped when the last reason goes", func(t *testing.T) {
+		w.Remove(dir, ReasonTracked)
+		stats := w.Stats()
+		assert.Equal(t, 0, stats.Watched+stats.Polled)
+		assert.Equal(t, ModeOff, stats.Mode)
+	})
+
+	t.Run("missing directories are reported", func(t *testing.T) {
+		assert.Error(t, w.Add(filepath.Join(dir, "missing"), ReasonTree))
+	})
+}
+
+func TestWatcherRemoveTree(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "a", "b")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+
+	w := New()
+	defer w.Close()
+	require.NoError(t, w.Add(root, ReasonTree))
+	require.NoError(t, w.Add(filepath.Join(root, "a"), ReasonTree))
+	require.NoError(t, w.Add(sub, ReasonTracked))
+
+	w.RemoveTree(filepath.Join(root, "a"))
+
+	assert.True(t, w.Has(root, ReasonTree))
+	assert.False(t, w.Has(filepath.Join(root, "a"), ReasonTree))
+	assert.False(t, w.Has(sub, ReasonTracked))
+}
+
+func TestWatcherPollingFallback(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "old.txt"), []byte("a"), 0644))
+
+	w := New()
+	defer w.Close()
+	w.limitHit = true // Pretend the watch limit is exhausted
+
+	require.NoError(t, w.Add(dir, ReasonTree))
+	stats := w.Stats()
+	assert.Equal(t, ModePolling, stats.Mode)
+	assert.Equal(t, 1, stats.Polled)
+
+	// Change the directory and rescan
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.txt"), []byte("b"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(dir, "old.txt")))
+
+	events := w.pollOnce()
+	ops := make(map[string]fsnotify.Op)
+	for _, e := range events {
+		ops[filepath.Base(e.Name)] = e.Op
+	}
+	assert.Equal(t, fsnotify.Create, ops["new.txt"])
+	assert.Equal(t, fsnotify.Remove, ops["old.txt"])
+
+	t.Run("vanished directory is dropped", func(t *testing.T) {
+		require.NoError(t, os.RemoveAll(dir))
+		events := w.pollOnce()
+		require.Len(t, events, 1)
+		assert.Equal(t, fsnotify.Remove, events[0].Op)
+		assert.Equal(t, ModeOff, w.Stats().Mode)
+	})
+}
+
+func TestWatcherLimitRecovers(t *testing.T) {
+	kernel := t.TempDir()
+	polled := t.TempDir()
+
+	w := New()
+	defer w.Close()
+	require.NoError(t, w.Add(kernel, ReasonTree))
+	w.limitHit = true // Pretend the watch limit is exhausted
+	require.NoError(t, w.Add(polled, ReasonTree))
+	assert.Equal(t, Stats{Mode: ModePolling, Watched: 1, Polled: 1}, w.Stats())
+
+	// Freeing a watch moves the polled directory to kernel notifications
+	w.Remove(kernel, ReasonTree)
+	assert.Equal(t, Stats{Mode: ModeNotify, Watched: 1}, w.Stats())
+	assert.False(t, w.limitHit)
+}
+
+func TestDiff(t *testing.T) {
+	now := time.Now()
+	old := map[string]entry{
+		"same.go":    {modTime: now, size: 10},
+		"edited.go":  {modTime: now, size: 10},
+		"deleted.go": {modTime: now, size: 10},
+	}
+	current := map[string]entry{
+		"same.go":   {modTime: now, size: 10},
+		"edited.go": {modTime: now.Add(time.Second), size: 12},
+		"added.go":  {modTime: now, size: 1},
+	}
+
+	ops := make(map[string]fsnotify.Op)
+	for _, e := range diff("/d", old, current) {
+		ops[e.Name] = e.Op
+	}
+
+	assert.Len(t, ops, 3)
+	assert.Equal(t, fsnotify.Write, ops["/d/edited.go"])
+	assert.Equal(t, fsnotify.Create, ops["/d/added.go"])
+	assert.Equal(t, fsnotify.Remove, ops["/d/deleted.go"])
+}
+
+func TestIsLimitError(t *testing.T) {
+	assert.True(t, IsLimitError(syscall.ENOSPC))
+	assert.True(t, IsLimitError(&os.PathError{Op: "add", Path: "/x", Err: syscall.EMFILE}))
+	assert.False(t, IsLimitError(syscall.ENOENT))
+	assert.False(t, IsLimitError(nil))
+}
+
+func TestNilWatcher(t *testing.T) {
+	var w *Watcher
+	assert.NoError(t, w.Add("/x", ReasonTree))
+	w.Remove("/x", ReasonTree)
+	w.RemoveTree("/x")
+	assert.False(t, w.Has("/x", ReasonTree))
+	assert.Equal(t, ModeOff, w.Stats().Mode)
+	assert.NoError(t, w.Close())
+}